@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mihongtech/linkchain-core/accounts/keystore"
+	"github.com/mihongtech/linkchain-core/node/config"
+)
+
+// accountCommand manages the accounts of the keystore:
+//
+//	linkchain account new [-datadir dir] [-keystore dir] [-password file] [-lightkdf]
+//	linkchain account list [-datadir dir] [-keystore dir]
+func accountCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: account new|list [flags]")
+	}
+	switch args[0] {
+	case "new":
+		return newAccount(args[1:])
+	case "list":
+		return listAccounts(args[1:])
+	default:
+		return fmt.Errorf("unknown account command %q, have new and list", args[0])
+	}
+}
+
+// keystoreFlags binds the flags locating the keystore, which is in the data
+// directory unless set apart.
+func keystoreFlags(flags *flag.FlagSet) func() string {
+	dataDir := flags.String("datadir", config.DefaultDataDir(), "data directory of the node")
+	keyDir := flags.String("keystore", "", "directory of the keys, the keystore of the data directory if empty")
+	return func() string {
+		if *keyDir != "" {
+			return *keyDir
+		}
+		return filepath.Join(*dataDir, config.DefaultKeyStoreDir)
+	}
+}
+
+// newAccount creates an account, its key encrypted with a passphrase read from
+// a file or, without one, from the first line of the standard input.
+func newAccount(args []string) error {
+	flags := flag.NewFlagSet("account new", flag.ContinueOnError)
+	keyDir := keystoreFlags(flags)
+	passwordFile := flags.String("password", "", "file holding the passphrase on its first line")
+	lightKDF := flags.Bool("lightkdf", false, "encrypt the key with weaker parameters, faster to unlock")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: account new [-datadir dir] [-keystore dir] [-password file] [-lightkdf]")
+	}
+
+	passphrase, err := readPassphrase(*passwordFile)
+	if err != nil {
+		return err
+	}
+	scryptN, scryptP := keystore.StandardScryptN, keystore.StandardScryptP
+	if *lightKDF {
+		scryptN, scryptP = keystore.LightScryptN, keystore.LightScryptP
+	}
+	account, err := keystore.NewKeyStore(keyDir(), scryptN, scryptP).NewAccount(passphrase)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Address: %s\n", account.Address)
+	fmt.Printf("Key: %s\n", account.URL.Path)
+	return nil
+}
+
+// listAccounts prints the address and key file of every account of the keystore.
+func listAccounts(args []string) error {
+	flags := flag.NewFlagSet("account list", flag.ContinueOnError)
+	keyDir := keystoreFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: account list [-datadir dir] [-keystore dir]")
+	}
+
+	// Only listed, the keys are not decrypted
+	ks := keystore.NewKeyStore(keyDir(), keystore.LightScryptN, keystore.LightScryptP)
+	for i, account := range ks.Accounts() {
+		fmt.Printf("Account #%d: %s %s\n", i, account.Address, account.URL)
+	}
+	return nil
+}
+
+// readPassphrase reads the passphrase on the first line of file, or of the
+// standard input if file is empty.
+func readPassphrase(file string) (string, error) {
+	if file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(strings.SplitN(string(data), "\n", 2)[0], "\r"), nil
+	}
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read the passphrase: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
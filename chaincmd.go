@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/node"
+	chainstorage "github.com/mihongtech/linkchain-core/node/chain/storage"
+	"github.com/mihongtech/linkchain-core/node/config"
+	"github.com/mihongtech/linkchain-core/proxy/rpc"
+	"github.com/mihongtech/linkchain-core/storage"
+)
+
+// exportChain writes canonical blocks of the local chain to a dump file:
+//
+//	linkchain export [-datadir dir] [-db backend] [-first n] [-last n] file[.gz]
+func exportChain(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	dataDir := flags.String("datadir", config.DefaultDataDir(), "data directory of the chain")
+	backend := flags.String("db", lcdb.DefaultBackend, "database backend of the chain")
+	first := flags.Uint64("first", 0, "height of the first block exported")
+	last := flags.Uint64("last", 0, "height of the last block exported, the head if 0")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: export [-datadir dir] [-db backend] [-first n] [-last n] file")
+	}
+
+	// The blocks are read from the database, neither the node nor its app run
+	s := storage.NewStrorage(&config.BaseConfig{DataDir: *dataDir, DatabaseBackend: *backend})
+	if s == nil {
+		return errors.New("failed to open the chain database")
+	}
+	defer s.GetDB().Close()
+
+	chain := node.NewStoredChain(s.GetDB())
+	if *last == 0 {
+		*last = chain.Head()
+	}
+	return node.ExportChainFile(chain, flags.Arg(0), *first, *last)
+}
+
+// verifyChain checks the consistency of the local chain database, and with
+// -repair rebuilds its indexes and rolls its head back to the last consistent
+// block. The node must not be running:
+//
+//	linkchain verify [-datadir dir] [-db backend] [-repair]
+func verifyChain(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	dataDir := flags.String("datadir", config.DefaultDataDir(), "data directory of the chain")
+	backend := flags.String("db", lcdb.DefaultBackend, "database backend of the chain")
+	repair := flags.Bool("repair", false, "repair the inconsistencies found")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: verify [-datadir dir] [-db backend] [-repair]")
+	}
+
+	s := storage.NewStrorage(&config.BaseConfig{DataDir: *dataDir, DatabaseBackend: *backend})
+	if s == nil {
+		return errors.New("failed to open the chain database")
+	}
+	defer s.GetDB().Close()
+
+	check := chainstorage.VerifyChain
+	if *repair {
+		check = chainstorage.RepairChain
+	}
+	report, err := check(s.GetDB())
+	if err != nil {
+		return err
+	}
+	for _, issue := range report.Issues {
+		fmt.Println(issue)
+	}
+	fmt.Printf("Verified %d blocks, consistent up to #%d, %d issues\n", report.Blocks, report.LastValid, len(report.Issues))
+	if !report.Consistent() {
+		if !*repair {
+			return errors.New("chain database inconsistent, run verify -repair")
+		}
+		fmt.Printf("Repaired, the head is #%d\n", report.LastValid)
+	}
+	return nil
+}
+
+// importChain processes the blocks of a dump file through the local chain,
+// which checks them with the app reached over BCSI RPC:
+//
+//	linkchain import -genesis genesis.json -app host:port [-datadir dir] [-db backend] file[.gz]
+func importChain(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dataDir := flags.String("datadir", config.DefaultDataDir(), "data directory of the chain")
+	backend := flags.String("db", lcdb.DefaultBackend, "database backend of the chain")
+	genesis := flags.String("genesis", "", "genesis file of the chain")
+	app := appFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *genesis == "" || app.RPCServer == "" {
+		return errors.New("usage: import -genesis file -app host:port [-datadir dir] [-db backend] file")
+	}
+
+	bcsiAPI := rpc.NewBCSIRPCClient(app)
+	defer bcsiAPI.Close()
+
+	n := node.NewNode(config.BaseConfig{})
+	cfg := &node.Config{
+		BaseConfig: config.BaseConfig{DataDir: *dataDir, GenesisPath: *genesis, DatabaseBackend: *backend},
+		BcsiAPI:    bcsiAPI,
+	}
+	if !n.Setup(cfg) {
+		return errors.New("failed to set up the node")
+	}
+	defer n.Stop()
+
+	stats, err := n.ImportChain(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d blocks, skipped %d known ones, up to #%d\n", stats.Imported, stats.Skipped, stats.Height)
+	return nil
+}
@@ -2,11 +2,13 @@ package client
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"net"
 	"net/http"
+	"time"
 
 	"github.com/mihongtech/linkchain-core/common/http/rpcjson"
 )
@@ -14,44 +16,93 @@ import (
 type Config struct {
 	RPCUser     string `short:"u" long:"rpcuser" description:"RPC username"`
 	RPCPassword string `short:"P" long:"rpcpass" default-mask:"-" description:"RPC password"`
+	RPCToken    string `long:"rpctoken" default-mask:"-" description:"RPC bearer token, used instead of the username and password"`
 	RPCServer   string `short:"s" long:"rpcserver" description:"RPC server to connect to"`
+	Protobuf    bool   `long:"rpcprotobuf" description:"Send binary protobuf payloads instead of hex inside JSON"`
+
+	Timeout time.Duration `long:"rpctimeout" description:"Time limit of a request, including reading the reply (0 for none)"`
+
+	TLS           bool   `long:"tls" description:"Connect to the RPC server over TLS"`
+	RPCCert       string `short:"c" long:"rpccert" description:"RPC server certificate chain for validation"`
+	ClientCert    string `long:"rpcclientcert" description:"Client certificate presented to the RPC server"`
+	ClientKey     string `long:"rpcclientkey" description:"Key of the client certificate"`
+	TLSSkipVerify bool   `long:"skipverify" description:"Do not verify tls certificates (not recommended!)"`
 }
 
 // newHTTPClient returns a new HTTP client that is configured according to the
-// proxy and TLS settings in the associated connection configuration.
+// TLS settings in the associated connection configuration.
 func newHTTPClient(cfg *Config) (*http.Client, error) {
-	// Configure proxy if needed.
-	var dial func(network, addr string) (net.Conn, error)
+	// Configure TLS if needed.
+	var tlsConfig *tls.Config
+	if cfg.TLS {
+		tlsConfig = &tls.Config{
+			InsecureSkipVerify: cfg.TLSSkipVerify,
+		}
+		if cfg.RPCCert != "" {
+			pem, err := ioutil.ReadFile(cfg.RPCCert)
+			if err != nil {
+				return nil, err
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", cfg.RPCCert)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if cfg.ClientCert != "" || cfg.ClientKey != "" {
+			cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+	}
 
-	// Create and return the new HTTP client potentially configured with a
-	// proxy and TLS.
+	// Create and return the new HTTP client potentially configured with
+	// TLS.
 	client := http.Client{
 		Transport: &http.Transport{
-			Dial: dial,
+			TLSClientConfig: tlsConfig,
 		},
+		Timeout: cfg.Timeout,
 	}
 	return &client, nil
 }
 
+// serverURL returns the URL of the RPC server described in the passed config.
+func serverURL(cfg *Config) string {
+	if cfg.TLS {
+		return "https://" + cfg.RPCServer
+	}
+	return "http://" + cfg.RPCServer
+}
+
+// setAuth configures the authorization of a request, which is the bearer
+// token when there is one and the basic access authorization otherwise.
+func setAuth(httpRequest *http.Request, cfg *Config) {
+	if cfg.RPCToken != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+cfg.RPCToken)
+		return
+	}
+	httpRequest.SetBasicAuth(cfg.RPCUser, cfg.RPCPassword)
+}
+
 // sendPostRequest sends the marshalled JSON-RPC command using HTTP-POST mode
 // to the server described in the passed config struct.  It also attempts to
 // unmarshal the response as a JSON-RPC response and returns either the result
 // field or the error field depending on whether or not there is an error.
 func sendPostRequest(marshalledJSON []byte, cfg *Config) ([]byte, error) {
 	// Generate a request to the configured RPC server.
-	protocol := "http"
-
-	url := protocol + "://" + cfg.RPCServer
 	bodyReader := bytes.NewReader(marshalledJSON)
-	httpRequest, err := http.NewRequest("POST", url, bodyReader)
+	httpRequest, err := http.NewRequest("POST", serverURL(cfg), bodyReader)
 	if err != nil {
 		return nil, err
 	}
 	httpRequest.Close = true
 	httpRequest.Header.Set("Content-Type", "application/json")
 
-	// Configure basic access authorization.
-	httpRequest.SetBasicAuth(cfg.RPCUser, cfg.RPCPassword)
+	// Configure the authorization.
+	setAuth(httpRequest, cfg)
 
 	// Create the new HTTP client that is configured according to the user-
 	// specified options and submit the request.
@@ -68,7 +119,7 @@ func sendPostRequest(marshalledJSON []byte, cfg *Config) ([]byte, error) {
 	respBytes, err := ioutil.ReadAll(httpResponse.Body)
 	httpResponse.Body.Close()
 	if err != nil {
-		err = fmt.Errorf("error reading json reply: %v", err)
+		err = fmt.Errorf("error reading json reply: %w", err)
 		return nil, err
 	}
 
@@ -116,3 +167,20 @@ func RPC(method string, cmd interface{}, cfg *Config) (string, error) {
 
 	return out.String(), nil
 }
+
+// Call sends a JSON-RPC request for method with the passed params and
+// unmarshals its result into result, which is left untouched by a null one.
+func Call(method string, params interface{}, result interface{}, cfg *Config) error {
+	marshalledJSON, err := rpcjson.MarshalCmd(1, method, params)
+	if err != nil {
+		return err
+	}
+	rawResult, err := sendPostRequest(marshalledJSON, cfg)
+	if err != nil {
+		return err
+	}
+	if result == nil || len(rawResult) == 0 {
+		return nil
+	}
+	return json.Unmarshal(rawResult, result)
+}
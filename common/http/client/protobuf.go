@@ -0,0 +1,76 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/mihongtech/linkchain-core/common/http/rpcjson"
+	"github.com/mihongtech/linkchain-core/protobuf"
+)
+
+// ProtobufContentType is the content type of protobuf encoded RPC requests,
+// matching the one accepted by the server package.
+const ProtobufContentType = "application/x-protobuf"
+
+// sendProtobufRequest sends a protobuf encoded request to the server described
+// in the passed config struct and returns the result bytes of the response or
+// its error.
+func sendProtobufRequest(request *protobuf.RPCRequest, cfg *Config) ([]byte, error) {
+	body, err := proto.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	httpRequest, err := http.NewRequest("POST", serverURL(cfg), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Close = true
+	httpRequest.Header.Set("Content-Type", ProtobufContentType)
+	setAuth(httpRequest, cfg)
+
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	httpResponse, err := httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	respBytes, err := ioutil.ReadAll(httpResponse.Body)
+	httpResponse.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error reading protobuf reply: %w", err)
+	}
+	if httpResponse.StatusCode < 200 || httpResponse.StatusCode >= 300 {
+		if len(respBytes) == 0 {
+			return nil, fmt.Errorf("%d %s", httpResponse.StatusCode,
+				http.StatusText(httpResponse.StatusCode))
+		}
+		return nil, fmt.Errorf("%s", respBytes)
+	}
+	if contentType := httpResponse.Header.Get("Content-Type"); contentType != ProtobufContentType {
+		return nil, fmt.Errorf("unexpected reply content type %q", contentType)
+	}
+
+	var resp protobuf.RPCResponse
+	if err := proto.Unmarshal(respBytes, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, rpcjson.NewRPCError(rpcjson.RPCErrorCode(resp.Error.GetCode()), resp.Error.GetMessage())
+	}
+	return resp.Result, nil
+}
+
+//protobuf rpc call, params and result are the protobuf encoded payloads
+func RPCProtobuf(method string, params []byte, cfg *Config) ([]byte, error) {
+	request := &protobuf.RPCRequest{
+		Id:     proto.Uint64(1),
+		Method: proto.String(method),
+		Params: params,
+	}
+	return sendProtobufRequest(request, cfg)
+}
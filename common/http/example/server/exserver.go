@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"time"
 
 	"github.com/mihongtech/linkchain-core/common/http/example"
+	"github.com/mihongtech/linkchain-core/common/http/rpcjson"
 	"github.com/mihongtech/linkchain-core/common/http/server"
 )
 
@@ -31,11 +33,11 @@ func main() {
 	//}()
 }
 
-func getinfo(s *server.Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+func getinfo(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
 	c, ok := cmd.(*example.InfoCmd)
 	if !ok {
 		fmt.Println("Type error:", reflect.TypeOf(cmd))
-		return nil, nil
+		return nil, rpcjson.ErrRPCInvalidParams
 	}
 	fmt.Printf("input id :%s", c.Id)
 	c.Id += "222"
@@ -0,0 +1,80 @@
+// Command rpcgen writes a typed Go client of the methods of an RPC server, from
+// its OpenRPC document read from a file or fetched with rpc_discover.
+//
+//	rpcgen -server localhost:8081 -user u -pass p -pkg coreclient -type Client -o client_gen.go
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/mihongtech/linkchain-core/common/http/client"
+	"github.com/mihongtech/linkchain-core/common/http/rpcgen"
+	"github.com/mihongtech/linkchain-core/common/http/server"
+)
+
+func main() {
+	var (
+		docFile    = flag.String("doc", "", "OpenRPC document file, instead of fetching it from -server")
+		rpcServer  = flag.String("server", "", "RPC server to fetch the document from")
+		user       = flag.String("user", "", "RPC username")
+		pass       = flag.String("pass", "", "RPC password")
+		token      = flag.String("token", "", "RPC bearer token")
+		pkg        = flag.String("pkg", "", "package name of the generated file")
+		importPath = flag.String("import", "", "import path of the generated package, whose types are used as they are")
+		clientType = flag.String("type", "Client", "name of the client type")
+		local      = flag.Bool("local", false, "declare the structs in the generated file instead of importing them")
+		out        = flag.String("o", "", "output file, standard output if empty")
+	)
+	flag.Parse()
+
+	doc := new(server.OpenRPCDocument)
+	source := ""
+	switch {
+	case *docFile != "":
+		data, err := ioutil.ReadFile(*docFile)
+		if err != nil {
+			fatal(err)
+		}
+		if err := json.Unmarshal(data, doc); err != nil {
+			fatal(fmt.Errorf("%s: %v", *docFile, err))
+		}
+		source = *docFile
+	case *rpcServer != "":
+		cfg := &client.Config{RPCServer: *rpcServer, RPCUser: *user, RPCPassword: *pass, RPCToken: *token}
+		if err := client.Call(server.DiscoverMethod, nil, doc, cfg); err != nil {
+			fatal(err)
+		}
+	default:
+		fatal(fmt.Errorf("either -doc or -server is required"))
+	}
+	if *pkg == "" {
+		fatal(fmt.Errorf("-pkg is required"))
+	}
+
+	code, err := rpcgen.Generate(doc, &rpcgen.Options{
+		Package:    *pkg,
+		ImportPath: *importPath,
+		Client:     *clientType,
+		LocalTypes: *local,
+		Source:     source,
+	})
+	if err != nil {
+		fatal(err)
+	}
+	if *out == "" {
+		os.Stdout.Write(code)
+		return
+	}
+	if err := ioutil.WriteFile(*out, code, 0644); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "rpcgen:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,371 @@
+// Package rpcgen generates typed Go clients from the OpenRPC document served by
+// the rpc_discover method of a server.
+//
+// Every method becomes a client method taking the params as arguments, in the
+// order of the fields of the command registered for it, and returning the
+// registered result type.  Methods without a registered result return the raw
+// JSON result.
+package rpcgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mihongtech/linkchain-core/common/http/server"
+)
+
+// ClientImportPath is the import path of the RPC client package the generated
+// code calls.
+const ClientImportPath = "github.com/mihongtech/linkchain-core/common/http/client"
+
+// Options configure the generated code.
+type Options struct {
+	// Package is the name of the package of the generated file.
+	Package string
+
+	// ImportPath is the import path of that package.  The types of the
+	// document defined in it are used as they are.
+	ImportPath string
+
+	// Client is the name of the generated client type.  Its constructor is
+	// New<Client>, unexported for an unexported client.
+	Client string
+
+	// LocalTypes declares the structs of the document in the generated file
+	// instead of importing the Go types they were generated from.
+	LocalTypes bool
+
+	// Source describes where the document came from, for the header.
+	Source string
+}
+
+// generator holds the state of one generation.
+type generator struct {
+	doc  *server.OpenRPCDocument
+	opts *Options
+
+	imports  map[string]string // import path to package name
+	names    map[string]string // package name to import path
+	declared map[string]bool   // components declared or queued
+	queue    []string          // components waiting to be declared
+}
+
+// Generate returns the gofmt-ed source of a client of the methods of doc.
+func Generate(doc *server.OpenRPCDocument, opts *Options) ([]byte, error) {
+	if !token.IsIdentifier(opts.Package) || !token.IsIdentifier(opts.Client) {
+		return nil, fmt.Errorf("invalid package %q or client %q name", opts.Package, opts.Client)
+	}
+	g := &generator{
+		doc:      doc,
+		opts:     opts,
+		imports:  make(map[string]string),
+		names:    make(map[string]string),
+		declared: make(map[string]bool),
+	}
+	g.qualify(ClientImportPath + ".Config")
+
+	var body bytes.Buffer
+	constructor := "New" + opts.Client
+	if !token.IsExported(opts.Client) {
+		constructor = "new" + exported(opts.Client)
+	}
+	fmt.Fprintf(&body, "// %s calls the methods of %s over JSON-RPC.\n", opts.Client, doc.Info.Title)
+	fmt.Fprintf(&body, "type %s struct {\n\tcfg *client.Config\n}\n\n", opts.Client)
+	fmt.Fprintf(&body, "// %s returns a client of the server described by cfg.\n", constructor)
+	fmt.Fprintf(&body, "func %s(cfg *client.Config) *%s {\n\treturn &%s{cfg: cfg}\n}\n", constructor, opts.Client, opts.Client)
+
+	methods := make([]*server.OpenRPCMethod, len(doc.Methods))
+	copy(methods, doc.Methods)
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+	for _, method := range methods {
+		if method.Name == server.DiscoverMethod {
+			continue
+		}
+		if err := g.method(&body, method); err != nil {
+			return nil, err
+		}
+	}
+	for len(g.queue) > 0 {
+		name := g.queue[0]
+		g.queue = g.queue[1:]
+		if err := g.declare(&body, name); err != nil {
+			return nil, err
+		}
+	}
+
+	var src bytes.Buffer
+	source := opts.Source
+	if source == "" {
+		source = "the OpenRPC document of " + doc.Info.Title
+	}
+	fmt.Fprintf(&src, "// Code generated by rpcgen from %s. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&src, "package %s\n\nimport (\n", opts.Package)
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		// Standard packages first, as goimports groups them.
+		if std := isStandard(paths[i]); std != isStandard(paths[j]) {
+			return std
+		}
+		return paths[i] < paths[j]
+	})
+	for i, path := range paths {
+		if i > 0 && isStandard(path) != isStandard(paths[i-1]) {
+			src.WriteString("\n")
+		}
+		if name := g.imports[path]; name != path[strings.LastIndex(path, "/")+1:] {
+			fmt.Fprintf(&src, "\t%s %q\n", name, path)
+		} else {
+			fmt.Fprintf(&src, "\t%q\n", path)
+		}
+	}
+	fmt.Fprintf(&src, ")\n\n")
+	src.Write(body.Bytes())
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid code: %v", err)
+	}
+	return out, nil
+}
+
+// method writes the client method calling method.
+func (g *generator) method(w *bytes.Buffer, method *server.OpenRPCMethod) error {
+	var (
+		args   []string
+		fields []string
+		values []string
+		used   = map[string]bool{"c": true, "params": true, "result": true, "err": true, "client": true}
+	)
+	for _, param := range method.Params {
+		typ, err := g.goType(param.Schema)
+		if err != nil {
+			return fmt.Errorf("method %s param %s: %v", method.Name, param.Name, err)
+		}
+		arg := unexported(exported(param.Name))
+		for token.Lookup(arg).IsKeyword() || used[arg] {
+			arg += "Arg"
+		}
+		used[arg] = true
+		args = append(args, arg+" "+typ)
+		fields = append(fields, fmt.Sprintf("%s %s %s", exported(param.Name), typ, jsonTag(param.Name, param.Required)))
+		values = append(values, arg)
+	}
+	result := "json.RawMessage"
+	if method.Result != nil && !isAny(method.Result.Schema) {
+		typ, err := g.goType(method.Result.Schema)
+		if err != nil {
+			return fmt.Errorf("method %s result: %v", method.Name, err)
+		}
+		result = typ
+		if method.Result.Schema.Ref != "" {
+			result = "*" + typ
+		}
+	} else {
+		g.qualify("encoding/json.RawMessage")
+	}
+
+	name := exported(method.Name)
+	fmt.Fprintf(w, "\n// %s calls the %s method.\n", name, method.Name)
+	fmt.Fprintf(w, "func (c *%s) %s(%s) (%s, error) {\n", g.opts.Client, name, strings.Join(args, ", "), result)
+	params := "nil"
+	if len(fields) > 0 {
+		fmt.Fprintf(w, "\tparams := struct {\n\t\t%s\n\t}{%s}\n", strings.Join(fields, "\n\t\t"), strings.Join(values, ", "))
+		params = "&params"
+	}
+	fmt.Fprintf(w, "\tvar result %s\n", result)
+	fmt.Fprintf(w, "\terr := client.Call(%q, %s, &result, c.cfg)\n", method.Name, params)
+	fmt.Fprintf(w, "\treturn result, err\n}\n")
+	return nil
+}
+
+// declare writes the struct declaration of a component.
+func (g *generator) declare(w *bytes.Buffer, name string) error {
+	typ, err := g.structType(g.doc.Components.Schemas[name])
+	if err != nil {
+		return fmt.Errorf("schema %s: %v", name, err)
+	}
+	fmt.Fprintf(w, "\n// %s is the %s schema of the document.\n", exported(name), name)
+	fmt.Fprintf(w, "type %s %s\n", exported(name), typ)
+	return nil
+}
+
+// goType returns the Go type of the values of schema.
+func (g *generator) goType(schema *server.Schema) (string, error) {
+	if schema == nil {
+		return "interface{}", nil
+	}
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, server.ComponentRef)
+		component, ok := g.doc.Components.Schemas[name]
+		if !ok || name == schema.Ref {
+			return "", fmt.Errorf("unknown schema %s", schema.Ref)
+		}
+		if component.GoType != "" && !g.opts.LocalTypes {
+			return g.qualify(component.GoType), nil
+		}
+		if !g.declared[name] {
+			g.declared[name] = true
+			g.queue = append(g.queue, name)
+		}
+		return exported(name), nil
+	}
+	if schema.GoType != "" {
+		return g.qualify(schema.GoType), nil
+	}
+
+	switch schema.Type {
+	case "boolean":
+		return "bool", nil
+	case "integer":
+		switch schema.Format {
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+			return schema.Format, nil
+		}
+		return "int64", nil
+	case "number":
+		if schema.Format == "float32" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "string":
+		if schema.Format == "byte" {
+			return "[]byte", nil
+		}
+		return "string", nil
+	case "array":
+		items, err := g.goType(schema.Items)
+		if err != nil {
+			return "", err
+		}
+		if schema.Items != nil && schema.Items.Ref != "" {
+			items = "*" + items
+		}
+		return "[]" + items, nil
+	case "object":
+		if schema.Properties != nil {
+			return g.structType(schema)
+		}
+		if schema.AdditionalProperties != nil {
+			values, err := g.goType(schema.AdditionalProperties)
+			if err != nil {
+				return "", err
+			}
+			return "map[string]" + values, nil
+		}
+		return "map[string]interface{}", nil
+	}
+	return "interface{}", nil
+}
+
+// structType returns the struct type of an object schema.
+func (g *generator) structType(schema *server.Schema) (string, error) {
+	order := schema.Order
+	if len(order) != len(schema.Properties) {
+		order = make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			order = append(order, name)
+		}
+		sort.Strings(order)
+	}
+	var fields []string
+	for _, name := range order {
+		typ, err := g.goType(schema.Properties[name])
+		if err != nil {
+			return "", fmt.Errorf("property %s: %v", name, err)
+		}
+		required := false
+		for _, r := range schema.Required {
+			required = required || r == name
+		}
+		fields = append(fields, fmt.Sprintf("%s %s %s", exported(name), typ, jsonTag(name, required)))
+	}
+	if len(fields) == 0 {
+		return "struct{}", nil
+	}
+	return "struct {\n" + strings.Join(fields, "\n") + "\n}", nil
+}
+
+// qualify returns the name of an import path qualified type in the generated
+// file, importing its package.
+func (g *generator) qualify(goType string) string {
+	star := ""
+	if strings.HasPrefix(goType, "*") {
+		star, goType = "*", goType[1:]
+	}
+	dot := strings.LastIndex(goType, ".")
+	if dot < 0 {
+		return star + goType
+	}
+	path, typ := goType[:dot], goType[dot+1:]
+	if path == g.opts.ImportPath {
+		return star + typ
+	}
+	name, ok := g.imports[path]
+	if !ok {
+		base := path[strings.LastIndex(path, "/")+1:]
+		name = base
+		for i := 2; g.names[name] != "" || name == g.opts.Package; i++ {
+			name = fmt.Sprintf("%s%d", base, i)
+		}
+		g.imports[path] = name
+		g.names[name] = path
+	}
+	return star + name + "." + typ
+}
+
+// isStandard tells whether path is the import path of a standard package.
+func isStandard(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+// isAny tells whether schema accepts any value.
+func isAny(schema *server.Schema) bool {
+	return schema == nil || (schema.Ref == "" && schema.Type == "" && schema.GoType == "")
+}
+
+func jsonTag(name string, required bool) string {
+	if required {
+		return fmt.Sprintf("`json:%q`", name)
+	}
+	return fmt.Sprintf("`json:%q`", name+",omitempty")
+}
+
+// exported returns the exported Go identifier of a JSON name, joining its
+// words in camel case.
+func exported(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if b.Len() == 0 && unicode.IsDigit(r) {
+			b.WriteRune('X')
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return "X"
+	}
+	return b.String()
+}
+
+// unexported returns an identifier with its first letter lowered.
+func unexported(name string) string {
+	r := []rune(name)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
@@ -0,0 +1,54 @@
+package rpcgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mihongtech/linkchain-core/common/http/server"
+)
+
+func TestGenerateLocalTypes(t *testing.T) {
+	doc := &server.OpenRPCDocument{
+		Info: server.OpenRPCInfo{Title: "test"},
+		Methods: []*server.OpenRPCMethod{{
+			Name: "get_block",
+			Params: []*server.ContentDescriptor{
+				{Name: "hash", Required: true, Schema: &server.Schema{Type: "string", GoType: "github.com/mihongtech/linkchain-core/common/math.Hash"}},
+				{Name: "type", Schema: &server.Schema{Type: "integer", Format: "uint32"}},
+			},
+			Result: &server.ContentDescriptor{Name: "result", Schema: &server.Schema{Ref: server.ComponentRef + "block"}},
+		}, {
+			Name:   "ping",
+			Result: &server.ContentDescriptor{Name: "result", Schema: &server.Schema{}},
+		}},
+		Components: server.OpenRPCComponents{Schemas: map[string]*server.Schema{
+			"block": {
+				Type:       "object",
+				GoType:     "example.com/chain.Block",
+				Order:      []string{"data", "txs"},
+				Required:   []string{"data"},
+				Properties: map[string]*server.Schema{"data": {Type: "string", Format: "byte"}, "txs": {Type: "array", Items: &server.Schema{Ref: server.ComponentRef + "tx"}}},
+			},
+			"tx": {Type: "object", Properties: map[string]*server.Schema{"id": {Type: "string"}}},
+		}},
+	}
+	code, err := Generate(doc, &Options{Package: "chain", Client: "Client", LocalTypes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"func (c *Client) GetBlock(hash math.Hash, typeArg uint32) (*Block, error) {",
+		"Type uint32    `json:\"type,omitempty\"`",
+		"func (c *Client) Ping() (json.RawMessage, error) {",
+		"Data []byte `json:\"data\"`",
+		"Txs  []*Tx  `json:\"txs,omitempty\"`",
+		"type Tx struct {",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Errorf("generated code lacks %q:\n%s", want, code)
+		}
+	}
+	if strings.Contains(string(code), "example.com/chain") {
+		t.Errorf("local types imported:\n%s", code)
+	}
+}
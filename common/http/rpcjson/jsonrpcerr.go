@@ -4,6 +4,10 @@
 
 package rpcjson
 
+import (
+	"encoding/json"
+)
+
 // Standard JSON-RPC 2.0 errors.
 var (
 	ErrRPCInvalidRequest = &RPCError{
@@ -83,3 +87,37 @@ const (
 	ErrRPCNoWallet      RPCErrorCode = -1
 	ErrRPCUnimplemented RPCErrorCode = -1
 )
+
+// CodedError is implemented by errors which carry their own RPC error code,
+// so handlers can return typed Go errors instead of building RPCErrors.
+type CodedError interface {
+	error
+	RPCErrorCode() RPCErrorCode
+}
+
+// ToRPCError converts an error returned while serving a request to the
+// RPCError sent to the client.  RPCErrors and CodedErrors keep their code,
+// errors of this package and JSON decoding errors of the parameters map to the
+// matching standard error, anything else is an internal error.
+func ToRPCError(err error) *RPCError {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *RPCError:
+		return e
+	case RPCError:
+		return &e
+	case CodedError:
+		return NewRPCError(e.RPCErrorCode(), e.Error())
+	case Error:
+		switch e.ErrorCode {
+		case ErrUnregisteredMethod:
+			return NewRPCError(ErrRPCMethodNotFound.Code, e.Description)
+		case ErrInvalidType, ErrNumParams, ErrMismatchedDefault:
+			return NewRPCError(ErrRPCInvalidParams.Code, e.Description)
+		}
+	case *json.UnmarshalTypeError, *json.SyntaxError:
+		return NewRPCError(ErrRPCInvalidParams.Code, ErrRPCInvalidParams.Message+": "+err.Error())
+	}
+	return NewRPCError(ErrRPCInternal.Code, err.Error())
+}
@@ -114,6 +114,15 @@ type Response struct {
 	ID     *interface{}    `json:"id"`
 }
 
+// ResponseV2 is the form of a JSON-RPC 2.0 response, which carries either the
+// result or the error, never both.
+type ResponseV2 struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+	ID      interface{}     `json:"id"`
+}
+
 // NewResponse returns a new JSON-RPC response rpcobject given the provided id,
 // marshalled result, and RPC error.  This function is only provided in case the
 // caller wants to construct raw responses for some reason.
@@ -148,6 +157,24 @@ func MarshalResponse(id interface{}, result interface{}, rpcErr *RPCError) ([]by
 	return json.Marshal(&response)
 }
 
+// MarshalResponseV2 marshals the passed id, result, and RPCError to a JSON-RPC
+// 2.0 response byte slice.  The result is left out when there is an error.
+func MarshalResponseV2(id interface{}, result interface{}, rpcErr *RPCError) ([]byte, error) {
+	if !IsValidIDType(id) {
+		str := fmt.Sprintf("the id of type '%T' is invalid", id)
+		return nil, makeError(ErrInvalidType, str)
+	}
+	response := ResponseV2{Jsonrpc: "2.0", Error: rpcErr, ID: id}
+	if rpcErr == nil {
+		marshalledResult, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		response.Result = marshalledResult
+	}
+	return json.Marshal(&response)
+}
+
 // MarshalCmd marshals the passed command to a JSON-RPC request byte slice that
 // is suitable for transmission to an RPC server.  The provided command type
 // must be a registered type.  All commands provided by this package are
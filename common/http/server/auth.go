@@ -0,0 +1,82 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// bearerPrefix starts the Authorization header of a request authenticated by
+// a token.
+const bearerPrefix = "Bearer "
+
+// secureEqual compares two secrets in constant time.  The secrets are hashed
+// first so neither their content nor their length leaks through timing.
+func secureEqual(a, b string) bool {
+	ha := sha256.Sum256([]byte(a))
+	hb := sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(ha[:], hb[:]) == 1
+}
+
+// checkAuth validates the authentication of a request, which is either the
+// basic authentication with the configured user and password, or a bearer
+// token among the configured ones.
+func (s *Server) checkAuth(r *http.Request) bool {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, bearerPrefix) {
+		token := strings.TrimPrefix(auth, bearerPrefix)
+		// Compare against every token so the time taken doesn't tell
+		// which one matched.
+		valid := false
+		for _, expected := range s.config.Tokens {
+			if secureEqual(expected, token) && expected != "" {
+				valid = true
+			}
+		}
+		return valid
+	}
+	rpcuser, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	userOk := secureEqual(s.config.rpcuser, rpcuser)
+	passwordOk := secureEqual(s.config.password, password)
+	return userOk && passwordOk
+}
+
+// tlsListener wraps a listener to serve TLS with the configured certificate.
+// Clients must present a certificate signed by ClientCA when one is set.
+func (s *Server) tlsListener(listener net.Listener) (net.Listener, error) {
+	if s.config.TLSCert == "" && s.config.TLSKey == "" {
+		if s.config.ClientCA != "" {
+			return nil, errors.New("client certificate verification requires a TLS certificate")
+		}
+		return listener, nil
+	}
+	cert, err := tls.LoadX509KeyPair(s.config.TLSCert, s.config.TLSKey)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if s.config.ClientCA != "" {
+		pem, err := ioutil.ReadFile(s.config.ClientCA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in " + s.config.ClientCA)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tls.NewListener(listener, tlsConfig), nil
+}
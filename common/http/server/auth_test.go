@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/http/client"
+)
+
+func TestCheckAuth(t *testing.T) {
+	cfg := NewConfig("test", 0, "", "user", "pass")
+	cfg.Tokens = []string{"token-a", "token-b"}
+	srv, _ := NewRPCServer(cfg, nil)
+
+	tests := []struct {
+		user, pass string
+		bearer     string
+		valid      bool
+	}{
+		{user: "user", pass: "pass", valid: true},
+		{user: "user", pass: "wrong"},
+		{user: "other", pass: "pass"},
+		{bearer: "token-b", valid: true},
+		{bearer: "token-c"},
+		{bearer: ""},
+	}
+	for i, tt := range tests {
+		r, _ := http.NewRequest("POST", "http://localhost", nil)
+		if tt.bearer != "" || tt.user == "" {
+			r.Header.Set("Authorization", "Bearer "+tt.bearer)
+		} else {
+			r.SetBasicAuth(tt.user, tt.pass)
+		}
+		if valid := srv.checkAuth(r); valid != tt.valid {
+			t.Errorf("test %d: have %v, want %v", i, valid, tt.valid)
+		}
+	}
+	// Without configured tokens no bearer token is accepted
+	r, _ := http.NewRequest("POST", "http://localhost", nil)
+	r.Header.Set("Authorization", "Bearer ")
+	srv, _ = NewRPCServer(NewConfig("test", 0, "", "user", "pass"), nil)
+	if srv.checkAuth(r) {
+		t.Errorf("empty bearer token accepted")
+	}
+}
+
+// writeTestCert creates a certificate signed by the parent, or a self signed
+// CA without parent, and writes it with its key to dir.
+func writeTestCert(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".crt"), certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+type echoCmd struct {
+	Data string `json:"data"`
+}
+
+func TestTLSClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeTestCert(t, dir, "ca", nil, nil)
+	writeTestCert(t, dir, "server", ca, caKey)
+	writeTestCert(t, dir, "client", ca, caKey)
+	other, otherKey := writeTestCert(t, dir, "other-ca", nil, nil)
+	writeTestCert(t, dir, "stranger", other, otherKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	cfg := NewConfig("test", time.Now().Unix(), addr, "user", "pass")
+	cfg.Tokens = []string{"secret"}
+	cfg.TLSCert = filepath.Join(dir, "server.crt")
+	cfg.TLSKey = filepath.Join(dir, "server.key")
+	cfg.ClientCA = filepath.Join(dir, "ca.crt")
+	srv, _ := NewRPCServer(cfg, nil)
+	srv.SetCmd("echo", reflect.TypeOf((*echoCmd)(nil)))
+	srv.SetHandleFunc("echo", func(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+		return cmd, nil
+	})
+	if err := srv.Start(); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer srv.Stop()
+
+	clientCfg := client.Config{
+		RPCServer:  addr,
+		RPCToken:   "secret",
+		TLS:        true,
+		RPCCert:    filepath.Join(dir, "ca.crt"),
+		ClientCert: filepath.Join(dir, "client.crt"),
+		ClientKey:  filepath.Join(dir, "client.key"),
+	}
+	if reply, err := client.RPC("echo", echoCmd{Data: "hello"}, &clientCfg); err != nil {
+		t.Fatalf("mutual TLS call failed: %v", err)
+	} else if want := "{\n  \"data\": \"hello\"\n}"; reply != want {
+		t.Fatalf("reply: have %q, want %q", reply, want)
+	}
+
+	wrongToken := clientCfg
+	wrongToken.RPCToken = "guess"
+	if _, err := client.RPC("echo", echoCmd{Data: "hello"}, &wrongToken); err == nil {
+		t.Fatalf("wrong token accepted")
+	}
+	noCert := clientCfg
+	noCert.ClientCert, noCert.ClientKey = "", ""
+	if _, err := client.RPC("echo", echoCmd{Data: "hello"}, &noCert); err == nil {
+		t.Fatalf("client without certificate accepted")
+	}
+	stranger := clientCfg
+	stranger.ClientCert, stranger.ClientKey = filepath.Join(dir, "stranger.crt"), filepath.Join(dir, "stranger.key")
+	if _, err := client.RPC("echo", echoCmd{Data: "hello"}, &stranger); err == nil {
+		t.Fatalf("client certificate of unknown authority accepted")
+	}
+	untrusted := clientCfg
+	untrusted.RPCCert = filepath.Join(dir, "other-ca.crt")
+	if _, err := client.RPC("echo", echoCmd{Data: "hello"}, &untrusted); err == nil {
+		t.Fatalf("server certificate of unknown authority trusted")
+	}
+	plain := clientCfg
+	plain.TLS = false
+	if _, err := client.RPC("echo", echoCmd{Data: "hello"}, &plain); err == nil {
+		t.Fatalf("plain text call to TLS server succeeded")
+	}
+}
@@ -0,0 +1,265 @@
+package server
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// DiscoverMethod is the method returning the OpenRPC document of the
+	// server.
+	DiscoverMethod = "rpc_discover"
+
+	// OpenRPCVersion is the version of the OpenRPC specification the
+	// documents follow.
+	OpenRPCVersion = "1.2.6"
+)
+
+// OpenRPCDocument describes the methods served, following the OpenRPC
+// specification.  Params are passed by name, as the fields of the command
+// registered for the method.
+type OpenRPCDocument struct {
+	OpenRPC    string            `json:"openrpc"`
+	Info       OpenRPCInfo       `json:"info"`
+	Methods    []*OpenRPCMethod  `json:"methods"`
+	Components OpenRPCComponents `json:"components"`
+}
+
+// OpenRPCInfo is the metadata of an OpenRPC document.
+type OpenRPCInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenRPCMethod describes a method, its params and its result.
+type OpenRPCMethod struct {
+	Name           string               `json:"name"`
+	ParamStructure string               `json:"paramStructure"`
+	Params         []*ContentDescriptor `json:"params"`
+	Result         *ContentDescriptor   `json:"result"`
+}
+
+// ContentDescriptor describes a param or a result.
+type ContentDescriptor struct {
+	Name     string  `json:"name"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// OpenRPCComponents holds the schemas of the named structs, referred to by
+// the other schemas.
+type OpenRPCComponents struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema is the JSON schema of a value.  GoType is the Go type the schema was
+// generated from, for the types with their own JSON encoding and the named
+// structs, and Order lists the properties of a struct in the order of its
+// fields.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	GoType               string             `json:"x-go-type,omitempty"`
+	Order                []string           `json:"x-order,omitempty"`
+}
+
+// ComponentRef is the prefix of the references to the component schemas.
+const ComponentRef = "#/components/schemas/"
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Discover returns the OpenRPC document of the methods registered so far.
+func (s *Server) Discover() *OpenRPCDocument {
+	doc := &OpenRPCDocument{
+		OpenRPC:    OpenRPCVersion,
+		Info:       OpenRPCInfo{Title: s.config.Name, Version: "1.0.0"},
+		Methods:    make([]*OpenRPCMethod, 0, len(s.handlerPool)),
+		Components: OpenRPCComponents{Schemas: make(map[string]*Schema)},
+	}
+	for name := range s.handlerPool {
+		if name == DiscoverMethod {
+			continue
+		}
+		method := &OpenRPCMethod{
+			Name:           name,
+			ParamStructure: "by-name",
+			Params:         []*ContentDescriptor{},
+			Result:         &ContentDescriptor{Name: "result", Schema: &Schema{}},
+		}
+		if cmdType, ok := s.cmdPool[name]; ok {
+			for cmdType.Kind() == reflect.Ptr {
+				cmdType = cmdType.Elem()
+			}
+			cmd := doc.schema(cmdType, false)
+			for _, field := range cmd.Order {
+				method.Params = append(method.Params, &ContentDescriptor{
+					Name:     field,
+					Required: contains(cmd.Required, field),
+					Schema:   cmd.Properties[field],
+				})
+			}
+		}
+		if resultType, ok := s.resultPool[name]; ok {
+			method.Result.Schema = doc.schema(resultType, true)
+		}
+		doc.Methods = append(doc.Methods, method)
+	}
+	sort.Slice(doc.Methods, func(i, j int) bool {
+		return doc.Methods[i].Name < doc.Methods[j].Name
+	})
+	return doc
+}
+
+// onDiscover is the handler of DiscoverMethod.
+func onDiscover(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	return s.Discover(), nil
+}
+
+// schema returns the schema of values of type t, adding the named structs to
+// the components unless inline is false for t itself.
+func (doc *OpenRPCDocument) schema(t reflect.Type, inline bool) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Types with their own encoding are described by a sample of it.
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return &Schema{Type: sampleType(t), GoType: goTypeName(t, jsonMarshalerType)}
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return &Schema{Type: "string", GoType: goTypeName(t, textMarshalerType)}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: t.Kind().String()}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: t.Kind().String()}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: doc.schema(t.Elem(), true)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: doc.schema(t.Elem(), true)}
+	case reflect.Struct:
+		if t.Name() == "" || !inline {
+			return doc.structSchema(t)
+		}
+		name := t.Name()
+		if known, ok := doc.Components.Schemas[name]; ok && known.GoType != goTypeName(t, nil) {
+			name = strings.Replace(t.PkgPath(), "/", "_", -1) + "_" + name
+		}
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			// Register the name first so recursive types refer to it.
+			doc.Components.Schemas[name] = &Schema{GoType: goTypeName(t, nil)}
+			schema := doc.structSchema(t)
+			schema.GoType = goTypeName(t, nil)
+			doc.Components.Schemas[name] = schema
+		}
+		return &Schema{Ref: ComponentRef + name}
+	}
+	return &Schema{}
+}
+
+// structSchema returns the object schema of the exported fields of a struct,
+// following the rules of encoding/json.
+func (doc *OpenRPCDocument) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+		name, opts := tag, ""
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, opts = tag[:i], tag[i:]
+		}
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			embedded := doc.structSchema(fieldType)
+			for _, name := range embedded.Order {
+				schema.Properties[name] = embedded.Properties[name]
+				schema.Order = append(schema.Order, name)
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = doc.schema(field.Type, true)
+		schema.Order = append(schema.Order, name)
+		if !strings.Contains(opts, ",omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// sampleType returns the JSON type of the encoding of a zero value of t.
+func sampleType(t reflect.Type) string {
+	sample, err := json.Marshal(reflect.New(t).Interface())
+	if err != nil || len(sample) == 0 {
+		return ""
+	}
+	switch sample[0] {
+	case '"':
+		return "string"
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return ""
+	}
+	if strings.ContainsAny(string(sample), ".eE") {
+		return "number"
+	}
+	return "integer"
+}
+
+// goTypeName returns the import path qualified name of t, starred if only
+// pointers to t implement iface.
+func goTypeName(t reflect.Type, iface reflect.Type) string {
+	name := t.PkgPath() + "." + t.Name()
+	if iface != nil && !t.Implements(iface) {
+		name = "*" + name
+	}
+	return name
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
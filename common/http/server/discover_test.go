@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/http/rpcjson"
+)
+
+type discoverResult struct {
+	Difference int               `json:"difference"`
+	Started    time.Time         `json:"started"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Next       *discoverResult   `json:"next,omitempty"`
+	hidden     bool
+}
+
+func TestDiscover(t *testing.T) {
+	srv, addr := newTestJSONRPCServer(t)
+	defer srv.Stop()
+	srv.SetResult("subtract", reflect.TypeOf((*discoverResult)(nil)))
+
+	req, _ := http.NewRequest("POST", "http://"+addr, strings.NewReader(`{"jsonrpc": "2.0", "method": "rpc_discover", "id": 1}`))
+	req.SetBasicAuth("user", "pass")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var reply rpcjson.Response
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil || reply.Error != nil {
+		t.Fatalf("rpc_discover: %v %v", err, reply.Error)
+	}
+	var doc OpenRPCDocument
+	if err := json.Unmarshal(reply.Result, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenRPC != OpenRPCVersion || len(doc.Methods) != 5 {
+		t.Fatalf("document: have version %s and %d methods", doc.OpenRPC, len(doc.Methods))
+	}
+
+	var subtract *OpenRPCMethod
+	for _, method := range doc.Methods {
+		if method.Name == DiscoverMethod {
+			t.Fatal("rpc_discover documented")
+		}
+		if method.Name == "subtract" {
+			subtract = method
+		}
+	}
+	if subtract == nil || len(subtract.Params) != 2 || subtract.Params[0].Name != "minuend" || !subtract.Params[1].Required ||
+		subtract.Params[1].Schema.Type != "integer" || subtract.Params[1].Schema.Format != "int" {
+		t.Fatalf("subtract params: have %+v", subtract)
+	}
+	if subtract.Result.Schema.Ref != ComponentRef+"discoverResult" {
+		t.Fatalf("subtract result: have %+v", subtract.Result.Schema)
+	}
+	result := doc.Components.Schemas["discoverResult"]
+	if result == nil || !reflect.DeepEqual(result.Order, []string{"difference", "started", "tags", "next"}) ||
+		!reflect.DeepEqual(result.Required, []string{"difference", "started"}) {
+		t.Fatalf("result schema: have %+v", result)
+	}
+	if started := result.Properties["started"]; started.Type != "string" || started.GoType != "time.Time" {
+		t.Fatalf("time schema: have %+v", started)
+	}
+	if next := result.Properties["next"]; next.Ref != subtract.Result.Schema.Ref {
+		t.Fatalf("recursive schema: have %+v", next)
+	}
+	if tags := result.Properties["tags"]; tags.Type != "object" || tags.AdditionalProperties.Type != "string" {
+		t.Fatalf("map schema: have %+v", tags)
+	}
+}
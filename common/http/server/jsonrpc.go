@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mihongtech/linkchain-core/common/http/rpcjson"
+)
+
+// RPCMaxBatchSize is the maximum number of requests in a JSON-RPC 2.0 batch.
+const RPCMaxBatchSize = 100
+
+// isBatch reports whether a request body is a JSON-RPC 2.0 batch, which is an
+// array of requests.
+func isBatch(body []byte) bool {
+	body = bytes.TrimLeft(body, " \t\r\n")
+	return len(body) > 0 && body[0] == '['
+}
+
+// processBatch serves the requests of a batch one after the other and returns
+// the array of their responses.  Nothing is returned when all of them are
+// notifications.
+func (s *Server) processBatch(ctx context.Context, body []byte) ([]byte, error) {
+	var requests []json.RawMessage
+	if err := json.Unmarshal(body, &requests); err != nil {
+		return rpcjson.MarshalResponseV2(nil, nil, &rpcjson.RPCError{
+			Code:    rpcjson.ErrRPCParse.Code,
+			Message: "Failed to parse request: " + err.Error(),
+		})
+	}
+	if len(requests) == 0 {
+		return rpcjson.MarshalResponseV2(nil, nil, rpcjson.ErrRPCInvalidRequest)
+	}
+	if len(requests) > RPCMaxBatchSize {
+		return rpcjson.MarshalResponseV2(nil, nil, rpcjson.NewRPCError(rpcjson.ErrRPCInvalidRequest.Code,
+			fmt.Sprintf("batch of %d requests exceeds the limit of %d", len(requests), RPCMaxBatchSize)))
+	}
+
+	responses := make([]json.RawMessage, 0, len(requests))
+	for _, request := range requests {
+		msg, err := s.processRequest(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		if msg != nil {
+			responses = append(responses, msg)
+		}
+	}
+	if len(responses) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(responses)
+}
+
+// processRequest serves a single request and returns its marshalled response,
+// or nothing for a notification.  JSON-RPC 2.0 requests are answered in the
+// 2.0 format, others in the 1.0 format.  Requests which can't be told apart
+// are answered in the 2.0 format, which 1.0 clients can read as well.
+func (s *Server) processRequest(ctx context.Context, body []byte) ([]byte, error) {
+	var request rpcjson.Request
+	if err := json.Unmarshal(body, &request); err != nil {
+		if !json.Valid(body) {
+			return rpcjson.MarshalResponseV2(nil, nil, &rpcjson.RPCError{
+				Code:    rpcjson.ErrRPCParse.Code,
+				Message: "Failed to parse request: " + err.Error(),
+			})
+		}
+		return rpcjson.MarshalResponseV2(nil, nil, rpcjson.ErrRPCInvalidRequest)
+	}
+	// The id member is missing from notifications, which is not the same
+	// as a null id.
+	var id struct {
+		ID json.RawMessage `json:"id"`
+	}
+	json.Unmarshal(body, &id)
+
+	version2 := request.Jsonrpc == "2.0"
+	if !rpcjson.IsValidIDType(request.ID) {
+		return rpcjson.MarshalResponseV2(nil, nil, rpcjson.ErrRPCInvalidRequest)
+	}
+	if !version2 && request.Jsonrpc != "" && request.Jsonrpc != "1.0" || request.Method == "" {
+		return rpcjson.MarshalResponseV2(request.ID, nil, rpcjson.ErrRPCInvalidRequest)
+	}
+	if !version2 && request.ID == nil && !(RPCQuirks && request.Jsonrpc == "") {
+		// JSON-RPC 1.0 notifications are ignored.
+		return nil, nil
+	}
+
+	// Attempt to parse the JSON-RPC request into a known concrete command.
+	var result interface{}
+	cmd, err := s.parseCmd(&request)
+	if err == nil {
+		result, err = s.standardCmdResult(ctx, request.Method, cmd)
+	}
+	if version2 && id.ID == nil {
+		return nil, nil
+	}
+	if version2 {
+		return rpcjson.MarshalResponseV2(request.ID, result, toRPCError(err))
+	}
+	return createMarshalledReply(request.ID, result, err)
+}
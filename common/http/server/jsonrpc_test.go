@@ -0,0 +1,235 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/http/rpcjson"
+)
+
+type subtractCmd struct {
+	Minuend    int `json:"minuend"`
+	Subtrahend int `json:"subtrahend"`
+}
+
+// typedError carries its own RPC error code.
+type typedError struct{}
+
+func (typedError) Error() string                      { return "custom failure" }
+func (typedError) RPCErrorCode() rpcjson.RPCErrorCode { return -32000 }
+
+func newTestJSONRPCServer(t *testing.T) (*Server, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	srv, _ := NewRPCServer(NewConfig("test", time.Now().Unix(), addr, "user", "pass"), nil)
+	srv.SetCmd("subtract", reflect.TypeOf((*subtractCmd)(nil)))
+	srv.SetHandleFunc("subtract", func(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+		c, ok := cmd.(*subtractCmd)
+		if !ok {
+			return nil, rpcjson.ErrRPCInvalidParams
+		}
+		return c.Minuend - c.Subtrahend, nil
+	})
+	srv.SetHandleFunc("update", func(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	srv.SetHandleFunc("get_data", func(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+		return []interface{}{"hello", 5}, nil
+	})
+	srv.SetHandleFunc("fail_typed", func(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+		return nil, typedError{}
+	})
+	srv.SetHandleFunc("fail_plain", func(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+	if err := srv.Start(); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	return srv, addr
+}
+
+// stripMessages removes the error messages from a decoded response wherever
+// the expected response leaves them out.
+func stripMessages(want, have interface{}) {
+	switch w := want.(type) {
+	case []interface{}:
+		if h, ok := have.([]interface{}); ok && len(h) == len(w) {
+			for i := range w {
+				stripMessages(w[i], h[i])
+			}
+		}
+	case map[string]interface{}:
+		h, ok := have.(map[string]interface{})
+		if !ok {
+			return
+		}
+		if we, ok := w["error"].(map[string]interface{}); ok {
+			if he, ok := h["error"].(map[string]interface{}); ok {
+				if _, ok := we["message"]; !ok {
+					delete(he, "message")
+				}
+			}
+		}
+	}
+}
+
+// Tests the server against the examples of the JSON-RPC 2.0 specification and
+// the error mapping of the handlers.
+func TestJSONRPCConformance(t *testing.T) {
+	srv, addr := newTestJSONRPCServer(t)
+	defer srv.Stop()
+
+	batch := make([]string, RPCMaxBatchSize+1)
+	for i := range batch {
+		batch[i] = `{"jsonrpc": "2.0", "method": "get_data", "id": 1}`
+	}
+	tests := []struct {
+		name    string
+		request string
+		want    string // Empty for no response
+	}{
+		{"call",
+			`{"jsonrpc": "2.0", "method": "subtract", "params": {"minuend": 42, "subtrahend": 23}, "id": 1}`,
+			`{"jsonrpc": "2.0", "result": 19, "id": 1}`},
+		{"string id",
+			`{"jsonrpc": "2.0", "method": "subtract", "params": {"minuend": 23, "subtrahend": 42}, "id": "abc"}`,
+			`{"jsonrpc": "2.0", "result": -19, "id": "abc"}`},
+		{"null id is no notification",
+			`{"jsonrpc": "2.0", "method": "get_data", "id": null}`,
+			`{"jsonrpc": "2.0", "result": ["hello", 5], "id": null}`},
+		{"notification",
+			`{"jsonrpc": "2.0", "method": "update", "params": {"minuend": 1}}`,
+			``},
+		{"notification of unknown method",
+			`{"jsonrpc": "2.0", "method": "foobar"}`,
+			``},
+		{"method not found",
+			`{"jsonrpc": "2.0", "method": "foobar", "id": "1"}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}, "id": "1"}`},
+		{"invalid json",
+			`{"jsonrpc": "2.0", "method": "foobar, "params": "bar", "baz]`,
+			`{"jsonrpc": "2.0", "error": {"code": -32700}, "id": null}`},
+		{"invalid request",
+			`{"jsonrpc": "2.0", "method": 1, "params": "bar"}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid request"}, "id": null}`},
+		{"missing method",
+			`{"jsonrpc": "2.0", "id": 4}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid request"}, "id": 4}`},
+		{"unknown version",
+			`{"jsonrpc": "3.0", "method": "get_data", "id": 4}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid request"}, "id": 4}`},
+		{"invalid params",
+			`{"jsonrpc": "2.0", "method": "subtract", "params": {"minuend": "x"}, "id": 2}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32602}, "id": 2}`},
+		{"missing params",
+			`{"jsonrpc": "2.0", "method": "subtract", "id": 3}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid parameters"}, "id": 3}`},
+		{"typed error",
+			`{"jsonrpc": "2.0", "method": "fail_typed", "id": 5}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32000, "message": "custom failure"}, "id": 5}`},
+		{"internal error",
+			`{"jsonrpc": "2.0", "method": "fail_plain", "id": 6}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32603, "message": "boom"}, "id": 6}`},
+		{"batch invalid json",
+			`[{"jsonrpc": "2.0", "method": "get_data", "id": "1"}, {"jsonrpc": "2.0", "method"]`,
+			`{"jsonrpc": "2.0", "error": {"code": -32700}, "id": null}`},
+		{"empty batch",
+			`[]`,
+			`{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid request"}, "id": null}`},
+		{"invalid batch",
+			`[1]`,
+			`[{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid request"}, "id": null}]`},
+		{"invalid batch entries",
+			`[1, 2, 3]`,
+			`[{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid request"}, "id": null},
+			  {"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid request"}, "id": null},
+			  {"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid request"}, "id": null}]`},
+		{"mixed batch",
+			`[{"jsonrpc": "2.0", "method": "subtract", "params": {"minuend": 42, "subtrahend": 23}, "id": "1"},
+			  {"jsonrpc": "2.0", "method": "update", "params": {"minuend": 7}},
+			  {"foo": "boo"},
+			  {"jsonrpc": "2.0", "method": "foo.get", "params": {"name": "myself"}, "id": "5"},
+			  {"jsonrpc": "2.0", "method": "get_data", "id": "9"}]`,
+			`[{"jsonrpc": "2.0", "result": 19, "id": "1"},
+			  {"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid request"}, "id": null},
+			  {"jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}, "id": "5"},
+			  {"jsonrpc": "2.0", "result": ["hello", 5], "id": "9"}]`},
+		{"notification batch",
+			`[{"jsonrpc": "2.0", "method": "update", "params": {"minuend": 1}},
+			  {"jsonrpc": "2.0", "method": "update", "params": {"minuend": 7}}]`,
+			``},
+		{"oversized batch",
+			`[` + strings.Join(batch, ",") + `]`,
+			`{"jsonrpc": "2.0", "error": {"code": -32600}, "id": null}`},
+		{"legacy call",
+			`{"jsonrpc": "1.0", "method": "subtract", "params": {"minuend": 42, "subtrahend": 23}, "id": 7}`,
+			`{"result": 19, "error": null, "id": 7}`},
+		{"legacy error",
+			`{"method": "foobar", "id": 8}`,
+			`{"result": null, "error": {"code": -32601, "message": "Method not found"}, "id": 8}`},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("POST", "http://"+addr, bytes.NewReader([]byte(tt.request)))
+		req.SetBasicAuth("user", "pass")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tt.name, err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if tt.want == "" {
+			if resp.StatusCode != http.StatusNoContent || len(body) != 0 {
+				t.Errorf("%s: have %d %s, want no response", tt.name, resp.StatusCode, body)
+			}
+			continue
+		}
+		var want, have interface{}
+		if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+			t.Fatalf("%s: bad expectation: %v", tt.name, err)
+		}
+		if err := json.Unmarshal(body, &have); err != nil {
+			t.Errorf("%s: invalid response %q: %v", tt.name, body, err)
+			continue
+		}
+		stripMessages(want, have)
+		if !reflect.DeepEqual(want, have) {
+			t.Errorf("%s: response mismatch\nhave %s\nwant %s", tt.name, body, tt.want)
+		}
+	}
+}
+
+func TestJSONRPCUnauthorizedBatch(t *testing.T) {
+	srv, addr := newTestJSONRPCServer(t)
+	defer srv.Stop()
+
+	req, _ := http.NewRequest("POST", "http://"+addr, strings.NewReader(`[{"jsonrpc": "2.0", "method": "get_data", "id": 1}]`))
+	req.SetBasicAuth("user", "wrong")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var reply rpcjson.Response
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Error == nil || reply.Error.Code != rpcjson.ErrRPCVerify {
+		t.Fatalf("unauthorized batch: have %v, want code %d", reply.Error, rpcjson.ErrRPCVerify)
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/mihongtech/linkchain-core/common/http/rpcjson"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/protobuf"
+)
+
+// ProtobufContentType is the content type of requests and responses encoded
+// as protobuf.RPCRequest and protobuf.RPCResponse. Their params and results
+// are the raw protobuf encoding of the payload instead of hex inside JSON.
+const ProtobufContentType = "application/x-protobuf"
+
+// isProtobufRequest reports whether the body of a request is protobuf encoded.
+func isProtobufRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == ProtobufContentType
+}
+
+// protobufRPCRead handles reading and responding to protobuf encoded RPC
+// messages. Methods without a binary handler are answered as not found, the
+// client is expected to fall back to JSON for them.
+func (s *Server) protobufRPCRead(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		errCode := http.StatusBadRequest
+		http.Error(w, "error reading protobuf message: "+err.Error(), errCode)
+		return
+	}
+
+	var (
+		request protobuf.RPCRequest
+		result  []byte
+		rpcErr  error
+	)
+	if err := proto.Unmarshal(body, &request); err != nil {
+		rpcErr = &rpcjson.RPCError{
+			Code:    rpcjson.ErrRPCParse.Code,
+			Message: "Failed to parse request: " + err.Error(),
+		}
+	} else if !s.checkAuth(r) {
+		rpcErr = &rpcjson.RPCError{
+			Code:    rpcjson.ErrRPCVerify,
+			Message: "The RPC Connect must be input correctly rpcuser and password",
+		}
+	} else if handler, ok := s.binaryPool[request.GetMethod()]; !ok {
+		log.Error("ErrRPCMethodNotFound", request.GetMethod())
+		rpcErr = rpcjson.ErrRPCMethodNotFound
+	} else {
+		result, rpcErr = handler(r.Context(), s, request.Params)
+	}
+
+	response := &protobuf.RPCResponse{Id: proto.Uint64(request.GetId()), Result: result}
+	if rpcErr != nil {
+		jsonErr := toRPCError(rpcErr)
+		response.Result = nil
+		response.Error = &protobuf.RPCError{
+			Code:    proto.Int32(int32(jsonErr.Code)),
+			Message: proto.String(jsonErr.Message),
+		}
+	}
+	msg, err := proto.Marshal(response)
+	if err != nil {
+		log.Error("Failed to marshal protobuf reply", "err", err)
+		http.Error(w, "500 "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ProtobufContentType)
+	if _, err := w.Write(msg); err != nil {
+		log.Error("rpc", "Failed to write protobuf reply", err)
+	}
+}
@@ -1,11 +1,15 @@
 package server
 
 import (
+	"context"
 	"reflect"
 )
 
-/**rpc handler,server,cmd,closeChan,context**/
-type commandHandler func(*Server, interface{}, <-chan struct{}) (interface{}, error)
+/**rpc handler,request context cancelled once the client is gone or the server stopped,server,cmd**/
+type commandHandler func(context.Context, *Server, interface{}) (interface{}, error)
+
+/**protobuf rpc handler,request context,server,encoded params,encoded result**/
+type binaryHandler func(context.Context, *Server, []byte) ([]byte, error)
 
 func (s *Server) SetHandleFunc(method string, handler commandHandler) {
 	s.handlerPool[method] = handler
@@ -14,3 +18,13 @@ func (s *Server) SetHandleFunc(method string, handler commandHandler) {
 func (s *Server) SetCmd(method string, cmdType reflect.Type) {
 	s.cmdPool[method] = cmdType
 }
+
+//SetResult set the type of the result of method, documented by rpc_discover
+func (s *Server) SetResult(method string, resultType reflect.Type) {
+	s.resultPool[method] = resultType
+}
+
+//SetBinaryHandleFunc set the handler of method for requests sent as protobuf
+func (s *Server) SetBinaryHandleFunc(method string, handler binaryHandler) {
+	s.binaryPool[method] = handler
+}
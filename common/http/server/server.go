@@ -1,14 +1,15 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"reflect"
 	"strconv"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -25,10 +26,15 @@ const (
 
 	RPCMaxClients = 60
 	RPCQuirks     = true
+
+	// RPCShutdownTimeout is the time Stop waits for requests in progress
+	// before cancelling their contexts, unless the config sets another.
+	RPCShutdownTimeout = 5 * time.Second
 )
 
 type Server struct {
-	numClients int32
+	numClients    int32
+	numWebsockets int32
 
 	config Config
 
@@ -36,10 +42,24 @@ type Server struct {
 	statusLock  sync.RWMutex
 
 	handlerPool map[string]commandHandler
+	binaryPool  map[string]binaryHandler
 	cmdPool     map[string]reflect.Type
+	resultPool  map[string]reflect.Type
+	topics      map[string]SubscribeFunc
 	Context     interface{}
+	httpServer  *http.Server
+	listener    net.Listener
 	//quit channel
 	requestProcessShutdown chan struct{}
+
+	// ctx is the parent of the request contexts, cancelled when Stop gives
+	// up waiting for them.  closing is closed as Stop begins.
+	ctx      context.Context
+	cancel   context.CancelFunc
+	closing  chan struct{}
+	lock     sync.Mutex
+	stopping bool
+	requests sync.WaitGroup
 }
 
 // rpcserverConfig is a descriptor containing the RPC server configuration.
@@ -52,6 +72,24 @@ type Config struct {
 	rpcuser  string
 	password string
 	Name     string
+
+	// Tokens are accepted as bearer tokens in addition to the user and
+	// password.
+	Tokens []string
+
+	// TLSCert and TLSKey are the certificate and key files to serve TLS
+	// with.  The server listens in plain text when they are empty.
+	TLSCert string
+	TLSKey  string
+
+	// ClientCA is a file of certificates authorities clients certificates
+	// are verified against.  Clients without a valid certificate are
+	// rejected when it is set.
+	ClientCA string
+
+	// ShutdownTimeout is the time Stop waits for requests in progress,
+	// RPCShutdownTimeout if zero.
+	ShutdownTimeout time.Duration
 }
 
 func NewConfig(useAge string, startupTime int64, addr string, rpcuser string, password string) *Config {
@@ -65,21 +103,28 @@ func NewConfig(useAge string, startupTime int64, addr string, rpcuser string, pa
 }
 
 // newRPCServer returns a new instance of the rpcServer struct.
-func NewRPCServer(cfg *Config, context interface{}) (*Server, error) {
+func NewRPCServer(cfg *Config, appContext interface{}) (*Server, error) {
 	rpc := Server{
 		config:                 *cfg,
 		statusLines:            make(map[int]string),
 		requestProcessShutdown: make(chan struct{}),
 		handlerPool:            make(map[string]commandHandler),
+		binaryPool:             make(map[string]binaryHandler),
 		cmdPool:                make(map[string]reflect.Type),
-		Context:                context,
+		resultPool:             make(map[string]reflect.Type),
+		topics:                 make(map[string]SubscribeFunc),
+		Context:                appContext,
+		closing:                make(chan struct{}),
 	}
+	rpc.ctx, rpc.cancel = context.WithCancel(context.Background())
+	rpc.handlerPool[DiscoverMethod] = onDiscover
 
 	return &rpc, nil
 }
 
-// Start is used by rpcserver.go to start the rpcserver listener.
-func (s *Server) Start() {
+// Start is used by rpcserver.go to start the rpcserver listener. It returns
+// once the server is listening.
+func (s *Server) Start() error {
 	log.Info("RPC Server", "Starting for", s.config.Name)
 	rpcServeMux := http.NewServeMux()
 	httpServer := &http.Server{
@@ -89,6 +134,10 @@ func (s *Server) Start() {
 		// Timeout connections which don't complete the initial
 		// handshake within the allowed timeframe.
 		ReadTimeout: time.Second * rpcAuthTimeoutSeconds,
+
+		// Derive the request contexts from the server's, so stopping
+		// the server cancels them.
+		BaseContext: func(net.Listener) context.Context { return s.ctx },
 	}
 
 	rpcServeMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -101,21 +150,116 @@ func (s *Server) Start() {
 			return
 		}
 
-		// Keep track of the number of connected clients.
+		// Keep track of the number of connected clients and the
+		// requests Stop waits for.
+		if !s.beginRequest(w) {
+			return
+		}
+		defer s.requests.Done()
 		s.incrementClients()
 		defer s.decrementClients()
 
-		// Read and respond to the request.
+		// Read and respond to the request in the encoding it was sent in.
+		if isProtobufRequest(r) {
+			s.protobufRPCRead(w, r)
+			return
+		}
 		s.jsonRPCRead(w, r)
 	})
 
+	rpcServeMux.HandleFunc(WebsocketPath, s.serveWebsocket)
+
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		log.Error("RPC Server", "listen", s.config.Addr, "err", err)
+		return err
+	}
+	if tlsListener, err := s.tlsListener(listener); err != nil {
+		listener.Close()
+		log.Error("RPC Server", "tls", s.config.Addr, "err", err)
+		return err
+	} else {
+		listener = tlsListener
+	}
+	s.httpServer = httpServer
+	s.listener = listener
 	go func() {
-		httpServer.ListenAndServe()
+		if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Error("RPC Server", "serve", s.config.Addr, "err", err)
+		}
 	}()
+	return nil
 }
 
+// Stop shuts the server down gracefully.  It stops accepting connections,
+// closes the websockets, and waits for the requests in progress up to the
+// shutdown timeout, after which their contexts are cancelled and the remaining
+// connections closed.
 func (s *Server) Stop() bool {
-	s.requestProcessShutdown <- struct{}{}
+	select {
+	case s.requestProcessShutdown <- struct{}{}:
+	default:
+	}
+	s.lock.Lock()
+	if s.stopping {
+		s.lock.Unlock()
+		return true
+	}
+	s.stopping = true
+	close(s.closing)
+	s.lock.Unlock()
+
+	timeout := s.config.ShutdownTimeout
+	if timeout == 0 {
+		timeout = RPCShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Hijacked connections are not tracked by the http.Server, so the
+	// requests are waited for separately.
+	done := make(chan struct{})
+	go func() {
+		s.requests.Wait()
+		close(done)
+	}()
+	if s.httpServer != nil {
+		// Close the listener here as well, Serve may not have picked it
+		// up yet for Shutdown to close.
+		s.listener.Close()
+		if err := s.httpServer.Shutdown(ctx); err != nil {
+			log.Warn("RPC Server", "shutdown", s.config.Addr, "err", err)
+		}
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Warn("RPC Server", "Cancelling requests in progress", s.config.Addr)
+	}
+	s.cancel()
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+	return true
+}
+
+// Closing returns a channel closed once the server starts stopping, for
+// long-running handlers to return early instead of holding up Stop.
+func (s *Server) Closing() <-chan struct{} {
+	return s.closing
+}
+
+// beginRequest registers a request Stop waits for, refusing it if the server
+// is stopping.
+func (s *Server) beginRequest(w http.ResponseWriter) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stopping {
+		http.Error(w, "503 Server stopping.", http.StatusServiceUnavailable)
+		return false
+	}
+	s.requests.Add(1)
 	return true
 }
 
@@ -156,63 +300,40 @@ func (s *Server) jsonRPCRead(w http.ResponseWriter, r *http.Request) {
 	defer conn.Close()
 	defer buf.Flush()
 
-	// Attempt to parse the raw body into a JSON-RPC request.
-	var responseID interface{}
-	var jsonErr error
-	var result interface{}
-	var request rpcjson.Request
-	if err := json.Unmarshal(body, &request); err != nil {
-		jsonErr = &rpcjson.RPCError{
-			Code:    rpcjson.ErrRPCParse.Code,
-			Message: "Failed to parse request: " + err.Error(),
+	// Cancel the request context once the client is gone.  Since the
+	// connection is hijacked, the http.Server doesn't notice it.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		_, err := conn.Read(make([]byte, 1))
+		if err != nil {
+			cancel()
 		}
-	}
+	}()
 
-	if jsonErr == nil {
-		//validate user and password
-		if rpcuser, password, ok := r.BasicAuth(); !ok || strings.Compare(s.config.rpcuser, rpcuser) != 0 || strings.Compare(s.config.password, password) != 0 {
-			jsonErr = &rpcjson.RPCError{
-				Code:    rpcjson.ErrRPCVerify,
-				Message: "The RPC Connect must be input correctly rpcuser and password",
-			}
-		}
+	// Serve a single request or a JSON-RPC 2.0 batch of them.  The
+	// authentication covers the whole HTTP request.
+	var msg []byte
+	if !s.checkAuth(r) {
+		msg, err = createMarshalledReply(nil, nil, &rpcjson.RPCError{
+			Code:    rpcjson.ErrRPCVerify,
+			Message: "The RPC Connect must be input correctly rpcuser and password",
+		})
+	} else if isBatch(body) {
+		msg, err = s.processBatch(ctx, body)
+	} else {
+		msg, err = s.processRequest(ctx, body)
 	}
-
-	if jsonErr == nil {
-		if request.ID == nil && !(RPCQuirks && request.Jsonrpc == "") {
-			return
-		}
-
-		// The parse was at least successful enough to have an ID so
-		// set it for the response.
-		responseID = request.ID
-
-		// Setup a close notifier.  Since the connection is hijacked,
-		// the CloseNotifer on the ResponseWriter is not available.
-		closeChan := make(chan struct{}, 1)
-		go func() {
-			_, err := conn.Read(make([]byte, 1))
-			if err != nil {
-				close(closeChan)
-			}
-		}()
-
-		if jsonErr == nil {
-			// Attempt to parse the JSON-RPC request into a known concrete
-			// command.
-			parsedCmd, err := s.parseCmd(&request)
-			if err != nil {
-				jsonErr = err
-			} else {
-				result, jsonErr = s.standardCmdResult(request.Method, parsedCmd, closeChan)
-			}
-		}
+	if err != nil {
+		log.Error("Failed to marshal reply", "err", err)
+		return
 	}
 
-	// Marshal the response.
-	msg, err := createMarshalledReply(responseID, result, jsonErr)
-	if err != nil {
-		log.Error("Failed to marshal reply: %v", err)
+	// Notifications are not answered, the HTTP response is left empty.
+	if msg == nil {
+		if err := s.writeHTTPResponseHeaders(r, w.Header(), http.StatusNoContent, buf); err != nil {
+			log.Error("rpc", "rpcResponse", err)
+		}
 		return
 	}
 
@@ -232,34 +353,36 @@ func (s *Server) jsonRPCRead(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// createMarshalledReply returns a new marshalled JSON-RPC response given the
-// passed parameters.  It will automatically convert errors that are not of
-// the type *btcjson.RPCError to the appropriate type as needed.
+// createMarshalledReply returns a new marshalled JSON-RPC 1.0 response given
+// the passed parameters.  Errors are converted to the matching RPC errors.
 func createMarshalledReply(id, result interface{}, replyErr error) ([]byte, error) {
-	var jsonErr *rpcjson.RPCError
-	if replyErr != nil {
-		if jErr, ok := replyErr.(*rpcjson.RPCError); ok {
-			jsonErr = jErr
-		} else {
-			jsonErr = internalRPCError(replyErr.Error(), "")
+	return rpcjson.MarshalResponse(id, result, toRPCError(replyErr))
+}
+
+// toRPCError converts an error to the RPC error sent to the client, logging
+// internal errors since they really should not occur.
+func toRPCError(err error) *rpcjson.RPCError {
+	rpcErr := rpcjson.ToRPCError(err)
+	if rpcErr != nil && rpcErr.Code == rpcjson.ErrRPCInternal.Code {
+		if _, ok := err.(*rpcjson.RPCError); !ok {
+			log.Error("Internal RPC error", "err", err)
 		}
 	}
-
-	return rpcjson.MarshalResponse(id, result, jsonErr)
+	return rpcErr
 }
 
 // standardCmdResult checks that a parsed command is a standard Bitcoin JSON-RPC
 // command and runs the appropriate handler to reply to the command.  Any
 // commands which are not recognized or not implemented will return an error
 // suitable for use in replies.
-func (s *Server) standardCmdResult(method string, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+func (s *Server) standardCmdResult(ctx context.Context, method string, cmd interface{}) (interface{}, error) {
 	handler, ok := s.handlerPool[method]
 	if !ok {
 		log.Error("ErrRPCMethodNotFound", method)
 		return nil, rpcjson.ErrRPCMethodNotFound
 	}
 
-	return handler(s, cmd, closeChan)
+	return handler(ctx, s, cmd)
 }
 
 // parseCmd parses a JSON-RPC request rpcobject into known concrete command.  The
@@ -267,11 +390,11 @@ func (s *Server) standardCmdResult(method string, cmd interface{}, closeChan <-c
 // is suitable for use in replies if the command is invalid in some way such as
 // an unregistered command or invalid parameters.
 func (s *Server) parseCmd(request *rpcjson.Request) (interface{}, error) {
-	if request.Params == nil {
+	rtp, ok := s.cmdPool[request.Method]
+	if request.Params == nil || !ok {
 		return nil, nil
 	}
 
-	rtp := s.cmdPool[request.Method]
 	cmd := reflect.New(rtp.Elem()).Interface()
 	err := json.Unmarshal(request.Params, cmd)
 
@@ -332,20 +455,6 @@ func (s *Server) limitConnections(w http.ResponseWriter, remoteAddr string) bool
 	return false
 }
 
-// internalRPCError is a convenience function to convert an internal error to
-// an RPC error with the appropriate code set.  It also logs the error to the
-// RPC server subsystem since internal errors really should not occur.  The
-// context parameter is only used in the log message and may be empty if it's
-// not needed.
-func internalRPCError(errStr, context string) *rpcjson.RPCError {
-	logStr := errStr
-	if context != "" {
-		logStr = context + ": " + errStr
-	}
-	log.Error(logStr)
-	return rpcjson.NewRPCError(rpcjson.ErrRPCInternal.Code, errStr)
-}
-
 // httpStatusLine returns a response Status-Line (RFC 2616 Section 6.1)
 // for the given request and response status code.  This function was lifted and
 // adapted from the standard library HTTP server code since it's not exported.
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/http/rpcjson"
+)
+
+// newShutdownTestServer starts a server whose "slow" method signals started
+// and then waits for release or the cancellation of its context.
+func newShutdownTestServer(t *testing.T, timeout time.Duration) (*Server, string, chan struct{}, chan struct{}) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	cfg := NewConfig("test", time.Now().Unix(), addr, "user", "pass")
+	cfg.ShutdownTimeout = timeout
+	srv, _ := NewRPCServer(cfg, nil)
+	srv.SetHandleFunc("slow", func(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+		close(started)
+		select {
+		case <-release:
+			return "done", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+	if err := srv.Start(); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	return srv, addr, started, release
+}
+
+// callSlow sends a "slow" request and delivers its reply, or nil if the
+// request failed.
+func callSlow(addr string) chan *rpcjson.Response {
+	replies := make(chan *rpcjson.Response, 1)
+	go func() {
+		req, _ := http.NewRequest("POST", "http://"+addr, strings.NewReader(`{"jsonrpc": "1.0", "method": "slow", "id": 1}`))
+		req.SetBasicAuth("user", "pass")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			replies <- nil
+			return
+		}
+		defer resp.Body.Close()
+
+		var reply rpcjson.Response
+		if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+			replies <- nil
+			return
+		}
+		replies <- &reply
+	}()
+	return replies
+}
+
+func TestStopWaitsForRequests(t *testing.T) {
+	srv, addr, started, release := newShutdownTestServer(t, 10*time.Second)
+	replies := callSlow(addr)
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		srv.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("server stopped while a request was in progress")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// New requests are refused while stopping.
+	if reply := <-callSlow(addr); reply != nil {
+		t.Fatalf("request accepted after stop: %+v", reply)
+	}
+
+	close(release)
+	reply := <-replies
+	if reply == nil || reply.Error != nil || string(reply.Result) != `"done"` {
+		t.Fatalf("in-progress request: have %+v, want result \"done\"", reply)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server didn't stop after the request finished")
+	}
+}
+
+func TestStopCancelsRequests(t *testing.T) {
+	srv, addr, started, _ := newShutdownTestServer(t, 200*time.Millisecond)
+	replies := callSlow(addr)
+	<-started
+
+	start := time.Now()
+	srv.Stop()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("stop took %v despite the shutdown timeout", elapsed)
+	}
+	select {
+	case reply := <-replies:
+		if reply != nil && reply.Error == nil {
+			t.Fatalf("cancelled request succeeded: %+v", reply)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled request never finished")
+	}
+}
@@ -0,0 +1,315 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/http/rpcjson"
+	"github.com/mihongtech/linkchain-core/common/util/event"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// RPCMaxWebsockets is the maximum number of websocket clients, which are
+	// tracked separately from the standard clients.
+	RPCMaxWebsockets = 25
+
+	// WebsocketPath is the path websocket clients connect to.
+	WebsocketPath = "/ws"
+
+	wsSendQueue         = 256              // Messages queued for a client before it counts as too slow
+	wsSubscriptionQueue = 16               // Notifications buffered between a topic and the client queue
+	wsMaxMessageSize    = 1024 * 1024      // Maximum size of a request read from a client
+	wsWriteTimeout      = 10 * time.Second // Time allowance for writing a single message
+	wsPongTimeout       = 60 * time.Second // Time allowance for the client to answer a ping
+	wsPingInterval      = 30 * time.Second // Interval between pings sent to the client
+)
+
+// SubscribeFunc subscribes a websocket client to a topic. The subscription
+// delivers the notifications into ch until it is unsubscribed.
+type SubscribeFunc func(ch chan<- interface{}) event.Subscription
+
+// SubscribeCmd is the parameter of the subscribe method.
+type SubscribeCmd struct {
+	Topic string `json:"topic"`
+}
+
+// UnsubscribeCmd is the parameter of the unsubscribe method.
+type UnsubscribeCmd struct {
+	Subscription string `json:"subscription"`
+}
+
+// Notification is a message pushed to a websocket client for a subscription,
+// a JSON-RPC 2.0 notification which has no id.
+type Notification struct {
+	Jsonrpc string             `json:"jsonrpc"`
+	Method  string             `json:"method"`
+	Params  NotificationParams `json:"params"`
+}
+
+// NotificationParams carries the subscription id and the event of a
+// Notification.
+type NotificationParams struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+}
+
+// SetSubscription registers a topic websocket clients can subscribe to.
+func (s *Server) SetSubscription(topic string, subscribe SubscribeFunc) {
+	s.topics[topic] = subscribe
+}
+
+// serveWebsocket upgrades an authenticated request to a websocket connection
+// and serves requests and subscriptions over it until it closes.
+func (s *Server) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	// Counted before upgrading, so concurrent clients can't exceed the limit
+	if int(atomic.AddInt32(&s.numWebsockets, 1)) > RPCMaxWebsockets {
+		atomic.AddInt32(&s.numWebsockets, -1)
+		log.Info("Max websocket clients exceeded", "max", RPCMaxWebsockets, "addr", r.RemoteAddr)
+		http.Error(w, "503 Too busy.  Try again later.", http.StatusServiceUnavailable)
+		return
+	}
+	defer atomic.AddInt32(&s.numWebsockets, -1)
+	if !s.checkAuth(r) {
+		http.Error(w, "401 Unauthorized.", http.StatusUnauthorized)
+		return
+	}
+	if !s.beginRequest(w) {
+		return
+	}
+	defer s.requests.Done()
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debug("Websocket upgrade failed", "addr", r.RemoteAddr, "err", err)
+		return
+	}
+
+	client := &wsClient{
+		server: s,
+		conn:   conn,
+		send:   make(chan []byte, wsSendQueue),
+		subs:   make(map[string]event.Subscription),
+	}
+	client.ctx, client.cancel = context.WithCancel(s.ctx)
+	client.run()
+}
+
+// wsClient is a websocket connection with its subscriptions. Outgoing messages
+// go through a bounded queue; a client which doesn't keep up with it is
+// disconnected instead of holding up the event sources.
+type wsClient struct {
+	server *Server
+	conn   *websocket.Conn
+	send   chan []byte
+	once   sync.Once
+
+	// ctx is the context of the client's requests, cancelled when it stops.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock   sync.Mutex
+	subs   map[string]event.Subscription
+	nextID uint64
+}
+
+// run serves the client until its connection fails or it is dropped.
+func (c *wsClient) run() {
+	go c.writeLoop()
+	defer c.stop(websocket.CloseNormalClosure, "")
+	go func() {
+		select {
+		case <-c.server.closing:
+			c.stop(websocket.CloseGoingAway, "server stopping")
+		case <-c.ctx.Done():
+		}
+	}()
+
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			log.Trace("Websocket read failed", "addr", c.conn.RemoteAddr(), "err", err)
+			return
+		}
+		c.handle(data)
+	}
+}
+
+// stop ends all subscriptions and closes the connection with the given reason.
+// The close message waits for a pending write, so it is sent in the background
+// to never hold up the event sources.
+func (c *wsClient) stop(code int, reason string) {
+	c.once.Do(func() {
+		c.cancel()
+
+		c.lock.Lock()
+		for id, sub := range c.subs {
+			sub.Unsubscribe()
+			delete(c.subs, id)
+		}
+		c.lock.Unlock()
+
+		go func() {
+			c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteTimeout))
+			c.conn.Close()
+		}()
+	})
+}
+
+// writeLoop writes the queued messages and keeps the connection alive.
+func (c *wsClient) writeLoop() {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				log.Trace("Websocket write failed", "addr", c.conn.RemoteAddr(), "err", err)
+				c.stop(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ping.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				c.stop(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// enqueue queues a message for the client, dropping the client if its queue
+// is full.
+func (c *wsClient) enqueue(msg []byte) bool {
+	select {
+	case c.send <- msg:
+		return true
+	case <-c.ctx.Done():
+		return false
+	default:
+		log.Warn("Dropping slow websocket client", "addr", c.conn.RemoteAddr(), "queued", len(c.send))
+		c.stop(websocket.CloseTryAgainLater, "too slow")
+		return false
+	}
+}
+
+// handle answers a single request read from the client.
+func (c *wsClient) handle(data []byte) {
+	var (
+		request rpcjson.Request
+		result  interface{}
+		jsonErr error
+	)
+	if err := json.Unmarshal(data, &request); err != nil {
+		jsonErr = &rpcjson.RPCError{
+			Code:    rpcjson.ErrRPCParse.Code,
+			Message: "Failed to parse request: " + err.Error(),
+		}
+	} else {
+		switch request.Method {
+		case "subscribe":
+			result, jsonErr = c.subscribe(request.Params)
+		case "unsubscribe":
+			result, jsonErr = c.unsubscribe(request.Params)
+		default:
+			cmd, err := c.server.parseCmd(&request)
+			if err != nil {
+				jsonErr = err
+			} else {
+				result, jsonErr = c.server.standardCmdResult(c.ctx, request.Method, cmd)
+			}
+		}
+	}
+	msg, err := createMarshalledReply(request.ID, result, jsonErr)
+	if err != nil {
+		log.Error("Failed to marshal websocket reply", "err", err)
+		return
+	}
+	c.enqueue(msg)
+}
+
+// subscribe starts feeding a topic to the client, returning the subscription id.
+func (c *wsClient) subscribe(params json.RawMessage) (interface{}, error) {
+	var cmd SubscribeCmd
+	if err := json.Unmarshal(params, &cmd); err != nil {
+		return nil, rpcjson.ErrRPCInvalidParams
+	}
+	subscribe, ok := c.server.topics[cmd.Topic]
+	if !ok {
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCInvalidParams.Code, "unknown topic "+cmd.Topic)
+	}
+	c.lock.Lock()
+	// stop cancels the client before ending its subscriptions under the lock,
+	// so a subscription added after it would never be ended
+	if c.ctx.Err() != nil {
+		c.lock.Unlock()
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCClientNotConnected, "websocket client stopped")
+	}
+	c.nextID++
+	id := fmt.Sprintf("0x%x", c.nextID)
+	ch := make(chan interface{}, wsSubscriptionQueue)
+	sub := subscribe(ch)
+	c.subs[id] = sub
+	c.lock.Unlock()
+
+	go func() {
+		for {
+			select {
+			case data := <-ch:
+				msg, err := json.Marshal(&Notification{
+					Jsonrpc: "2.0",
+					Method:  "subscription",
+					Params:  NotificationParams{Subscription: id, Result: data},
+				})
+				if err != nil {
+					log.Error("Failed to marshal notification", "topic", cmd.Topic, "err", err)
+					continue
+				}
+				if !c.enqueue(msg) {
+					return
+				}
+			case <-sub.Err():
+				return
+			case <-c.ctx.Done():
+				return
+			}
+		}
+	}()
+	return id, nil
+}
+
+// unsubscribe ends a subscription of the client, returning whether it existed.
+func (c *wsClient) unsubscribe(params json.RawMessage) (interface{}, error) {
+	var cmd UnsubscribeCmd
+	if err := json.Unmarshal(params, &cmd); err != nil {
+		return nil, rpcjson.ErrRPCInvalidParams
+	}
+	c.lock.Lock()
+	sub, ok := c.subs[cmd.Subscription]
+	delete(c.subs, cmd.Subscription)
+	c.lock.Unlock()
+
+	if ok {
+		sub.Unsubscribe()
+	}
+	return ok, nil
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/util/event"
+
+	"github.com/gorilla/websocket"
+)
+
+// feedTopic forwards the values sent on a feed to the subscribers.
+func feedTopic(feed *event.Feed) SubscribeFunc {
+	return func(ch chan<- interface{}) event.Subscription {
+		return event.NewSubscription(func(quit <-chan struct{}) error {
+			values := make(chan string)
+			sub := feed.Subscribe(values)
+			defer sub.Unsubscribe()
+			for {
+				select {
+				case v := <-values:
+					select {
+					case ch <- v:
+					case <-quit:
+						return nil
+					}
+				case <-quit:
+					return nil
+				}
+			}
+		})
+	}
+}
+
+func newTestWSServer(t *testing.T, feed *event.Feed) (*Server, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	srv, _ := NewRPCServer(NewConfig("test", time.Now().Unix(), addr, "user", "pass"), nil)
+	srv.SetSubscription("values", feedTopic(feed))
+	if err := srv.Start(); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	return srv, addr
+}
+
+func dialTestWS(addr, user, pass string) (*websocket.Conn, error) {
+	header := http.Header{}
+	header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user+":"+pass)))
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+addr+WebsocketPath, header)
+	return conn, err
+}
+
+// wsReply is a response or a notification read by a test client.
+type wsReply struct {
+	Jsonrpc string             `json:"jsonrpc"`
+	ID      *json.RawMessage   `json:"id"`
+	Result  json.RawMessage    `json:"result"`
+	Error   *json.RawMessage   `json:"error"`
+	Method  string             `json:"method"`
+	Params  NotificationParams `json:"params"`
+}
+
+func wsCall(t *testing.T, conn *websocket.Conn, method string, params interface{}) wsReply {
+	raw, _ := json.Marshal(params)
+	req, _ := json.Marshal(map[string]interface{}{"jsonrpc": "1.0", "id": 1, "method": method, "params": json.RawMessage(raw)})
+	if err := conn.WriteMessage(websocket.TextMessage, req); err != nil {
+		t.Fatalf("failed to send %s: %v", method, err)
+	}
+	var reply wsReply
+	if err := conn.ReadJSON(&reply); err != nil {
+		t.Fatalf("failed to read %s reply: %v", method, err)
+	}
+	return reply
+}
+
+func TestWebsocketSubscription(t *testing.T) {
+	feed := new(event.Feed)
+	srv, addr := newTestWSServer(t, feed)
+	defer srv.Stop()
+
+	if _, err := dialTestWS(addr, "user", "wrong"); err == nil {
+		t.Fatalf("unauthenticated websocket accepted")
+	}
+	conn, err := dialTestWS(addr, "user", "pass")
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	if reply := wsCall(t, conn, "subscribe", SubscribeCmd{Topic: "unknown"}); reply.Error == nil {
+		t.Fatalf("subscribed to unknown topic")
+	}
+	reply := wsCall(t, conn, "subscribe", SubscribeCmd{Topic: "values"})
+	var id string
+	if err := json.Unmarshal(reply.Result, &id); err != nil || id == "" {
+		t.Fatalf("no subscription id: %s %v", reply.Result, err)
+	}
+	// Wait for the subscription to reach the feed
+	for feed.Send("hello") == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	var note wsReply
+	if err := conn.ReadJSON(&note); err != nil {
+		t.Fatalf("failed to read notification: %v", err)
+	}
+	if note.Method != "subscription" || note.Params.Subscription != id || note.Params.Result != "hello" {
+		t.Fatalf("unexpected notification: %+v", note)
+	}
+	if note.Jsonrpc != "2.0" || note.ID != nil {
+		t.Fatalf("notification not a JSON-RPC 2.0 notification: version %q, id %s", note.Jsonrpc, note.ID)
+	}
+
+	if reply := wsCall(t, conn, "unsubscribe", UnsubscribeCmd{Subscription: id}); string(reply.Result) != "true" {
+		t.Fatalf("unsubscribe failed: %s", reply.Result)
+	}
+	if reply := wsCall(t, conn, "unsubscribe", UnsubscribeCmd{Subscription: id}); string(reply.Result) != "false" {
+		t.Fatalf("unsubscribed twice: %s", reply.Result)
+	}
+	deadline := time.Now().Add(time.Second)
+	for feed.Send("gone") != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("feed still subscribed after unsubscribe")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebsocketSlowConsumer(t *testing.T) {
+	feed := new(event.Feed)
+	srv, addr := newTestWSServer(t, feed)
+	defer srv.Stop()
+
+	conn, err := dialTestWS(addr, "user", "pass")
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+	wsCall(t, conn, "subscribe", SubscribeCmd{Topic: "values"})
+
+	// Publish without reading until the client gets dropped, the publisher
+	// must never be held up by the client.
+	payload := strings.Repeat("x", 16*1024)
+	for feed.Send(payload) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	done := make(chan int)
+	go func() {
+		sent := 1
+		for sent < 10000 && feed.Send(payload) > 0 {
+			sent++
+		}
+		done <- sent
+	}()
+	select {
+	case sent := <-done:
+		if sent >= 10000 {
+			t.Fatalf("slow client never dropped")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("publisher blocked by slow client")
+	}
+	// The client sees the queued messages followed by the close reason
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+				t.Fatalf("unexpected close: %v", err)
+			}
+			break
+		}
+	}
+}
+
+func TestWebsocketClientLimit(t *testing.T) {
+	srv, addr := newTestWSServer(t, new(event.Feed))
+	defer srv.Stop()
+
+	// Concurrent clients never get more connections than the limit
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		conns []*websocket.Conn
+	)
+	for i := 0; i < 2*RPCMaxWebsockets; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if conn, err := dialTestWS(addr, "user", "pass"); err == nil {
+				mu.Lock()
+				conns = append(conns, conn)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(conns) == 0 || len(conns) > RPCMaxWebsockets {
+		t.Fatalf("have %d connections, want 1 to %d", len(conns), RPCMaxWebsockets)
+	}
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
+
+// Tests that a subscription racing with the stop of its client is never left
+// behind, holding up the event source.
+func TestWebsocketSubscribeWhileStopping(t *testing.T) {
+	feed := new(event.Feed)
+	srv, _ := NewRPCServer(NewConfig("test", time.Now().Unix(), "", "user", "pass"), nil)
+	srv.SetSubscription("values", feedTopic(feed))
+
+	conns := make(chan *websocket.Conn, 1)
+	httpSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, err := upgrader.Upgrade(w, r, nil); err == nil {
+			conns <- conn
+		}
+	}))
+	defer httpSrv.Close()
+
+	params, _ := json.Marshal(SubscribeCmd{Topic: "values"})
+	for i := 0; i < 50; i++ {
+		remote, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpSrv.URL, "http"), nil)
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		client := &wsClient{
+			server: srv,
+			conn:   <-conns,
+			send:   make(chan []byte, wsSendQueue),
+			subs:   make(map[string]event.Subscription),
+		}
+		client.ctx, client.cancel = context.WithCancel(context.Background())
+
+		// Every other client is stopped before the request is handled
+		stopped := make(chan struct{})
+		go func() {
+			client.stop(websocket.CloseGoingAway, "")
+			close(stopped)
+		}()
+		if i%2 == 0 {
+			<-stopped
+		}
+		client.subscribe(params)
+		<-stopped
+		remote.Close()
+
+		client.lock.Lock()
+		left := len(client.subs)
+		client.lock.Unlock()
+		if left != 0 {
+			t.Fatalf("attempt %d: %d subscriptions left after stop", i, left)
+		}
+	}
+	if n := feed.Send("value"); n != 0 {
+		t.Fatalf("feed still has %d subscribers", n)
+	}
+}
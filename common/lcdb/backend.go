@@ -0,0 +1,97 @@
+package lcdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// DefaultBackend is the backend used when none is configured.
+const DefaultBackend = "leveldb"
+
+// Backend is a key-value engine a persistent Database can be opened with.
+type Backend struct {
+	// Open opens, or creates, the database stored in the directory dir. The
+	// cache (in MB) and handles (open files) hints are ignored by the engines
+	// which do not use them.
+	Open func(dir string, cache int, handles int) (Database, error)
+
+	// Marker is a file the engine always creates in its directory, which tells
+	// which backend an existing database was created with.
+	Marker string
+}
+
+var (
+	backendsLock sync.RWMutex
+	backends     = map[string]Backend{
+		"leveldb": {
+			Open: func(dir string, cache int, handles int) (Database, error) {
+				return NewLDBDatabase(dir, cache, handles)
+			},
+			Marker: "CURRENT",
+		},
+		"boltdb": {
+			Open: func(dir string, cache int, handles int) (Database, error) {
+				return NewBoltDatabase(dir)
+			},
+			Marker: boltFileName,
+		},
+		"memory": {
+			Open: func(dir string, cache int, handles int) (Database, error) {
+				return NewMemDatabase()
+			},
+		},
+	}
+)
+
+// RegisterBackend makes a key-value engine available under name, replacing the
+// backend previously registered under it.
+func RegisterBackend(name string, backend Backend) {
+	backendsLock.Lock()
+	defer backendsLock.Unlock()
+
+	backends[name] = backend
+}
+
+// Backends returns the names of the registered backends, sorted.
+func Backends() []string {
+	backendsLock.RLock()
+	defer backendsLock.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open opens the database in dir with the named backend, DefaultBackend if name
+// is empty. It refuses a directory holding a database of another backend, which
+// would otherwise be silently shadowed by an empty one.
+func Open(name string, dir string, cache int, handles int) (Database, error) {
+	if name == "" {
+		name = DefaultBackend
+	}
+	backendsLock.RLock()
+	backend, ok := backends[name]
+	others := make(map[string]string)
+	for other, b := range backends {
+		if other != name && b.Marker != "" && b.Marker != backend.Marker {
+			others[other] = b.Marker
+		}
+	}
+	backendsLock.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown database backend %q, have %v", name, Backends())
+	}
+	for other, marker := range others {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return nil, fmt.Errorf("database %s was created by the %s backend, not %s", dir, other, name)
+		}
+	}
+	return backend.Open(dir, cache, handles)
+}
@@ -0,0 +1,302 @@
+package lcdb
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/util/log"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	boltFileName = "bolt.db"
+
+	// Growing the file past its memory map waits for the open iterators and
+	// snapshots, a large virtual map lets writes go on while they are used.
+	boltMmapSize = 1 << 30
+)
+
+var (
+	boltBucket = []byte("linkchain")
+
+	// BoltDB refuses empty keys, which the other backends accept, so every key
+	// is stored behind this byte.
+	boltKeyPrefix = []byte{'k'}
+
+	errBoltNotFound = errors.New("not found")
+)
+
+// BoltDatabase is a Database stored in a single BoltDB file. Its reads are
+// served from a memory map, so it needs neither a cache nor file handles.
+type BoltDatabase struct {
+	fn string   // directory for reporting
+	db *bolt.DB // BoltDB instance
+
+	log log.Logger // Contextual logger tracking the database path
+}
+
+// NewBoltDatabase opens, or creates, the BoltDB database in the directory dir.
+func NewBoltDatabase(dir string) (*BoltDatabase, error) {
+	logger := log.New("database", dir)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	// Time out instead of hanging on a database another process holds
+	db, err := bolt.Open(filepath.Join(dir, boltFileName), 0600, &bolt.Options{Timeout: time.Second, InitialMmapSize: boltMmapSize})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	logger.Info("Opened BoltDB database")
+	return &BoltDatabase{
+		fn:  dir,
+		db:  db,
+		log: logger,
+	}, nil
+}
+
+func boltKey(key []byte) []byte {
+	return append(append(make([]byte, 0, len(boltKeyPrefix)+len(key)), boltKeyPrefix...), key...)
+}
+
+// Path returns the path to the database directory.
+func (db *BoltDatabase) Path() string {
+	return db.fn
+}
+
+func (db *BoltDatabase) Put(key []byte, value []byte) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(boltKey(key), value)
+	})
+}
+
+func (db *BoltDatabase) Has(key []byte) (bool, error) {
+	var ok bool
+	err := db.db.View(func(tx *bolt.Tx) error {
+		ok = tx.Bucket(boltBucket).Get(boltKey(key)) != nil
+		return nil
+	})
+	return ok, err
+}
+
+// Get returns the given key if it's present.
+func (db *BoltDatabase) Get(key []byte) ([]byte, error) {
+	var dat []byte
+	err := db.db.View(func(tx *bolt.Tx) error {
+		// The value lives in the memory map only during the transaction
+		if v := tx.Bucket(boltBucket).Get(boltKey(key)); v != nil {
+			dat = append([]byte{}, v...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dat == nil {
+		return nil, errBoltNotFound
+	}
+	return dat, nil
+}
+
+func (db *BoltDatabase) Delete(key []byte) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete(boltKey(key))
+	})
+}
+
+func (db *BoltDatabase) NewIterator() Iterator {
+	return db.NewIteratorWithRange(nil, nil)
+}
+
+// NewIteratorWithPrefix returns a iterator to iterate over subset of database content with a particular prefix.
+func (db *BoltDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return db.NewIteratorWithRange(prefix, util.BytesPrefix(prefix).Limit)
+}
+
+// NewIteratorWithRange returns a iterator to iterate over the keys from start to limit excluded.
+// The iterator holds a read transaction, so a consistent view, until it is released.
+func (db *BoltDatabase) NewIteratorWithRange(start, limit []byte) Iterator {
+	tx, err := db.db.Begin(false)
+	if err != nil {
+		return &boltIterator{err: err}
+	}
+	return newBoltIterator(tx, true, start, limit)
+}
+
+// NewSnapshot opens a read transaction, which keeps its view of the database
+// until the snapshot is released.
+func (db *BoltDatabase) NewSnapshot() (Snapshot, error) {
+	tx, err := db.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	return &boltSnapshot{tx: tx}, nil
+}
+
+func (db *BoltDatabase) Close() {
+	err := db.db.Close()
+	if err == nil {
+		db.log.Info("Database closed")
+	} else {
+		db.log.Error("Failed to close database", "err", err)
+	}
+}
+
+func (db *BoltDatabase) NewBatch() Batch {
+	return &boltBatch{db: db.db}
+}
+
+type boltBatch struct {
+	db     *bolt.DB
+	writes []kv
+	size   int
+}
+
+func (b *boltBatch) Put(key, value []byte) error {
+	b.writes = append(b.writes, kv{CopyBytes(key), CopyBytes(value), false})
+	b.size += len(value)
+	return nil
+}
+
+func (b *boltBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, kv{CopyBytes(key), nil, true})
+	b.size += 1
+	return nil
+}
+
+// Write commits the batch as a single transaction.
+func (b *boltBatch) Write() error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		for _, kv := range b.writes {
+			var err error
+			if kv.del {
+				err = bucket.Delete(boltKey(kv.k))
+			} else {
+				err = bucket.Put(boltKey(kv.k), kv.v)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *boltBatch) ValueSize() int {
+	return b.size
+}
+
+func (b *boltBatch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
+
+// boltSnapshot reads through a read-only transaction, rolled back on release.
+type boltSnapshot struct {
+	tx *bolt.Tx
+}
+
+func (s *boltSnapshot) Get(key []byte) ([]byte, error) {
+	if v := s.tx.Bucket(boltBucket).Get(boltKey(key)); v != nil {
+		return append([]byte{}, v...), nil
+	}
+	return nil, errBoltNotFound
+}
+
+func (s *boltSnapshot) Has(key []byte) (bool, error) {
+	return s.tx.Bucket(boltBucket).Get(boltKey(key)) != nil, nil
+}
+
+func (s *boltSnapshot) NewIterator() Iterator {
+	return newBoltIterator(s.tx, false, nil, nil)
+}
+
+func (s *boltSnapshot) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return newBoltIterator(s.tx, false, prefix, util.BytesPrefix(prefix).Limit)
+}
+
+func (s *boltSnapshot) NewIteratorWithRange(start, limit []byte) Iterator {
+	return newBoltIterator(s.tx, false, start, limit)
+}
+
+func (s *boltSnapshot) Release() {
+	s.tx.Rollback()
+}
+
+// boltIterator walks a range of keys through a cursor of a read-only
+// transaction, which it rolls back on release if it owns it.
+type boltIterator struct {
+	tx      *bolt.Tx
+	ownTx   bool
+	cursor  *bolt.Cursor
+	start   []byte
+	limit   []byte
+	key     []byte
+	value   []byte
+	started bool
+	err     error
+}
+
+// newBoltIterator iterates from start to limit, in stored keys.
+func newBoltIterator(tx *bolt.Tx, ownTx bool, start, limit []byte) *boltIterator {
+	it := &boltIterator{tx: tx, ownTx: ownTx, cursor: tx.Bucket(boltBucket).Cursor(), start: boltKey(start)}
+	if limit != nil {
+		it.limit = boltKey(limit)
+	} else {
+		it.limit = util.BytesPrefix(boltKeyPrefix).Limit
+	}
+	return it
+}
+
+func (it *boltIterator) Next() bool {
+	if it.cursor == nil {
+		return false
+	}
+	var k, v []byte
+	if !it.started {
+		it.started = true
+		k, v = it.cursor.Seek(it.start)
+	} else {
+		k, v = it.cursor.Next()
+	}
+	if k == nil || bytes.Compare(k, it.limit) >= 0 {
+		// Stay past the end
+		it.cursor, it.key, it.value = nil, nil, nil
+		return false
+	}
+	it.key, it.value = k[len(boltKeyPrefix):], v
+	return true
+}
+
+func (it *boltIterator) Key() []byte {
+	return it.key
+}
+
+func (it *boltIterator) Value() []byte {
+	return it.value
+}
+
+func (it *boltIterator) Error() error {
+	return it.err
+}
+
+func (it *boltIterator) Release() {
+	if it.ownTx && it.tx != nil {
+		it.tx.Rollback()
+	}
+	it.tx, it.cursor, it.key, it.value = nil, nil, nil, nil
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
 	"github.com/syndtr/goleveldb/leveldb/filter"
-	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
@@ -93,15 +92,29 @@ func (db *LDBDatabase) Delete(key []byte) error {
 	return db.db.Delete(key, nil)
 }
 
-func (db *LDBDatabase) NewIterator() iterator.Iterator {
+func (db *LDBDatabase) NewIterator() Iterator {
 	return db.db.NewIterator(nil, nil)
 }
 
 // NewIteratorWithPrefix returns a iterator to iterate over subset of database content with a particular prefix.
-func (db *LDBDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+func (db *LDBDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
 	return db.db.NewIterator(util.BytesPrefix(prefix), nil)
 }
 
+// NewIteratorWithRange returns a iterator to iterate over the keys from start to limit excluded.
+func (db *LDBDatabase) NewIteratorWithRange(start, limit []byte) Iterator {
+	return db.db.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+}
+
+// NewSnapshot returns a LevelDB snapshot of the current database content.
+func (db *LDBDatabase) NewSnapshot() (Snapshot, error) {
+	snap, err := db.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &ldbSnapshot{snap: snap}, nil
+}
+
 func (db *LDBDatabase) Close() {
 	// Stop the metrics collection to avoid internal database races
 	db.quitLock.Lock()
@@ -142,6 +155,12 @@ func (b *ldbBatch) Put(key, value []byte) error {
 	return nil
 }
 
+func (b *ldbBatch) Delete(key []byte) error {
+	b.b.Delete(key)
+	b.size += 1
+	return nil
+}
+
 func (b *ldbBatch) Write() error {
 	return b.db.Write(b.b, nil)
 }
@@ -155,6 +174,34 @@ func (b *ldbBatch) Reset() {
 	b.size = 0
 }
 
+type ldbSnapshot struct {
+	snap *leveldb.Snapshot
+}
+
+func (s *ldbSnapshot) Get(key []byte) ([]byte, error) {
+	return s.snap.Get(key, nil)
+}
+
+func (s *ldbSnapshot) Has(key []byte) (bool, error) {
+	return s.snap.Has(key, nil)
+}
+
+func (s *ldbSnapshot) NewIterator() Iterator {
+	return s.snap.NewIterator(nil, nil)
+}
+
+func (s *ldbSnapshot) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return s.snap.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+func (s *ldbSnapshot) NewIteratorWithRange(start, limit []byte) Iterator {
+	return s.snap.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+}
+
+func (s *ldbSnapshot) Release() {
+	s.snap.Release()
+}
+
 type table struct {
 	db     Database
 	prefix string
@@ -185,10 +232,99 @@ func (dt *table) Delete(key []byte) error {
 	return dt.db.Delete(append([]byte(dt.prefix), key...))
 }
 
+func (dt *table) NewIterator() Iterator {
+	return newTableIterator(dt.db, dt.prefix, nil, nil)
+}
+
+func (dt *table) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return newTableIterator(dt.db, dt.prefix, prefix, util.BytesPrefix(prefix).Limit)
+}
+
+func (dt *table) NewIteratorWithRange(start, limit []byte) Iterator {
+	return newTableIterator(dt.db, dt.prefix, start, limit)
+}
+
+func (dt *table) NewSnapshot() (Snapshot, error) {
+	snap, err := dt.db.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &tableSnapshot{snap: snap, prefix: dt.prefix}, nil
+}
+
 func (dt *table) Close() {
 	// Do nothing; don't close the underlying DB.
 }
 
+// newTableIterator iterates over the keys of the table from start to limit,
+// either nil leaving the range open within the table.
+func newTableIterator(db Iteratee, prefix string, start, limit []byte) Iterator {
+	tableStart := append([]byte(prefix), start...)
+	tableLimit := util.BytesPrefix([]byte(prefix)).Limit
+	if limit != nil {
+		tableLimit = append([]byte(prefix), limit...)
+	}
+	return &tableIterator{it: db.NewIteratorWithRange(tableStart, tableLimit), prefix: len(prefix)}
+}
+
+// tableIterator strips the table prefix from the keys of an iterator.
+type tableIterator struct {
+	it     Iterator
+	prefix int
+}
+
+func (it *tableIterator) Next() bool {
+	return it.it.Next()
+}
+
+func (it *tableIterator) Key() []byte {
+	if key := it.it.Key(); key != nil {
+		return key[it.prefix:]
+	}
+	return nil
+}
+
+func (it *tableIterator) Value() []byte {
+	return it.it.Value()
+}
+
+func (it *tableIterator) Error() error {
+	return it.it.Error()
+}
+
+func (it *tableIterator) Release() {
+	it.it.Release()
+}
+
+type tableSnapshot struct {
+	snap   Snapshot
+	prefix string
+}
+
+func (ts *tableSnapshot) Get(key []byte) ([]byte, error) {
+	return ts.snap.Get(append([]byte(ts.prefix), key...))
+}
+
+func (ts *tableSnapshot) Has(key []byte) (bool, error) {
+	return ts.snap.Has(append([]byte(ts.prefix), key...))
+}
+
+func (ts *tableSnapshot) NewIterator() Iterator {
+	return newTableIterator(ts.snap, ts.prefix, nil, nil)
+}
+
+func (ts *tableSnapshot) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return newTableIterator(ts.snap, ts.prefix, prefix, util.BytesPrefix(prefix).Limit)
+}
+
+func (ts *tableSnapshot) NewIteratorWithRange(start, limit []byte) Iterator {
+	return newTableIterator(ts.snap, ts.prefix, start, limit)
+}
+
+func (ts *tableSnapshot) Release() {
+	ts.snap.Release()
+}
+
 type tableBatch struct {
 	batch  Batch
 	prefix string
@@ -207,6 +343,10 @@ func (tb *tableBatch) Put(key, value []byte) error {
 	return tb.batch.Put(append([]byte(tb.prefix), key...), value)
 }
 
+func (tb *tableBatch) Delete(key []byte) error {
+	return tb.batch.Delete(append([]byte(tb.prefix), key...))
+}
+
 func (tb *tableBatch) Write() error {
 	return tb.batch.Write()
 }
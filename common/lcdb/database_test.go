@@ -111,6 +111,47 @@ func testPutGet(db lcdb.Database, t *testing.T) {
 	}
 }
 
+func TestLDB_BatchDelete(t *testing.T) {
+	db, remove := newTestLDB()
+	defer remove()
+	testBatchDelete(db, t)
+}
+
+func TestMemoryDB_BatchDelete(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+	testBatchDelete(db, t)
+}
+
+func testBatchDelete(db lcdb.Database, t *testing.T) {
+	t.Parallel()
+
+	for _, v := range test_values {
+		if err := db.Put([]byte("old"+v), []byte(v)); err != nil {
+			t.Fatalf("put failed: %v", err)
+		}
+	}
+	batch := db.NewBatch()
+	for _, v := range test_values {
+		batch.Put([]byte("new"+v), []byte(v))
+		batch.Delete([]byte("old" + v))
+	}
+	// Nothing changes before the batch is written
+	if ok, _ := db.Has([]byte("old" + test_values[0])); !ok {
+		t.Fatalf("batch delete applied before write")
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("batch write failed: %v", err)
+	}
+	for _, v := range test_values {
+		if ok, _ := db.Has([]byte("old" + v)); ok {
+			t.Fatalf("batch deleted value %q still present", v)
+		}
+		if data, err := db.Get([]byte("new" + v)); err != nil || !bytes.Equal(data, []byte(v)) {
+			t.Fatalf("batch put value %q: have %q, %v", v, data, err)
+		}
+	}
+}
+
 func TestLDB_ParallelPutGet(t *testing.T) {
 	db, remove := newTestLDB()
 	defer remove()
@@ -225,3 +266,202 @@ func TestMaxSizeValue(t *testing.T) {
 func timeGet(t *testing.T) int64 {
 	return time.Now().UnixNano()
 }
+
+// newTestBackend opens an empty database of the named backend.
+func newTestBackend(t *testing.T, backend string) (lcdb.Database, func()) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "lcdb_test_")
+	if err != nil {
+		t.Fatalf("failed to create test directory: %v", err)
+	}
+	db, err := lcdb.Open(backend, dirname, 0, 0)
+	if err != nil {
+		os.RemoveAll(dirname)
+		t.Fatalf("failed to open %s database: %v", backend, err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dirname)
+	}
+}
+
+// TestBackends runs the database conformance tests against every backend.
+func TestBackends(t *testing.T) {
+	tests := map[string]func(lcdb.Database, *testing.T){
+		"PutGet":         testPutGet,
+		"BatchDelete":    testBatchDelete,
+		"ParallelPutGet": testParallelPutGet,
+		"Table":          testTable,
+		"Iterator":       testIterator,
+		"Snapshot":       testSnapshot,
+		"DeleteRange":    testDeleteRange,
+	}
+	for _, backend := range lcdb.Backends() {
+		for name, test := range tests {
+			backend, test := backend, test
+			t.Run(backend+"/"+name, func(t *testing.T) {
+				db, remove := newTestBackend(t, backend)
+				defer remove()
+				test(db, t)
+			})
+		}
+	}
+}
+
+func testTable(db lcdb.Database, t *testing.T) {
+	table := lcdb.NewTable(db, "t-")
+	if err := table.Put([]byte("key"), []byte("table")); err != nil {
+		t.Fatalf("table put failed: %v", err)
+	}
+	if err := db.Put([]byte("key"), []byte("db")); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	batch := table.NewBatch()
+	batch.Put([]byte("batch"), []byte("table"))
+	if err := batch.Write(); err != nil {
+		t.Fatalf("table batch write failed: %v", err)
+	}
+	for key, want := range map[string]string{"key": "db", "t-key": "table", "t-batch": "table"} {
+		if data, err := db.Get([]byte(key)); err != nil || string(data) != want {
+			t.Fatalf("get %q: have %q, %v, want %q", key, data, err, want)
+		}
+	}
+}
+
+// iterated lists the keys of an iterator, which it releases, checking that
+// every value is "v" followed by the key it is stored at, maybe in a table.
+func iterated(t *testing.T, it lcdb.Iterator) string {
+	defer it.Release()
+
+	var keys []string
+	for it.Next() {
+		if value := it.Value(); len(value) == 0 || value[0] != 'v' || !bytes.HasSuffix(value, it.Key()) {
+			t.Fatalf("key %q: have value %q", it.Key(), it.Value())
+		}
+		keys = append(keys, string(it.Key()))
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	return fmt.Sprint(keys)
+}
+
+func testIterator(db lcdb.Database, t *testing.T) {
+	for _, key := range []string{"", "a", "b1", "b2", "b3", "c", "\xff"} {
+		if err := db.Put([]byte(key), []byte("v"+key)); err != nil {
+			t.Fatalf("put failed: %v", err)
+		}
+	}
+	tests := []struct {
+		it   lcdb.Iterator
+		want string
+	}{
+		{db.NewIterator(), "[ a b1 b2 b3 c \xff]"},
+		{db.NewIteratorWithPrefix([]byte("b")), "[b1 b2 b3]"},
+		{db.NewIteratorWithPrefix([]byte("d")), "[]"},
+		{db.NewIteratorWithRange([]byte("a"), []byte("b3")), "[a b1 b2]"},
+		{db.NewIteratorWithRange([]byte("b15"), nil), "[b2 b3 c \xff]"},
+		{db.NewIteratorWithRange(nil, []byte("b")), "[ a]"},
+	}
+	for i, test := range tests {
+		if have := iterated(t, test.it); have != test.want {
+			t.Errorf("iterator %d: have keys %s, want %s", i, have, test.want)
+		}
+	}
+
+	// Iterators keep the content the database had when they were created
+	it := db.NewIteratorWithPrefix([]byte("b"))
+	db.Delete([]byte("b2"))
+	db.Put([]byte("b4"), []byte("vb4"))
+	if have := iterated(t, it); have != "[b1 b2 b3]" {
+		t.Errorf("iterator over modified database: have keys %s, want [b1 b2 b3]", have)
+	}
+
+	table := lcdb.NewTable(db, "b")
+	if have := iterated(t, table.NewIterator()); have != "[1 3 4]" {
+		t.Errorf("table iterator: have keys %s, want [1 3 4]", have)
+	}
+}
+
+func testSnapshot(db lcdb.Database, t *testing.T) {
+	db.Put([]byte("a"), []byte("va"))
+	db.Put([]byte("b"), []byte("vb"))
+	snap, err := db.NewSnapshot()
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	defer snap.Release()
+
+	db.Put([]byte("a"), []byte("changed"))
+	db.Delete([]byte("b"))
+	db.Put([]byte("c"), []byte("vc"))
+
+	if data, err := snap.Get([]byte("a")); err != nil || string(data) != "va" {
+		t.Fatalf("snapshot get: have %q, %v, want va", data, err)
+	}
+	if ok, _ := snap.Has([]byte("b")); !ok {
+		t.Fatalf("snapshot lost a deleted key")
+	}
+	if ok, _ := snap.Has([]byte("c")); ok {
+		t.Fatalf("snapshot has a later key")
+	}
+	if have := iterated(t, snap.NewIterator()); have != "[a b]" {
+		t.Fatalf("snapshot iterator: have keys %s, want [a b]", have)
+	}
+	if data, _ := db.Get([]byte("a")); string(data) != "changed" {
+		t.Fatalf("database get: have %q, want changed", data)
+	}
+
+	// Table snapshots see the keys of the table only
+	db.Put([]byte("t-x"), []byte("vx"))
+	tsnap, err := lcdb.NewTable(db, "t-").NewSnapshot()
+	if err != nil {
+		t.Fatalf("table snapshot failed: %v", err)
+	}
+	defer tsnap.Release()
+	db.Put([]byte("t-y"), []byte("vy"))
+	if have := iterated(t, tsnap.NewIterator()); have != "[x]" {
+		t.Fatalf("table snapshot iterator: have keys %s, want [x]", have)
+	}
+}
+
+func testDeleteRange(db lcdb.Database, t *testing.T) {
+	for i := 0; i < 300; i++ {
+		key := fmt.Sprintf("k%03d", i)
+		db.Put([]byte(key), bytes.Repeat([]byte{1}, 1024))
+	}
+	deleted, err := lcdb.DeleteRange(db, []byte("k100"), []byte("k250"))
+	if err != nil || deleted != 150 {
+		t.Fatalf("delete range: have %d, %v, want 150, nil", deleted, err)
+	}
+	for i := 0; i < 300; i++ {
+		key := fmt.Sprintf("k%03d", i)
+		if ok, _ := db.Has([]byte(key)); ok != (i < 100 || i >= 250) {
+			t.Fatalf("key %s: have present %v", key, ok)
+		}
+	}
+}
+
+func TestOpenBackendMismatch(t *testing.T) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "lcdb_test_")
+	if err != nil {
+		t.Fatalf("failed to create test directory: %v", err)
+	}
+	defer os.RemoveAll(dirname)
+
+	db, err := lcdb.Open("", dirname, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open default database: %v", err)
+	}
+	db.Close()
+	if _, err := lcdb.Open("boltdb", dirname, 0, 0); err == nil {
+		t.Fatalf("opened a leveldb database with boltdb")
+	}
+	if _, err := lcdb.Open("unknown", dirname, 0, 0); err == nil {
+		t.Fatalf("opened a database with an unknown backend")
+	}
+	db, err = lcdb.Open("leveldb", dirname, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	db.Close()
+}
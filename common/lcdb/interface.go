@@ -9,12 +9,60 @@ type Putter interface {
 	Put(key []byte, value []byte) error
 }
 
+// Deleter wraps the database delete operation supported by both batches and regular databases.
+type Deleter interface {
+	Delete(key []byte) error
+}
+
+// Reader wraps the read operations supported by both databases and snapshots.
+type Reader interface {
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) (bool, error)
+}
+
+// Iterator iterates over key/value pairs in ascending key order. The key and
+// value are only valid until the next call to Next, and the iterator must be
+// released after use.
+type Iterator interface {
+	// Next moves to the next pair, returning false when there is none left.
+	Next() bool
+	Key() []byte
+	Value() []byte
+	// Error returns the error which stopped the iteration, if any.
+	Error() error
+	Release()
+}
+
+// Iteratee wraps the iteration operations supported by both databases and
+// snapshots. A database iterator sees the content the database had when it was
+// created, unaffected by later writes.
+type Iteratee interface {
+	// NewIterator iterates over the whole content.
+	NewIterator() Iterator
+	// NewIteratorWithPrefix iterates over the keys starting with prefix.
+	NewIteratorWithPrefix(prefix []byte) Iterator
+	// NewIteratorWithRange iterates over the keys from start included to limit
+	// excluded. A nil start or limit leaves the range open on that side.
+	NewIteratorWithRange(start, limit []byte) Iterator
+}
+
+// Snapshot is a read-only view of a database at the time it was taken, which
+// several reads can share to be consistent with each other. It must be
+// released after use, and can't be used concurrently.
+type Snapshot interface {
+	Reader
+	Iteratee
+	Release()
+}
+
 // Database wraps all database operations. All methods are safe for concurrent use.
 type Database interface {
 	Putter
-	Get(key []byte) ([]byte, error)
-	Has(key []byte) (bool, error)
-	Delete(key []byte) error
+	Deleter
+	Reader
+	Iteratee
+	// NewSnapshot takes a point-in-time view of the database.
+	NewSnapshot() (Snapshot, error)
 	Close()
 	NewBatch() Batch
 }
@@ -23,6 +71,7 @@ type Database interface {
 // when Write is called. Batch cannot be used concurrently.
 type Batch interface {
 	Putter
+	Deleter
 	ValueSize() int // amount of data in the batch
 	Write() error
 	// Reset resets the batch for reuse
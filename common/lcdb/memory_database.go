@@ -2,7 +2,10 @@ package lcdb
 
 import (
 	"errors"
+	"sort"
 	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 /*
@@ -70,6 +73,46 @@ func (db *MemDatabase) Delete(key []byte) error {
 	return nil
 }
 
+func (db *MemDatabase) NewIterator() Iterator {
+	return db.NewIteratorWithRange(nil, nil)
+}
+
+func (db *MemDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return db.NewIteratorWithRange(prefix, util.BytesPrefix(prefix).Limit)
+}
+
+// NewIteratorWithRange copies the pairs in the range, so that the iterator
+// isn't affected by later writes.
+func (db *MemDatabase) NewIteratorWithRange(start, limit []byte) Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	it := &memIterator{index: -1}
+	for key := range db.db {
+		if (start != nil && key < string(start)) || (limit != nil && key >= string(limit)) {
+			continue
+		}
+		it.keys = append(it.keys, key)
+	}
+	sort.Strings(it.keys)
+	for _, key := range it.keys {
+		it.values = append(it.values, CopyBytes(db.db[key]))
+	}
+	return it
+}
+
+// NewSnapshot copies the whole database.
+func (db *MemDatabase) NewSnapshot() (Snapshot, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	snap, _ := NewMemDatabaseWithCap(len(db.db))
+	for key, value := range db.db {
+		snap.db[key] = CopyBytes(value)
+	}
+	return &memSnapshot{db: snap}, nil
+}
+
 func (db *MemDatabase) Close() {}
 
 func (db *MemDatabase) NewBatch() Batch {
@@ -78,7 +121,10 @@ func (db *MemDatabase) NewBatch() Batch {
 
 func (db *MemDatabase) Len() int { return len(db.db) }
 
-type kv struct{ k, v []byte }
+type kv struct {
+	k, v []byte
+	del  bool
+}
 
 type memBatch struct {
 	db     *MemDatabase
@@ -97,16 +143,26 @@ func CopyBytes(b []byte) (copiedBytes []byte) {
 }
 
 func (b *memBatch) Put(key, value []byte) error {
-	b.writes = append(b.writes, kv{CopyBytes(key), CopyBytes(value)})
+	b.writes = append(b.writes, kv{CopyBytes(key), CopyBytes(value), false})
 	b.size += len(value)
 	return nil
 }
 
+func (b *memBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, kv{CopyBytes(key), nil, true})
+	b.size += 1
+	return nil
+}
+
 func (b *memBatch) Write() error {
 	b.db.lock.Lock()
 	defer b.db.lock.Unlock()
 
 	for _, kv := range b.writes {
+		if kv.del {
+			delete(b.db.db, string(kv.k))
+			continue
+		}
 		b.db.db[string(kv.k)] = kv.v
 	}
 	return nil
@@ -120,3 +176,66 @@ func (b *memBatch) Reset() {
 	b.writes = b.writes[:0]
 	b.size = 0
 }
+
+type memIterator struct {
+	keys   []string
+	values [][]byte
+	index  int
+}
+
+func (it *memIterator) Next() bool {
+	if it.index >= len(it.keys) {
+		return false
+	}
+	it.index++
+	return it.index < len(it.keys)
+}
+
+func (it *memIterator) Key() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return []byte(it.keys[it.index])
+}
+
+func (it *memIterator) Value() []byte {
+	if it.index < 0 || it.index >= len(it.values) {
+		return nil
+	}
+	return it.values[it.index]
+}
+
+func (it *memIterator) Error() error {
+	return nil
+}
+
+func (it *memIterator) Release() {
+	it.keys, it.values = nil, nil
+}
+
+// memSnapshot is a read-only copy of a database, released by dropping it.
+type memSnapshot struct {
+	db *MemDatabase
+}
+
+func (s *memSnapshot) Get(key []byte) ([]byte, error) {
+	return s.db.Get(key)
+}
+
+func (s *memSnapshot) Has(key []byte) (bool, error) {
+	return s.db.Has(key)
+}
+
+func (s *memSnapshot) NewIterator() Iterator {
+	return s.db.NewIterator()
+}
+
+func (s *memSnapshot) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return s.db.NewIteratorWithPrefix(prefix)
+}
+
+func (s *memSnapshot) NewIteratorWithRange(start, limit []byte) Iterator {
+	return s.db.NewIteratorWithRange(start, limit)
+}
+
+func (s *memSnapshot) Release() {}
@@ -0,0 +1,42 @@
+package lcdb
+
+// DeleteRange deletes the keys from start included to limit excluded, a nil
+// start or limit leaving the range open on that side. The deletes are written
+// in batches of IdealBatchSize, each after releasing the iterator which found
+// them, so a failure can leave the range partly deleted. It returns the number
+// of keys deleted.
+func DeleteRange(db Database, start, limit []byte) (int, error) {
+	batch := db.NewBatch()
+	deleted := 0
+	for {
+		var (
+			it   = db.NewIteratorWithRange(start, limit)
+			last []byte
+			n    int
+		)
+		for batch.ValueSize() < IdealBatchSize && it.Next() {
+			last = CopyBytes(it.Key())
+			if err := batch.Delete(last); err != nil {
+				it.Release()
+				return deleted, err
+			}
+			n++
+		}
+		err := it.Error()
+		it.Release()
+		if err != nil {
+			return deleted, err
+		}
+		if last == nil {
+			return deleted, nil
+		}
+		if err := batch.Write(); err != nil {
+			return deleted, err
+		}
+		deleted += n
+		batch.Reset()
+
+		// Resume right after the last key deleted
+		start = append(last, 0)
+	}
+}
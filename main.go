@@ -1,13 +1,64 @@
 package main
 
 import (
-	"github.com/mihongtech/linkchain-core/node"
-	"github.com/mihongtech/linkchain-core/node/config"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
 )
 
+// command is a subcommand of the linkchain executable, run with the arguments
+// following its name.
+type command struct {
+	run   func(args []string) error
+	usage string
+}
+
+var commands = map[string]command{
+	"run":     {runNode, "run the node until interrupted"},
+	"init":    {initChain, "write the genesis block of a new chain"},
+	"export":  {exportChain, "write canonical blocks to a dump file"},
+	"import":  {importChain, "process the blocks of a dump file"},
+	"verify":  {verifyChain, "check, or repair, the chain database"},
+	"account": {accountCommand, "create and list the accounts of the keystore"},
+	"peers":   {listPeers, "list the peers of a running node"},
+	"version": {printVersion, "print the version"},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: linkchain <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr)
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run linkchain <command> -h for the flags of a command.")
+}
+
 func main() {
-	n := node.NewNode(config.BaseConfig{})
-	n.Setup(nil)
-	n.Start()
-	n.Stop()
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		if name := os.Args[1]; name != "help" && name != "-h" && name != "--help" {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		}
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		if err == flag.ErrHelp {
+			// The flag set printed its usage
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
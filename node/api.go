@@ -1,16 +1,31 @@
 package node
 
 import (
+	"errors"
+	"math/big"
+	"time"
+
 	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/common/util/event"
+	"github.com/mihongtech/linkchain-core/common/util/log"
 	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/chain"
 	"github.com/mihongtech/linkchain-core/node/chain/storage"
 	"github.com/mihongtech/linkchain-core/node/config"
-	"github.com/mihongtech/linkchain-core/node/event"
+	node_event "github.com/mihongtech/linkchain-core/node/event"
+	"github.com/mihongtech/linkchain-core/node/net/p2p"
 	"github.com/mihongtech/linkchain-core/node/net/p2p/discover"
 	"github.com/mihongtech/linkchain-core/node/net/p2p/peer"
-	"math/big"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/peer_error"
 )
 
+var ErrLightMode = errors.New("not supported in light mode")
+
+type chainReader interface {
+	chain.ChainReader
+	GetBlockNumber(hash math.Hash) uint64
+}
+
 type CoreAPI struct {
 	node *Node
 }
@@ -19,38 +34,65 @@ func NewPublicCoreAPI(node *Node) *CoreAPI {
 	return &CoreAPI{node: node}
 }
 
+//chain returns the full chain, or the header-only chain in light mode.
+//Blocks read from the header-only chain carry no transactions.
+func (c *CoreAPI) chain() chainReader {
+	if c.node.lightchain != nil {
+		return c.node.lightchain
+	}
+	return c.node.blockchain
+}
+
 /**chainReader inteface**/
 
 func (c *CoreAPI) HasBlock(hash meta.BlockID) bool {
-	return c.node.blockchain.HasBlock(hash)
+	return c.chain().HasBlock(hash)
 }
 
 func (c *CoreAPI) GetHeader(hash math.Hash, height uint64) *meta.BlockHeader {
-	return c.node.blockchain.GetHeader(hash, height)
+	return c.chain().GetHeader(hash, height)
 }
 
 func (c *CoreAPI) GetChainConfig() *config.ChainConfig {
-	return c.node.blockchain.GetChainConfig()
+	return c.chain().GetChainConfig()
 }
 
 func (c *CoreAPI) GetBestBlock() *meta.Block {
-	return c.node.blockchain.GetBestBlock()
+	return c.chain().GetBestBlock()
 }
 
 func (c *CoreAPI) GetBlockNumber(id meta.BlockID) uint64 {
-	return c.node.blockchain.GetBlockNumber(id)
+	return c.chain().GetBlockNumber(id)
 }
 
 func (c *CoreAPI) GetBlockByID(hash meta.BlockID) (*meta.Block, error) {
-	return c.node.blockchain.GetBlockByID(hash)
+	return c.chain().GetBlockByID(hash)
 }
 
 func (c *CoreAPI) GetBlockByHeight(height uint32) (*meta.Block, error) {
-	return c.node.blockchain.GetBlockByHeight(height)
+	return c.chain().GetBlockByHeight(height)
 }
 
 func (c *CoreAPI) GetChainID() *big.Int {
-	return c.node.blockchain.GetChainID()
+	return c.chain().GetChainID()
+}
+
+//GetBlocksBySigner returns in increasing height order the headers of at most limit
+//canonical blocks signed by signer, from the height from on. It needs the block indexes.
+func (c *CoreAPI) GetBlocksBySigner(signer meta.Address, from uint64, limit int) ([]*meta.BlockHeader, error) {
+	if c.node.blockchain == nil {
+		return nil, ErrLightMode
+	}
+	return c.node.blockchain.GetBlocksBySigner(signer.CloneBytes(), from, limit)
+}
+
+//GetBlockByTime returns the header of the last canonical block created at or before t.
+//It needs the block indexes.
+func (c *CoreAPI) GetBlockByTime(t time.Time) (*meta.BlockHeader, error) {
+	if c.node.blockchain == nil {
+		return nil, ErrLightMode
+	}
+	return c.node.blockchain.GetBlockByTime(t)
 }
 
 /**P2PNet inteface**/
@@ -69,14 +111,25 @@ func (c *CoreAPI) RemovePeer(node *discover.Node) {
 
 /**Tx inteface**/
 func (c *CoreAPI) ProcessTx(tx *meta.Transaction) error {
+	if c.node.txPool == nil {
+		return ErrLightMode
+	}
 	if err := c.node.txPool.ProcessTx(tx); err != nil {
 		return err
 	}
-	c.node.newTxEvent.Send(event.TxEvent{tx})
+	c.node.newTxEvent.Send(node_event.TxEvent{tx})
 	return nil
 }
 
 func (c *CoreAPI) GetTXByID(id meta.TxID) (*meta.Transaction, meta.BlockID, uint64, uint64) {
+	if c.node.lightClient != nil {
+		tx, blockId, number, index, err := c.node.lightClient.GetTransaction(id)
+		if err != nil {
+			log.Debug("CoreAPI", "GetTXByID", err)
+			return nil, math.Hash{}, 0, 0
+		}
+		return tx, blockId, number, index
+	}
 	tx, blockId, number, index := storage.GetTransaction(c.node.db, id)
 	if tx == nil {
 		return nil, math.Hash{}, 0, 0
@@ -84,3 +137,35 @@ func (c *CoreAPI) GetTXByID(id meta.TxID) (*meta.Transaction, meta.BlockID, uint
 		return tx, blockId, number, index
 	}
 }
+
+/**Event inteface**/
+
+//SubscribeChainHeadEvent reports every new head of the chain.
+func (c *CoreAPI) SubscribeChainHeadEvent(ch chan<- meta.ChainHeadEvent) event.Subscription {
+	if c.node.lightchain != nil {
+		return c.node.lightchain.SubscribeChainHeadEvent(ch)
+	}
+	return c.node.blockchain.SubscribeChainHeadEvent(ch)
+}
+
+//SubscribeChainSideEvent reports the blocks imported off the canonical chain.
+//The header-only chain of light mode reports none.
+func (c *CoreAPI) SubscribeChainSideEvent(ch chan<- meta.ChainSideEvent) event.Subscription {
+	if c.node.lightchain != nil {
+		return event.NewSubscription(func(quit <-chan struct{}) error {
+			<-quit
+			return nil
+		})
+	}
+	return c.node.blockchain.SubscribeChainSideEvent(ch)
+}
+
+//SubscribeTxEvent reports the transactions submitted through ProcessTx.
+func (c *CoreAPI) SubscribeTxEvent(ch chan<- node_event.TxEvent) event.Subscription {
+	return c.node.newTxEvent.Subscribe(ch)
+}
+
+//SubscribePeerEvent reports the peers connecting and disconnecting.
+func (c *CoreAPI) SubscribePeerEvent(ch chan *peer_error.PeerEvent) event.Subscription {
+	return c.node.p2pSvc.(*p2p.Service).SubscribeEvents(ch)
+}
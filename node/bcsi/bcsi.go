@@ -1,9 +1,16 @@
 package bcsi
 
 import (
+	"errors"
+
+	"github.com/mihongtech/linkchain-core/common/math"
 	"github.com/mihongtech/linkchain-core/core/meta"
 )
 
+//ErrAppUnavailable is returned by the BCSI when the app can't be reached.
+//The call failed regardless of its arguments, so the core must not take it as a verdict on them.
+var ErrAppUnavailable = errors.New("bcsi app unavailable")
+
 //app provide to core for querying information
 type Querier interface {
 	//app provide
@@ -28,9 +35,40 @@ type Validator interface {
 type Configurator interface {
 }
 
+//app optionally provide to core for state snapshot sync.
+//The app state is a trie rooted at BlockHeader.Status whose nodes are keyed by their hash.
+type StateSyncer interface {
+	//GetStateNode return the encoded state trie node of hash, used to serve peers
+	GetStateNode(hash math.Hash) ([]byte, error)
+	//PutStateNode store a state trie node downloaded from peers
+	PutStateNode(hash math.Hash, data []byte) error
+	//ImportState accept the downloaded trie rooted at root as the app state after block id
+	ImportState(id meta.BlockID, root meta.TreeID) error
+}
+
 type BCSI interface {
 	Querier
 	Processor
 	Validator
 	Configurator
 }
+
+//core provide to app for calling back into the core
+type CoreServices interface {
+	ProcessTx(tx *meta.Transaction) error
+	GetBlockByID(hash meta.BlockID) (*meta.Block, error)
+	GetBestBlock() *meta.Block
+	GetTXByID(id meta.TxID) (*meta.Transaction, meta.BlockID, uint64, uint64)
+}
+
+//app optionally provide to core for receiving the core services once the core started.
+//The proxies implement it to carry the core services over to the app side.
+type CoreServicesSetter interface {
+	SetCoreServices(core CoreServices)
+}
+
+//app proxies optionally provide to core for reporting whether the app is reachable.
+//The core pauses mining and block import while it isn't.
+type HealthChecker interface {
+	Healthy() bool
+}
@@ -13,6 +13,7 @@ type ChainReader interface {
 	HasBlock(hash meta.BlockID) bool
 	GetBlockByID(hash meta.BlockID) (*meta.Block, error)
 	GetHeader(hash math.Hash, height uint64) *meta.BlockHeader
+	GetHeaderByHeight(height uint64) *meta.BlockHeader
 	GetBlockByHeight(height uint32) (*meta.Block, error)
 	GetChainConfig() *config.ChainConfig
 	GetChainID() *big.Int
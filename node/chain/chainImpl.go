@@ -24,7 +24,11 @@ import (
 )
 
 var (
-	ErrNoGenesis = errors.New("Genesis not found in chain")
+	ErrNoGenesis       = errors.New("Genesis not found in chain")
+	ErrFastChainBroken = errors.New("fast block does not extend the fast chain")
+	ErrNotFastBlock    = errors.New("block is not in the fast chain")
+	ErrNoBlockIndexes  = errors.New("block indexes are not maintained")
+	ErrBlockNotFound   = errors.New("block not found")
 )
 
 const (
@@ -36,8 +40,9 @@ const (
 	triesInMemory       = 128
 	receiptsCacheLimit  = 32
 
-	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
-	BlockChainVersion = 3
+	// BlockChainVersion is the version of the database layout, older databases
+	// are migrated on start and newer ones refused.
+	BlockChainVersion = storage.DatabaseVersion
 )
 
 // CacheConfig contains the configuration values for the trie caching/pruning
@@ -46,6 +51,9 @@ type CacheConfig struct {
 	Disabled      bool          // Whether to disable trie write caching (archive node)
 	TrieNodeLimit int           // Memory limit (MB) at which to flush the current in-memory trie to disk
 	TrieTimeLimit time.Duration // Time limit after which to flush the current in-memory trie to disk
+
+	BlockRetention uint64 // Number of recent blocks whose bodies are kept, 0 keeps every block (archive node)
+	BlockIndexes   bool   // Whether to index the canonical blocks by signer and time
 }
 
 // ChainImpl represents the canonical chain given a database with a genesis
@@ -85,6 +93,10 @@ type ChainImpl struct {
 	currentFastBlock atomic.Value // Current head of the fast-sync chain (may be above the block chain!)
 	currentBlockHash math.Hash    // Hash of the current head of the header chain (prevent recomputing all the time)
 
+	prunedHeight uint64     // Lowest canonical height whose body is kept (atomic)
+	pruneLock    sync.Mutex // Pruning lock, one pruning round at a time
+
+	headerCache   *lru.Cache // Cache for the most recent block headers
 	blockCache    *lru.Cache // Cache for the most recent entire blocks
 	receiptsCache *lru.Cache // Cache for the most recent receipts per block
 	futureBlocks  *lru.Cache // future blocks are blocks added for later processing
@@ -113,6 +125,10 @@ func NewBlockChain(db lcdb.Database, genesisHash math.Hash, cacheConfig *CacheCo
 			TrieTimeLimit: 5 * time.Minute,
 		}
 	}
+	if err := storage.Migrate(db); err != nil {
+		return nil, err
+	}
+	headerCache, _ := lru.New(headerCacheLimit)
 	blockCache, _ := lru.New(blockCacheLimit)
 	futureBlocks, _ := lru.New(maxFutureBlocks)
 	badBlocks, _ := lru.New(badBlockLimit)
@@ -124,6 +140,7 @@ func NewBlockChain(db lcdb.Database, genesisHash math.Hash, cacheConfig *CacheCo
 		db:            db,
 		triegc:        prque.New(),
 		quit:          make(chan struct{}),
+		headerCache:   headerCache,
 		blockCache:    blockCache,
 		futureBlocks:  futureBlocks,
 		badBlocks:     badBlocks,
@@ -140,9 +157,17 @@ func NewBlockChain(db lcdb.Database, genesisHash math.Hash, cacheConfig *CacheCo
 	if err := bc.loadLastChain(); err != nil {
 		return nil, err
 	}
+	if err := bc.loadIndexes(); err != nil {
+		return nil, err
+	}
 	bc.SetCurrentBlockHead(bc.CurrentBlock())
+	bc.prunedHeight = storage.GetPrunedHeight(db)
 
 	go bc.update()
+	if cacheConfig.BlockRetention > 0 {
+		bc.wg.Add(1)
+		go bc.pruneLoop(cacheConfig.BlockRetention)
+	}
 	return bc, nil
 }
 
@@ -159,17 +184,30 @@ func (bc *ChainImpl) getProcInterrupt() bool {
 func (bc *ChainImpl) loadLastChain() error {
 	// Restore the last known head block
 	head := storage.GetHeadBlockHash(bc.db)
-	if head == (math.Hash{}) {
-		// Corrupt or empty database, init from scratch
-		log.Warn("Empty database, resetting chain")
-		return bc.Reset()
-	}
 	// Make sure the entire head block is available
-	currentBlock, _ := bc.GetBlockByID(head)
+	var currentBlock *meta.Block
+	if head != (math.Hash{}) {
+		currentBlock, _ = bc.GetBlockByID(head)
+	}
 	if currentBlock == nil {
-		// Corrupt or empty database, init from scratch
-		log.Warn("Head block missing, resetting chain", "hash", head)
-		return bc.Reset()
+		// A crash left the head out of the stored blocks, roll it back to the
+		// last consistent block instead of dropping the chain
+		log.Warn("Head block missing, repairing chain", "hash", head)
+		report, err := storage.RepairChain(bc.db)
+		if err != nil {
+			return err
+		}
+		for _, issue := range report.Issues {
+			log.Warn("Repaired chain database", "issue", issue)
+		}
+		if head = storage.GetHeadBlockHash(bc.db); head != (math.Hash{}) {
+			currentBlock, _ = bc.GetBlockByID(head)
+		}
+		if currentBlock == nil {
+			// Empty database, init from scratch
+			log.Warn("Empty database, resetting chain")
+			return bc.Reset()
+		}
 	}
 	// Everything seems to be fine, set as the head block
 	bc.currentBlock.Store(currentBlock)
@@ -209,40 +247,48 @@ func (bc *ChainImpl) SetHead(head uint64) error {
 		height = uint64(hdr.GetHeight())
 	}
 
-	for hdr := bc.CurrentBlock(); hdr != nil && uint64(hdr.GetHeight()) > head; hdr = bc.CurrentBlock() {
+	// The whole rewind is committed at once, a crash leaves the old head
+	batch := bc.db.NewBatch()
+	currentBlock, currentFastBlock := bc.CurrentBlock(), bc.CurrentFastBlock()
+	for hdr := currentBlock; hdr != nil && uint64(hdr.GetHeight()) > head; hdr = currentBlock {
 		hash := *hdr.GetBlockID()
 		num := uint64(hdr.GetHeight())
 		prev := *hdr.GetPrevBlockID()
-		storage.DeleteBlock(bc.db, hash, num)
-		bc.currentBlock.Store(bc.GetBlock(prev, num-1))
-		bc.currentFastBlock.Store(bc.GetBlock(prev, num-1))
+		storage.DeleteBlock(batch, hash, num)
+		bc.deleteIndexes(batch, &hdr.Header)
+		currentBlock = bc.GetBlock(prev, num-1)
+		currentFastBlock = currentBlock
 	}
 	// Roll back the canonical chain numbering
 	for i := height; i > head; i-- {
-		storage.DeleteCanonicalHash(bc.db, i)
+		storage.DeleteCanonicalHash(batch, i)
 	}
-	bc.SetCurrentBlockHead(bc.CurrentBlock())
+	// If either blocks reached nil, reset to the genesis
+	if currentBlock == nil {
+		currentBlock = bc.genesisBlock
+	}
+	if currentFastBlock == nil {
+		currentFastBlock = bc.genesisBlock
+	}
+	storage.WriteHeadBlockHash(batch, *currentBlock.GetBlockID())
+	storage.WriteHeadFastBlockHash(batch, *currentFastBlock.GetBlockID())
+	if bc.cacheConfig.BlockIndexes {
+		storage.WriteIndexedHeight(batch, uint64(currentBlock.GetHeight()))
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	bc.currentBlock.Store(currentBlock)
+	bc.currentFastBlock.Store(currentFastBlock)
+	bc.SetCurrentBlockHead(currentBlock)
+
 	// Clear out any stale content from the caches
+	bc.headerCache.Purge()
 	bc.blockCache.Purge()
 	bc.futureBlocks.Purge()
 	bc.receiptsCache.Purge()
 	bc.numberCache.Purge()
 
-	// If either blocks reached nil, reset to the genesis
-	if currentBlock := bc.CurrentBlock(); currentBlock == nil {
-		bc.currentBlock.Store(bc.genesisBlock)
-	}
-	if currentFastBlock := bc.CurrentFastBlock(); currentFastBlock == nil {
-		bc.currentFastBlock.Store(bc.genesisBlock)
-	}
-	currentBlock := bc.CurrentBlock()
-	currentFastBlock := bc.CurrentFastBlock()
-	if err := storage.WriteHeadBlockHash(bc.db, *currentBlock.GetBlockID()); err != nil {
-		log.Crit("Failed to reset head full block", "err", err)
-	}
-	if err := storage.WriteHeadFastBlockHash(bc.db, *currentFastBlock.GetBlockID()); err != nil {
-		log.Crit("Failed to reset head fast block", "err", err)
-	}
 	return bc.loadLastChain()
 }
 
@@ -275,11 +321,16 @@ func (bc *ChainImpl) ResetWithGenesisBlock(genesis *meta.Block) error {
 	defer bc.mu.Unlock()
 
 	// Prepare the genesis block and reinitialise the chain
-	if err := storage.WriteBlock(bc.db, genesis); err != nil {
-		log.Crit("Failed to write genesis block", "err", err)
+	batch := bc.db.NewBatch()
+	if err := storage.WriteBlock(batch, genesis); err != nil {
+		return err
+	}
+	updateHeads := bc.writeHead(batch, genesis)
+	if err := batch.Write(); err != nil {
+		return err
 	}
 	bc.genesisBlock = genesis
-	bc.insert(bc.genesisBlock)
+	bc.setHead(genesis, updateHeads)
 	bc.currentBlock.Store(bc.genesisBlock)
 	bc.SetCurrentBlockHead(bc.genesisBlock)
 	bc.currentFastBlock.Store(bc.genesisBlock)
@@ -293,26 +344,41 @@ func (bc *ChainImpl) ResetWithGenesisBlock(genesis *meta.Block) error {
 // or if they are on a different side chain.
 //
 // Note, this function assumes that the `mu` mutex is held!
-func (bc *ChainImpl) insert(block *meta.Block) {
+func (bc *ChainImpl) insert(block *meta.Block) error {
+	batch := bc.db.NewBatch()
+	updateHeads := bc.writeHead(batch, block)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	bc.setHead(block, updateHeads)
+	return nil
+}
+
+// writeHead adds to batch the canonical number and head pointers making block
+// the head of the chain, and tells whether the fast head moves too. The head
+// only changes in memory once the batch is written, with setHead.
+func (bc *ChainImpl) writeHead(batch lcdb.Putter, block *meta.Block) bool {
 	// If the block is on a side chain or an unknown one, force other heads onto it too
 	updateHeads := (storage.GetCanonicalHash(bc.db, uint64(block.GetHeight()))) != *block.GetBlockID()
 
 	// Add the block to the canonical chain number scheme and mark as the head
-	if err := storage.WriteCanonicalHash(bc.db, *block.GetBlockID(), uint64(block.GetHeight())); err != nil {
-		log.Crit("Failed to insert block number", "err", err)
-	}
-	if err := storage.WriteHeadBlockHash(bc.db, *block.GetBlockID()); err != nil {
-		log.Crit("Failed to insert head block hash", "err", err)
-	}
-	bc.currentBlock.Store(block)
+	storage.WriteCanonicalHash(batch, *block.GetBlockID(), uint64(block.GetHeight()))
+	storage.WriteHeadBlockHash(batch, *block.GetBlockID())
+	bc.writeIndexes(batch, &block.Header)
 
 	// If the block is better than our head or is on a different chain, force update heads
 	if updateHeads {
-		bc.SetCurrentBlockHead(block)
+		storage.WriteHeadFastBlockHash(batch, *block.GetBlockID())
+	}
+	return updateHeads
+}
 
-		if err := storage.WriteHeadFastBlockHash(bc.db, *block.GetBlockID()); err != nil {
-			log.Crit("Failed to insert head fast block hash", "err", err)
-		}
+// setHead makes block the head of the chain in memory, once the writes of
+// writeHead are committed.
+func (bc *ChainImpl) setHead(block *meta.Block, updateHeads bool) {
+	bc.currentBlock.Store(block)
+	if updateHeads {
+		bc.SetCurrentBlockHead(block)
 		bc.currentFastBlock.Store(block)
 	}
 }
@@ -462,12 +528,89 @@ func (bc *ChainImpl) WriteBlockWithoutState(block *meta.Block) (err error) {
 	bc.wg.Add(1)
 	defer bc.wg.Done()
 
-	if err := storage.WriteBlock(bc.db, block); err != nil {
+	batch := bc.db.NewBatch()
+	if err := storage.WriteBlock(batch, block); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// InsertFastBlocks writes a batch of blocks downloaded by fast sync into the
+// canonical chain and advances the fast head, without handing them to the app.
+// Only the consensus rules are checked, the state of the blocks is imported
+// later at the pivot. Blocks already in the fast chain are skipped.
+func (bc *ChainImpl) InsertFastBlocks(blocks []*meta.Block) error {
+	bc.wg.Add(1)
+	defer bc.wg.Done()
+
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	head := bc.CurrentFastBlock()
+	batch := bc.db.NewBatch()
+	for _, block := range blocks {
+		if block.GetHeight() <= head.GetHeight() {
+			if storage.GetCanonicalHash(bc.db, uint64(block.GetHeight())) == *block.GetBlockID() {
+				continue
+			}
+			return ErrFastChainBroken
+		}
+		if block.GetHeight() != head.GetHeight()+1 || !block.GetPrevBlockID().IsEqual(head.GetBlockID()) {
+			return ErrFastChainBroken
+		}
+		if err := bc.engine.CheckBlock(block); err != nil {
+			return err
+		}
+		if err := bc.engine.ProcessBlock(block); err != nil {
+			return err
+		}
+		if err := storage.WriteBlock(batch, block); err != nil {
+			return err
+		}
+		if err := storage.WriteCanonicalHash(batch, *block.GetBlockID(), uint64(block.GetHeight())); err != nil {
+			return err
+		}
+		if err := storage.WriteTxLookupEntries(batch, block); err != nil {
+			return err
+		}
+		bc.writeIndexes(batch, &block.Header)
+		head = block
+	}
+	if err := storage.WriteHeadFastBlockHash(batch, *head.GetBlockID()); err != nil {
 		return err
 	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	bc.currentFastBlock.Store(head)
 	return nil
 }
 
+// CommitFastHead makes a block of the fast chain the head of the chain, once
+// the app has imported the state of that block.
+func (bc *ChainImpl) CommitFastHead(id meta.BlockID) error {
+	block, err := bc.GetBlockByID(id)
+	if err != nil {
+		return err
+	}
+	if block.GetHeight() > bc.CurrentFastBlock().GetHeight() ||
+		storage.GetCanonicalHash(bc.db, uint64(block.GetHeight())) != id {
+		return ErrNotFastBlock
+	}
+	bc.mu.Lock()
+	err = bc.insert(block)
+	if err == nil {
+		bc.SetCurrentBlockHead(block)
+	}
+	bc.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	log.Info("Committed fast sync head", "number", block.GetHeight(), "hash", id)
+	return bc.bcsiAPI.UpdateChain(*block)
+}
+
 // WriteBlockWithState writes the block and all associated state to the database.
 func (bc *ChainImpl) WriteBlockWithState(block *meta.Block) (status WriteStatus, err error) {
 	bc.wg.Add(1)
@@ -489,13 +632,17 @@ func (bc *ChainImpl) WriteBlockWithState(block *meta.Block) (status WriteStatus,
 		return NonStatTy, err
 	}
 
-	// If the externHeight is higher than our known, add it to the canonical chain
+	// If the externHeight is higher than our known, add it to the canonical chain.
+	// The block, the reorganised canonical chain and the new head are committed
+	// in the same batch, a crash leaves either the old head or the new one.
 	reorg := externHeight > localHeight
 	currentBlock = bc.CurrentBlock()
+	updateHeads := false
+	var dropped meta.Blocks
 	if reorg {
 		// Reorganise the chain if the parent is not the head block
 		if !block.GetPrevBlockID().IsEqual(currentBlock.GetBlockID()) {
-			if err := bc.reorg(currentBlock, block); err != nil {
+			if dropped, err = bc.reorg(batch, currentBlock, block); err != nil {
 				return NonStatTy, err
 			}
 		}
@@ -503,6 +650,7 @@ func (bc *ChainImpl) WriteBlockWithState(block *meta.Block) (status WriteStatus,
 		if err := storage.WriteTxLookupEntries(batch, block); err != nil {
 			return NonStatTy, err
 		}
+		updateHeads = bc.writeHead(batch, block)
 
 		status = CanonStatTy
 	} else {
@@ -514,7 +662,14 @@ func (bc *ChainImpl) WriteBlockWithState(block *meta.Block) (status WriteStatus,
 
 	// Set new head.
 	if status == CanonStatTy {
-		bc.insert(block)
+		bc.setHead(block, updateHeads)
+	}
+	if len(dropped) > 0 {
+		go func() {
+			for _, block := range dropped {
+				bc.chainSideFeed.Send(meta.ChainSideEvent{Block: block})
+			}
+		}()
 	}
 	bc.futureBlocks.Remove(*block.GetBlockID())
 	return status, nil
@@ -560,6 +715,10 @@ func (bc *ChainImpl) insertChain(chain *meta.Block) ([]interface{}, error) {
 		lastCanon *meta.Block
 	)
 
+	// Wait for the app to come back rather than failing blocks it can't check
+	if !bc.appHealthy() {
+		return events, bcsi.ErrAppUnavailable
+	}
 	err := bc.CheckBlock(chain)
 	if err != nil {
 		return events, err
@@ -598,6 +757,9 @@ func (bc *ChainImpl) insertChain(chain *meta.Block) ([]interface{}, error) {
 		}
 		//TODO don't understand the code.
 
+	case err == bcsi.ErrAppUnavailable:
+		return events, err
+
 	case err != nil:
 		bc.reportBlock(chain, err)
 		return events, err
@@ -617,7 +779,9 @@ func (bc *ChainImpl) insertChain(chain *meta.Block) ([]interface{}, error) {
 	// BCSI:Process block to app
 
 	if err = bc.bcsiAPI.ProcessBlock(*chain); err != nil {
-		bc.reportBlock(chain, err)
+		if err != bcsi.ErrAppUnavailable {
+			bc.reportBlock(chain, err)
+		}
 		return events, err
 	}
 	// Write the block to the chain and get the status.
@@ -651,9 +815,9 @@ func (bc *ChainImpl) insertChain(chain *meta.Block) ([]interface{}, error) {
 }
 
 // reorgs takes two blocks, an old chain and a new chain and will reconstruct the blocks and inserts them
-// to be part of the new canonical chain and accumulates potential missing transactions and post an
-// event about them
-func (bc *ChainImpl) reorg(oldBlock, newBlock *meta.Block) error {
+// to be part of the new canonical chain and accumulates potential missing transactions. The writes are
+// added to batch, the caller commits them and posts side events for the dropped blocks it returns.
+func (bc *ChainImpl) reorg(batch lcdb.Batch, oldBlock, newBlock *meta.Block) (meta.Blocks, error) {
 	var (
 		newChain    meta.Blocks
 		oldChain    meta.Blocks
@@ -678,10 +842,10 @@ func (bc *ChainImpl) reorg(oldBlock, newBlock *meta.Block) error {
 		}
 	}
 	if oldBlock == nil {
-		return fmt.Errorf("Invalid old chain")
+		return nil, fmt.Errorf("Invalid old chain")
 	}
 	if newBlock == nil {
-		return fmt.Errorf("Invalid new chain")
+		return nil, fmt.Errorf("Invalid new chain")
 	}
 
 	for {
@@ -696,10 +860,10 @@ func (bc *ChainImpl) reorg(oldBlock, newBlock *meta.Block) error {
 
 		oldBlock, newBlock = bc.GetBlock(*oldBlock.GetPrevBlockID(), uint64(oldBlock.GetHeight()-1)), bc.GetBlock(*newBlock.GetPrevBlockID(), uint64(newBlock.GetHeight()-1))
 		if oldBlock == nil {
-			return fmt.Errorf("Invalid old chain")
+			return nil, fmt.Errorf("Invalid old chain")
 		}
 		if newBlock == nil {
-			return fmt.Errorf("Invalid new chain")
+			return nil, fmt.Errorf("Invalid new chain")
 		}
 	}
 	// Ensure the user sees large reorgs
@@ -713,14 +877,18 @@ func (bc *ChainImpl) reorg(oldBlock, newBlock *meta.Block) error {
 	} else {
 		log.Error("Impossible reorg, please file an issue", "oldnum", oldBlock.GetHeight(), "oldhash", oldBlock.GetBlockID(), "newnum", newBlock.GetHeight(), "newhash", newBlock.GetBlockID())
 	}
+	// Unindex the old chain before indexing the new one over it
+	for _, block := range oldChain {
+		bc.deleteIndexes(batch, &block.Header)
+	}
 	// Insert the new chain, taking care of the proper incremental order
 	var addedTxs []meta.Transaction
 	for i := len(newChain) - 1; i >= 0; i-- {
 		// insert the block in the canonical way, re-writing history
-		bc.insert(newChain[i])
+		bc.writeHead(batch, newChain[i])
 		// write lookup entries for hash based transaction/receipt searches
-		if err := storage.WriteTxLookupEntries(bc.db, newChain[i]); err != nil {
-			return err
+		if err := storage.WriteTxLookupEntries(batch, newChain[i]); err != nil {
+			return nil, err
 		}
 		addedTxs = append(addedTxs, newChain[i].GetTxs()...)
 	}
@@ -730,18 +898,9 @@ func (bc *ChainImpl) reorg(oldBlock, newBlock *meta.Block) error {
 	// receipts that were created in the fork must also be deleted
 	for _, tx := range diff {
 		// transaction := &tx
-		storage.DeleteTxLookupEntry(bc.db, *tx.GetTxID())
+		storage.DeleteTxLookupEntry(batch, *tx.GetTxID())
 	}
-
-	if len(oldChain) > 0 {
-		go func() {
-			for _, block := range oldChain {
-				bc.chainSideFeed.Send(meta.ChainSideEvent{Block: block})
-			}
-		}()
-	}
-
-	return nil
+	return oldChain, nil
 }
 
 // PostChainEvents iterates over the events generated by a chain insertion and
@@ -781,9 +940,45 @@ func (bc *ChainImpl) Engine() consensus.Engine {
 	return bc.engine
 }
 
+// GetHeader retrieves a block header from the database by hash and number,
+// caching it if found. Only the header is read, not the body.
 func (bc *ChainImpl) GetHeader(hash math.Hash, height uint64) *meta.BlockHeader {
-	block := bc.GetBlock(hash, height)
-	return &block.Header
+	if header, ok := bc.headerCache.Get(hash); ok {
+		return header.(*meta.BlockHeader)
+	}
+	if block, ok := bc.blockCache.Get(hash); ok {
+		return &block.(*meta.Block).Header
+	}
+	header := storage.GetHeader(bc.db, hash, height)
+	if header == nil {
+		return nil
+	}
+	bc.headerCache.Add(hash, header)
+	return header
+}
+
+// GetHeaderByHeight retrieves a canonical block header by number.
+func (bc *ChainImpl) GetHeaderByHeight(height uint64) *meta.BlockHeader {
+	hash := storage.GetCanonicalHash(bc.db, height)
+	if hash == (math.Hash{}) {
+		return nil
+	}
+	return bc.GetHeader(hash, height)
+}
+
+// GetBody retrieves the transactions of a block from the database by hash and
+// number, or nil if the body is missing.
+func (bc *ChainImpl) GetBody(hash math.Hash, height uint64) *meta.Transactions {
+	if block, ok := bc.blockCache.Get(hash); ok {
+		return &block.(*meta.Block).TXs
+	}
+	return storage.GetBody(bc.db, hash, height)
+}
+
+// GetTxLookupEntry returns the hash, number and position of the canonical
+// block including the transaction, or an empty hash if it is unknown.
+func (bc *ChainImpl) GetTxLookupEntry(txid meta.TxID) (meta.BlockID, uint64, uint64) {
+	return storage.GetTxLookupEntry(bc.db, txid)
 }
 
 func (bc *ChainImpl) GetBestBlock() *meta.Block {
@@ -842,6 +1037,15 @@ func (bc *ChainImpl) addBadBlock(block *meta.Block) {
 	bc.badBlocks.Add(block.GetBlockID(), block)
 }
 
+// appHealthy reports whether the app behind the BCSI is reachable. Apps which
+// don't report their health are assumed to be.
+func (bc *ChainImpl) appHealthy() bool {
+	if checker, ok := bc.bcsiAPI.(bcsi.HealthChecker); ok {
+		return checker.Healthy()
+	}
+	return true
+}
+
 // reportBlock logs a bad block error.
 func (bc *ChainImpl) reportBlock(block *meta.Block, err error) {
 	bc.addBadBlock(block)
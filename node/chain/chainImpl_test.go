@@ -1 +1,147 @@
 package chain
+
+import (
+	"testing"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/chain/genesis"
+	"github.com/mihongtech/linkchain-core/node/chain/storage"
+)
+
+// nopBCSI is an app accepting everything, remembering the last head it was given.
+type nopBCSI struct {
+	head meta.Block
+}
+
+func (a *nopBCSI) GetBlockState(id meta.BlockID) (meta.TreeID, error) { return meta.TreeID{}, nil }
+func (a *nopBCSI) UpdateChain(head meta.Block) error                  { a.head = head; return nil }
+func (a *nopBCSI) ProcessBlock(block meta.Block) error                { return nil }
+func (a *nopBCSI) Commit(id meta.BlockID) error                       { return nil }
+func (a *nopBCSI) CheckBlock(block meta.Block) error                  { return nil }
+func (a *nopBCSI) CheckTx(transaction meta.Transaction) error         { return nil }
+func (a *nopBCSI) FilterTx(txs []meta.Transaction) []meta.Transaction { return txs }
+
+func newTestChain(t *testing.T) (*ChainImpl, *nopBCSI) {
+	db, _ := lcdb.NewMemDatabase()
+	cfg, hash, err := genesis.SetupGenesisBlock(db, nil)
+	if err != nil {
+		t.Fatalf("failed to setup genesis: %v", err)
+	}
+	app := &nopBCSI{}
+	bc, err := NewBlockChain(db, hash, nil, cfg, app, nopEngine{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	return bc, app
+}
+
+func TestInsertFastBlocks(t *testing.T) {
+	bc, app := newTestChain(t)
+	defer bc.Stop()
+
+	headers := makeHeaders(&bc.Genesis().Header, 5, 0)
+	blocks := make([]*meta.Block, len(headers))
+	for i, header := range headers {
+		blocks[i] = &meta.Block{Header: *header}
+	}
+	if err := bc.InsertFastBlocks(blocks[1:]); err != ErrFastChainBroken {
+		t.Fatalf("gapped fast blocks: have %v, want %v", err, ErrFastChainBroken)
+	}
+	if err := bc.InsertFastBlocks(blocks[:3]); err != nil {
+		t.Fatalf("failed to insert fast blocks: %v", err)
+	}
+	// Known blocks are skipped, the rest extends the fast chain
+	if err := bc.InsertFastBlocks(blocks); err != nil {
+		t.Fatalf("failed to extend fast blocks: %v", err)
+	}
+	if have := bc.CurrentFastBlock().GetHeight(); have != 5 {
+		t.Fatalf("fast head height: have %d, want 5", have)
+	}
+	if have := bc.CurrentBlock().GetHeight(); have != 0 {
+		t.Fatalf("head moved by fast blocks: have %d, want 0", have)
+	}
+	if block, err := bc.GetBlockByHeight(4); err != nil || !block.GetBlockID().IsEqual(blocks[3].GetBlockID()) {
+		t.Fatalf("fast block not canonical: %v", err)
+	}
+	fork := &meta.Block{Header: *makeHeaders(&bc.Genesis().Header, 1, 1)[0]}
+	if err := bc.InsertFastBlocks([]*meta.Block{fork}); err != ErrFastChainBroken {
+		t.Fatalf("forked fast block: have %v, want %v", err, ErrFastChainBroken)
+	}
+
+	if err := bc.CommitFastHead(*fork.GetBlockID()); err == nil {
+		t.Fatalf("unknown block committed as head")
+	}
+	if err := bc.CommitFastHead(*blocks[2].GetBlockID()); err != nil {
+		t.Fatalf("failed to commit fast head: %v", err)
+	}
+	if have := bc.CurrentBlock().GetHeight(); have != 3 {
+		t.Fatalf("head height: have %d, want 3", have)
+	}
+	if !app.head.GetBlockID().IsEqual(blocks[2].GetBlockID()) {
+		t.Fatalf("app not updated to the committed head")
+	}
+}
+
+func TestHeaderOnlyReads(t *testing.T) {
+	bc, _ := newTestChain(t)
+	defer bc.Stop()
+
+	block := &meta.Block{Header: *makeHeaders(&bc.Genesis().Header, 1, 0)[0]}
+	if err := bc.InsertFastBlocks([]*meta.Block{block}); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	hash, number := *block.GetBlockID(), uint64(block.GetHeight())
+	bc.blockCache.Purge()
+	bc.headerCache.Purge()
+
+	// Losing the body doesn't affect the header
+	storage.DeleteBody(bc.db, hash, number)
+	if header := bc.GetHeader(hash, number); header == nil || !header.GetBlockID().IsEqual(&hash) {
+		t.Fatalf("header: have %v, want %v", header, hash)
+	}
+	if !bc.headerCache.Contains(hash) || bc.blockCache.Contains(hash) {
+		t.Fatalf("header read loaded the whole block")
+	}
+	if bc.GetBody(hash, number) != nil || bc.GetBlock(hash, number) != nil {
+		t.Fatalf("block returned without its body")
+	}
+	if header := bc.GetHeaderByHeight(number); header == nil || !header.GetBlockID().IsEqual(&hash) {
+		t.Fatalf("canonical header: have %v, want %v", header, hash)
+	}
+	if bc.GetHeader(math.Hash{}, number) != nil {
+		t.Fatalf("unknown header returned")
+	}
+}
+
+func TestLoadLastChainRepair(t *testing.T) {
+	bc, app := newTestChain(t)
+
+	blocks := make([]*meta.Block, 5)
+	for i, header := range makeHeaders(&bc.Genesis().Header, len(blocks), 0) {
+		blocks[i] = &meta.Block{Header: *header}
+	}
+	if err := bc.InsertFastBlocks(blocks); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	if err := bc.CommitFastHead(*blocks[4].GetBlockID()); err != nil {
+		t.Fatalf("failed to commit head: %v", err)
+	}
+	bc.Stop()
+
+	// A crash lost the last two bodies, the head rolls back below them
+	storage.DeleteBody(bc.db, *blocks[4].GetBlockID(), 5)
+	storage.DeleteBody(bc.db, *blocks[3].GetBlockID(), 4)
+	restarted, err := NewBlockChain(bc.db, *bc.Genesis().GetBlockID(), nil, bc.chainConfig, app, nopEngine{})
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer restarted.Stop()
+	if head := restarted.CurrentBlock(); !head.GetBlockID().IsEqual(blocks[2].GetBlockID()) {
+		t.Fatalf("head: have #%d, want #3", head.GetHeight())
+	}
+	if app.head.GetHeight() != 3 || restarted.GetHeaderByHeight(4) != nil {
+		t.Fatalf("chain not rolled back: app head #%d", app.head.GetHeight())
+	}
+}
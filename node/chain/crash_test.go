@@ -0,0 +1,165 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/chain/storage"
+)
+
+var errCrashed = errors.New("crashed")
+
+// faultDB is a database which crashes on its n-th write: that write and all
+// the later ones fail without reaching the wrapped database. A batch write
+// counts as a single write.
+type faultDB struct {
+	lcdb.Database
+	left    int
+	crashed bool
+}
+
+func (db *faultDB) write() error {
+	if db.left == 0 {
+		db.crashed = true
+	}
+	if db.crashed {
+		return errCrashed
+	}
+	db.left--
+	return nil
+}
+
+func (db *faultDB) Put(key []byte, value []byte) error {
+	if err := db.write(); err != nil {
+		return err
+	}
+	return db.Database.Put(key, value)
+}
+
+func (db *faultDB) Delete(key []byte) error {
+	if err := db.write(); err != nil {
+		return err
+	}
+	return db.Database.Delete(key)
+}
+
+func (db *faultDB) NewBatch() lcdb.Batch {
+	return &faultBatch{Batch: db.Database.NewBatch(), db: db}
+}
+
+type faultBatch struct {
+	lcdb.Batch
+	db *faultDB
+}
+
+func (b *faultBatch) Write() error {
+	if err := b.db.write(); err != nil {
+		return err
+	}
+	return b.Batch.Write()
+}
+
+// makeBlocks makes a chain of n blocks above parent, each with a transaction,
+// nonce telling the forks apart.
+func makeBlocks(parent *meta.Block, n int, nonce uint32) []*meta.Block {
+	blocks := make([]*meta.Block, n)
+	for i, header := range makeHeaders(&parent.Header, n, nonce) {
+		tx := meta.Transaction{Data: []byte(fmt.Sprintf("tx %d/%d", nonce, i+1))}
+		blocks[i] = meta.NewBlock(*header, []meta.Transaction{tx})
+	}
+	return blocks
+}
+
+// checkCrashedChain reopens the chain of bc after a crash, checking that its
+// database is consistent and that its head is one of heads.
+func checkCrashedChain(t *testing.T, bc *ChainImpl, db lcdb.Database, heads ...*meta.Block) {
+	report, err := storage.VerifyChain(db)
+	if err != nil || !report.Consistent() {
+		t.Fatalf("inconsistent database: %v %v", err, report.Issues)
+	}
+	bc, err = NewBlockChain(db, *bc.Genesis().GetBlockID(), nil, bc.chainConfig, &nopBCSI{}, nopEngine{})
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer bc.Stop()
+	for _, head := range heads {
+		if bc.CurrentBlock().GetBlockID().IsEqual(head.GetBlockID()) {
+			return
+		}
+	}
+	t.Fatalf("head #%d isn't an expected one", bc.CurrentBlock().GetHeight())
+}
+
+func TestReorgCrash(t *testing.T) {
+	for crash := 0; ; crash++ {
+		bc, _ := newTestChain(t)
+		old := makeBlocks(bc.Genesis(), 3, 0)
+		fork := makeBlocks(bc.Genesis(), 4, 1)
+		for _, block := range old {
+			if err := bc.ProcessBlock(block); err != nil {
+				t.Fatalf("failed to insert block: %v", err)
+			}
+		}
+		db := bc.db
+		bc.Stop()
+
+		// Switch to the longer fork on a database crashing along the way
+		faulty := &faultDB{Database: db, left: crash}
+		bc, err := NewBlockChain(faulty, *bc.Genesis().GetBlockID(), nil, bc.chainConfig, &nopBCSI{}, nopEngine{})
+		if err != nil {
+			t.Fatalf("crash %d: failed to reopen chain: %v", crash, err)
+		}
+		for _, block := range fork {
+			if err := bc.ProcessBlock(block); err != nil {
+				break
+			}
+		}
+		bc.Stop()
+
+		checkCrashedChain(t, bc, db, old[2], fork[3])
+		if !faulty.crashed {
+			// Every write went through, the fork is the chain
+			if head := storage.GetHeadBlockHash(db); head != *fork[3].GetBlockID() {
+				t.Fatalf("fork not the chain after %d writes", crash)
+			}
+			return
+		}
+	}
+}
+
+func TestSetHeadCrash(t *testing.T) {
+	bc, _ := newTestChain(t)
+	blocks := makeBlocks(bc.Genesis(), 5, 0)
+	for _, block := range blocks {
+		if err := bc.ProcessBlock(block); err != nil {
+			t.Fatalf("failed to insert block: %v", err)
+		}
+	}
+	db := bc.db
+	bc.Stop()
+
+	faulty := &faultDB{Database: db, left: 0}
+	bc, err := NewBlockChain(faulty, *bc.Genesis().GetBlockID(), nil, bc.chainConfig, &nopBCSI{}, nopEngine{})
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	if err := bc.SetHead(2); err != errCrashed {
+		t.Fatalf("rewind: have %v, want %v", err, errCrashed)
+	}
+	bc.Stop()
+	checkCrashedChain(t, bc, db, blocks[4])
+
+	// Without crash the rewind drops the blocks above the new head
+	bc, err = NewBlockChain(db, *bc.Genesis().GetBlockID(), nil, bc.chainConfig, &nopBCSI{}, nopEngine{})
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	if err := bc.SetHead(2); err != nil {
+		t.Fatalf("rewind failed: %v", err)
+	}
+	bc.Stop()
+	checkCrashedChain(t, bc, db, blocks[1])
+}
@@ -105,7 +105,7 @@ func SetupGenesisBlock(db lcdb.Database, genesis *Genesis) (*config.ChainConfig,
 	// Check whether the genesis block is already written.
 	if genesis != nil {
 		hash := math.BytesToHash(genesis.ToBlock(nil).GetBlockID().CloneBytes())
-		if hash == stored {
+		if hash != stored {
 			return genesis.Config, hash, &GenesisMismatchError{stored, hash}
 		}
 	}
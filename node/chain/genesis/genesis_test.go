@@ -0,0 +1,33 @@
+package genesis
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/node/config"
+)
+
+func TestSetupGenesisBlock(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+	custom := &Genesis{Config: &config.ChainConfig{ChainId: big.NewInt(7), Period: 5}, Time: 1500000000}
+
+	_, hash, err := SetupGenesisBlock(db, custom)
+	if err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	// Setting the same genesis up again keeps it
+	if _, again, err := SetupGenesisBlock(db, custom); err != nil || again != hash {
+		t.Fatalf("same genesis: have %x (%v), want %x", again[:4], err, hash[:4])
+	}
+	if cfg, stored, err := SetupGenesisBlock(db, nil); err != nil || stored != hash || cfg.ChainId.Int64() != 7 {
+		t.Fatalf("stored genesis: have %x %v (%v), want %x", stored[:4], cfg, err, hash[:4])
+	}
+	// Another genesis is refused
+	other := &Genesis{Config: custom.Config, Time: 1600000000}
+	if _, _, err := SetupGenesisBlock(db, other); err == nil {
+		t.Fatalf("incompatible genesis accepted")
+	} else if _, ok := err.(*GenesisMismatchError); !ok {
+		t.Fatalf("incompatible genesis: have %v, want a mismatch error", err)
+	}
+}
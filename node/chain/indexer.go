@@ -0,0 +1,121 @@
+package chain
+
+import (
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/chain/storage"
+)
+
+const indexBatchBlocks = 1024 // Blocks indexed per database batch when catching up
+
+// blockSigner returns the signer of a block, nil if the engine can't tell it.
+func (bc *ChainImpl) blockSigner(header *meta.BlockHeader) []byte {
+	signer, err := bc.engine.Author(header)
+	if err != nil {
+		log.Debug("Block signer unknown", "number", header.Height, "hash", header.GetBlockID(), "err", err)
+		return nil
+	}
+	return signer
+}
+
+// writeIndexes adds to batch the index entries of a block joining the
+// canonical chain, if the indexes are maintained.
+func (bc *ChainImpl) writeIndexes(batch lcdb.Putter, header *meta.BlockHeader) {
+	if bc.cacheConfig.BlockIndexes {
+		storage.WriteBlockIndexes(batch, header, bc.blockSigner(header))
+		storage.WriteIndexedHeight(batch, uint64(header.Height))
+	}
+}
+
+// deleteIndexes adds to batch the deletion of the index entries of a block
+// leaving the canonical chain, if the indexes are maintained.
+func (bc *ChainImpl) deleteIndexes(batch lcdb.Batch, header *meta.BlockHeader) {
+	if bc.cacheConfig.BlockIndexes {
+		storage.DeleteBlockIndexes(batch, header, bc.blockSigner(header))
+	}
+}
+
+// loadIndexes brings the block indexes up to the canonical chain when they are
+// maintained, indexing the blocks inserted while they weren't, and marks them
+// as not maintained otherwise.
+func (bc *ChainImpl) loadIndexes() error {
+	if !bc.cacheConfig.BlockIndexes {
+		storage.DeleteIndexedHeight(bc.db)
+		return nil
+	}
+	number, ok := storage.GetIndexedHeight(bc.db)
+	if ok {
+		number++
+	} else {
+		// Entries left from an earlier run may be stale, start over
+		if err := storage.DeleteAllBlockIndexes(bc.db); err != nil {
+			return err
+		}
+	}
+	head := uint64(bc.CurrentFastBlock().GetHeight())
+	if number > head {
+		return nil
+	}
+	log.Info("Indexing blocks", "from", number, "to", head)
+	start := time.Now()
+	for number <= head {
+		batch := bc.db.NewBatch()
+		for end := number + indexBatchBlocks; number <= head && number < end; number++ {
+			header := bc.GetHeaderByHeight(number)
+			if header == nil {
+				// Leave the indexes complete up to the last block found
+				log.Warn("Canonical header missing, indexing stopped", "number", number)
+				head = number - 1
+				break
+			}
+			bc.writeIndexes(batch, header)
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+	}
+	log.Info("Indexed blocks", "number", head, "elapsed", time.Since(start))
+	return nil
+}
+
+// GetBlocksBySigner returns in increasing height order the headers of at most
+// limit canonical blocks signed by signer, from the height from on.
+func (bc *ChainImpl) GetBlocksBySigner(signer []byte, from uint64, limit int) ([]*meta.BlockHeader, error) {
+	if !bc.cacheConfig.BlockIndexes {
+		return nil, ErrNoBlockIndexes
+	}
+	heights, err := storage.GetSignerHeights(bc.db, signer, from, limit)
+	if err != nil {
+		return nil, err
+	}
+	headers := make([]*meta.BlockHeader, 0, len(heights))
+	for _, number := range heights {
+		if header := bc.GetHeaderByHeight(number); header != nil {
+			headers = append(headers, header)
+		}
+	}
+	return headers, nil
+}
+
+// GetBlockByTime returns the header of the last canonical block created at or
+// before t.
+func (bc *ChainImpl) GetBlockByTime(t time.Time) (*meta.BlockHeader, error) {
+	if !bc.cacheConfig.BlockIndexes {
+		return nil, ErrNoBlockIndexes
+	}
+	if _, ok := storage.GetIndexedHeight(bc.db); !ok {
+		return nil, ErrNoBlockIndexes
+	}
+	number, ok := storage.FindHeightByTime(bc.db, t.Unix())
+	if !ok {
+		return nil, ErrBlockNotFound
+	}
+	header := bc.GetHeaderByHeight(number)
+	if header == nil {
+		return nil, ErrBlockNotFound
+	}
+	return header, nil
+}
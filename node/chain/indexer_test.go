@@ -0,0 +1,89 @@
+package chain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/core/meta"
+)
+
+// nonceEngine accepts every block seal, telling the signer of a block by its nonce.
+type nonceEngine struct {
+	nopEngine
+}
+
+func (nonceEngine) Author(header *meta.BlockHeader) ([]byte, error) {
+	return []byte{byte(header.Nonce)}, nil
+}
+
+// checkSignerBlocks checks the canonical blocks indexed for the signer of nonce.
+func checkSignerBlocks(t *testing.T, bc *ChainImpl, nonce uint32, want ...*meta.Block) {
+	headers, err := bc.GetBlocksBySigner([]byte{byte(nonce)}, 1, 10)
+	if err != nil {
+		t.Fatalf("signer %d: failed to get blocks: %v", nonce, err)
+	}
+	if len(headers) != len(want) {
+		t.Fatalf("signer %d: have %d blocks, want %d", nonce, len(headers), len(want))
+	}
+	for i, header := range headers {
+		if !header.GetBlockID().IsEqual(want[i].GetBlockID()) {
+			t.Fatalf("signer %d block %d: have #%d, want #%d", nonce, i, header.Height, want[i].GetHeight())
+		}
+	}
+}
+
+func TestBlockIndexes(t *testing.T) {
+	bc, app := newTestChain(t)
+	if _, err := bc.GetBlocksBySigner([]byte{0}, 0, 10); err != ErrNoBlockIndexes {
+		t.Fatalf("query without indexes: have %v, want %v", err, ErrNoBlockIndexes)
+	}
+	old := makeBlocks(bc.Genesis(), 3, 0)
+	fork := makeBlocks(bc.Genesis(), 4, 1)
+	for _, block := range old {
+		if err := bc.ProcessBlock(block); err != nil {
+			t.Fatalf("failed to insert block: %v", err)
+		}
+	}
+	bc.Stop()
+
+	// The blocks inserted without indexes are indexed on start
+	cacheCfg := &CacheConfig{BlockIndexes: true}
+	bc, err := NewBlockChain(bc.db, *bc.Genesis().GetBlockID(), cacheCfg, bc.chainConfig, app, nonceEngine{})
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer bc.Stop()
+	checkSignerBlocks(t, bc, 0, old...)
+
+	// A reorg moves the indexes to the new chain
+	for _, block := range fork {
+		if err := bc.ProcessBlock(block); err != nil {
+			t.Fatalf("failed to insert block: %v", err)
+		}
+	}
+	checkSignerBlocks(t, bc, 0)
+	checkSignerBlocks(t, bc, 1, fork...)
+
+	genesisTime := bc.Genesis().Header.Time
+	if _, err := bc.GetBlockByTime(genesisTime.Add(-time.Second)); err != ErrBlockNotFound {
+		t.Fatalf("block before genesis: have %v, want %v", err, ErrBlockNotFound)
+	}
+	for i, block := range fork {
+		header, err := bc.GetBlockByTime(block.Header.Time)
+		if err != nil || !header.GetBlockID().IsEqual(block.GetBlockID()) {
+			t.Fatalf("block %d by time: have %v (%v), want #%d", i, header, err, block.GetHeight())
+		}
+	}
+	if header, _ := bc.GetBlockByTime(fork[3].Header.Time.Add(time.Hour)); header == nil || header.Height != 4 {
+		t.Fatalf("block after head by time: have %v, want #4", header)
+	}
+
+	// A rewind drops the indexes above the new head
+	if err := bc.SetHead(2); err != nil {
+		t.Fatalf("rewind failed: %v", err)
+	}
+	checkSignerBlocks(t, bc, 1, fork[:2]...)
+	if header, _ := bc.GetBlockByTime(fork[3].Header.Time); header == nil || header.Height != 2 {
+		t.Fatalf("block by time after rewind: have %v, want #2", header)
+	}
+}
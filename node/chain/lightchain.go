@@ -0,0 +1,250 @@
+package chain
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/common/util/event"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/chain/storage"
+	"github.com/mihongtech/linkchain-core/node/config"
+	"github.com/mihongtech/linkchain-core/node/consensus"
+
+	"github.com/hashicorp/golang-lru"
+)
+
+const (
+	headerCacheLimit = 512
+)
+
+// LightChain represents a header-only canonical chain. It verifies and stores
+// block headers, but never block bodies, so blocks returned by it carry an
+// empty transaction list. It is used by nodes running in light mode, which
+// retrieve transactions from full peers together with an inclusion proof
+// against the header's TxRoot.
+type LightChain struct {
+	chainConfig *config.ChainConfig // chain & network configuration
+
+	db            lcdb.Database // Low level persistent database to store headers in
+	chainHeadFeed event.Feed
+	scope         event.SubscriptionScope
+	genesisHeader *meta.BlockHeader
+
+	chainmu sync.RWMutex // header insertion lock
+
+	currentHeader atomic.Value // Current head of the header chain
+
+	headerCache *lru.Cache // Cache for the most recent block headers
+	numberCache *lru.Cache // Cache for the most recent block numbers
+
+	engine consensus.Engine // Engine used to verify header seals
+}
+
+// NewLightChain returns a fully initialised header chain using information
+// available in the database.
+func NewLightChain(db lcdb.Database, genesisHash math.Hash, chainConfig *config.ChainConfig, engine consensus.Engine) (*LightChain, error) {
+	if err := storage.Migrate(db); err != nil {
+		return nil, err
+	}
+	headerCache, _ := lru.New(headerCacheLimit)
+	numberCache, _ := lru.New(numberCacheLimit)
+	lc := &LightChain{
+		chainConfig: chainConfig,
+		db:          db,
+		headerCache: headerCache,
+		numberCache: numberCache,
+		engine:      engine,
+	}
+
+	lc.genesisHeader = lc.GetHeader(genesisHash, 0)
+	if lc.genesisHeader == nil {
+		return nil, ErrNoGenesis
+	}
+
+	head := storage.GetHeadHeaderHash(db)
+	current := lc.GetHeader(head, lc.GetBlockNumber(head))
+	if current == nil {
+		log.Warn("Head header missing, starting from genesis", "hash", head)
+		current = lc.genesisHeader
+	}
+	lc.currentHeader.Store(current)
+
+	log.Info("Loaded most recent local header", "number", current.Height, "hash", current.GetBlockID())
+	return lc, nil
+}
+
+// CurrentHeader retrieves the current head header of the canonical chain.
+func (lc *LightChain) CurrentHeader() *meta.BlockHeader {
+	return lc.currentHeader.Load().(*meta.BlockHeader)
+}
+
+// Genesis retrieves the chain's genesis header.
+func (lc *LightChain) Genesis() *meta.BlockHeader {
+	return lc.genesisHeader
+}
+
+// InsertHeader verifies a header and writes it into the chain. If the header
+// extends a chain higher than the current head, the canonical number mapping
+// is rewritten down to the common ancestor and the new head is announced.
+func (lc *LightChain) InsertHeader(header *meta.BlockHeader) error {
+	lc.chainmu.Lock()
+	defer lc.chainmu.Unlock()
+
+	hash := *header.GetBlockID()
+	if lc.HasBlock(hash) {
+		return nil
+	}
+	if err := lc.verifyHeader(header); err != nil {
+		return err
+	}
+
+	batch := lc.db.NewBatch()
+	if err := storage.WriteHeader(batch, header); err != nil {
+		return err
+	}
+	head := lc.CurrentHeader()
+	reorg := header.Height > head.Height
+	if reorg {
+		storage.WriteCanonicalHash(batch, hash, uint64(header.Height))
+
+		// Overwrite any stale canonical numbers left by a side chain
+		prev, number := header.Prev, uint64(header.Height)-1
+		for storage.GetCanonicalHash(lc.db, number) != prev {
+			storage.WriteCanonicalHash(batch, prev, number)
+			parent := lc.GetHeader(prev, number)
+			if parent == nil || number == 0 {
+				break
+			}
+			prev, number = parent.Prev, number-1
+		}
+		storage.WriteHeadHeaderHash(batch, hash)
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	lc.headerCache.Add(hash, header)
+
+	if reorg {
+		lc.currentHeader.Store(header)
+		lc.chainHeadFeed.Send(meta.ChainHeadEvent{Block: &meta.Block{Header: *header}})
+	}
+	return nil
+}
+
+// verifyHeader checks that a header links to a known parent and carries a
+// valid consensus seal.
+func (lc *LightChain) verifyHeader(header *meta.BlockHeader) error {
+	if header.Height == 0 {
+		return errors.New("genesis header mismatch")
+	}
+	parent := lc.GetHeader(header.Prev, uint64(header.Height)-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	if parent.Height+1 != header.Height {
+		return consensus.ErrInvalidNumber
+	}
+	if header.Time.After(time.Now().Add(maxTimeFutureBlocks * time.Second)) {
+		return consensus.ErrFutureBlock
+	}
+	return lc.engine.ProcessBlock(&meta.Block{Header: *header})
+}
+
+// GetBlockNumber returns the block number assigned to a header hash.
+func (lc *LightChain) GetBlockNumber(hash math.Hash) uint64 {
+	if cached, ok := lc.numberCache.Get(hash); ok {
+		return cached.(uint64)
+	}
+	number := storage.GetBlockNumber(lc.db, hash)
+	if number != storage.MissingNumber {
+		lc.numberCache.Add(hash, number)
+	}
+	return number
+}
+
+// GetHeader retrieves a block header from the database by hash and number,
+// caching it if found.
+func (lc *LightChain) GetHeader(hash math.Hash, height uint64) *meta.BlockHeader {
+	if header, ok := lc.headerCache.Get(hash); ok {
+		return header.(*meta.BlockHeader)
+	}
+	header := storage.GetHeader(lc.db, hash, height)
+	if header == nil {
+		return nil
+	}
+	lc.headerCache.Add(hash, header)
+	return header
+}
+
+// GetHeaderByHeight retrieves a canonical block header by number.
+func (lc *LightChain) GetHeaderByHeight(height uint64) *meta.BlockHeader {
+	hash := storage.GetCanonicalHash(lc.db, height)
+	if hash == (math.Hash{}) {
+		return nil
+	}
+	return lc.GetHeader(hash, height)
+}
+
+func (lc *LightChain) HasBlock(hash meta.BlockID) bool {
+	if lc.headerCache.Contains(hash) {
+		return true
+	}
+	return storage.HasHeader(lc.db, hash, lc.GetBlockNumber(hash))
+}
+
+// GetBestBlock returns the current head as a block without body.
+func (lc *LightChain) GetBestBlock() *meta.Block {
+	return &meta.Block{Header: *lc.CurrentHeader()}
+}
+
+// GetBlockByID returns the header with the given hash as a block without body.
+func (lc *LightChain) GetBlockByID(hash meta.BlockID) (*meta.Block, error) {
+	header := lc.GetHeader(hash, lc.GetBlockNumber(hash))
+	if header == nil {
+		return nil, errors.New("GetBlockByID:header not found")
+	}
+	return &meta.Block{Header: *header}, nil
+}
+
+// GetBlockByHeight returns the canonical header at height as a block without body.
+func (lc *LightChain) GetBlockByHeight(height uint32) (*meta.Block, error) {
+	header := lc.GetHeaderByHeight(uint64(height))
+	if header == nil {
+		return nil, errors.New("header not found")
+	}
+	return &meta.Block{Header: *header}, nil
+}
+
+func (lc *LightChain) GetChainConfig() *config.ChainConfig { return lc.chainConfig }
+
+func (lc *LightChain) GetChainID() *big.Int {
+	return lc.chainConfig.ChainId
+}
+
+// ProcessBlock imports the header of a block, the body is ignored.
+func (lc *LightChain) ProcessBlock(block *meta.Block) error {
+	return lc.InsertHeader(&block.Header)
+}
+
+// CheckBlock verifies the header of a block, the body is ignored.
+func (lc *LightChain) CheckBlock(block *meta.Block) error {
+	lc.chainmu.RLock()
+	defer lc.chainmu.RUnlock()
+	return lc.verifyHeader(&block.Header)
+}
+
+// Stop unsubscribes all subscriptions registered from the chain.
+func (lc *LightChain) Stop() {
+	lc.scope.Close()
+}
+
+// SubscribeChainHeadEvent registers a subscription of ChainHeadEvent.
+func (lc *LightChain) SubscribeChainHeadEvent(ch chan<- meta.ChainHeadEvent) event.Subscription {
+	return lc.scope.Track(lc.chainHeadFeed.Subscribe(ch))
+}
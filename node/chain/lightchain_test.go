@@ -0,0 +1,93 @@
+package chain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/chain/genesis"
+)
+
+// nopEngine accepts every block seal.
+type nopEngine struct{}
+
+func (nopEngine) Setup(i interface{}) bool                        { return true }
+func (nopEngine) Start() bool                                     { return true }
+func (nopEngine) Stop()                                           {}
+func (nopEngine) Author(header *meta.BlockHeader) ([]byte, error) { return nil, nil }
+func (nopEngine) CheckBlock(block *meta.Block) error              { return nil }
+func (nopEngine) ProcessBlock(block *meta.Block) error            { return nil }
+
+func newTestLightChain(t *testing.T) *LightChain {
+	db, _ := lcdb.NewMemDatabase()
+	cfg, hash, err := genesis.SetupGenesisBlock(db, nil)
+	if err != nil {
+		t.Fatalf("failed to setup genesis: %v", err)
+	}
+	lc, err := NewLightChain(db, hash, cfg, nopEngine{})
+	if err != nil {
+		t.Fatalf("failed to create light chain: %v", err)
+	}
+	return lc
+}
+
+// makeHeaders creates a chain of n headers on top of parent, nonce tells forks apart.
+func makeHeaders(parent *meta.BlockHeader, n int, nonce uint32) []*meta.BlockHeader {
+	headers := make([]*meta.BlockHeader, 0, n)
+	for i := 0; i < n; i++ {
+		header := &meta.BlockHeader{
+			Height: parent.Height + 1,
+			Time:   time.Unix(parent.Time.Unix()+1, 0),
+			Nonce:  nonce,
+			Prev:   *parent.GetBlockID(),
+		}
+		headers = append(headers, header)
+		parent = header
+	}
+	return headers
+}
+
+func TestLightChainInsert(t *testing.T) {
+	lc := newTestLightChain(t)
+	headers := makeHeaders(lc.Genesis(), 5, 0)
+
+	if err := lc.InsertHeader(headers[1]); err == nil {
+		t.Fatalf("header with unknown parent accepted")
+	}
+	for i, header := range headers {
+		if err := lc.InsertHeader(header); err != nil {
+			t.Fatalf("header %d: insert failed: %v", i, err)
+		}
+	}
+	if head := lc.CurrentHeader(); !head.GetBlockID().IsEqual(headers[4].GetBlockID()) {
+		t.Fatalf("head mismatch: have %v, want %v", head.GetBlockID(), headers[4].GetBlockID())
+	}
+	if block, _ := lc.GetBlockByHeight(3); block == nil || !block.GetBlockID().IsEqual(headers[2].GetBlockID()) {
+		t.Fatalf("canonical header mismatch at height 3")
+	}
+}
+
+func TestLightChainReorg(t *testing.T) {
+	lc := newTestLightChain(t)
+	main := makeHeaders(lc.Genesis(), 3, 0)
+	for _, header := range main {
+		lc.InsertHeader(header)
+	}
+	// A longer side chain forking at height 1 takes over the canonical numbers
+	side := makeHeaders(main[0], 4, 1)
+	for i, header := range side {
+		if err := lc.InsertHeader(header); err != nil {
+			t.Fatalf("side header %d: insert failed: %v", i, err)
+		}
+	}
+	if head := lc.CurrentHeader(); !head.GetBlockID().IsEqual(side[3].GetBlockID()) {
+		t.Fatalf("head mismatch after reorg")
+	}
+	for i, header := range append(main[:1], side...) {
+		have := lc.GetHeaderByHeight(uint64(i + 1))
+		if have == nil || !have.GetBlockID().IsEqual(header.GetBlockID()) {
+			t.Fatalf("canonical header mismatch at height %d", i+1)
+		}
+	}
+}
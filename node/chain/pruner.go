@@ -0,0 +1,111 @@
+package chain
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/node/chain/storage"
+)
+
+const (
+	minBlockRetention = 64               // Fewest recent blocks kept whole, leaving room for reorgs
+	pruneBatchBlocks  = 128              // Blocks pruned per database batch
+	pruneInterval     = 10 * time.Second // Delay between two pruning rounds
+)
+
+// PrunedHeight returns the lowest canonical height whose block body and
+// transaction lookups are kept, 0 if no block was pruned. Headers are kept
+// for the whole chain.
+func (bc *ChainImpl) PrunedHeight() uint64 {
+	return atomic.LoadUint64(&bc.prunedHeight)
+}
+
+// pruneLoop prunes the blocks falling out of the retention window in the
+// background until the chain is stopped.
+func (bc *ChainImpl) pruneLoop(retention uint64) {
+	defer bc.wg.Done()
+
+	if retention < minBlockRetention {
+		log.Warn("Block retention too low, raising it", "retention", retention, "minimum", minBlockRetention)
+		retention = minBlockRetention
+	}
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		if head := uint64(bc.CurrentBlock().GetHeight()); head > retention {
+			if _, err := bc.PruneBlocks(head - retention + 1); err != nil {
+				log.Error("Failed to prune blocks", "err", err)
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// PruneBlocks deletes the bodies and transaction lookups of the canonical
+// blocks below height, and the bodies of the side chain blocks there, keeping
+// their headers and the genesis block. Blocks are deleted a batch at a time,
+// each batch recording the new pruned height, so pruning stops cleanly with
+// the chain. It returns the number of blocks pruned.
+func (bc *ChainImpl) PruneBlocks(height uint64) (int, error) {
+	bc.pruneLock.Lock()
+	defer bc.pruneLock.Unlock()
+
+	number := bc.PrunedHeight()
+	if number == 0 {
+		number = 1
+	}
+	if current := uint64(bc.CurrentBlock().GetHeight()); height > current {
+		height = current
+	}
+	pruned := 0
+	for number < height {
+		batch := bc.db.NewBatch()
+		end := number + pruneBatchBlocks
+		if end > height {
+			end = height
+		}
+		from := number
+		for ; number < end; number++ {
+			hash := storage.GetCanonicalHash(bc.db, number)
+			body := storage.GetBody(bc.db, hash, number)
+			if body == nil {
+				continue
+			}
+			for _, tx := range body.Txs {
+				// Only drop the lookups pointing at this very block
+				if included, _, _ := storage.GetTxLookupEntry(bc.db, *tx.GetTxID()); included == hash {
+					storage.DeleteTxLookupEntry(batch, *tx.GetTxID())
+				}
+			}
+		}
+		// The side chain blocks have no lookups, their bodies go by height
+		hashes, err := storage.DeleteBodies(bc.db, batch, from, end)
+		if err != nil {
+			return pruned, err
+		}
+		storage.WritePrunedHeight(batch, number)
+		if err := batch.Write(); err != nil {
+			return pruned, err
+		}
+		atomic.StoreUint64(&bc.prunedHeight, number)
+		for _, hash := range hashes {
+			bc.blockCache.Remove(hash)
+		}
+		pruned += len(hashes)
+
+		select {
+		case <-bc.quit:
+			return pruned, nil
+		default:
+		}
+	}
+	if pruned > 0 {
+		log.Info("Pruned block bodies", "blocks", pruned, "height", number)
+	}
+	return pruned, nil
+}
@@ -0,0 +1,86 @@
+package chain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/chain/storage"
+)
+
+func TestPruneBlocks(t *testing.T) {
+	bc, _ := newTestChain(t)
+	defer bc.Stop()
+
+	headers := makeHeaders(&bc.Genesis().Header, 10, 0)
+	blocks := make([]*meta.Block, len(headers))
+	for i, header := range headers {
+		tx := meta.Transaction{Data: []byte(fmt.Sprintf("tx %d", i+1))}
+		blocks[i] = meta.NewBlock(*header, []meta.Transaction{tx})
+	}
+	if err := bc.InsertFastBlocks(blocks); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	if err := bc.CommitFastHead(*blocks[9].GetBlockID()); err != nil {
+		t.Fatalf("failed to commit head: %v", err)
+	}
+
+	// Side chain blocks below the pruned height lose their bodies as well
+	var sides []*meta.Block
+	for _, i := range []int{2, 6} {
+		canonical := &blocks[i].Header
+		header := meta.BlockHeader{Height: canonical.Height, Time: canonical.Time, Prev: canonical.Prev, Data: []byte("side")}
+		side := meta.NewBlock(header, []meta.Transaction{{Data: []byte("side")}})
+		if err := storage.WriteBlock(bc.db, side); err != nil {
+			t.Fatalf("failed to write side block: %v", err)
+		}
+		sides = append(sides, side)
+	}
+	pruned, err := bc.PruneBlocks(6)
+	if err != nil || pruned != 6 {
+		t.Fatalf("pruned blocks: have %d, %v, want 6, nil", pruned, err)
+	}
+	for _, side := range sides {
+		hash, number := *side.GetBlockID(), uint64(side.GetHeight())
+		if kept := number >= 6; kept != storage.HasBody(bc.db, hash, number) || bc.GetHeader(hash, number) == nil {
+			t.Fatalf("side block %d: have body %v, want %v", number, !kept, kept)
+		}
+	}
+	if bc.PrunedHeight() != 6 {
+		t.Fatalf("pruned height: have %d, want 6", bc.PrunedHeight())
+	}
+	for i, block := range blocks {
+		hash, number := *block.GetBlockID(), uint64(block.GetHeight())
+		if bc.GetHeader(hash, number) == nil {
+			t.Fatalf("header %d pruned", number)
+		}
+		lookup, _, _ := bc.GetTxLookupEntry(*block.TXs.Txs[0].GetTxID())
+		if kept := number >= 6; kept != (bc.GetBlock(hash, number) != nil) || kept != (lookup == hash) {
+			t.Fatalf("block %d: have body %v lookup %v, want kept %v", i+1, bc.GetBlock(hash, number) != nil, lookup == hash, kept)
+		}
+	}
+	if bc.GetBlock(*bc.Genesis().GetBlockID(), 0) == nil {
+		t.Fatalf("genesis pruned")
+	}
+
+	// Pruning is incremental and never reaches the head
+	if pruned, err := bc.PruneBlocks(6); err != nil || pruned != 0 {
+		t.Fatalf("pruning again: have %d, %v, want 0, nil", pruned, err)
+	}
+	if pruned, err := bc.PruneBlocks(100); err != nil || pruned != 5 {
+		t.Fatalf("pruning past the head: have %d, %v, want 5, nil", pruned, err)
+	}
+	if bc.CurrentBlock() == nil || bc.GetBlock(*blocks[9].GetBlockID(), 10) == nil {
+		t.Fatalf("head pruned")
+	}
+
+	// The pruned height survives a restart
+	restarted, err := NewBlockChain(bc.db, *bc.Genesis().GetBlockID(), nil, bc.chainConfig, &nopBCSI{}, nopEngine{})
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer restarted.Stop()
+	if restarted.PrunedHeight() != 10 {
+		t.Fatalf("reopened pruned height: have %d, want 10", restarted.PrunedHeight())
+	}
+}
@@ -25,15 +25,25 @@ type DatabaseDeleter interface {
 }
 
 var (
-	headBlockKey = []byte("LastBlock")
-	headFastKey  = []byte("LastFast")
+	headBlockKey  = []byte("LastBlock")
+	headFastKey   = []byte("LastFast")
+	headHeaderKey = []byte("LastHeader")
+
+	databaseVersionKey = []byte("DatabaseVersion") // version of the key layout, see Migrate
+	prunedHeightKey    = []byte("PrunedHeight")    // lowest canonical height whose block body is kept
+	indexedHeightKey   = []byte("IndexedHeight")   // canonical height up to which the block indexes are maintained
 
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`).
-	blockPrefix = []byte("h") // blockPrefix + num (uint64 big endian) + hash -> block
+	blockPrefix  = []byte("h") // blockPrefix + num (uint64 big endian) + hash -> block (version 3, see migrateBlockBodies)
+	headerPrefix = []byte("e") // headerPrefix + num (uint64 big endian) + hash -> header
+	bodyPrefix   = []byte("b") // bodyPrefix + num (uint64 big endian) + hash -> block body (transactions)
 
 	numSuffix       = []byte("n") // blockPrefix + num (uint64 big endian) + numSuffix -> hash
 	blockHashPrefix = []byte("H") // blockHashPrefix + hash -> num (uint64 big endian)
-	lookupPrefix    = []byte("l") // lookupPrefix + hash -> transaction/receipt lookup metadata
+	lookupPrefix    = []byte("l") // lookupPrefix + hash -> num (uint64 big endian) + index (uint32 big endian), see encodeTxLookupEntry
+
+	signerIndexPrefix = []byte("S") // signerIndexPrefix + len(signer) + signer + num (uint64 big endian) -> nil
+	timeIndexPrefix   = []byte("T") // timeIndexPrefix + block time (unix, uint64 big endian) + num (uint64 big endian) -> nil
 
 	configPrefix = []byte("linkchain-config-") // config prefix for the db
 
@@ -41,7 +51,8 @@ var (
 )
 
 // TxLookupEntry is a positional metadata to help looking up the data content of
-// a transaction or receipt given only its hash.
+// a transaction or receipt given only its hash, as stored in JSON up to version
+// 4. Newer entries are encoded by encodeTxLookupEntry.
 type TxLookupEntry struct {
 	BlockHash  string `json:"blockHash"`
 	BlockIndex uint64 `json:"blockIndex"`
@@ -91,6 +102,16 @@ func GetHeadBlockHash(db DatabaseReader) math.Hash {
 	return math.BytesToHash(data)
 }
 
+// GetHeadHeaderHash retrieves the hash of the current canonical head header
+// of a header-only (light) chain.
+func GetHeadHeaderHash(db DatabaseReader) math.Hash {
+	data, _ := db.Get(headHeaderKey)
+	if len(data) == 0 {
+		return math.Hash{}
+	}
+	return math.BytesToHash(data)
+}
+
 // GetHeadFastBlockHash retrieves the hash of the current canonical head block during
 // fast synchronization. The difference between this and GetHeadBlockHash is that
 // whereas the last block hash is only updated upon a full block import, the last
@@ -103,15 +124,31 @@ func GetHeadFastBlockHash(db DatabaseReader) math.Hash {
 	return math.BytesToHash(data)
 }
 
-// GetHeaderBytes retrieves a block header in its raw database encoding, or nil
-// if the header's not found.
-func GetBlockBytes(db DatabaseReader, hash math.Hash, number uint64) []byte {
-	data, _ := db.Get(blockKey(hash, number))
+// GetPrunedHeight retrieves the lowest canonical height whose block body and
+// transaction lookups are kept, 0 if no block was ever pruned.
+func GetPrunedHeight(db DatabaseReader) uint64 {
+	data, _ := db.Get(prunedHeightKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// GetBodyBytes retrieves a block body in its raw database encoding, or nil
+// if the body's not found.
+func GetBodyBytes(db DatabaseReader, hash math.Hash, number uint64) []byte {
+	data, _ := db.Get(bodyKey(hash, number))
 	return data
 }
 
+// HasBlock checks if the body of a block, and thus the entire block, is present.
 func HasBlock(db DatabaseReader, hash math.Hash, number uint64) bool {
-	ok, _ := db.Has(blockKey(hash, number))
+	return HasBody(db, hash, number)
+}
+
+// HasBody checks if a block body corresponding to the hash is present.
+func HasBody(db DatabaseReader, hash math.Hash, number uint64) bool {
+	ok, _ := db.Has(bodyKey(hash, number))
 	return ok
 }
 
@@ -119,6 +156,50 @@ func blockKey(hash math.Hash, number uint64) []byte {
 	return append(append(blockPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+func headerKey(hash math.Hash, number uint64) []byte {
+	return append(append(headerPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+func bodyKey(hash math.Hash, number uint64) []byte {
+	return append(append(bodyPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// HasHeader checks if a block header corresponding to the hash is present.
+func HasHeader(db DatabaseReader, hash math.Hash, number uint64) bool {
+	ok, _ := db.Has(headerKey(hash, number))
+	return ok
+}
+
+// GetHeader retrieves a block header stored without its body, or nil if the
+// header's not found.
+func GetHeader(db DatabaseReader, hash math.Hash, number uint64) *meta.BlockHeader {
+	data, _ := db.Get(headerKey(hash, number))
+	if len(data) == 0 {
+		return nil
+	}
+	header := &meta.BlockHeader{}
+	if err := header.DecodeFromBytes(data); err != nil {
+		log.Error("Invalid block header", "hash", hash, "err", err)
+		return nil
+	}
+	return header
+}
+
+// GetBody retrieves the transactions of a block, or nil if the body's not found.
+func GetBody(db DatabaseReader, hash math.Hash, number uint64) *meta.Transactions {
+	// A block without transactions has an empty body, only missing bodies fail
+	data, err := db.Get(bodyKey(hash, number))
+	if err != nil {
+		return nil
+	}
+	body := &meta.Transactions{}
+	if err := body.DecodeFromBytes(data); err != nil {
+		log.Error("Invalid block body", "hash", hash, "err", err)
+		return nil
+	}
+	return body
+}
+
 // GetBlock retrieves an entire block corresponding to the hash, assembling it
 // back from the stored header and body. If either the header or body could not
 // be retrieved nil is returned.
@@ -126,13 +207,15 @@ func blockKey(hash math.Hash, number uint64) []byte {
 // Note, due to concurrent download of header and block body the header and thus
 // canonical hash can be stored in the database but the body data not (yet).
 func GetBlock(db DatabaseReader, hash math.Hash, number uint64) *meta.Block {
-	data := GetBlockBytes(db, hash, number)
-	if len(data) == 0 {
+	header := GetHeader(db, hash, number)
+	if header == nil {
+		return nil
+	}
+	body := GetBody(db, hash, number)
+	if body == nil {
 		return nil
 	}
-	block := &meta.Block{}
-	block.DecodeFromBytes(data)
-	return block
+	return &meta.Block{Header: *header, TXs: *body}
 }
 
 // GetTxLookupEntry retrieves the positional metadata associated with a transaction
@@ -144,14 +227,22 @@ func GetTxLookupEntry(db DatabaseReader, hash math.Hash) (math.Hash, uint64, uin
 		return math.Hash{}, 0, 0
 	}
 
-	// Parse and return the contents of the lookup entry
+	// The block of a binary entry is the canonical one at its number
+	if number, index, ok := decodeTxLookupEntry(data); ok {
+		return GetCanonicalHash(db, number), number, index
+	}
+	// Parse and return the contents of a legacy lookup entry
 	var entry TxLookupEntry
 	if err := json.Unmarshal(data, &entry); err != nil {
 		log.Error("Invalid lookup entry json data", "hash", hash, "err", err)
 		return math.Hash{}, 0, 0
 	}
 
-	blockHash, _ := math.NewHashFromStr(entry.BlockHash)
+	blockHash, err := math.NewHashFromStr(entry.BlockHash)
+	if err != nil {
+		log.Error("Invalid lookup entry block hash", "hash", hash, "err", err)
+		return math.Hash{}, 0, 0
+	}
 	return *blockHash, entry.BlockIndex, entry.Index
 }
 
@@ -162,12 +253,12 @@ func GetTransaction(db DatabaseReader, hash math.Hash) (*meta.Transaction, math.
 	blockHash, blockNumber, txIndex := GetTxLookupEntry(db, hash)
 	// log.Info("get tx id", "blockHash", blockHash)
 	if !blockHash.IsEmpty() {
-		block := GetBlock(db, blockHash, blockNumber)
-		if block == nil || len(block.TXs.Txs) <= int(txIndex) {
+		body := GetBody(db, blockHash, blockNumber)
+		if body == nil || len(body.Txs) <= int(txIndex) {
 			log.Error("Transaction referenced missing", "number", blockNumber, "hash", blockHash, "index", txIndex)
 			return nil, math.Hash{}, 0, 0
 		}
-		return &block.TXs.Txs[txIndex], blockHash, blockNumber, txIndex
+		return &body.Txs[txIndex], blockHash, blockNumber, txIndex
 	} else {
 		log.Error("Transaction not found", "hash", hash)
 		return nil, math.Hash{}, 0, 0
@@ -200,61 +291,152 @@ func WriteHeadFastBlockHash(db lcdb.Putter, hash math.Hash) error {
 	return nil
 }
 
-// WriteBlock serializes a block into the database, header and body separately.
-func WriteBlock(db lcdb.Putter, block *meta.Block) error {
-	bytesData, err := block.EncodeToBytes()
+// WritePrunedHeight stores the lowest canonical height whose block body is kept.
+func WritePrunedHeight(db lcdb.Putter, number uint64) error {
+	if err := db.Put(prunedHeightKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store pruned height", "err", err)
+	}
+	return nil
+}
+
+// WriteHeadHeaderHash stores the head header's hash of a header-only chain.
+func WriteHeadHeaderHash(db lcdb.Putter, hash math.Hash) error {
+	if err := db.Put(headHeaderKey, hash.Bytes()); err != nil {
+		log.Crit("Failed to store last header's hash", "err", err)
+	}
+	return nil
+}
+
+// WriteHeader serializes a block header into the database together with its
+// hash to number mapping.
+func WriteHeader(db lcdb.Putter, header *meta.BlockHeader) error {
+	bytesData, err := header.EncodeToBytes()
 	if err != nil {
 		return err
 	}
 
-	hash := block.GetBlockID().CloneBytes()
-	num := block.GetHeight()
-	encNum := encodeBlockNumber(uint64(num))
+	hash := header.GetBlockID().CloneBytes()
+	encNum := encodeBlockNumber(uint64(header.Height))
 	key := append(blockHashPrefix, hash...)
 	if err := db.Put(key, encNum); err != nil {
 		log.Crit("Failed to store hash to number mapping", "err", err)
 	}
-	key = append(append(blockPrefix, encNum...), hash...)
-
+	key = append(append(headerPrefix, encNum...), hash...)
 	if err := db.Put(key, bytesData); err != nil {
-		log.Crit("Failed to store block", "err", err)
+		log.Crit("Failed to store header", "err", err)
 	}
 	return nil
 }
 
+// WriteBody serializes the body of a block into the database.
+func WriteBody(db lcdb.Putter, hash math.Hash, number uint64, body *meta.Transactions) error {
+	bytesData, err := body.EncodeToBytes()
+	if err != nil {
+		return err
+	}
+	if err := db.Put(bodyKey(hash, number), bytesData); err != nil {
+		log.Crit("Failed to store block body", "err", err)
+	}
+	return nil
+}
+
+// WriteBlock serializes a block into the database, header and body separately.
+func WriteBlock(db lcdb.Putter, block *meta.Block) error {
+	if err := WriteBody(db, *block.GetBlockID(), uint64(block.GetHeight()), &block.TXs); err != nil {
+		return err
+	}
+	return WriteHeader(db, &block.Header)
+}
+
 // WriteTxLookupEntries stores a positional metadata for every transaction from
 // a block, enabling hash based transaction and receipt lookups.
 func WriteTxLookupEntries(db lcdb.Putter, block *meta.Block) error {
+	// The keys and entries of the whole block share one allocation, the
+	// databases copy what they are given
+	const size = 1 + math.HashSize + txLookupEntryLength
+	buf := make([]byte, size*len(block.TXs.Txs))
+	number := uint64(block.GetHeight())
 
 	// Iterate over each transaction and encode its metadata
 	for i, tx := range block.TXs.Txs {
-		entry := TxLookupEntry{
-			BlockHash:  block.GetBlockID().String(),
-			BlockIndex: uint64(block.GetHeight()),
-			Index:      uint64(i),
-		}
-		data, err := json.Marshal(entry)
-
-		if err != nil {
-			return err
-		}
-		// log.Info("write tx id", "id", tx.GetTxID(), "entry", entry, "data", data)
-		if err := db.Put(append(lookupPrefix, tx.GetTxID().CloneBytes()...), data); err != nil {
+		key, entry := buf[i*size:i*size+1+math.HashSize], buf[i*size+1+math.HashSize:(i+1)*size]
+		copy(key, lookupPrefix)
+		copy(key[1:], tx.GetTxID()[:])
+		encodeTxLookupEntry(entry, number, uint64(i))
+		if err := db.Put(key, entry); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// txLookupEntryLength is the length of a binary transaction lookup entry.
+const txLookupEntryLength = 8 + 4
+
+// encodeTxLookupEntry encodes in entry the number of the block including a
+// transaction and its index in the block. The block hash is left out, the
+// canonical hash at the number tells it.
+func encodeTxLookupEntry(entry []byte, number uint64, index uint64) {
+	binary.BigEndian.PutUint64(entry, number)
+	binary.BigEndian.PutUint32(entry[8:], uint32(index))
+}
+
+// decodeTxLookupEntry decodes a binary transaction lookup entry, false if data
+// is a legacy JSON one.
+func decodeTxLookupEntry(data []byte) (number uint64, index uint64, ok bool) {
+	if len(data) != txLookupEntryLength {
+		return 0, 0, false
+	}
+	return binary.BigEndian.Uint64(data), uint64(binary.BigEndian.Uint32(data[8:])), true
+}
+
 // DeleteCanonicalHash removes the number to hash canonical mapping.
 func DeleteCanonicalHash(db DatabaseDeleter, number uint64) {
 	db.Delete(append(append(blockPrefix, encodeBlockNumber(number)...), numSuffix...))
 }
 
-// DeleteHeader removes all block header data associated with a hash.
+// DeleteBlockData removes the header and body of a block, and any copy of it
+// left in the legacy format.
 func DeleteBlockData(db DatabaseDeleter, hash math.Hash, number uint64) {
+	DeleteHeader(db, hash, number)
+	DeleteBody(db, hash, number)
+	db.Delete(blockKey(hash, number))
+}
+
+// DeleteBody removes the body of a block.
+func DeleteBody(db DatabaseDeleter, hash math.Hash, number uint64) {
+	db.Delete(bodyKey(hash, number))
+}
+
+// DeleteBodies removes into batch the bodies of all the blocks of db, side
+// chain blocks included, from height from included to height to excluded. It
+// returns the hashes of the blocks whose body it removed.
+func DeleteBodies(db lcdb.Iteratee, batch DatabaseDeleter, from, to uint64) ([]math.Hash, error) {
+	start := append(append([]byte{}, bodyPrefix...), encodeBlockNumber(from)...)
+	limit := append(append([]byte{}, bodyPrefix...), encodeBlockNumber(to)...)
+	it := db.NewIteratorWithRange(start, limit)
+	defer it.Release()
+
+	var hashes []math.Hash
+	for it.Next() {
+		key := append([]byte{}, it.Key()...)
+		if len(key) != len(bodyPrefix)+8+math.HashSize {
+			continue
+		}
+		if err := batch.Delete(key); err != nil {
+			return hashes, err
+		}
+		hash := math.Hash{}
+		hash.SetBytes(key[len(bodyPrefix)+8:])
+		hashes = append(hashes, hash)
+	}
+	return hashes, it.Error()
+}
+
+// DeleteHeader removes a header stored without its body.
+func DeleteHeader(db DatabaseDeleter, hash math.Hash, number uint64) {
 	db.Delete(append(blockHashPrefix, hash.Bytes()...))
-	db.Delete(append(append(blockPrefix, encodeBlockNumber(number)...), hash.Bytes()...))
+	db.Delete(headerKey(hash, number))
 }
 
 // DeleteBlock removes all block data associated with a hash.
@@ -0,0 +1,336 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/core/meta"
+)
+
+func newTestBlock(height uint32, txs ...string) *meta.Block {
+	// The transactions are left out of the header, tell the blocks apart by time
+	header := meta.BlockHeader{Height: height, Time: time.Unix(int64(height)*100+int64(len(txs)), 0)}
+	transactions := make([]meta.Transaction, len(txs))
+	for i, data := range txs {
+		transactions[i] = meta.Transaction{Data: []byte(data)}
+	}
+	return meta.NewBlock(header, transactions)
+}
+
+func checkBlock(t *testing.T, db DatabaseReader, want *meta.Block) {
+	hash, number := *want.GetBlockID(), uint64(want.GetHeight())
+	header := GetHeader(db, hash, number)
+	if header == nil || !header.GetBlockID().IsEqual(&hash) {
+		t.Fatalf("header %d: have %v, want %v", number, header, want.Header)
+	}
+	body := GetBody(db, hash, number)
+	if body == nil || len(body.Txs) != len(want.TXs.Txs) {
+		t.Fatalf("body %d: have %v, want %d transactions", number, body, len(want.TXs.Txs))
+	}
+	for i := range body.Txs {
+		if !bytes.Equal(body.Txs[i].Data, want.TXs.Txs[i].Data) {
+			t.Fatalf("body %d transaction %d: have %x, want %x", number, i, body.Txs[i].Data, want.TXs.Txs[i].Data)
+		}
+	}
+	block := GetBlock(db, hash, number)
+	if block == nil || !block.GetBlockID().IsEqual(&hash) || len(block.TXs.Txs) != len(want.TXs.Txs) {
+		t.Fatalf("block %d: have %v, want %v", number, block, want)
+	}
+	if GetBlockNumber(db, hash) != number {
+		t.Fatalf("block %d number: have %d", number, GetBlockNumber(db, hash))
+	}
+}
+
+func TestBlockStorage(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+
+	empty := newTestBlock(1)
+	full := newTestBlock(2, "a", "b")
+	for _, block := range []*meta.Block{empty, full} {
+		if HasBlock(db, *block.GetBlockID(), uint64(block.GetHeight())) {
+			t.Fatalf("block %d present before being written", block.GetHeight())
+		}
+		if err := WriteBlock(db, block); err != nil {
+			t.Fatalf("failed to write block %d: %v", block.GetHeight(), err)
+		}
+		if !HasBlock(db, *block.GetBlockID(), uint64(block.GetHeight())) {
+			t.Fatalf("block %d missing after being written", block.GetHeight())
+		}
+		checkBlock(t, db, block)
+	}
+
+	// Headers are readable without the body
+	hash, number := *full.GetBlockID(), uint64(full.GetHeight())
+	DeleteBody(db, hash, number)
+	if GetHeader(db, hash, number) == nil {
+		t.Fatalf("header lost with the body")
+	}
+	if GetBlock(db, hash, number) != nil || HasBlock(db, hash, number) {
+		t.Fatalf("block without body still present")
+	}
+
+	DeleteBlock(db, *empty.GetBlockID(), uint64(empty.GetHeight()))
+	if GetHeader(db, *empty.GetBlockID(), 1) != nil || GetBody(db, *empty.GetBlockID(), 1) != nil {
+		t.Fatalf("deleted block still present")
+	}
+}
+
+func TestMigrateBlockBodies(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+
+	// Store blocks the way version 3 did, as a whole under one key
+	blocks := []*meta.Block{newTestBlock(0), newTestBlock(1, "a"), newTestBlock(1, "b", "c")}
+	for _, block := range blocks {
+		data, err := block.EncodeToBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash, number := *block.GetBlockID(), uint64(block.GetHeight())
+		db.Put(blockKey(hash, number), data)
+		db.Put(append(blockHashPrefix, hash.Bytes()...), encodeBlockNumber(number))
+	}
+	WriteCanonicalHash(db, *blocks[0].GetBlockID(), 0)
+	WriteCanonicalHash(db, *blocks[1].GetBlockID(), 1)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+	if version, ok := GetDatabaseVersion(db); !ok || version != DatabaseVersion {
+		t.Fatalf("version: have %d, %v, want %d", version, ok, DatabaseVersion)
+	}
+	for _, block := range blocks {
+		checkBlock(t, db, block)
+		if ok, _ := db.Has(blockKey(*block.GetBlockID(), uint64(block.GetHeight()))); ok {
+			t.Fatalf("whole block %d left after migration", block.GetHeight())
+		}
+	}
+	if GetCanonicalHash(db, 1) != *blocks[1].GetBlockID() {
+		t.Fatalf("canonical hash changed by migration")
+	}
+
+	// A migrated database isn't scanned again
+	db.Put(blockKey(*blocks[2].GetBlockID(), 1), []byte("garbage"))
+	if err := Migrate(db); err != nil {
+		t.Fatalf("second migration: %v", err)
+	}
+}
+
+func TestMigrateVersions(t *testing.T) {
+	if last := migrations[len(migrations)-1].Version; last != DatabaseVersion {
+		t.Fatalf("last migration to version %d, want %d", last, DatabaseVersion)
+	}
+
+	// A new database is stamped without migrating
+	db, _ := lcdb.NewMemDatabase()
+	if err := Migrate(db); err != nil {
+		t.Fatalf("new database: %v", err)
+	}
+	if version, ok := GetDatabaseVersion(db); !ok || version != DatabaseVersion {
+		t.Fatalf("new database version: have %d, %v, want %d", version, ok, DatabaseVersion)
+	}
+
+	// Newer databases are refused untouched
+	WriteDatabaseVersion(db, DatabaseVersion+1)
+	if err, ok := Migrate(db).(*DatabaseVersionError); !ok || err.Stored != DatabaseVersion+1 || err.Supported != DatabaseVersion {
+		t.Fatalf("newer database: have %v, want a version error", err)
+	}
+	if version, _ := GetDatabaseVersion(db); version != DatabaseVersion+1 {
+		t.Fatalf("newer database version changed to %d", version)
+	}
+}
+
+func TestMigrateResume(t *testing.T) {
+	defer func(registered []Migration) { migrations = registered }(migrations)
+
+	var ran []uint64
+	fail := true
+	migrations = []Migration{
+		{Version: 4, Name: "first", Migrate: func(db lcdb.Database) error { ran = append(ran, 4); return nil }},
+		{Version: 5, Name: "second", Migrate: func(db lcdb.Database) error {
+			ran = append(ran, 5)
+			if fail {
+				return errors.New("interrupted")
+			}
+			return nil
+		}},
+		{Version: 6, Name: "third", Migrate: func(db lcdb.Database) error { ran = append(ran, 6); return nil }},
+	}
+
+	db, _ := lcdb.NewMemDatabase()
+	WriteCanonicalHash(db, math.Hash{1}, 0)
+	if err := Migrate(db); err == nil {
+		t.Fatalf("interrupted migration succeeded")
+	}
+	if version, _ := GetDatabaseVersion(db); version != 4 {
+		t.Fatalf("version after interruption: have %d, want 4", version)
+	}
+
+	// The next run resumes with the interrupted migration
+	fail = false
+	if err := Migrate(db); err != nil {
+		t.Fatalf("resumed migration failed: %v", err)
+	}
+	if want := []uint64{4, 5, 5, 6}; fmt.Sprint(ran) != fmt.Sprint(want) {
+		t.Fatalf("migrations run: have %v, want %v", ran, want)
+	}
+	if version, _ := GetDatabaseVersion(db); version != 6 {
+		t.Fatalf("version after resume: have %d, want 6", version)
+	}
+}
+
+// writeLegacyTxLookupEntries stores the transaction lookups of block the way
+// version 4 did, in JSON.
+func writeLegacyTxLookupEntries(t testing.TB, db lcdb.Putter, block *meta.Block) {
+	for i, tx := range block.TXs.Txs {
+		data, err := json.Marshal(TxLookupEntry{
+			BlockHash:  block.GetBlockID().String(),
+			BlockIndex: uint64(block.GetHeight()),
+			Index:      uint64(i),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.Put(append(lookupPrefix, tx.GetTxID().CloneBytes()...), data)
+	}
+}
+
+// checkTxLookups checks the lookups of the transactions of block.
+func checkTxLookups(t *testing.T, db DatabaseReader, block *meta.Block) {
+	for i, tx := range block.TXs.Txs {
+		hash, number, index := GetTxLookupEntry(db, *tx.GetTxID())
+		if hash != *block.GetBlockID() || number != uint64(block.GetHeight()) || index != uint64(i) {
+			t.Fatalf("block %d tx %d lookup: have %x #%d/%d", block.GetHeight(), i, hash[:4], number, index)
+		}
+	}
+}
+
+func TestTxLookupEntries(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+
+	binary, legacy := newTestBlock(1, "a", "b", "c"), newTestBlock(2, "d", "e")
+	for _, block := range []*meta.Block{binary, legacy} {
+		WriteBlock(db, block)
+		WriteCanonicalHash(db, *block.GetBlockID(), uint64(block.GetHeight()))
+	}
+	if err := WriteTxLookupEntries(db, binary); err != nil {
+		t.Fatalf("failed to write lookups: %v", err)
+	}
+	writeLegacyTxLookupEntries(t, db, legacy)
+	checkTxLookups(t, db, binary)
+	checkTxLookups(t, db, legacy)
+
+	if data, _ := db.Get(append(lookupPrefix, binary.TXs.Txs[2].GetTxID().CloneBytes()...)); len(data) != txLookupEntryLength {
+		t.Fatalf("binary lookup of %d bytes, want %d", len(data), txLookupEntryLength)
+	}
+	tx, hash, number, index := GetTransaction(db, *binary.TXs.Txs[1].GetTxID())
+	if tx == nil || !bytes.Equal(tx.Data, []byte("b")) || hash != *binary.GetBlockID() || number != 1 || index != 1 {
+		t.Fatalf("transaction: have %v in %x #%d/%d", tx, hash[:4], number, index)
+	}
+
+	// A binary lookup follows the canonical chain
+	DeleteCanonicalHash(db, 1)
+	if hash, _, _ := GetTxLookupEntry(db, *binary.TXs.Txs[0].GetTxID()); hash != (math.Hash{}) {
+		t.Fatalf("lookup into a non-canonical block: %x", hash[:4])
+	}
+}
+
+func TestMigrateTxLookupEntries(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+
+	// Store lookups the way version 4 did, one of them to a side block
+	canonical := []*meta.Block{newTestBlock(0), newTestBlock(1, "a", "b"), newTestBlock(2, "c")}
+	side := newTestBlock(2, "d", "e")
+	for _, block := range canonical {
+		WriteBlock(db, block)
+		WriteCanonicalHash(db, *block.GetBlockID(), uint64(block.GetHeight()))
+		writeLegacyTxLookupEntries(t, db, block)
+	}
+	WriteBlock(db, side)
+	writeLegacyTxLookupEntries(t, db, side)
+	db.Put(append(configPrefix, canonical[0].GetBlockID().CloneBytes()...), []byte("{}"))
+	WriteDatabaseVersion(db, 4)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+	if version, _ := GetDatabaseVersion(db); version != DatabaseVersion {
+		t.Fatalf("version: have %d, want %d", version, DatabaseVersion)
+	}
+	for _, block := range canonical {
+		checkTxLookups(t, db, block)
+		for _, tx := range block.TXs.Txs {
+			if data, _ := db.Get(append(lookupPrefix, tx.GetTxID().CloneBytes()...)); len(data) != txLookupEntryLength {
+				t.Fatalf("block %d lookup not converted: %s", block.GetHeight(), data)
+			}
+		}
+	}
+	if ok, _ := db.Has(append(lookupPrefix, side.TXs.Txs[0].GetTxID().CloneBytes()...)); ok {
+		t.Fatalf("lookup to a side block kept")
+	}
+	if ok, _ := db.Has(append(configPrefix, canonical[0].GetBlockID().CloneBytes()...)); !ok {
+		t.Fatalf("chain config dropped by the migration")
+	}
+
+	// The migration resumes over converted entries
+	if err := migrateTxLookupEntries(db); err != nil {
+		t.Fatalf("second migration failed: %v", err)
+	}
+	checkTxLookups(t, db, canonical[1])
+}
+
+// newBenchBlock creates a block of n transactions.
+func newBenchBlock(n int) *meta.Block {
+	txs := make([]string, n)
+	for i := range txs {
+		txs[i] = fmt.Sprintf("tx %d", i)
+	}
+	return newTestBlock(1, txs...)
+}
+
+func BenchmarkWriteTxLookupEntries(b *testing.B) {
+	block := newBenchBlock(5000)
+	db, _ := lcdb.NewMemDatabase()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		batch := db.NewBatch()
+		WriteTxLookupEntries(batch, block)
+	}
+}
+
+func BenchmarkWriteLegacyTxLookupEntries(b *testing.B) {
+	block := newBenchBlock(5000)
+	db, _ := lcdb.NewMemDatabase()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		batch := db.NewBatch()
+		writeLegacyTxLookupEntries(b, batch, block)
+	}
+}
+
+func benchmarkGetTxLookupEntry(b *testing.B, write func(db lcdb.Putter, block *meta.Block)) {
+	block := newBenchBlock(1000)
+	db, _ := lcdb.NewMemDatabase()
+	WriteCanonicalHash(db, *block.GetBlockID(), 1)
+	write(db, block)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		GetTxLookupEntry(db, *block.TXs.Txs[i%len(block.TXs.Txs)].GetTxID())
+	}
+}
+
+func BenchmarkGetTxLookupEntry(b *testing.B) {
+	benchmarkGetTxLookupEntry(b, func(db lcdb.Putter, block *meta.Block) { WriteTxLookupEntries(db, block) })
+}
+
+func BenchmarkGetLegacyTxLookupEntry(b *testing.B) {
+	benchmarkGetTxLookupEntry(b, func(db lcdb.Putter, block *meta.Block) { writeLegacyTxLookupEntries(b, db, block) })
+}
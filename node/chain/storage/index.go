@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"encoding/binary"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/core/meta"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// The block indexes find the canonical blocks of a signer and the canonical
+// block at a time without scanning the chain. They are optional: the chain
+// maintains them only when configured to, and records the height up to which
+// they are complete.
+
+// signerIndexKeyPrefix is the prefix of the index keys of a signer, the length
+// keeping signers of different lengths apart.
+func signerIndexKeyPrefix(signer []byte) []byte {
+	key := make([]byte, 0, len(signerIndexPrefix)+1+len(signer)+8)
+	key = append(key, signerIndexPrefix...)
+	key = append(key, byte(len(signer)))
+	return append(key, signer...)
+}
+
+func signerIndexKey(signer []byte, number uint64) []byte {
+	return append(signerIndexKeyPrefix(signer), encodeBlockNumber(number)...)
+}
+
+// timeIndexKeyPrefix is the prefix of the index keys of the blocks created at t.
+func timeIndexKeyPrefix(t uint64) []byte {
+	key := make([]byte, 0, len(timeIndexPrefix)+16)
+	key = append(key, timeIndexPrefix...)
+	return append(key, encodeBlockNumber(t)...)
+}
+
+func timeIndexKey(t uint64, number uint64) []byte {
+	return append(timeIndexKeyPrefix(t), encodeBlockNumber(number)...)
+}
+
+// blockIndexTime is the time of a block in the time index, which orders the
+// blocks created before 1970 first.
+func blockIndexTime(header *meta.BlockHeader) uint64 {
+	if t := header.Time.Unix(); t > 0 {
+		return uint64(t)
+	}
+	return 0
+}
+
+// WriteBlockIndexes indexes a canonical block by its signer and time. A block
+// without signer is indexed by time only.
+func WriteBlockIndexes(db lcdb.Putter, header *meta.BlockHeader, signer []byte) error {
+	number := uint64(header.Height)
+	if len(signer) > 0 {
+		if err := db.Put(signerIndexKey(signer, number), nil); err != nil {
+			return err
+		}
+	}
+	return db.Put(timeIndexKey(blockIndexTime(header), number), nil)
+}
+
+// DeleteBlockIndexes removes the index entries of a block leaving the
+// canonical chain.
+func DeleteBlockIndexes(db DatabaseDeleter, header *meta.BlockHeader, signer []byte) {
+	number := uint64(header.Height)
+	if len(signer) > 0 {
+		db.Delete(signerIndexKey(signer, number))
+	}
+	db.Delete(timeIndexKey(blockIndexTime(header), number))
+}
+
+// GetSignerHeights returns in increasing order the heights, from from on, of
+// at most limit canonical blocks signed by signer.
+func GetSignerHeights(db lcdb.Iteratee, signer []byte, from uint64, limit int) ([]uint64, error) {
+	prefix := signerIndexKeyPrefix(signer)
+	it := db.NewIteratorWithRange(signerIndexKey(signer, from), util.BytesPrefix(prefix).Limit)
+	defer it.Release()
+
+	var heights []uint64
+	for len(heights) < limit && it.Next() {
+		heights = append(heights, binary.BigEndian.Uint64(it.Key()[len(prefix):]))
+	}
+	return heights, it.Error()
+}
+
+// hasTimeIndex reports whether a block created between from and to, both
+// included, is indexed.
+func hasTimeIndex(db lcdb.Iteratee, from, to uint64) bool {
+	limit := util.BytesPrefix(timeIndexKeyPrefix(to)).Limit
+	it := db.NewIteratorWithRange(timeIndexKeyPrefix(from), limit)
+	defer it.Release()
+	return it.Next()
+}
+
+// FindHeightByTime returns the height of the last canonical block created at
+// or before t, the highest of the blocks created in the same second. The block
+// times needn't increase along the chain. It returns false if no indexed block
+// was created at or before t.
+func FindHeightByTime(db lcdb.Iteratee, t int64) (uint64, bool) {
+	// The iterators only move forward, so the last key at or before t is
+	// found by searching the earliest time from which a block is indexed
+	// up to t.
+	if t < 0 || !hasTimeIndex(db, 0, uint64(t)) {
+		return 0, false
+	}
+	lo, hi := uint64(0), uint64(t)
+	for lo < hi {
+		mid := lo + (hi-lo+1)/2
+		if hasTimeIndex(db, mid, uint64(t)) {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	// The blocks created at lo are iterated in increasing height order
+	prefix := timeIndexKeyPrefix(lo)
+	it := db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+
+	var number uint64
+	found := false
+	for it.Next() {
+		number, found = binary.BigEndian.Uint64(it.Key()[len(prefix):]), true
+	}
+	return number, found
+}
+
+// GetIndexedHeight retrieves the canonical height up to which the block
+// indexes are complete, false if they aren't maintained.
+func GetIndexedHeight(db DatabaseReader) (uint64, bool) {
+	data, _ := db.Get(indexedHeightKey)
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+// WriteIndexedHeight stores the canonical height up to which the block indexes
+// are complete.
+func WriteIndexedHeight(db lcdb.Putter, number uint64) error {
+	return db.Put(indexedHeightKey, encodeBlockNumber(number))
+}
+
+// DeleteAllBlockIndexes drops the block indexes, which stop being maintained.
+func DeleteAllBlockIndexes(db lcdb.Database) error {
+	if err := db.Delete(indexedHeightKey); err != nil {
+		return err
+	}
+	for _, prefix := range [][]byte{signerIndexPrefix, timeIndexPrefix} {
+		if _, err := lcdb.DeleteRange(db, prefix, util.BytesPrefix(prefix).Limit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteIndexedHeight marks the block indexes as no longer maintained, they are
+// dropped and rebuilt when maintained again.
+func DeleteIndexedHeight(db DatabaseDeleter) {
+	db.Delete(indexedHeightKey)
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/core/meta"
+)
+
+func TestBlockIndexes(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+
+	// Signers of different lengths sharing a prefix are kept apart
+	signers := [][]byte{[]byte("a"), []byte("ab"), []byte("a"), nil, []byte("ab"), []byte("a")}
+	for i, signer := range signers {
+		if err := WriteBlockIndexes(db, &newTestBlock(uint32(i)).Header, signer); err != nil {
+			t.Fatalf("block %d: failed to index: %v", i, err)
+		}
+	}
+	tests := []struct {
+		signer string
+		from   uint64
+		limit  int
+		want   []uint64
+	}{
+		{"a", 0, 10, []uint64{0, 2, 5}},
+		{"ab", 0, 10, []uint64{1, 4}},
+		{"a", 1, 10, []uint64{2, 5}},
+		{"a", 0, 2, []uint64{0, 2}},
+		{"a", 6, 10, nil},
+		{"b", 0, 10, nil},
+	}
+	for i, tt := range tests {
+		heights, err := GetSignerHeights(db, []byte(tt.signer), tt.from, tt.limit)
+		if err != nil || !reflect.DeepEqual(heights, tt.want) {
+			t.Errorf("test %d: have %v (%v), want %v", i, heights, err, tt.want)
+		}
+	}
+
+	// newTestBlock creates the block at height n at time n*100, the later
+	// blocks go back in time, one to the second of block 3
+	for number, at := range map[uint32]int64{6: 300, 7: 250} {
+		header := &meta.BlockHeader{Height: number, Time: time.Unix(at, 0)}
+		if err := WriteBlockIndexes(db, header, nil); err != nil {
+			t.Fatalf("block %d: failed to index: %v", number, err)
+		}
+	}
+	checkTimes := func(stage string, tests map[int64]int64) {
+		for at, want := range tests {
+			number, ok := FindHeightByTime(db, at)
+			if want < 0 && ok {
+				t.Errorf("%s: time %d: have %d, want none", stage, at, number)
+			}
+			if want >= 0 && (!ok || number != uint64(want)) {
+				t.Errorf("%s: time %d: have %d (%v), want %d", stage, at, number, ok, want)
+			}
+		}
+	}
+	checkTimes("indexed", map[int64]int64{-1: -1, 0: 0, 99: 0, 100: 1, 249: 2, 250: 7, 299: 7, 300: 6, 450: 4, 1000: 5})
+
+	// A block leaving the chain leaves the indexes
+	DeleteBlockIndexes(db, &newTestBlock(4).Header, []byte("ab"))
+	if heights, _ := GetSignerHeights(db, []byte("ab"), 0, 10); !reflect.DeepEqual(heights, []uint64{1}) {
+		t.Errorf("signer heights after delete: have %v, want [1]", heights)
+	}
+	checkTimes("deleted", map[int64]int64{399: 6, 450: 6, 500: 5})
+
+	WriteIndexedHeight(db, 5)
+	if number, ok := GetIndexedHeight(db); number != 5 || !ok {
+		t.Fatalf("indexed height: have %d (%v), want 5", number, ok)
+	}
+	if err := DeleteAllBlockIndexes(db); err != nil {
+		t.Fatalf("failed to delete indexes: %v", err)
+	}
+	if _, ok := GetIndexedHeight(db); ok {
+		t.Errorf("indexed height left after delete")
+	}
+	if heights, _ := GetSignerHeights(db, []byte("a"), 0, 10); len(heights) != 0 {
+		t.Errorf("signer heights left after delete: %v", heights)
+	}
+	if number, ok := FindHeightByTime(db, 1000); ok {
+		t.Errorf("time index left after delete: found %d", number)
+	}
+}
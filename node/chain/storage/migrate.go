@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/core/meta"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	// DatabaseVersion is the version of the key layout written by this code.
+	// Every change to the layout bumps it and registers a migration to it.
+	DatabaseVersion = 5
+
+	// legacyDatabaseVersion is the version of the databases written before
+	// the version was persisted.
+	legacyDatabaseVersion = 3
+)
+
+// Migration converts a database to Version from the version before it.
+// Migrations must be safe to run again over a partly converted database, so
+// an interrupted migration resumes on the next start.
+type Migration struct {
+	Version uint64
+	Name    string
+	Migrate func(db lcdb.Database) error
+}
+
+// migrations are the registered migrations, in increasing version order.
+var migrations = []Migration{
+	{Version: 4, Name: "split blocks into headers and bodies", Migrate: migrateBlockBodies},
+	{Version: 5, Name: "encode transaction lookups in binary", Migrate: migrateTxLookupEntries},
+}
+
+// DatabaseVersionError is returned when a database was written by a newer
+// version of the node, with a key layout it doesn't know.
+type DatabaseVersionError struct {
+	Stored, Supported uint64
+}
+
+func (e *DatabaseVersionError) Error() string {
+	return fmt.Sprintf("database version %d is newer than the supported version %d", e.Stored, e.Supported)
+}
+
+// GetDatabaseVersion retrieves the version of the key layout of the database,
+// or false if it was never written.
+func GetDatabaseVersion(db DatabaseReader) (uint64, bool) {
+	data, _ := db.Get(databaseVersionKey)
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+// WriteDatabaseVersion stores the version of the key layout of the database.
+func WriteDatabaseVersion(db lcdb.Putter, version uint64) error {
+	return db.Put(databaseVersionKey, encodeBlockNumber(version))
+}
+
+// Migrate brings the database to DatabaseVersion, running in order the
+// migrations it misses and recording the version after each of them. A new
+// database is stamped with the current version, a database newer than it is
+// refused with a *DatabaseVersionError.
+func Migrate(db lcdb.Database) error {
+	version, ok := GetDatabaseVersion(db)
+	if !ok {
+		if GetCanonicalHash(db, 0) == (math.Hash{}) && GetHeadBlockHash(db) == (math.Hash{}) {
+			return WriteDatabaseVersion(db, DatabaseVersion)
+		}
+		version = legacyDatabaseVersion
+	}
+	if version > DatabaseVersion {
+		return &DatabaseVersionError{Stored: version, Supported: DatabaseVersion}
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= version {
+			continue
+		}
+		log.Info("Migrating database", "from", version, "to", migration.Version, "migration", migration.Name)
+		if err := migration.Migrate(db); err != nil {
+			return fmt.Errorf("database migration to version %d (%s) failed: %v", migration.Version, migration.Name, err)
+		}
+		if err := WriteDatabaseVersion(db, migration.Version); err != nil {
+			return err
+		}
+		version = migration.Version
+	}
+	return nil
+}
+
+// legacyBlockKeyLength is the length of a blockPrefix + num + hash key,
+// telling whole blocks apart from the canonical hashes sharing their prefix.
+const legacyBlockKeyLength = 1 + 8 + 32
+
+// migrateBlockBodies converts the whole blocks stored by version 3 under a
+// single key into a header and a body stored apart.
+func migrateBlockBodies(db lcdb.Database) error {
+	keys, err := legacyBlockKeys(db)
+	if err != nil {
+		return err
+	}
+	log.Info("Splitting stored blocks into headers and bodies", "blocks", len(keys))
+
+	for _, key := range keys {
+		data, err := db.Get(key)
+		if err != nil {
+			return err
+		}
+		block := &meta.Block{}
+		if err := block.DecodeFromBytes(data); err != nil {
+			return err
+		}
+		if !bytes.Equal(key, blockKey(*block.GetBlockID(), uint64(block.GetHeight()))) {
+			log.Warn("Dropping block stored under a foreign key", "key", key, "hash", block.GetBlockID())
+			db.Delete(key)
+			continue
+		}
+
+		// The header and body are committed together before the whole block
+		// is dropped, a crash in between only repeats the conversion.
+		batch := db.NewBatch()
+		if err := WriteBlock(batch, block); err != nil {
+			return err
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		if err := db.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// legacyBlockKeys lists the keys of the whole blocks stored in db.
+func legacyBlockKeys(db lcdb.Database) ([][]byte, error) {
+	var keys [][]byte
+	it := db.NewIteratorWithPrefix(blockPrefix)
+	defer it.Release()
+	for it.Next() {
+		if len(it.Key()) == legacyBlockKeyLength {
+			keys = append(keys, lcdb.CopyBytes(it.Key()))
+		}
+	}
+	return keys, it.Error()
+}
+
+// txLookupKeyLength is the length of a lookupPrefix + hash key, telling the
+// lookups apart from the config keys sharing their prefix.
+const txLookupKeyLength = 1 + math.HashSize
+
+// migrateTxLookupEntries converts the JSON transaction lookups of version 4
+// into binary ones. The lookups to blocks out of the canonical chain, which a
+// binary entry can't tell, are dropped. The conversion is written a batch at a
+// time, the converted entries are skipped when it resumes.
+func migrateTxLookupEntries(db lcdb.Database) error {
+	var (
+		start     = lookupPrefix
+		limit     = util.BytesPrefix(lookupPrefix).Limit
+		batch     = db.NewBatch()
+		converted int
+		dropped   int
+	)
+	for {
+		// The iterator is released before writing, some backends can't write
+		// while it is open
+		it := db.NewIteratorWithRange(start, limit)
+		var last []byte
+		for batch.ValueSize() < lcdb.IdealBatchSize && it.Next() {
+			last = lcdb.CopyBytes(it.Key())
+			if len(last) != txLookupKeyLength {
+				continue
+			}
+			if _, _, ok := decodeTxLookupEntry(it.Value()); ok {
+				continue
+			}
+			var entry TxLookupEntry
+			if err := json.Unmarshal(it.Value(), &entry); err != nil {
+				log.Warn("Dropping invalid transaction lookup", "key", last, "err", err)
+				batch.Delete(last)
+				dropped++
+				continue
+			}
+			hash, err := math.NewHashFromStr(entry.BlockHash)
+			if err != nil || GetCanonicalHash(db, entry.BlockIndex) != *hash {
+				batch.Delete(last)
+				dropped++
+				continue
+			}
+			data := make([]byte, txLookupEntryLength)
+			encodeTxLookupEntry(data, entry.BlockIndex, entry.Index)
+			batch.Put(last, data)
+			converted++
+		}
+		err := it.Error()
+		it.Release()
+		if err != nil {
+			return err
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		if last == nil {
+			break
+		}
+		// Resume right after the last key read
+		start = append(last, 0)
+	}
+	log.Info("Converted transaction lookups", "converted", converted, "dropped", dropped)
+	return nil
+}
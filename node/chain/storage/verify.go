@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+)
+
+// IssueKind classifies an inconsistency of a chain database.
+type IssueKind string
+
+const (
+	// Canonical block data, which can't be rebuilt. The canonical chain is
+	// only consistent below the first block having one of these.
+	MissingHeader   IssueKind = "missing header"    // canonical hash without a header
+	WrongHeader     IssueKind = "wrong header"      // header of another height or parent than the canonical chain
+	MissingBody     IssueKind = "missing body"      // canonical header without a body, above the pruned height
+	StaleCanonical  IssueKind = "stale canonical"   // canonical hash above the last consistent block
+	MissingHeadHash IssueKind = "missing head hash" // head pointer not set
+	WrongHeadHash   IssueKind = "wrong head hash"   // head pointer to a block out of the consistent canonical chain
+
+	// Indexes derived from the block data, rebuilt by RepairChain.
+	WrongBlockNumber IssueKind = "wrong block number" // hash to number entry missing or different from the header height
+	WrongTxLookup    IssueKind = "wrong tx lookup"    // transaction lookup missing or pointing to another block
+)
+
+// Issue is an inconsistency found in a chain database.
+type Issue struct {
+	Kind   IssueKind
+	Number uint64
+	Hash   math.Hash
+}
+
+func (i Issue) String() string {
+	if i.Number == MissingNumber {
+		return fmt.Sprintf("%s [%x]", i.Kind, i.Hash[:4])
+	}
+	return fmt.Sprintf("%s at #%d [%x]", i.Kind, i.Number, i.Hash[:4])
+}
+
+// IntegrityReport is the result of a chain database verification.
+type IntegrityReport struct {
+	Light     bool    // Header-only database, without block bodies
+	Head      uint64  // Height of the head pointer, MissingNumber if not set or unknown
+	LastValid uint64  // Height of the last block of the consistent canonical chain
+	Blocks    int     // Canonical blocks verified
+	Issues    []Issue // Inconsistencies found, chain data first
+}
+
+// Consistent tells whether no issue was found.
+func (r *IntegrityReport) Consistent() bool {
+	return len(r.Issues) == 0
+}
+
+func (r *IntegrityReport) add(kind IssueKind, number uint64, hash math.Hash) {
+	r.Issues = append(r.Issues, Issue{Kind: kind, Number: number, Hash: hash})
+}
+
+// VerifyChain checks the canonical chain stored in db from its genesis block,
+// with its hash to number and transaction lookup indexes, and its head
+// pointers. It reads a snapshot of db, so it can run while the chain is
+// written, and changes nothing.
+func VerifyChain(db lcdb.Database) (*IntegrityReport, error) {
+	snap, err := db.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Release()
+
+	return verifyChain(snap, nil)
+}
+
+// RepairChain rebuilds the hash to number and transaction lookup indexes of
+// the consistent canonical chain in db, drops the canonical hashes above it and
+// rolls the head pointers back to its last block. It returns the report of
+// the verification which drove the repair.
+//
+// The node must not run on db during the repair.
+func RepairChain(db lcdb.Database) (*IntegrityReport, error) {
+	batch := db.NewBatch()
+	report, err := verifyChain(db, batch)
+	if err != nil {
+		return nil, err
+	}
+	if report.Blocks == 0 {
+		// Without a genesis block there is nothing to roll back to
+		return report, nil
+	}
+	rollback, rollbackFast := false, false
+	for _, issue := range report.Issues {
+		switch issue.Kind {
+		case StaleCanonical:
+			DeleteCanonicalHash(batch, issue.Number)
+		case MissingHeadHash:
+			rollback = true
+		case WrongHeadHash:
+			// A head block above the consistent chain takes the fast head
+			// back with it, a fast head alone is rolled back alone
+			if report.Light || issue.Hash == GetHeadBlockHash(db) {
+				rollback = true
+			} else {
+				rollbackFast = true
+			}
+		}
+	}
+	hash := GetCanonicalHash(db, report.LastValid)
+	switch {
+	case rollback:
+		log.Warn("Rolling chain head back", "number", report.LastValid, "hash", hash)
+		if report.Light {
+			WriteHeadHeaderHash(batch, hash)
+		} else {
+			WriteHeadBlockHash(batch, hash)
+			WriteHeadFastBlockHash(batch, hash)
+		}
+	case rollbackFast:
+		log.Warn("Rolling fast chain head back", "number", report.LastValid, "hash", hash)
+		WriteHeadFastBlockHash(batch, hash)
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// verifyChain verifies the chain of db, adding the index entries to rebuild
+// to repair if it isn't nil.
+func verifyChain(db DatabaseReader, repair lcdb.Putter) (*IntegrityReport, error) {
+	report := &IntegrityReport{Head: MissingNumber}
+
+	// A light chain moves the header head pointer only
+	headHash := GetHeadBlockHash(db)
+	if headHash == (math.Hash{}) {
+		if hash := GetHeadHeaderHash(db); hash != (math.Hash{}) {
+			headHash, report.Light = hash, true
+		}
+	}
+	// The blocks above a canonical head were never fully inserted. A node
+	// in the middle of a fast sync has its fast chain above the head block,
+	// with bodies but not yet the app state, which is walked as well.
+	limit := canonicalNumber(db, headHash)
+	fastHash, fastLimit := math.Hash{}, MissingNumber
+	if !report.Light {
+		fastHash = GetHeadFastBlockHash(db)
+		fastLimit = canonicalNumber(db, fastHash)
+	}
+	headLimit := limit
+	if limit != MissingNumber && fastLimit != MissingNumber && fastLimit > limit {
+		limit = fastLimit
+	}
+	pruned := GetPrunedHeight(db)
+
+	// Walk the canonical chain up to its first inconsistent block
+	var (
+		number uint64
+		parent math.Hash
+	)
+	for ; number <= limit; number++ {
+		hash := GetCanonicalHash(db, number)
+		if hash == (math.Hash{}) {
+			break
+		}
+		header := GetHeader(db, hash, number)
+		if header == nil {
+			report.add(MissingHeader, number, hash)
+			break
+		}
+		if !header.GetBlockID().IsEqual(&hash) || uint64(header.Height) != number || (number > 0 && !header.Prev.IsEqual(&parent)) {
+			report.add(WrongHeader, number, hash)
+			break
+		}
+		if GetBlockNumber(db, hash) != number {
+			report.add(WrongBlockNumber, number, hash)
+			if repair != nil {
+				if err := repair.Put(append(blockHashPrefix, hash.Bytes()...), encodeBlockNumber(number)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if !report.Light && (number == 0 || number >= pruned) {
+			block := GetBlock(db, hash, number)
+			if block == nil {
+				report.add(MissingBody, number, hash)
+				break
+			}
+			rebuild := false
+			for i := range block.TXs.Txs {
+				txHash := *block.TXs.Txs[i].GetTxID()
+				if lookup, height, index := GetTxLookupEntry(db, txHash); lookup != hash || height != number || index != uint64(i) {
+					report.add(WrongTxLookup, number, txHash)
+					rebuild = true
+				}
+			}
+			if rebuild && repair != nil {
+				if err := WriteTxLookupEntries(repair, block); err != nil {
+					return nil, err
+				}
+			}
+		}
+		report.Blocks++
+		report.LastValid, parent = number, hash
+	}
+	if report.Blocks == 0 {
+		return report, nil
+	}
+
+	// Nothing above the consistent chain is canonical any more
+	for stale := report.LastValid + 1; ; stale++ {
+		hash := GetCanonicalHash(db, stale)
+		if hash == (math.Hash{}) {
+			break
+		}
+		report.add(StaleCanonical, stale, hash)
+	}
+
+	// The head pointer must be in the consistent chain, and the fast head
+	// pointer, at or above it, the last consistent block
+	switch {
+	case headHash == (math.Hash{}):
+		report.add(MissingHeadHash, MissingNumber, headHash)
+	case headLimit == MissingNumber || headLimit > report.LastValid:
+		report.add(WrongHeadHash, GetBlockNumber(db, headHash), headHash)
+	default:
+		report.Head = headLimit
+	}
+	if fastHash != (math.Hash{}) && fastLimit != report.LastValid {
+		report.add(WrongHeadHash, GetBlockNumber(db, fastHash), fastHash)
+	}
+	return report, nil
+}
+
+// canonicalNumber returns the height of a canonical block, MissingNumber if
+// hash isn't one.
+func canonicalNumber(db DatabaseReader, hash math.Hash) uint64 {
+	if number := GetBlockNumber(db, hash); number != MissingNumber && GetCanonicalHash(db, number) == hash {
+		return number
+	}
+	return MissingNumber
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/core/meta"
+)
+
+// writeTestChain stores a canonical chain of n blocks with its indexes and
+// head pointers.
+func writeTestChain(t *testing.T, db lcdb.Database, n int) []*meta.Block {
+	blocks := make([]*meta.Block, n)
+	for i := range blocks {
+		blocks[i] = newTestBlock(uint32(i), fmt.Sprintf("tx %d", i))
+		if i > 0 {
+			blocks[i].Header.Prev = *blocks[i-1].GetBlockID()
+		}
+		hash := *blocks[i].GetBlockID()
+		if err := WriteBlock(db, blocks[i]); err != nil {
+			t.Fatalf("failed to write block %d: %v", i, err)
+		}
+		WriteCanonicalHash(db, hash, uint64(i))
+		WriteTxLookupEntries(db, blocks[i])
+		WriteHeadBlockHash(db, hash)
+		WriteHeadFastBlockHash(db, hash)
+	}
+	return blocks
+}
+
+func checkIssues(t *testing.T, report *IntegrityReport, want ...IssueKind) {
+	if len(report.Issues) != len(want) {
+		t.Fatalf("issues: have %v, want %v", report.Issues, want)
+	}
+	for i, issue := range report.Issues {
+		if issue.Kind != want[i] {
+			t.Fatalf("issue %d: have %v, want %v", i, issue, want[i])
+		}
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+	blocks := writeTestChain(t, db, 6)
+
+	report, err := VerifyChain(db)
+	if err != nil {
+		t.Fatalf("verification failed: %v", err)
+	}
+	if !report.Consistent() || report.Blocks != 6 || report.Head != 5 || report.LastValid != 5 {
+		t.Fatalf("consistent chain: have %+v", report)
+	}
+
+	// Lost indexes leave the chain consistent
+	db.Delete(append(blockHashPrefix, blocks[2].GetBlockID().Bytes()...))
+	DeleteTxLookupEntry(db, *blocks[3].TXs.Txs[0].GetTxID())
+	report, _ = VerifyChain(db)
+	checkIssues(t, report, WrongBlockNumber, WrongTxLookup)
+	if report.LastValid != 5 || report.Head != 5 {
+		t.Fatalf("lost indexes: have %+v", report)
+	}
+
+	// A crash before the head moved leaves blocks above it
+	next := newTestBlock(6, "tx 6")
+	next.Header.Prev = *blocks[5].GetBlockID()
+	WriteBlock(db, next)
+	WriteCanonicalHash(db, *next.GetBlockID(), 6)
+	report, _ = VerifyChain(db)
+	checkIssues(t, report, WrongBlockNumber, WrongTxLookup, StaleCanonical)
+
+	// A missing body cuts the chain below it
+	DeleteBody(db, *blocks[4].GetBlockID(), 4)
+	report, _ = VerifyChain(db)
+	checkIssues(t, report, WrongBlockNumber, WrongTxLookup, MissingBody, StaleCanonical, StaleCanonical, StaleCanonical, WrongHeadHash, WrongHeadHash)
+	if report.LastValid != 3 || report.Head != MissingNumber {
+		t.Fatalf("missing body: have %+v", report)
+	}
+
+	// Bodies below the pruned height aren't missing
+	WritePrunedHeight(db, 5)
+	report, _ = VerifyChain(db)
+	checkIssues(t, report, WrongBlockNumber, StaleCanonical)
+	WritePrunedHeight(db, 0)
+}
+
+func TestRepairChain(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+	blocks := writeTestChain(t, db, 6)
+
+	db.Delete(append(blockHashPrefix, blocks[2].GetBlockID().Bytes()...))
+	DeleteTxLookupEntry(db, *blocks[3].TXs.Txs[0].GetTxID())
+	DeleteBody(db, *blocks[4].GetBlockID(), 4)
+
+	report, err := RepairChain(db)
+	if err != nil {
+		t.Fatalf("repair failed: %v", err)
+	}
+	checkIssues(t, report, WrongBlockNumber, WrongTxLookup, MissingBody, StaleCanonical, StaleCanonical, WrongHeadHash, WrongHeadHash)
+
+	report, _ = VerifyChain(db)
+	if !report.Consistent() || report.Head != 3 || report.Blocks != 4 {
+		t.Fatalf("repaired chain: have %+v", report)
+	}
+	for _, block := range blocks[:4] {
+		checkBlock(t, db, block)
+	}
+	if hash, number, index := GetTxLookupEntry(db, *blocks[3].TXs.Txs[0].GetTxID()); hash != *blocks[3].GetBlockID() || number != 3 || index != 0 {
+		t.Fatalf("rebuilt lookup: have %x %d %d", hash, number, index)
+	}
+	if GetHeadBlockHash(db) != *blocks[3].GetBlockID() || GetHeadFastBlockHash(db) != *blocks[3].GetBlockID() {
+		t.Fatalf("head not rolled back")
+	}
+
+	// A missing head pointer is set to the top of the chain
+	db.Delete(headBlockKey)
+	if report, _ := RepairChain(db); report.LastValid != 3 || GetHeadBlockHash(db) != *blocks[3].GetBlockID() {
+		t.Fatalf("missing head: have %+v", report)
+	}
+}
+
+func TestVerifyFastChain(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+	blocks := writeTestChain(t, db, 6)
+
+	// A node in the middle of a fast sync has its fast head above the head block
+	WriteHeadBlockHash(db, *blocks[2].GetBlockID())
+	report, err := RepairChain(db)
+	if err != nil {
+		t.Fatalf("repair failed: %v", err)
+	}
+	if !report.Consistent() || report.Blocks != 6 || report.Head != 2 || report.LastValid != 5 {
+		t.Fatalf("fast chain: have %+v", report)
+	}
+	if GetCanonicalHash(db, 5) != *blocks[5].GetBlockID() || GetHeadFastBlockHash(db) != *blocks[5].GetBlockID() {
+		t.Fatalf("fast chain dropped by repair")
+	}
+
+	// A broken fast chain rolls the fast head back, not the head block
+	DeleteBody(db, *blocks[4].GetBlockID(), 4)
+	report, _ = RepairChain(db)
+	checkIssues(t, report, MissingBody, StaleCanonical, StaleCanonical, WrongHeadHash)
+	report, _ = VerifyChain(db)
+	if !report.Consistent() || report.Head != 2 || report.LastValid != 3 {
+		t.Fatalf("repaired fast chain: have %+v", report)
+	}
+	if GetHeadBlockHash(db) != *blocks[2].GetBlockID() || GetHeadFastBlockHash(db) != *blocks[3].GetBlockID() {
+		t.Fatalf("heads: have %x %x", GetHeadBlockHash(db), GetHeadFastBlockHash(db))
+	}
+
+	// A fast head below the head block is wrong, the blocks above the head
+	// block aren't in any chain then
+	WriteHeadFastBlockHash(db, *blocks[1].GetBlockID())
+	report, _ = VerifyChain(db)
+	checkIssues(t, report, StaleCanonical, WrongHeadHash)
+}
@@ -26,8 +26,45 @@ type BaseConfig struct {
 	NoDiscovery        bool
 	BootstrapNodes     string
 	InterpreterAPIType string
+	// DiscoveryMode selects the peer discovery backend: "udp" (default),
+	// "static" to dial the nodes listed in StaticNodesFile, "exchange" to learn
+	// nodes from connected peers, or "none".
+	DiscoveryMode   string
+	StaticNodesFile string
+	// LightMode runs a header-only node, which stores no block bodies and
+	// retrieves transactions with inclusion proofs from full peers.
+	LightMode bool
+	// FastSync downloads the app state of a recent block instead of replaying
+	// every block through the app, if the app supports it.
+	FastSync bool
+	// PruneBlocks keeps the bodies and transaction lookups of only the most
+	// recent PruneBlocks blocks, headers are kept for the whole chain. 0 keeps
+	// every block.
+	PruneBlocks uint64
+	// BlockIndexes indexes the canonical blocks by signer and time, built from
+	// the existing chain on start. Disabling them has them rebuilt when enabled
+	// again.
+	BlockIndexes bool
+	// DatabaseBackend is the key-value engine of the chain database, one of
+	// lcdb.Backends(): "leveldb" (default), "boltdb" or "memory". An existing
+	// database must be opened with the backend which created it.
+	DatabaseBackend string
+	// DatabaseCache (in MB) and DatabaseHandles (open files) size the engines
+	// which use them, defaults apply if 0.
+	DatabaseCache   int
+	DatabaseHandles int
 	//Rpc
-	RpcAddr string
+	RpcAddr     string
+	RpcUser     string
+	RpcPassword string
+	// RpcTokens are bearer tokens accepted by the RPC server besides the user
+	// and password.
+	RpcTokens []string
+	// RpcTLSCert and RpcTLSKey make the RPC server listen on TLS. Clients need
+	// a certificate signed by RpcClientCA if it is set.
+	RpcTLSCert  string
+	RpcTLSKey   string
+	RpcClientCA string
 }
 
 // DefaultDataDir is the default data directory to use for the databases and other
@@ -15,8 +15,9 @@ const (
 	DefaultTransactionVersion = 0x00000001 //the version of transaction
 	DefaultBlockReward        = 5000000000 //the reward of mining a block
 
-	DefaultNodeDatabaseDir = "nodes"   // Path within the datadir to store the node infos
-	DefaultPrivateKeyDir   = "nodekey" // Path within the datadir to the node's private key
+	DefaultNodeDatabaseDir = "nodes"    // Path within the datadir to store the node infos
+	DefaultPrivateKeyDir   = "nodekey"  // Path within the datadir to the node's private key
+	DefaultKeyStoreDir     = "keystore" // Path within the datadir to the account keys
 	DefaultMaxPeers        = 25
 )
 
@@ -61,6 +61,10 @@ func (m *Miner) Stop() {
 }
 
 func (m *Miner) MineBlock() (*meta.Block, error) {
+	if checker, ok := m.bcsiAPI.(bcsi.HealthChecker); ok && !checker.Healthy() {
+		log.Debug("Miner", "Skip mining", bcsi.ErrAppUnavailable)
+		return nil, bcsi.ErrAppUnavailable
+	}
 	best := m.chain.GetBestBlock()
 	block, err := CreateBlock(best.GetHeight(), *best.GetBlockID())
 	if err != nil {
@@ -0,0 +1,263 @@
+package node
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/chain/storage"
+)
+
+// A block dump file is a sequence of canonical blocks in increasing height,
+// each encoded as a protobuf block preceded by its length as a uvarint. Files
+// named *.gz are gzip compressed, which import detects by itself.
+const (
+	maxBlockRecordSize  = 32 * 1024 * 1024 // Largest encoded block accepted by import
+	progressLogInterval = 8 * time.Second  // Delay between two progress reports
+)
+
+// ErrBlockRecordTooLarge is returned when a block dump file announces a block
+// larger than any valid one, usually because the file isn't a dump.
+var ErrBlockRecordTooLarge = errors.New("block record too large")
+
+// BlockSource is a chain whose canonical blocks can be exported.
+type BlockSource interface {
+	GetBlockByHeight(height uint32) (*meta.Block, error)
+}
+
+// BlockImporter is a chain which validates and inserts imported blocks.
+type BlockImporter interface {
+	HasBlock(hash meta.BlockID) bool
+	ProcessBlock(block *meta.Block) error
+}
+
+// ImportStats sums up an import.
+type ImportStats struct {
+	Imported int    // Blocks inserted into the chain
+	Skipped  int    // Blocks already in the chain, from a previous import
+	Height   uint64 // Height of the last block read
+}
+
+// ExportChain writes the canonical blocks from first to last, both included,
+// to w, returning the number of blocks written.
+func ExportChain(src BlockSource, w io.Writer, first, last uint64) (int, error) {
+	if first > last {
+		return 0, fmt.Errorf("export range [%d, %d] is empty", first, last)
+	}
+	var (
+		buf      = bufio.NewWriter(w)
+		length   = make([]byte, binary.MaxVarintLen64)
+		reported = time.Now()
+		written  = 0
+	)
+	for number := first; number <= last; number++ {
+		block, err := src.GetBlockByHeight(uint32(number))
+		if err != nil || block == nil {
+			return written, fmt.Errorf("export failed on block #%d: block not found", number)
+		}
+		data, err := block.EncodeToBytes()
+		if err != nil {
+			return written, fmt.Errorf("export failed on block #%d: %v", number, err)
+		}
+		if _, err := buf.Write(length[:binary.PutUvarint(length, uint64(len(data)))]); err != nil {
+			return written, err
+		}
+		if _, err := buf.Write(data); err != nil {
+			return written, err
+		}
+		written++
+
+		if time.Since(reported) > progressLogInterval {
+			log.Info("Exporting blocks", "exported", written, "number", number, "last", last)
+			reported = time.Now()
+		}
+	}
+	return written, buf.Flush()
+}
+
+// ExportChainFile writes the canonical blocks from first to last, both
+// included, to the file fn, gzip compressed if its name ends with ".gz".
+func ExportChainFile(src BlockSource, fn string, first, last uint64) error {
+	log.Info("Exporting blockchain", "file", fn, "first", first, "last", last)
+	start := time.Now()
+
+	file, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	// Closed again on success, to report the errors of the last writes
+	defer file.Close()
+
+	var (
+		w  io.Writer = file
+		gz *gzip.Writer
+	)
+	if strings.HasSuffix(fn, ".gz") {
+		gz = gzip.NewWriter(file)
+		w = gz
+	}
+	written, err := ExportChain(src, w, first, last)
+	if err != nil {
+		return err
+	}
+	// Closing the gzip writer flushes the last compressed data and the trailer
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	log.Info("Exported blockchain", "file", fn, "blocks", written, "elapsed", time.Since(start))
+	return nil
+}
+
+// ImportChain reads the blocks of a dump from r, gzip compressed or not, and
+// processes them through the chain, which validates them. Blocks the chain
+// already has are skipped without being validated again, so an interrupted
+// import resumes where it stopped.
+func ImportChain(dst BlockImporter, r io.Reader) (ImportStats, error) {
+	var stats ImportStats
+
+	in := bufio.NewReader(r)
+	if magic, err := in.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(in)
+		if err != nil {
+			return stats, err
+		}
+		defer gz.Close()
+		in = bufio.NewReader(gz)
+	}
+
+	reported := time.Now()
+	for {
+		block, err := readBlockRecord(in)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return stats, fmt.Errorf("import failed after block #%d: %v", stats.Height, err)
+		}
+		stats.Height = uint64(block.GetHeight())
+
+		if dst.HasBlock(*block.GetBlockID()) {
+			stats.Skipped++
+		} else {
+			if err := dst.ProcessBlock(block); err != nil {
+				return stats, fmt.Errorf("import failed on block #%d [%x]: %v", block.GetHeight(), block.GetBlockID().CloneBytes()[:4], err)
+			}
+			stats.Imported++
+		}
+
+		if time.Since(reported) > progressLogInterval {
+			log.Info("Importing blocks", "imported", stats.Imported, "skipped", stats.Skipped, "number", stats.Height)
+			reported = time.Now()
+		}
+	}
+	return stats, nil
+}
+
+// ImportChainFile imports the blocks of the dump file fn.
+func ImportChainFile(dst BlockImporter, fn string) (ImportStats, error) {
+	log.Info("Importing blockchain", "file", fn)
+	start := time.Now()
+
+	file, err := os.Open(fn)
+	if err != nil {
+		return ImportStats{}, err
+	}
+	defer file.Close()
+
+	stats, err := ImportChain(dst, file)
+	if err != nil {
+		return stats, err
+	}
+	log.Info("Imported blockchain", "file", fn, "imported", stats.Imported, "skipped", stats.Skipped,
+		"number", stats.Height, "elapsed", time.Since(start))
+	return stats, nil
+}
+
+// readBlockRecord reads the next block of a dump, io.EOF at its end.
+func readBlockRecord(r *bufio.Reader) (*meta.Block, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxBlockRecordSize {
+		return nil, ErrBlockRecordTooLarge
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	block := &meta.Block{}
+	if err := block.DecodeFromBytes(data); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// ExportChain writes the canonical blocks of the node from first to last to
+// the file fn.
+func (n *Node) ExportChain(fn string, first, last uint64) error {
+	if n.blockchain == nil {
+		return ErrLightMode
+	}
+	return ExportChainFile(n.blockchain, fn, first, last)
+}
+
+// ImportChain processes the blocks of the dump file fn through the chain of
+// the node.
+func (n *Node) ImportChain(fn string) (ImportStats, error) {
+	if n.blockchain == nil {
+		return ImportStats{}, ErrLightMode
+	}
+	return ImportChainFile(n.blockchain, fn)
+}
+
+// StoredChain reads the canonical blocks straight from a chain database, to
+// export a chain without running a node or reaching its app.
+type StoredChain struct {
+	db lcdb.Database
+}
+
+// NewStoredChain returns the canonical chain stored in db.
+func NewStoredChain(db lcdb.Database) *StoredChain {
+	return &StoredChain{db: db}
+}
+
+// GetBlockByHeight returns the canonical block at height.
+func (c *StoredChain) GetBlockByHeight(height uint32) (*meta.Block, error) {
+	hash := storage.GetCanonicalHash(c.db, uint64(height))
+	if hash == (math.Hash{}) {
+		return nil, errors.New("block not found")
+	}
+	block := storage.GetBlock(c.db, hash, uint64(height))
+	if block == nil {
+		return nil, errors.New("block body not found")
+	}
+	return block, nil
+}
+
+// Head returns the height of the head block, 0 for an empty database.
+func (c *StoredChain) Head() uint64 {
+	number := storage.GetBlockNumber(c.db, storage.GetHeadBlockHash(c.db))
+	if number == storage.MissingNumber {
+		return 0
+	}
+	return number
+}
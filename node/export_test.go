@@ -0,0 +1,139 @@
+package node
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/chain/genesis"
+)
+
+// testChain is a chain of blocks by height, accepting the blocks extending it.
+type testChain struct {
+	blocks []*meta.Block
+}
+
+func newTestChain(n int) *testChain {
+	c := &testChain{}
+	prev := meta.BlockID{}
+	for i := 0; i < n; i++ {
+		header := meta.BlockHeader{Height: uint32(i), Time: time.Unix(int64(i), 0), Prev: prev}
+		tx := meta.Transaction{Data: []byte(fmt.Sprintf("tx %d", i))}
+		block := meta.NewBlock(header, []meta.Transaction{tx})
+		c.blocks = append(c.blocks, block)
+		prev = *block.GetBlockID()
+	}
+	return c
+}
+
+func (c *testChain) GetBlockByHeight(height uint32) (*meta.Block, error) {
+	if int(height) >= len(c.blocks) {
+		return nil, errors.New("block not found")
+	}
+	return c.blocks[height], nil
+}
+
+func (c *testChain) HasBlock(hash meta.BlockID) bool {
+	for _, block := range c.blocks {
+		if block.GetBlockID().IsEqual(&hash) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *testChain) ProcessBlock(block *meta.Block) error {
+	head := c.blocks[len(c.blocks)-1]
+	if !block.GetPrevBlockID().IsEqual(head.GetBlockID()) {
+		return errors.New("unknown parent")
+	}
+	c.blocks = append(c.blocks, block)
+	return nil
+}
+
+func TestExportImportChain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linkchain-export-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := newTestChain(10)
+	for _, name := range []string{"blocks.dump", "blocks.dump.gz"} {
+		fn := filepath.Join(dir, name)
+		if err := ExportChainFile(src, fn, 1, 9); err != nil {
+			t.Fatalf("%s: export failed: %v", name, err)
+		}
+		// Resume over the blocks a previous import left
+		dst := &testChain{blocks: src.blocks[:4]}
+		stats, err := ImportChainFile(dst, fn)
+		if err != nil {
+			t.Fatalf("%s: import failed: %v", name, err)
+		}
+		if stats.Imported != 6 || stats.Skipped != 3 || stats.Height != 9 {
+			t.Fatalf("%s: import stats: have %+v, want 6 imported, 3 skipped up to 9", name, stats)
+		}
+		for i, block := range dst.blocks {
+			if !block.GetBlockID().IsEqual(src.blocks[i].GetBlockID()) || len(block.TXs.Txs) != 1 {
+				t.Fatalf("%s: block %d differs from the exported one", name, i)
+			}
+		}
+	}
+
+	if _, err := ExportChain(src, ioutil.Discard, 5, 10); err == nil {
+		t.Fatalf("export past the head succeeded")
+	}
+}
+
+func TestImportChainErrors(t *testing.T) {
+	src := newTestChain(5)
+	var dump bytes.Buffer
+	if _, err := ExportChain(src, &dump, 1, 4); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	// A truncated dump imports up to the last whole block
+	dst := &testChain{blocks: src.blocks[:1]}
+	stats, err := ImportChain(dst, bytes.NewReader(dump.Bytes()[:dump.Len()-3]))
+	if err == nil || !strings.Contains(err.Error(), io.ErrUnexpectedEOF.Error()) {
+		t.Fatalf("truncated dump: have %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if stats.Imported != 3 || len(dst.blocks) != 4 {
+		t.Fatalf("truncated dump: imported %d blocks, want 3", stats.Imported)
+	}
+
+	// Blocks the chain rejects stop the import
+	if _, err := ImportChain(&testChain{blocks: src.blocks[:1]}, bytes.NewReader(dump.Bytes())); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if _, err := ImportChain(newTestChain(1), bytes.NewReader(dump.Bytes()[dump.Len()/2:])); err == nil {
+		t.Fatalf("garbage imported")
+	}
+}
+
+func TestStoredChain(t *testing.T) {
+	db, _ := lcdb.NewMemDatabase()
+	if _, _, err := genesis.SetupGenesisBlock(db, nil); err != nil {
+		t.Fatalf("failed to setup genesis: %v", err)
+	}
+	chain := NewStoredChain(db)
+	if chain.Head() != 0 {
+		t.Fatalf("head: have %d, want 0", chain.Head())
+	}
+	var dump bytes.Buffer
+	if written, err := ExportChain(chain, &dump, 0, chain.Head()); err != nil || written != 1 {
+		t.Fatalf("export: have %d, %v, want 1, nil", written, err)
+	}
+	if _, err := chain.GetBlockByHeight(1); err == nil {
+		t.Fatalf("missing block exported")
+	}
+}
@@ -55,7 +55,7 @@ func (t TCPDialer) Dial(dest *discover.Node) (net.Conn, error) {
 // of the main loop in Server.run.
 type dialstate struct {
 	maxDynDials int
-	ntab        discoverTable
+	ntab        Discovery
 	netrestrict *netutil.Netlist
 
 	lookupRunning bool
@@ -69,14 +69,6 @@ type dialstate struct {
 	bootnodes []*discover.Node // default dials when there are no peers
 }
 
-type discoverTable interface {
-	Self() *discover.Node
-	Close()
-	Resolve(target discover.NodeID) *discover.Node
-	Lookup(target discover.NodeID) []*discover.Node
-	ReadRandomNodes([]*discover.Node) int
-}
-
 // the dial history remembers recent dials.
 type dialHistory []pastDial
 
@@ -112,7 +104,7 @@ type waitExpireTask struct {
 	time.Duration
 }
 
-func newDialState(static []*discover.Node, bootnodes []*discover.Node, ntab discoverTable, maxdyn int, netrestrict *netutil.Netlist) *dialstate {
+func newDialState(static []*discover.Node, bootnodes []*discover.Node, ntab Discovery, maxdyn int, netrestrict *netutil.Netlist) *dialstate {
 	s := &dialstate{
 		maxDynDials: maxdyn,
 		ntab:        ntab,
@@ -206,7 +198,7 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*peer.Peer,
 	// Use random nodes from the table for half of the necessary
 	// dynamic dials.
 	randomCandidates := needDynDials / 2
-	if randomCandidates > 0 {
+	if randomCandidates > 0 && s.ntab != nil {
 		n := s.ntab.ReadRandomNodes(s.randomNodes)
 		for i := 0; i < randomCandidates && i < n; i++ {
 			if addDial(peer.DynDialedConn, s.randomNodes[i]) {
@@ -224,7 +216,7 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*peer.Peer,
 	}
 	s.lookupBuf = s.lookupBuf[:copy(s.lookupBuf, s.lookupBuf[i:])]
 	// Launch a discovery lookup if more candidates are needed.
-	if len(s.lookupBuf) < needDynDials && !s.lookupRunning {
+	if len(s.lookupBuf) < needDynDials && !s.lookupRunning && s.ntab != nil {
 		s.lookupRunning = true
 		newtasks = append(newtasks, &discoverTask{})
 	}
@@ -664,7 +664,7 @@ func uintID(i uint32) discover.NodeID {
 	return id
 }
 
-// implements discoverTable for TestDialResolve
+// implements Discovery for TestDialResolve
 type resolveMock struct {
 	resolveCalls []discover.NodeID
 	answer       *discover.Node
@@ -0,0 +1,288 @@
+package p2p
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/discover"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/peer"
+)
+
+// Discovery modes selecting the source of dial candidates.
+const (
+	DiscoveryUDP      = "udp"      // Kademlia UDP table of the discover package
+	DiscoveryStatic   = "static"   // Fixed node list read from StaticNodesFile
+	DiscoveryExchange = "exchange" // Nodes learned from the connected peers
+	DiscoveryNone     = "none"     // Only static and bootstrap nodes are dialed
+)
+
+const (
+	maxExchangeNodes = 1024            // Maximum number of nodes remembered by the peer exchange
+	exchangeFanout   = 3               // Number of peers asked for nodes on every lookup
+	exchangeWait     = 2 * time.Second // Time a lookup waits for peer exchange replies
+)
+
+// Discovery is a source of nodes to dial.
+type Discovery interface {
+	Self() *discover.Node
+	Close()
+	Resolve(target discover.NodeID) *discover.Node
+	Lookup(target discover.NodeID) []*discover.Node
+	ReadRandomNodes([]*discover.Node) int
+}
+
+// setupDiscovery creates the discovery backend chosen by the config, unless
+// one was given directly.
+func (srv *Service) setupDiscovery() error {
+	if srv.Discovery != nil {
+		srv.ntab = srv.Discovery
+		return nil
+	}
+	mode := srv.DiscoveryMode
+	if srv.NoDiscovery {
+		mode = DiscoveryNone
+	}
+	self := discover.PubkeyID(&srv.PrivateKey.PublicKey)
+
+	switch mode {
+	case DiscoveryUDP, "":
+		addr, err := net.ResolveUDPAddr("udp", srv.ListenAddr)
+		if err != nil {
+			log.Error("discover resolve udp failed", "err", err)
+			return err
+		}
+		conn, err := net.ListenUDP("udp", addr)
+		if err != nil {
+			log.Error("discover net listen udp failed", "err", err)
+			return err
+		}
+		cfg := discover.Config{
+			PrivateKey:   srv.PrivateKey,
+			AnnounceAddr: conn.LocalAddr().(*net.UDPAddr),
+			NodeDBPath:   srv.NodeDatabase,
+			NetRestrict:  srv.NetRestrict,
+			Bootnodes:    srv.BootstrapNodes,
+		}
+		ntab, err := discover.ListenUDP(conn, cfg)
+		if err != nil {
+			log.Error("discover listen udp failed", "err", err)
+			return err
+		}
+		srv.ntab = ntab
+
+	case DiscoveryStatic:
+		nodes, err := loadNodesFile(srv.StaticNodesFile)
+		if err != nil {
+			log.Error("Failed to load static discovery nodes", "file", srv.StaticNodesFile, "err", err)
+			return err
+		}
+		srv.ntab = newStaticDiscovery(self, nodes)
+
+	case DiscoveryExchange:
+		srv.ntab = newPeerExchange(self, srv.BootstrapNodes, srv.Peers)
+
+	case DiscoveryNone:
+
+	default:
+		return fmt.Errorf("unknown discovery mode %q", mode)
+	}
+	return nil
+}
+
+// loadNodesFile reads a list of node URLs, one per line. Empty lines and lines
+// starting with # are skipped.
+func loadNodesFile(path string) ([]*discover.Node, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		nodes   []*discover.Node
+		scanner = bufio.NewScanner(file)
+		line    int
+	)
+	for scanner.Scan() {
+		line++
+		url := strings.TrimSpace(scanner.Text())
+		if url == "" || strings.HasPrefix(url, "#") {
+			continue
+		}
+		node, err := discover.ParseNode(url)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, scanner.Err()
+}
+
+// shuffleNodes returns the nodes in random order.
+func shuffleNodes(nodes []*discover.Node) []*discover.Node {
+	shuffled := make([]*discover.Node, len(nodes))
+	for i, j := range rand.Perm(len(nodes)) {
+		shuffled[i] = nodes[j]
+	}
+	return shuffled
+}
+
+// staticDiscovery offers a fixed list of nodes, for networks where UDP
+// discovery is blocked.
+type staticDiscovery struct {
+	self  *discover.Node
+	nodes []*discover.Node
+}
+
+func newStaticDiscovery(self discover.NodeID, nodes []*discover.Node) *staticDiscovery {
+	return &staticDiscovery{self: &discover.Node{ID: self}, nodes: nodes}
+}
+
+func (d *staticDiscovery) Self() *discover.Node { return d.self }
+func (d *staticDiscovery) Close()               {}
+
+func (d *staticDiscovery) Resolve(target discover.NodeID) *discover.Node {
+	for _, n := range d.nodes {
+		if n.ID == target {
+			return n
+		}
+	}
+	return nil
+}
+
+func (d *staticDiscovery) Lookup(target discover.NodeID) []*discover.Node {
+	return shuffleNodes(d.nodes)
+}
+
+func (d *staticDiscovery) ReadRandomNodes(buf []*discover.Node) int {
+	return copy(buf, shuffleNodes(d.nodes))
+}
+
+// peerExchange learns nodes from the connected peers through the base
+// protocol's peer exchange messages, starting out from the bootstrap nodes.
+type peerExchange struct {
+	self  *discover.Node
+	peers func() []*peer.Peer // Currently connected peers
+
+	lock  sync.Mutex
+	nodes map[discover.NodeID]*discover.Node
+
+	added  chan struct{} // Signals lookups that new nodes were learned
+	closed chan struct{}
+}
+
+func newPeerExchange(self discover.NodeID, bootnodes []*discover.Node, peers func() []*peer.Peer) *peerExchange {
+	ex := &peerExchange{
+		self:   &discover.Node{ID: self},
+		peers:  peers,
+		nodes:  make(map[discover.NodeID]*discover.Node),
+		added:  make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+	ex.AddNodes(self, bootnodes)
+	return ex
+}
+
+func (ex *peerExchange) Self() *discover.Node { return ex.self }
+func (ex *peerExchange) Close()               { close(ex.closed) }
+
+func (ex *peerExchange) Resolve(target discover.NodeID) *discover.Node {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	return ex.nodes[target]
+}
+
+// Lookup asks a few connected peers for the nodes they know, returning all
+// known nodes once some replied or the wait is over.
+func (ex *peerExchange) Lookup(target discover.NodeID) []*discover.Node {
+	select {
+	case <-ex.added:
+	default:
+	}
+	peers := ex.peers()
+	for i, j := range rand.Perm(len(peers)) {
+		if i == exchangeFanout {
+			break
+		}
+		go peers[j].RequestPeers()
+	}
+	if len(peers) > 0 {
+		select {
+		case <-ex.added:
+		case <-time.After(exchangeWait):
+		case <-ex.closed:
+		}
+	}
+	return ex.Nodes(discover.NodeID{}, maxExchangeNodes)
+}
+
+func (ex *peerExchange) ReadRandomNodes(buf []*discover.Node) int {
+	return copy(buf, ex.Nodes(discover.NodeID{}, len(buf)))
+}
+
+// Nodes implements peer.NodeExchange, offering random known nodes apart from
+// the asking peer itself.
+func (ex *peerExchange) Nodes(id discover.NodeID, max int) []*discover.Node {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	nodes := make([]*discover.Node, 0, len(ex.nodes))
+	for _, n := range ex.nodes {
+		if n.ID != id {
+			nodes = append(nodes, n)
+		}
+	}
+	nodes = shuffleNodes(nodes)
+	if len(nodes) > max {
+		nodes = nodes[:max]
+	}
+	return nodes
+}
+
+// AddNodes implements peer.NodeExchange, remembering the dialable nodes
+// offered by a peer. Random old nodes are dropped once the set is full.
+func (ex *peerExchange) AddNodes(id discover.NodeID, nodes []*discover.Node) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	added := false
+	for _, n := range nodes {
+		if n.ID == ex.self.ID || n.Incomplete() || n.TCP == 0 || n.IP.IsUnspecified() || n.IP.IsMulticast() {
+			continue
+		}
+		if _, ok := ex.nodes[n.ID]; !ok {
+			for old := range ex.nodes {
+				if len(ex.nodes) < maxExchangeNodes {
+					break
+				}
+				delete(ex.nodes, old)
+			}
+			added = true
+		}
+		ex.nodes[n.ID] = n
+	}
+	if added {
+		select {
+		case ex.added <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// connNode returns the dialable node of a connected peer, made of its remote
+// address and the listening port it advertised.
+func connNode(c *peer.Conn) *discover.Node {
+	addr, ok := c.FD.RemoteAddr().(*net.TCPAddr)
+	if !ok || c.ListenPort == 0 {
+		return nil
+	}
+	return discover.NewNode(c.ID, addr.IP, c.ListenPort, c.ListenPort)
+}
@@ -0,0 +1,80 @@
+package p2p
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mihongtech/linkchain-core/node/net/p2p/discover"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/peer"
+)
+
+func TestLoadNodesFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "p2p-discovery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "nodes")
+	content := `# static nodes
+enode://1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439@127.0.0.1:30303
+
+  enode://2dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439@10.0.0.2:30304
+`
+	ioutil.WriteFile(path, []byte(content), 0600)
+	nodes, err := loadNodesFile(path)
+	if err != nil {
+		t.Fatalf("failed to load nodes: %v", err)
+	}
+	if len(nodes) != 2 || nodes[1].TCP != 30304 {
+		t.Fatalf("unexpected nodes loaded: %v", nodes)
+	}
+	tab := newStaticDiscovery(uintID(1), nodes)
+	if n := tab.Resolve(nodes[1].ID); n != nodes[1] {
+		t.Fatalf("static node not resolved: %v", n)
+	}
+	if n := tab.ReadRandomNodes(make([]*discover.Node, 5)); n != 2 {
+		t.Fatalf("random static nodes: have %d, want 2", n)
+	}
+
+	ioutil.WriteFile(path, []byte("enode://bad\n"), 0600)
+	if _, err := loadNodesFile(path); err == nil {
+		t.Fatalf("invalid node URL accepted")
+	}
+}
+
+func TestPeerExchangeNodes(t *testing.T) {
+	self := uintID(1)
+	boot := discover.NewNode(uintID(2), net.IP{10, 0, 0, 2}, 0, 30303)
+	ex := newPeerExchange(self, []*discover.Node{boot}, func() []*peer.Peer { return nil })
+	defer ex.Close()
+
+	ex.AddNodes(uintID(2), []*discover.Node{
+		discover.NewNode(self, net.IP{10, 0, 0, 1}, 0, 30303),          // ourselves
+		discover.NewNode(uintID(3), nil, 0, 30303),                     // no address
+		discover.NewNode(uintID(4), net.IP{10, 0, 0, 4}, 0, 0),         // not listening
+		discover.NewNode(uintID(5), net.IPv4zero, 0, 30303),            // unspecified
+		discover.NewNode(uintID(6), net.IP{10, 0, 0, 6}, 30303, 30303), // valid
+	})
+	if nodes := ex.Lookup(discover.NodeID{}); len(nodes) != 2 {
+		t.Fatalf("known nodes: have %v, want 2", nodes)
+	}
+	// Peers aren't offered themselves
+	nodes := ex.Nodes(uintID(6), 10)
+	if len(nodes) != 1 || nodes[0] != boot {
+		t.Fatalf("offered nodes: have %v, want bootnode", nodes)
+	}
+	if n := ex.Resolve(uintID(6)); n == nil || n.TCP != 30303 {
+		t.Fatalf("learned node not resolved: %v", n)
+	}
+
+	for i := uint32(0); i < maxExchangeNodes*2; i++ {
+		ex.AddNodes(boot.ID, []*discover.Node{discover.NewNode(uintID(100+i), net.IP{10, 1, 0, 1}, 0, 30303)})
+	}
+	if have := len(ex.Nodes(discover.NodeID{}, maxExchangeNodes*2)); have != maxExchangeNodes {
+		t.Fatalf("exchange set size: have %d, want %d", have, maxExchangeNodes)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/mihongtech/linkchain-core/node/net/p2p/peer_error"
 	"github.com/mihongtech/linkchain-core/node/net/p2p/transport"
 	data_sync "github.com/mihongtech/linkchain-core/node/net/sync"
+	"github.com/mihongtech/linkchain-core/node/net/sync/light"
 )
 
 var errServerStopped = errors.New("server stopped")
@@ -89,6 +90,18 @@ type Config struct {
 	// Disabling is useful for protocol debugging (manual topology).
 	NoDiscovery bool
 
+	// DiscoveryMode selects where dial candidates come from: the UDP table
+	// (default), a static node file or the peer exchange with connected peers.
+	DiscoveryMode string `toml:",omitempty"`
+
+	// StaticNodesFile lists the nodes used by the static discovery mode, one
+	// node URL per line.
+	StaticNodesFile string `toml:",omitempty"`
+
+	// If Discovery is set to a non-nil value, it is used instead of the
+	// backend selected by DiscoveryMode.
+	Discovery Discovery `toml:"-"`
+
 	// If Dialer is set to a non-nil value, the given Dialer
 	// is used to dial outbound peer connections.
 	Dialer NodeDialer `toml:"-"`
@@ -115,7 +128,7 @@ type Service struct {
 
 	running bool
 
-	ntab         discoverTable
+	ntab         Discovery
 	ourHandshake *message.ProtoHandshake
 	lastLookup   time.Time
 	listener     net.Listener
@@ -152,6 +165,8 @@ func NewP2P(cfg config.BaseConfig) *Service {
 	srv.ListenAddr = cfg.ListenAddress
 	srv.PrivateKey = srv.NodeKey(filepath.Join(cfg.DataDir, config.DefaultPrivateKeyDir))
 	srv.NoDiscovery = cfg.NoDiscovery
+	srv.DiscoveryMode = cfg.DiscoveryMode
+	srv.StaticNodesFile = cfg.StaticNodesFile
 	srv.NodeDatabase = filepath.Join(cfg.DataDir, config.DefaultNodeDatabaseDir)
 	srv.sync = &data_sync.Service{}
 	srv.NoDial = false
@@ -216,39 +231,10 @@ func (srv *Service) Start() bool {
 	srv.peerOpDone = make(chan struct{})
 	srv.Protocols = append(srv.Protocols, srv.sync.Protocols()...)
 
-	var (
-		conn      *net.UDPConn
-		realaddr  *net.UDPAddr
-		unhandled chan discover.ReadPacket
-	)
-
-	if !srv.NoDiscovery {
-		addr, err := net.ResolveUDPAddr("udp", srv.ListenAddr)
-		if err != nil {
-			log.Error("discover resolve udp failed", "err", err)
-			return false
-		}
-		conn, err = net.ListenUDP("udp", addr)
-		if err != nil {
-			log.Error("discover net listen udp failed", "err", err)
-			return false
-		}
-		realaddr = conn.LocalAddr().(*net.UDPAddr)
-
-		cfg := discover.Config{
-			PrivateKey:   srv.PrivateKey,
-			AnnounceAddr: realaddr,
-			NodeDBPath:   srv.NodeDatabase,
-			NetRestrict:  srv.NetRestrict,
-			Bootnodes:    srv.BootstrapNodes,
-			Unhandled:    unhandled,
-		}
-		ntab, err := discover.ListenUDP(conn, cfg)
-		if err != nil {
-			log.Error("discover listen udp failed", "err", err)
-			return false
-		}
-		srv.ntab = ntab
+	if err := srv.setupDiscovery(); err != nil {
+		log.Error("Failed to set up discovery", "mode", srv.DiscoveryMode, "err", err)
+		srv.running = false
+		return false
 	}
 
 	for _, n := range srv.BootstrapNodes {
@@ -260,12 +246,16 @@ func (srv *Service) Start() bool {
 	// listen/dial
 	if srv.ListenAddr != "" {
 		if err := srv.startListening(); err != nil {
+			srv.running = false
 			return false
 		}
 	}
 
 	// handshake
 	srv.ourHandshake = &message.ProtoHandshake{Version: peer.BaseProtocolVersion, Name: srv.Name, ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
+	if srv.listener != nil {
+		srv.ourHandshake.ListenPort = uint64(srv.listener.Addr().(*net.TCPAddr).Port)
+	}
 	for _, p := range srv.Protocols {
 		srv.ourHandshake.Caps = append(srv.ourHandshake.Caps, p.Cap())
 	}
@@ -283,13 +273,17 @@ func (srv *Service) Start() bool {
 
 func (srv *Service) Stop() {
 	log.Info("Stop p2p service ...")
-	srv.sync.Stop()
 	srv.lock.Lock()
-	defer srv.lock.Unlock()
 	if !srv.running {
+		srv.lock.Unlock()
 		return
 	}
 	srv.running = false
+	srv.lock.Unlock()
+
+	// The sync protocols are started last, so they are stopped only for a
+	// started server. Their peers leave through the run loop, still running.
+	srv.sync.Stop()
 	if srv.listener != nil {
 		// this unblocks listener Accept
 		srv.listener.Close()
@@ -298,6 +292,11 @@ func (srv *Service) Stop() {
 	srv.loopWG.Wait()
 }
 
+// LightClient returns the light protocol client when running in light mode.
+func (srv *Service) LightClient() *light.Client {
+	return srv.sync.LightClient
+}
+
 func (srv *Service) startListening() error {
 	// Launch the TCP listener.
 	log.Info("Start tcp listenner", "srv.ListenAddr", srv.ListenAddr)
@@ -376,7 +375,7 @@ func (srv *Service) Self() *discover.Node {
 	return srv.makeSelf(srv.listener, srv.ntab)
 }
 
-func (srv *Service) makeSelf(listener net.Listener, ntab discoverTable) *discover.Node {
+func (srv *Service) makeSelf(listener net.Listener, ntab Discovery) *discover.Node {
 	// If the server's not running, return an empty node.
 	// If the node is running but discovery is off, manually assemble the node infos.
 	if ntab == nil {
@@ -392,8 +391,11 @@ func (srv *Service) makeSelf(listener net.Listener, ntab discoverTable) *discove
 			TCP: uint16(addr.Port),
 		}
 	}
-	// Otherwise return the discovery node.
-	return ntab.Self()
+	// Otherwise return the discovery node, if it knows its endpoint.
+	if self := ntab.Self(); !self.Incomplete() {
+		return self
+	}
+	return srv.makeSelf(listener, nil)
 }
 
 // SetupConn runs the handshakes and attempts to add the connection
@@ -444,7 +446,7 @@ func (srv *Service) setupConn(c *peer.Conn, flags peer.ConnFlag, dialDest *disco
 		clog.Trace("Wrong devp2p handshake identity", "err", phs.ID.String(), "c.ID", c.ID.String())
 		return peer_error.DiscUnexpectedIdentity
 	}
-	c.Caps, c.Name = phs.Caps, phs.Name
+	c.Caps, c.Name, c.ListenPort = phs.Caps, phs.Name, uint16(phs.ListenPort)
 	err = srv.checkpoint(c, srv.addpeer)
 	if err != nil {
 		clog.Trace("Rejected peer", "err", err)
@@ -658,6 +660,13 @@ running:
 				if srv.EnableMsgEvents {
 					p.SetEvents(&srv.peerFeed)
 				}
+				// Peer exchanging discoveries learn about and from every peer
+				if exchange, ok := srv.ntab.(peer.NodeExchange); ok {
+					p.SetExchange(exchange)
+					if n := connNode(c); n != nil {
+						exchange.AddNodes(c.ID, []*discover.Node{n})
+					}
+				}
 				name := truncateName(c.Name)
 				srv.log.Debug("Adding p2p peer", "name", name, "addr", c.FD.RemoteAddr(), "peers", len(peers)+1)
 				go srv.runPeer(p)
@@ -36,6 +36,8 @@ type Conn struct {
 	ID    discover.NodeID // valid after the encryption handshake
 	Caps  []message.Cap   // valid after the protocol handshake
 	Name  string          // valid after the protocol handshake
+
+	ListenPort uint16 // TCP port advertised in the protocol handshake
 }
 
 func NewConn(fd net.Conn, transporter func(net.Conn) transport.Transport, flags ConnFlag, cont chan error) *Conn {
@@ -0,0 +1,66 @@
+package peer
+
+import (
+	"github.com/mihongtech/linkchain-core/node/net/p2p/discover"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/message"
+	"github.com/mihongtech/linkchain-core/protobuf"
+)
+
+// MaxExchangedPeers is the maximum number of nodes in a peer exchange reply.
+const MaxExchangedPeers = 16
+
+// NodeExchange is the node set swapped with the connected peers through the
+// base protocol's GetPeersMsg and PeersMsg.
+type NodeExchange interface {
+	// Nodes returns at most max known nodes to offer to the given peer.
+	Nodes(peer discover.NodeID, max int) []*discover.Node
+	// AddNodes learns the nodes offered by the given peer.
+	AddNodes(peer discover.NodeID, nodes []*discover.Node)
+}
+
+// SetExchange enables the peer exchange messages. It must be called before
+// the peer is run.
+func (p *Peer) SetExchange(exchange NodeExchange) {
+	p.exchange = exchange
+}
+
+// RequestPeers asks the remote peer for the nodes it knows about.
+func (p *Peer) RequestPeers() error {
+	return message.Send(p.RW, message.GetPeersMsg, nil)
+}
+
+// sendPeers answers a peer exchange request, with no nodes if exchange is off.
+func (p *Peer) sendPeers() error {
+	var nodes []*protobuf.Node
+	if p.exchange != nil {
+		for _, n := range p.exchange.Nodes(p.ID(), MaxExchangedPeers) {
+			nodes = append(nodes, n.Serialize().(*protobuf.Node))
+		}
+	}
+	return message.Send(p.RW, message.PeersMsg, &protobuf.Peers{Nodes: nodes})
+}
+
+// handlePeers decodes a peer exchange reply and passes its nodes on.
+func (p *Peer) handlePeers(msg message.Msg) error {
+	var data protobuf.Peers
+	if err := msg.Decode(&data); err != nil {
+		return err
+	}
+	if p.exchange == nil {
+		return nil
+	}
+	if len(data.Nodes) > MaxExchangedPeers {
+		data.Nodes = data.Nodes[:MaxExchangedPeers]
+	}
+	nodes := make([]*discover.Node, 0, len(data.Nodes))
+	for _, pb := range data.Nodes {
+		n := new(discover.Node)
+		if err := n.Deserialize(pb); err != nil {
+			p.log.Trace("Invalid exchanged node", "err", err)
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	p.exchange.AddNodes(p.ID(), nodes)
+	return nil
+}
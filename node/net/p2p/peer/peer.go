@@ -44,6 +44,9 @@ type Peer struct {
 
 	// events receives message send / receive events if set
 	events *event.Feed
+
+	// exchange serves and learns nodes through the peer exchange messages if set
+	exchange NodeExchange
 }
 
 // NewPeer returns a peer for testing purposes.
@@ -218,6 +221,11 @@ func (p *Peer) handle(msg message.Msg) error {
 	case msg.Code == message.PingMsg:
 		msg.Discard()
 		go message.SendItems(p.RW, message.PongMsg, nil)
+	case msg.Code == message.GetPeersMsg:
+		msg.Discard()
+		go p.sendPeers()
+	case msg.Code == message.PeersMsg:
+		return p.handlePeers(msg)
 	case msg.Code == message.DiscMsg:
 
 		var reason [1]peer_error.DiscReason
@@ -14,6 +14,7 @@ import (
 	"github.com/mihongtech/linkchain-core/node/net/p2p/peer_error"
 	"github.com/mihongtech/linkchain-core/node/net/p2p/proto/example"
 	"github.com/mihongtech/linkchain-core/node/net/p2p/transport"
+	"github.com/mihongtech/linkchain-core/protobuf"
 )
 
 var discard = Protocol{
@@ -344,3 +345,55 @@ func randomID() (id discover.NodeID) {
 	}
 	return id
 }
+
+// testExchange offers a fixed node and records the nodes it learns.
+type testExchange struct {
+	offer   *discover.Node
+	learned chan []*discover.Node
+}
+
+func (ex *testExchange) Nodes(id discover.NodeID, max int) []*discover.Node {
+	return []*discover.Node{ex.offer}
+}
+
+func (ex *testExchange) AddNodes(id discover.NodeID, nodes []*discover.Node) {
+	ex.learned <- nodes
+}
+
+func TestPeerExchange(t *testing.T) {
+	fd1, fd2 := net.Pipe()
+	c1 := &Conn{FD: fd1, Transport: newTestTransport(randomID(), fd1)}
+	c2 := &Conn{FD: fd2, Transport: newTestTransport(randomID(), fd2)}
+	defer c2.Close(errors.New("test done"))
+
+	ex := &testExchange{
+		offer:   discover.NewNode(randomID(), net.IP{10, 0, 0, 1}, 30303, 30303),
+		learned: make(chan []*discover.Node, 1),
+	}
+	peer := NewPeer(c1, nil)
+	peer.SetExchange(ex)
+	go peer.Run()
+
+	// Requests are answered with the offered nodes
+	if err := message.Send(c2, message.GetPeersMsg, nil); err != nil {
+		t.Fatal(err)
+	}
+	offer := &protobuf.Peers{Nodes: []*protobuf.Node{ex.offer.Serialize().(*protobuf.Node)}}
+	if err := message.ExpectMsg(c2, message.PeersMsg, offer); err != nil {
+		t.Fatal(err)
+	}
+	// Replies are handed to the exchange
+	remote := discover.NewNode(randomID(), net.IP{10, 0, 0, 2}, 30304, 30304)
+	reply := &protobuf.Peers{Nodes: []*protobuf.Node{remote.Serialize().(*protobuf.Node)}}
+	if err := message.Send(c2, message.PeersMsg, reply); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case nodes := <-ex.learned:
+		if len(nodes) != 1 || nodes[0].ID != remote.ID || nodes[0].TCP != remote.TCP {
+			t.Fatalf("learned nodes mismatch: %v", nodes)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("exchanged nodes not learned")
+	}
+}
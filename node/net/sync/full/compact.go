@@ -0,0 +1,184 @@
+package full
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/core/meta"
+)
+
+const (
+	recentBlocksLimit  = 32              // Number of relayed blocks kept to answer transaction requests
+	maxPendingCompacts = 16              // Maximum compact blocks waiting for missing transactions
+	compactTimeout     = 5 * time.Second // Time allowance for missing transactions before fetching the full block
+)
+
+// pendingCompact is a compact block waiting for the transactions missing from
+// the local pool.
+type pendingCompact struct {
+	peer    string
+	block   *meta.Block
+	missing []uint32
+	timer   *time.Timer
+}
+
+// shortTxID derives the 48 bit short id of a transaction within a block. The
+// block hash salts the id so colliding transactions can't be crafted once for
+// every block.
+func shortTxID(block meta.BlockID, txid meta.TxID) uint64 {
+	hash := math.HashB(append(block.CloneBytes(), txid.CloneBytes()...))
+	return binary.BigEndian.Uint64(hash[:8]) >> 16
+}
+
+// newCompactBlock strips a block down to its header and short transaction ids.
+func newCompactBlock(block *meta.Block) *compactBlock {
+	hash := *block.GetBlockID()
+	ids := make([]uint64, len(block.TXs.Txs))
+	for i, tx := range block.TXs.Txs {
+		ids[i] = shortTxID(hash, *tx.GetTxID())
+	}
+	return &compactBlock{Header: block.Header, ShortIDs: ids}
+}
+
+// reconstructBlock rebuilds a compact block from the transaction pool,
+// returning the positions of the transactions it could not fill in. Short ids
+// shared by several pooled transactions are treated as missing.
+func (pm *ProtocolManager) reconstructBlock(compact *compactBlock) (*meta.Block, []uint32) {
+	hash := *compact.Header.GetBlockID()
+
+	pooled := make(map[uint64]meta.Transaction)
+	collided := make(map[uint64]bool)
+	if pm.txPool != nil {
+		for _, tx := range pm.txPool.GetAllTransaction() {
+			id := shortTxID(hash, *tx.GetTxID())
+			if _, ok := pooled[id]; ok {
+				collided[id] = true
+			}
+			pooled[id] = tx
+		}
+	}
+	block := &meta.Block{Header: compact.Header}
+	block.TXs.Txs = make([]meta.Transaction, len(compact.ShortIDs))
+
+	var missing []uint32
+	for i, id := range compact.ShortIDs {
+		if tx, ok := pooled[id]; ok && !collided[id] {
+			block.TXs.Txs[i] = tx
+		} else {
+			missing = append(missing, uint32(i))
+		}
+	}
+	return block, missing
+}
+
+// handleCompactBlock rebuilds an announced compact block, asking the peer for
+// the transactions not found in the pool.
+func (pm *ProtocolManager) handleCompactBlock(p *peer, compact *compactBlock) {
+	hash := *compact.Header.GetBlockID()
+	p.MarkBlock(hash)
+	if pm.chain.HasBlock(hash) {
+		return
+	}
+	block, missing := pm.reconstructBlock(compact)
+	if len(missing) == 0 {
+		pm.completeCompact(p, block)
+		return
+	}
+	pm.compactLock.Lock()
+	if _, ok := pm.compacts[hash]; ok {
+		// Already being reconstructed from another peer
+		pm.compactLock.Unlock()
+		return
+	}
+	if len(pm.compacts) >= maxPendingCompacts {
+		pm.compactLock.Unlock()
+		log.Debug("Too many pending compact blocks, fetching full block", "hash", hash)
+		p.RequestOneBlock(hash)
+		return
+	}
+	pm.compacts[hash] = &pendingCompact{
+		peer:    p.id,
+		block:   block,
+		missing: missing,
+		timer:   time.AfterFunc(compactTimeout, func() { pm.expireCompact(hash) }),
+	}
+	pm.compactLock.Unlock()
+
+	log.Debug("Fetching missing compact block transactions", "hash", hash, "missing", len(missing), "txs", len(compact.ShortIDs))
+	p.RequestBlockTxn(hash, missing)
+}
+
+// handleBlockTxn fills the missing transactions into a pending compact block.
+func (pm *ProtocolManager) handleBlockTxn(p *peer, data *blockTxnData) {
+	pm.compactLock.Lock()
+	pending, ok := pm.compacts[data.Hash]
+	if !ok || pending.peer != p.id {
+		pm.compactLock.Unlock()
+		return
+	}
+	delete(pm.compacts, data.Hash)
+	pm.compactLock.Unlock()
+	pending.timer.Stop()
+
+	if len(data.Txs) != len(pending.missing) {
+		log.Debug("Incomplete compact block transactions, fetching full block", "hash", data.Hash)
+		p.RequestOneBlock(data.Hash)
+		return
+	}
+	for i, index := range pending.missing {
+		pending.block.TXs.Txs[index] = data.Txs[i]
+	}
+	pm.completeCompact(p, pending.block)
+}
+
+// completeCompact imports a reconstructed block like a propagated one, unless
+// it does not match its header, in which case the full block is fetched.
+func (pm *ProtocolManager) completeCompact(p *peer, block *meta.Block) {
+	root := block.CalculateTxTreeRoot()
+	if !block.GetMerkleRoot().IsEqual(&root) {
+		log.Debug("Compact block reconstruction mismatch, fetching full block", "hash", block.GetBlockID())
+		p.RequestOneBlock(*block.GetBlockID())
+		return
+	}
+	pm.importNewBlock(p, block)
+}
+
+// expireCompact falls back to the full block once a peer failed to deliver the
+// missing transactions of a compact block in time.
+func (pm *ProtocolManager) expireCompact(hash meta.BlockID) {
+	pm.compactLock.Lock()
+	pending, ok := pm.compacts[hash]
+	delete(pm.compacts, hash)
+	pm.compactLock.Unlock()
+
+	if !ok {
+		return
+	}
+	if p := pm.peers.Peer(pending.peer); p != nil {
+		log.Debug("Compact block transactions timed out, fetching full block", "hash", hash)
+		p.RequestOneBlock(hash)
+	}
+}
+
+// getBlockTxn collects the transactions of a recently relayed block at the
+// requested positions. Nothing is returned if any position is unknown.
+func (pm *ProtocolManager) getBlockTxn(query *getBlockTxnData) []meta.Transaction {
+	var block *meta.Block
+	if cached, ok := pm.recentBlocks.Get(query.Hash); ok {
+		block = cached.(*meta.Block)
+	} else if b, err := pm.chain.GetBlockByID(query.Hash); err == nil && b != nil {
+		block = b
+	} else {
+		return nil
+	}
+	txs := make([]meta.Transaction, 0, len(query.Indexes))
+	for _, index := range query.Indexes {
+		if int(index) >= len(block.TXs.Txs) {
+			return nil
+		}
+		txs = append(txs, block.TXs.Txs[index])
+	}
+	return txs
+}
@@ -0,0 +1,76 @@
+package full
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/pool"
+	"github.com/mihongtech/linkchain-core/protobuf"
+)
+
+func makeCompactTestBlock(n int) *meta.Block {
+	block := &meta.Block{Header: meta.BlockHeader{Height: 1, Time: time.Unix(1, 0)}}
+	txs := make([]meta.Transaction, n)
+	for i := range txs {
+		txs[i] = meta.Transaction{Data: []byte{byte(i), 0xc0, 0xde}}
+	}
+	block.SetTx(txs...)
+	return block
+}
+
+func TestCompactBlockEncoding(t *testing.T) {
+	block := makeCompactTestBlock(4)
+	compact := newCompactBlock(block)
+	if len(compact.ShortIDs) != 4 {
+		t.Fatalf("short id count: have %d, want 4", len(compact.ShortIDs))
+	}
+	decoded := &compactBlock{}
+	decoded.Deserialize(compact.Serialize().(*protobuf.CompactBlock))
+
+	if !decoded.Header.GetBlockID().IsEqual(block.GetBlockID()) {
+		t.Fatalf("header mismatch after decoding")
+	}
+	if !reflect.DeepEqual(decoded.ShortIDs, compact.ShortIDs) {
+		t.Fatalf("short ids mismatch: have %v, want %v", decoded.ShortIDs, compact.ShortIDs)
+	}
+	// The same transaction gets another short id in another block
+	other := makeCompactTestBlock(4)
+	other.Header.Height = 2
+	if newCompactBlock(other).ShortIDs[0] == compact.ShortIDs[0] {
+		t.Fatalf("short id not salted with the block hash")
+	}
+}
+
+func TestReconstructBlock(t *testing.T) {
+	block := makeCompactTestBlock(5)
+	txPool := pool.NewTxPool(nil)
+	pm := &ProtocolManager{txPool: txPool}
+
+	// Every transaction but the 2nd and 4th is pooled
+	for i, tx := range block.TXs.Txs {
+		if i != 1 && i != 3 {
+			tx := tx
+			txPool.AddTransaction(&tx)
+		}
+	}
+	txPool.AddTransaction(&meta.Transaction{Data: []byte("unrelated")})
+
+	rebuilt, missing := pm.reconstructBlock(newCompactBlock(block))
+	if !reflect.DeepEqual(missing, []uint32{1, 3}) {
+		t.Fatalf("missing transactions: have %v, want [1 3]", missing)
+	}
+	for _, index := range missing {
+		rebuilt.TXs.Txs[index] = block.TXs.Txs[index]
+	}
+	root := rebuilt.CalculateTxTreeRoot()
+	if !block.GetMerkleRoot().IsEqual(&root) {
+		t.Fatalf("reconstructed block doesn't match its merkle root")
+	}
+	for i := range block.TXs.Txs {
+		if !rebuilt.TXs.Txs[i].GetTxID().IsEqual(block.TXs.Txs[i].GetTxID()) {
+			t.Fatalf("transaction %d out of place", i)
+		}
+	}
+}
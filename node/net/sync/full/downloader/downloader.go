@@ -12,12 +12,16 @@ import (
 	"github.com/mihongtech/linkchain-core/common/util/event"
 	"github.com/mihongtech/linkchain-core/common/util/log"
 	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/bcsi"
 	"github.com/mihongtech/linkchain-core/node/chain"
 )
 
 var (
 	MaxBlockFetch   = 192 // Amount of blocks to be fetched per retrieval request
 	MaxSkeletonSize = 128 // Number of header fetches to need for a skeleton assembly
+	MaxStateFetch   = 384 // Amount of node state values to allow fetching per request
+
+	minStateProtocol = 3 // First full protocol version serving state nodes (full/03)
 
 	rttMinEstimate   = 2 * time.Second  // Minimum round-trip time to target for download requests
 	rttMaxEstimate   = 20 * time.Second // Maximum rount-trip time to target for download requests
@@ -33,6 +37,7 @@ var (
 	maxResultsProcess = 2048 // Number of content download results to import at once into the chain
 
 	fsBlockContCheck = 3 * time.Second
+	fsMinFullBlocks  = 64 // Number of blocks to retrieve fully even in fast sync
 )
 
 var (
@@ -49,13 +54,25 @@ var (
 	errCancelBlockFetch        = errors.New("block download canceled (requested)")
 	errCancelBlockProcessing   = errors.New("block processing canceled (requested)")
 	errCancelContentProcessing = errors.New("content processing canceled (requested)")
+	errCancelStateFetch        = errors.New("state data download canceled (requested)")
 	errNoSyncActive            = errors.New("no sync active")
 	errTooOld                  = errors.New("peer doesn't speak recent enough protocol version")
 )
 
+// FastChain is implemented by chains which can be fast synced. The blocks below
+// the pivot are stored without being processed, and the chain head is moved to
+// the pivot's parent once its state has been imported.
+type FastChain interface {
+	chain.Chain
+	CurrentFastBlock() *meta.Block
+	InsertFastBlocks(blocks []*meta.Block) error
+	CommitFastHead(id meta.BlockID) error
+}
+
 type Downloader struct {
-	mode SyncMode       // Synchronisation mode defining the strategy used (per sync cycle)
-	mux  *event.TypeMux // Event multiplexer to announce sync operation events
+	mode     SyncMode       // Synchronisation mode defining the strategy used (per sync cycle)
+	fastSync bool           // Whether to fast sync when the chain is still empty
+	mux      *event.TypeMux // Event multiplexer to announce sync operation events
 
 	queue *queue   // Scheduler for selecting the hashes to download
 	peers *peerSet // Set of active peers from which download can proceed
@@ -63,7 +80,8 @@ type Downloader struct {
 	rttEstimate   uint64 // Round trip time to target for download requests
 	rttConfidence uint64 // Confidence in the estimated RTT (unit: millionths to allow atomic ops)
 
-	chain chain.Chain
+	chain       chain.Chain
+	stateSyncer bcsi.StateSyncer // App hook receiving the state downloaded by fast sync
 
 	// Callbacks
 	dropPeer peerDropFn // Drops a peer for misbehaving
@@ -72,10 +90,12 @@ type Downloader struct {
 	synchronising int32
 	notified      int32
 	committed     int32
+	fastSyncing   int32 // Set while a fast sync cycle imports the blocks itself
 
 	// Channels
 	blockCh     chan dataPack      // [full/62] Channel receiving inbound block headers
 	blockProcCh chan []*meta.Block // [full/62] Channel to feed the header processor new tasks
+	stateCh     chan dataPack      // Channel receiving inbound node state data
 
 	// Cancellation and termination
 	cancelPeer string        // Identifier of the peer currently being used as the master (cancel on drop)
@@ -87,19 +107,32 @@ type Downloader struct {
 }
 
 // New creates a new downloader to fetch hashes and blocks from remote peers.
-func New(mux *event.TypeMux, chain chain.Chain, dropPeer peerDropFn) *Downloader {
-
+// Fast sync is only used if the chain supports it and the app provides a
+// state syncer, otherwise the downloader falls back to full sync.
+func New(mode SyncMode, mux *event.TypeMux, chain chain.Chain, stateSyncer bcsi.StateSyncer, dropPeer peerDropFn) *Downloader {
+	fastSync := false
+	if mode == FastSync {
+		if _, ok := chain.(FastChain); ok && stateSyncer != nil {
+			fastSync = true
+		} else {
+			log.Warn("Fast sync not supported by the chain or app, using full sync")
+		}
+	}
 	dl := &Downloader{
 		mode:          FullSync,
+		fastSync:      fastSync,
 		mux:           mux,
 		queue:         newQueue(),
 		peers:         newPeerSet(),
 		rttEstimate:   uint64(rttMaxEstimate),
 		rttConfidence: uint64(1000000),
 		chain:         chain,
+		stateSyncer:   stateSyncer,
+		committed:     1,
 		dropPeer:      dropPeer,
 		blockCh:       make(chan dataPack, 1),
 		blockProcCh:   make(chan []*meta.Block, 1),
+		stateCh:       make(chan dataPack, 1),
 		quitCh:        make(chan struct{}),
 	}
 	go dl.qosTuner()
@@ -162,6 +195,9 @@ func (d *Downloader) Synchronise(id string, head meta.BlockID) error {
 	case nil:
 	case errBusy:
 
+	case bcsi.ErrAppUnavailable:
+		log.Warn("Synchronisation paused, app unavailable", "peer", id)
+
 	case errTimeout, errBadPeer, errStallingPeer,
 		errEmptyBlockSet, errPeersUnavailable, errTooOld,
 		errInvalidAncestor, errInvalidChain:
@@ -197,7 +233,7 @@ func (d *Downloader) synchronise(id string, hash meta.BlockID) error {
 	d.queue.Reset()
 	d.peers.Reset()
 
-	for _, ch := range []chan dataPack{d.blockCh} {
+	for _, ch := range []chan dataPack{d.blockCh, d.stateCh} {
 		for empty := false; !empty; {
 			select {
 			case <-ch:
@@ -213,6 +249,11 @@ func (d *Downloader) synchronise(id string, hash meta.BlockID) error {
 			empty = true
 		}
 	}
+	// Fast sync only pays off when starting out with an empty chain
+	d.mode = FullSync
+	if d.fastSync && d.chain.GetBestBlock().GetHeight() == 0 {
+		d.mode = FastSync
+	}
 	// Create cancel channel for aborting mid-flight and mark the master peer
 	d.cancelLock.Lock()
 	d.cancelCh = make(chan struct{})
@@ -263,9 +304,25 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash meta.BlockID) (err err
 		return err
 	}
 
-	d.committed = 1
-	d.queue.Prepare(origin+1, d.mode)
+	atomic.StoreInt32(&d.committed, 1)
 	pivot := uint64(0)
+	if d.mode == FastSync {
+		// Import the state of a recent pivot block instead of processing all
+		// blocks below it. The parent of the pivot must be a fast block.
+		pivot = origin + 1
+		if height > uint64(fsMinFullBlocks) && height-uint64(fsMinFullBlocks) > pivot {
+			pivot = height - uint64(fsMinFullBlocks)
+		}
+		if pivot-1 <= uint64(d.chain.GetBestBlock().GetHeight()) || pivot > height {
+			d.mode, pivot = FullSync, 0
+		} else {
+			log.Info("Fast syncing to pivot", "pivot", pivot, "height", height)
+			atomic.StoreInt32(&d.committed, 0)
+			atomic.StoreInt32(&d.fastSyncing, 1)
+			defer atomic.StoreInt32(&d.fastSyncing, 0)
+		}
+	}
+	d.queue.Prepare(origin+1, d.mode)
 	fetchers := []func() error{
 		func() error { return d.fetchBlocks(p, origin+1, pivot) },
 		func() error { return d.processBlocks(origin+1, pivot) },
@@ -382,8 +439,11 @@ func (d *Downloader) fetchHeight(p *peerConnection) (*meta.Block, error) {
 func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, error) {
 	var ceil uint64
 	floor := int64(-1)
-	if d.mode == FullSync {
+	switch d.mode {
+	case FullSync:
 		ceil = uint64(d.chain.GetBestBlock().GetHeight())
+	case FastSync:
+		ceil = uint64(d.chain.(FastChain).CurrentFastBlock().GetHeight())
 	}
 
 	p.log.Debug("Looking for common ancestor", "local", ceil, "remote", height)
@@ -445,7 +505,7 @@ func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, err
 					continue
 				}
 				// Otherwise check if we already know the header or not
-				if d.mode == FullSync && d.chain.HasBlock(*blocks[i].GetBlockID()) {
+				if d.mode != LightSync && d.chain.HasBlock(*blocks[i].GetBlockID()) {
 					number, hash = uint64(blocks[i].GetHeight()), *blocks[i].GetBlockID()
 
 					// If every header is known, even future ones, the peer straight out lied about its head
@@ -504,7 +564,7 @@ func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, err
 				arrived = true
 
 				// Modify the search interval based on the response
-				if d.mode == FullSync && !d.chain.HasBlock(*blocks[0].GetBlockID()) {
+				if d.mode != LightSync && !d.chain.HasBlock(*blocks[0].GetBlockID()) {
 					end = check
 					break
 				}
@@ -880,7 +940,7 @@ func (d *Downloader) processBlocks(origin uint64, pivot uint64) error {
 				chunk := blocks[:limit]
 
 				// Unless we're doing light chains, schedule the headers for associated content retrieval
-				if d.mode == FullSync {
+				if d.mode != LightSync {
 					// Otherwise insert the headers for content retrieval
 					inserts := d.queue.Schedule(chunk, origin)
 					if len(inserts) != len(chunk) {
@@ -888,6 +948,12 @@ func (d *Downloader) processBlocks(origin uint64, pivot uint64) error {
 						return errBadPeer
 					}
 				}
+				// In fast sync the blocks are imported in order right here
+				if d.mode == FastSync {
+					if err := d.importFastBlocks(chunk, pivot); err != nil {
+						return err
+					}
+				}
 				blocks = blocks[limit:]
 				origin += uint64(limit)
 			}
@@ -911,6 +977,10 @@ func (d *Downloader) processFullSyncContent() error {
 }
 
 func (d *Downloader) ImportBlocks(id string, blocks []*meta.Block) error {
+	// Blocks of a fast sync cycle are imported by the cycle itself
+	if atomic.LoadInt32(&d.fastSyncing) == 1 {
+		return nil
+	}
 	var results []*fetchResult
 	for _, block := range blocks {
 		results = append(results, &fetchResult{Hash: *block.GetBlockID(), Block: block})
@@ -947,6 +1017,11 @@ func (d *Downloader) importBlockResults(results []*fetchResult) error {
 		}
 
 		if err := d.chain.ProcessBlock(result.Block); err != nil {
+			if err == bcsi.ErrAppUnavailable {
+				// Not the peer's fault, the import waits for the app to come back
+				log.Warn("App unavailable, pausing block import", "number", result.Block.GetHeight())
+				return err
+			}
 			log.Error("Downloaded item processing failed", "number", result.Block.GetHeight(), "hash", result.Block.GetBlockID(), "err", err)
 			return errInvalidChain
 		}
@@ -975,6 +1050,11 @@ func (d *Downloader) DeliverBlocks(id string, blocks []*meta.Block) (err error)
 	return d.deliver(id, d.blockCh, &blockPack{id, blocks})
 }
 
+// DeliverNodeData injects a new batch of node state data received from a remote node.
+func (d *Downloader) DeliverNodeData(id string, data [][]byte) (err error) {
+	return d.deliver(id, d.stateCh, &statePack{id, data})
+}
+
 // deliver injects a new batch of data received from a remote node.
 func (d *Downloader) deliver(id string, destCh chan dataPack, packet dataPack) (err error) {
 	// Deliver or abort if the sync is canceled while queuing
@@ -0,0 +1,36 @@
+package downloader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/util/event"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/bcsi"
+	"github.com/mihongtech/linkchain-core/node/chain"
+)
+
+// unavailableChain is a chain whose app can't be reached, knowing only the
+// parent of the imported blocks.
+type unavailableChain struct {
+	chain.Chain
+	parent meta.BlockID
+}
+
+func (c *unavailableChain) HasBlock(hash meta.BlockID) bool      { return hash == c.parent }
+func (c *unavailableChain) ProcessBlock(block *meta.Block) error { return bcsi.ErrAppUnavailable }
+
+// Tests that blocks which can't be imported for want of the app don't make
+// the chain of the peer invalid.
+func TestImportAppUnavailable(t *testing.T) {
+	parent := &meta.Block{Header: meta.BlockHeader{Time: time.Unix(1, 0)}}
+	block := &meta.Block{Header: meta.BlockHeader{Height: 1, Time: time.Unix(2, 0), Prev: *parent.GetBlockID()}}
+
+	dl := New(FullSync, new(event.TypeMux), &unavailableChain{parent: *parent.GetBlockID()}, nil, nil)
+	defer dl.Terminate()
+
+	results := []*fetchResult{{Hash: *block.GetBlockID(), Block: block}}
+	if err := dl.importBlockResults(results); err != bcsi.ErrAppUnavailable {
+		t.Fatalf("import without app: have %v, want %v", err, bcsi.ErrAppUnavailable)
+	}
+}
@@ -24,6 +24,7 @@ var (
 	errAlreadyFetching   = errors.New("already fetching blocks from peer")
 	errAlreadyRegistered = errors.New("peer is already registered")
 	errNotRegistered     = errors.New("peer is not registered")
+	errNoStateSync       = errors.New("state sync not supported by light peers")
 )
 
 // peerConnection represents an active peer from which hashes and blocks are retrieved.
@@ -57,6 +58,7 @@ type LightPeer interface {
 // Peer encapsulates the methods required to synchronise with a remote full peer.
 type Peer interface {
 	LightPeer
+	RequestNodeData([]meta.TreeID) error
 }
 
 // lightPeerWrapper wraps a LightPeer struct, stubbing out the Peer-only methods.
@@ -71,6 +73,9 @@ func (w *lightPeerWrapper) RequestBlocksByHash(h meta.BlockID, amount int, skip
 func (w *lightPeerWrapper) RequestBlocksByNumber(i uint64, amount int, skip int) error {
 	return w.peer.RequestBlocksByNumber(i, amount, skip)
 }
+func (w *lightPeerWrapper) RequestNodeData([]meta.TreeID) error {
+	return errNoStateSync
+}
 
 // newPeerConnection creates a new downloader peer.
 func newPeerConnection(id string, version int, peer Peer, logger log.Logger) *peerConnection {
@@ -297,6 +302,21 @@ func (ps *peerSet) AllPeers() []*peerConnection {
 	return list
 }
 
+// StatePeers retrieves a flat list of the peers within the set speaking a
+// protocol version which serves state nodes.
+func (ps *peerSet) StatePeers() []*peerConnection {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*peerConnection, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		if p.version >= minStateProtocol {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
 // HeaderIdlePeers retrieves a flat list of all the currently header-idle peers
 // within the active peer set, ordered by their reputation.
 func (ps *peerSet) BlockIdlePeers() ([]*peerConnection, int) {
@@ -0,0 +1,212 @@
+package downloader
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/common/trie"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/bcsi"
+)
+
+// stateReader lets the trie scheduler skip the state nodes the app already has.
+type stateReader struct {
+	syncer bcsi.StateSyncer
+}
+
+func (r stateReader) Get(key []byte) ([]byte, error) {
+	return r.syncer.GetStateNode(math.BytesToHash(key))
+}
+
+func (r stateReader) Has(key []byte) (bool, error) {
+	data, err := r.Get(key)
+	return err == nil && len(data) > 0, nil
+}
+
+// stateWriter hands the state nodes committed by the trie scheduler to the app.
+type stateWriter struct {
+	syncer bcsi.StateSyncer
+}
+
+func (w stateWriter) Put(key []byte, value []byte) error {
+	return w.syncer.PutStateNode(math.BytesToHash(key), value)
+}
+
+// stateReq is a batch of state nodes requested from a single peer.
+type stateReq struct {
+	hashes []math.Hash
+	sent   time.Time
+}
+
+// importFastBlocks writes the blocks below the pivot into the chain without
+// processing them. Once the pivot arrives its state is downloaded and imported,
+// and the pivot and all later blocks are processed as in full sync.
+func (d *Downloader) importFastBlocks(blocks []*meta.Block, pivot uint64) error {
+	split := sort.Search(len(blocks), func(i int) bool {
+		return uint64(blocks[i].GetHeight()) >= pivot
+	})
+	if split > 0 {
+		if err := d.chain.(FastChain).InsertFastBlocks(blocks[:split]); err != nil {
+			log.Error("Fast block insertion failed", "err", err)
+			return errInvalidChain
+		}
+	}
+	if split == len(blocks) {
+		return nil
+	}
+	if atomic.LoadInt32(&d.committed) == 0 {
+		if err := d.commitPivot(blocks[split]); err != nil {
+			return err
+		}
+	}
+	var results []*fetchResult
+	for _, block := range blocks[split:] {
+		results = append(results, &fetchResult{Hash: *block.GetBlockID(), Block: block})
+	}
+	return d.importBlockResults(results)
+}
+
+// commitPivot downloads the state the pivot block is built upon, hands it to
+// the app and moves the chain head to the pivot's parent.
+func (d *Downloader) commitPivot(pivot *meta.Block) error {
+	// The status of a block is the app state after its parent
+	root := *pivot.GetStatus()
+	parent := *pivot.GetPrevBlockID()
+
+	if err := d.syncState(root); err != nil {
+		return err
+	}
+	if err := d.stateSyncer.ImportState(parent, root); err != nil {
+		log.Error("App failed to import state", "root", root, "err", err)
+		return err
+	}
+	if err := d.chain.(FastChain).CommitFastHead(parent); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&d.committed, 1)
+	return nil
+}
+
+// syncState downloads the state trie rooted at root from all available peers,
+// committing the completed nodes to the app as it goes.
+func (d *Downloader) syncState(root meta.TreeID) error {
+	if root.IsEmpty() {
+		return nil
+	}
+	log.Info("Starting state sync", "root", root)
+
+	var (
+		sched  = trie.NewTrieSync(root, stateReader{d.stateSyncer}, nil)
+		writer = stateWriter{d.stateSyncer}
+		active = make(map[string]*stateReq) // In-flight requests by peer id
+		failed = make(map[string]bool)      // Peers which failed to deliver
+		retry  []math.Hash                  // Hashes to request again
+		nodes  int
+
+		reqFail = make(chan string)   // Peers whose request could not be sent
+		done    = make(chan struct{}) // Stops reporting the failed requests
+	)
+	defer close(done)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for sched.Pending() > 0 {
+		// Assign the missing nodes to the idle peers
+		for _, p := range d.peers.StatePeers() {
+			if active[p.id] != nil || failed[p.id] {
+				continue
+			}
+			hashes := retry
+			if len(hashes) > MaxStateFetch {
+				hashes, retry = retry[:MaxStateFetch], retry[MaxStateFetch:]
+			} else {
+				retry = nil
+			}
+			if len(hashes) < MaxStateFetch {
+				hashes = append(hashes, sched.Missing(MaxStateFetch-len(hashes))...)
+			}
+			if len(hashes) == 0 {
+				break
+			}
+			active[p.id] = &stateReq{hashes: hashes, sent: time.Now()}
+			go func(p *peerConnection, hashes []math.Hash) {
+				if err := p.peer.RequestNodeData(hashes); err != nil {
+					p.log.Debug("State request failed", "err", err)
+					select {
+					case reqFail <- p.id:
+					case <-done:
+					}
+				}
+			}(p, hashes)
+		}
+		if len(active) == 0 {
+			return errPeersUnavailable
+		}
+
+		select {
+		case <-d.cancelCh:
+			return errCancelStateFetch
+
+		case packet := <-d.stateCh:
+			req := active[packet.PeerId()]
+			if req == nil {
+				// Stale delivery of a timed out request
+				break
+			}
+			delete(active, packet.PeerId())
+
+			// Only accept the requested nodes, their hash proves their content
+			wanted := make(map[math.Hash]bool, len(req.hashes))
+			for _, hash := range req.hashes {
+				wanted[hash] = true
+			}
+			var results []trie.SyncResult
+			for _, data := range packet.(*statePack).states {
+				hash := math.HashH(data)
+				if !wanted[hash] {
+					continue
+				}
+				delete(wanted, hash)
+				results = append(results, trie.SyncResult{Hash: hash, Data: data})
+			}
+			if len(results) == 0 {
+				failed[packet.PeerId()] = true
+			}
+			for hash := range wanted {
+				retry = append(retry, hash)
+			}
+			if _, index, err := sched.Process(results); err != nil {
+				log.Warn("State node processing failed", "peer", packet.PeerId(), "index", index, "err", err)
+				return errInvalidChain
+			}
+			written, err := sched.Commit(writer)
+			if err != nil {
+				return err
+			}
+			nodes += written
+
+		case id := <-reqFail:
+			if req := active[id]; req != nil {
+				delete(active, id)
+				failed[id] = true
+				retry = append(retry, req.hashes...)
+			}
+
+		case <-ticker.C:
+			ttl := d.requestTTL()
+			for id, req := range active {
+				if time.Since(req.sent) > ttl {
+					log.Trace("State request timed out", "peer", id, "count", len(req.hashes))
+					delete(active, id)
+					failed[id] = true
+					retry = append(retry, req.hashes...)
+				}
+			}
+		}
+	}
+	log.Info("State sync completed", "root", root, "nodes", nodes)
+	return nil
+}
@@ -0,0 +1,154 @@
+package downloader
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/common/trie"
+	"github.com/mihongtech/linkchain-core/common/util/event"
+	"github.com/mihongtech/linkchain-core/core/meta"
+)
+
+// testStateSyncer keeps the app state trie nodes in a database.
+type testStateSyncer struct {
+	db lcdb.Database
+}
+
+func (s *testStateSyncer) GetStateNode(hash math.Hash) ([]byte, error) {
+	return s.db.Get(hash.Bytes())
+}
+
+func (s *testStateSyncer) PutStateNode(hash math.Hash, data []byte) error {
+	return s.db.Put(hash.Bytes(), data)
+}
+
+func (s *testStateSyncer) ImportState(id meta.BlockID, root meta.TreeID) error {
+	return nil
+}
+
+// testStatePeer serves state nodes from a trie database, dropping every
+// skip-th requested node to force retries.
+type testStatePeer struct {
+	id     string
+	dl     *Downloader
+	triedb *trie.Database
+	skip   int
+}
+
+func (p *testStatePeer) Head() (meta.BlockID, uint64)                     { return meta.BlockID{}, 0 }
+func (p *testStatePeer) RequestBlocksByHash(meta.BlockID, int, int) error { return nil }
+func (p *testStatePeer) RequestBlocksByNumber(uint64, int, int) error     { return nil }
+
+func (p *testStatePeer) RequestNodeData(hashes []meta.TreeID) error {
+	var data [][]byte
+	for i, hash := range hashes {
+		if p.skip > 0 && i%p.skip == 0 {
+			continue
+		}
+		if node, err := p.triedb.Node(hash); err == nil {
+			data = append(data, node)
+		}
+	}
+	return p.dl.DeliverNodeData(p.id, data)
+}
+
+func makeTestState(t *testing.T) (*trie.Database, math.Hash, map[string][]byte) {
+	diskdb, _ := lcdb.NewMemDatabase()
+	triedb := trie.NewDatabase(diskdb)
+	tr, _ := trie.New(math.Hash{}, triedb)
+
+	content := make(map[string][]byte)
+	for i := 0; i < 500; i++ {
+		key := math.HashH([]byte{byte(i), byte(i >> 8)}).Bytes()
+		val := []byte{byte(i), 1, 2, 3}
+		content[string(key)] = val
+		tr.Update(key, val)
+	}
+	root, err := tr.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit state trie: %v", err)
+	}
+	return triedb, root, content
+}
+
+func newTestStateDownloader(syncer *testStateSyncer) *Downloader {
+	dl := New(FullSync, new(event.TypeMux), nil, syncer, nil)
+	dl.cancelCh = make(chan struct{})
+	return dl
+}
+
+func TestSyncState(t *testing.T) {
+	srcdb, root, content := makeTestState(t)
+
+	diskdb, _ := lcdb.NewMemDatabase()
+	syncer := &testStateSyncer{db: diskdb}
+	dl := newTestStateDownloader(syncer)
+	defer dl.Terminate()
+
+	for i, skip := range []int{0, 3} {
+		id := string('a' + rune(i))
+		if err := dl.RegisterPeer(id, minStateProtocol, &testStatePeer{id: id, dl: dl, triedb: srcdb, skip: skip}); err != nil {
+			t.Fatalf("failed to register peer: %v", err)
+		}
+	}
+	if err := dl.syncState(root); err != nil {
+		t.Fatalf("state sync failed: %v", err)
+	}
+	tr, err := trie.New(root, trie.NewDatabase(diskdb))
+	if err != nil {
+		t.Fatalf("synced state missing: %v", err)
+	}
+	for key, val := range content {
+		if have := tr.Get([]byte(key)); !bytes.Equal(have, val) {
+			t.Fatalf("entry %x: have %x, want %x", key, have, val)
+		}
+	}
+}
+
+func TestSyncStateNoPeers(t *testing.T) {
+	_, root, _ := makeTestState(t)
+
+	diskdb, _ := lcdb.NewMemDatabase()
+	dl := newTestStateDownloader(&testStateSyncer{db: diskdb})
+	defer dl.Terminate()
+
+	if err := dl.syncState(root); err != errPeersUnavailable {
+		t.Fatalf("sync without peers: have %v, want %v", err, errPeersUnavailable)
+	}
+	// A peer of a protocol version without state nodes isn't asked
+	srcdb, _, _ := makeTestState(t)
+	old := &testStatePeer{id: "old", dl: dl, triedb: srcdb}
+	dl.RegisterPeer(old.id, minStateProtocol-1, old)
+	if err := dl.syncState(root); err != errPeersUnavailable {
+		t.Fatalf("sync from old peer: have %v, want %v", err, errPeersUnavailable)
+	}
+	// A peer without any state is given up on
+	peer := &testStatePeer{id: "empty", triedb: trie.NewDatabase(diskdb)}
+	peer.dl = dl
+	dl.RegisterPeer(peer.id, minStateProtocol, peer)
+	if err := dl.syncState(root); err != errPeersUnavailable {
+		t.Fatalf("sync from empty peer: have %v, want %v", err, errPeersUnavailable)
+	}
+}
+
+func TestSyncStateLightPeer(t *testing.T) {
+	srcdb, root, _ := makeTestState(t)
+
+	diskdb, _ := lcdb.NewMemDatabase()
+	dl := newTestStateDownloader(&testStateSyncer{db: diskdb})
+	defer dl.Terminate()
+
+	// A light peer can't serve state, it fails without stalling the sync
+	light := &testStatePeer{id: "light", dl: dl, triedb: srcdb}
+	dl.RegisterLightPeer(light.id, minStateProtocol, light)
+	if err := dl.syncState(root); err != errPeersUnavailable {
+		t.Fatalf("sync from light peer: have %v, want %v", err, errPeersUnavailable)
+	}
+	full := &testStatePeer{id: "full", dl: dl, triedb: srcdb}
+	dl.RegisterPeer(full.id, minStateProtocol, full)
+	if err := dl.syncState(root); err != nil {
+		t.Fatalf("sync from light and full peers failed: %v", err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/mihongtech/linkchain-core/common/util/log"
 	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/bcsi"
 	"github.com/mihongtech/linkchain-core/node/chain"
 	node_event "github.com/mihongtech/linkchain-core/node/event"
 	p2p_node "github.com/mihongtech/linkchain-core/node/net/p2p/discover"
@@ -22,6 +23,8 @@ import (
 	"github.com/mihongtech/linkchain-core/node/net/sync/full/fetcher"
 	"github.com/mihongtech/linkchain-core/node/pool"
 	"github.com/mihongtech/linkchain-core/protobuf"
+
+	"github.com/hashicorp/golang-lru"
 )
 
 // errIncompatibleConfig is returned if the requested protocols and configs are
@@ -30,6 +33,8 @@ var errIncompatibleConfig = errors.New("incompatible configuration")
 
 const (
 	txChanSize = 4096
+
+	softResponseLimit = 2 * 1024 * 1024 // Target maximum size of returned node data
 )
 
 type ProtocolManager struct {
@@ -48,8 +53,14 @@ type ProtocolManager struct {
 	txSub         event.Subscription
 	minedBlockSub *event.TypeMuxSubscription
 
-	chain  chain.Chain
-	txPool pool.TxPool
+	chain       chain.Chain
+	txPool      pool.TxPool
+	stateSyncer bcsi.StateSyncer // Serves the app state to fast syncing peers, may be nil
+
+	// compact block relay
+	recentBlocks *lru.Cache                       // Blocks recently relayed, to serve missing transactions
+	compacts     map[meta.BlockID]*pendingCompact // Compact blocks waiting for missing transactions
+	compactLock  sync.Mutex
 
 	// channels for fetcher, syncer, txsyncLoop
 	newPeerCh   chan *peer
@@ -64,7 +75,7 @@ type ProtocolManager struct {
 
 // NewProtocolManager returns a new linkchain sub protocol manager. The Linkchain sub protocol manages peers capable
 // with the linkchain network.
-func NewProtocolManager(chain chain.Chain, txPool pool.TxPool, networkId uint64, mux *event.TypeMux, tx *event.Feed) (*ProtocolManager, error) {
+func NewProtocolManager(mode downloader.SyncMode, chain chain.Chain, txPool pool.TxPool, stateSyncer bcsi.StateSyncer, networkId uint64, mux *event.TypeMux, tx *event.Feed) (*ProtocolManager, error) {
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
 		networkId:   networkId,
@@ -76,10 +87,14 @@ func NewProtocolManager(chain chain.Chain, txPool pool.TxPool, networkId uint64,
 		noMorePeers: make(chan struct{}),
 		chain:       chain,
 		txPool:      txPool,
+		stateSyncer: stateSyncer,
+		compacts:    make(map[meta.BlockID]*pendingCompact),
 		txsyncCh:    make(chan *txsync),
 		quitSync:    make(chan struct{}),
 	}
 
+	manager.recentBlocks, _ = lru.New(recentBlocksLimit)
+
 	// Initiate a sub-protocol for every implemented version we can handle
 	manager.SubProtocols = make([]p2p_peer.Protocol, 0, len(ProtocolVersions))
 	for i, version := range ProtocolVersions {
@@ -115,7 +130,7 @@ func NewProtocolManager(chain chain.Chain, txPool pool.TxPool, networkId uint64,
 		return nil, errIncompatibleConfig
 	}
 
-	manager.downloader = downloader.New(manager.eventMux, manager.chain, manager.removePeer)
+	manager.downloader = downloader.New(mode, manager.eventMux, manager.chain, stateSyncer, manager.dropSyncPeer)
 
 	heighter := func() uint64 {
 		return uint64(manager.chain.GetBestBlock().GetHeight())
@@ -180,13 +195,13 @@ func (pm *ProtocolManager) handle(p *peer) error {
 
 	// Execute the Linkchain handshake
 	var (
-		genesis, _ = pm.chain.GetBlockByHeight(0)
-		current    = pm.chain.GetBestBlock()
-		hash       = *current.GetBlockID()
-		number     = uint64(current.GetHeight())
+		genesis = pm.chain.GetHeaderByHeight(0)
+		current = pm.chain.GetBestBlock()
+		hash    = *current.GetBlockID()
+		number  = uint64(current.GetHeight())
 	)
 	p.Log().Debug("Linkchain handshake data", "genesis", genesis, "number", current.GetHeight(), "current", hash)
-	if err := p.Handshake(pm.networkId, number, hash, *genesis.GetBlockID()); err != nil {
+	if err := p.Handshake(pm.networkId, number, hash, *genesis.GetBlockID(), pm.prunedHeight()); err != nil {
 		p.Log().Debug("Linkchain handshake failed", "err", err)
 		return err
 	}
@@ -252,12 +267,17 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		var (
 			blocks  []*meta.Block
 			unknown bool
+			pruned  = pm.prunedHeight()
 		)
 		for !unknown && len(blocks) < int(data.Amount) && len(blocks) < downloader.MaxBlockFetch {
 			// Retrieve the next header satisfying the query
 			var block *meta.Block
 			var err error
 			if data.Hash.IsEmpty() {
+				// The bodies below the pruned height are gone, as advertised in the handshake and the replies
+				if data.Number < pruned {
+					break
+				}
 				block, err = pm.chain.GetBlockByHeight(uint32(data.Number))
 				log.Debug("get block by height", "number", data.Number, "block", block)
 			} else {
@@ -301,7 +321,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			log.Debug("Receive GetBlockMsg", "query is", data, "index", i, "block", b)
 		}
 
-		p.SendBlock(blocks)
+		p.SendBlock(blocks, pruned)
 
 		return nil
 
@@ -312,6 +332,10 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if err := msg.Decode(&b); err != nil {
 			return errResp(ErrDecode, "%v: %v", msg, err)
 		}
+		// The peer pruned its chain since the handshake, or keeps its pruned height to itself
+		if b.PrunedHeight != nil {
+			p.SetPruned(b.GetPrunedHeight())
+		}
 		for _, prob := range b.Block {
 			data := &meta.Block{}
 			data.Deserialize(prob)
@@ -343,17 +367,68 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		block := &meta.Block{}
 		block.Deserialize(&b)
 
-		// Mark the peer as owning the block and schedule it for import
-		p.MarkBlock(*block.GetBlockID())
-		pm.fetcher.Enqueue(p.id, block)
+		log.Debug("Receive NewBlockMsg", "block is", block)
+		pm.importNewBlock(p, block)
+
+	case msg.Code == CompactBlockMsg:
+		var c protobuf.CompactBlock
+		if err := msg.Decode(&c); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		compact := &compactBlock{}
+		compact.Deserialize(&c)
+		pm.handleCompactBlock(p, compact)
 
+	case msg.Code == GetBlockTxnMsg:
+		var query protobuf.GetBlockTxnData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		data := &getBlockTxnData{}
+		data.Deserialize(&query)
+		return p.SendBlockTxn(data.Hash, pm.getBlockTxn(data))
+
+	case msg.Code == BlockTxnMsg:
+		var t protobuf.BlockTxnData
+		if err := msg.Decode(&t); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		data := &blockTxnData{}
+		data.Deserialize(&t)
+		pm.handleBlockTxn(p, data)
+
+	case msg.Code == GetNodeDataMsg:
+		var query protobuf.GetNodeData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		// Gather state data until the fetch or network limits is reached
 		var (
-			trueHead = *block.GetPrevBlockID()
+			bytes int
+			data  [][]byte
 		)
-		log.Debug("Receive NewBlockMsg", "block is", block)
-		p.SetHead(trueHead, uint64(block.GetHeight()))
+		for _, h := range query.Hashes {
+			if pm.stateSyncer == nil || bytes >= softResponseLimit || len(data) >= downloader.MaxStateFetch {
+				break
+			}
+			hash := meta.TreeID{}
+			hash.Deserialize(h)
+			if entry, err := pm.stateSyncer.GetStateNode(hash); err == nil && len(entry) > 0 {
+				data = append(data, entry)
+				bytes += len(entry)
+			}
+		}
+		return p.SendNodeData(data)
 
-		go pm.synchronise(p)
+	case msg.Code == NodeDataMsg:
+		var data protobuf.NodeData
+		if err := msg.Decode(&data); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		// Deliver all to the downloader
+		if err := pm.downloader.DeliverNodeData(p.id, data.Data); err != nil {
+			log.Debug("Failed to deliver node state data", "err", err)
+		}
 
 	case msg.Code == TxMsg:
 
@@ -379,6 +454,21 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	return nil
 }
 
+// importNewBlock schedules a block propagated by a peer for import and
+// synchronises with the peer if it is ahead.
+func (pm *ProtocolManager) importNewBlock(p *peer, block *meta.Block) {
+	// Mark the peer as owning the block and schedule it for import
+	p.MarkBlock(*block.GetBlockID())
+	pm.fetcher.Enqueue(p.id, block)
+
+	var (
+		trueHead = *block.GetPrevBlockID()
+	)
+	p.SetHead(trueHead, uint64(block.GetHeight()))
+
+	go pm.synchronise(p)
+}
+
 func (pm *ProtocolManager) newPeer(pv int, p *p2p_peer.Peer, rw message.MsgReadWriter) *peer {
 	return newPeer(pv, p, rw)
 }
@@ -402,21 +492,48 @@ func (pm *ProtocolManager) removePeer(id string) {
 	}
 }
 
+// dropSyncPeer removes a peer failing a sync, unless it pruned the blocks
+// missing locally since its handshake: it isn't misbehaving, the next sync
+// picks a peer still serving them.
+func (pm *ProtocolManager) dropSyncPeer(id string) {
+	if p := pm.peers.Peer(id); p != nil && p.Pruned() > pm.syncFrom() {
+		log.Debug("Keeping pruned sync peer", "peer", id, "pruned", p.Pruned(), "from", pm.syncFrom())
+		return
+	}
+	pm.removePeer(id)
+}
+
 // NodeInfo represents a short summary of the Linkchain sub-protocol metadata
 // known about the host peer.
 type NodeInfo struct {
-	Network uint64       `json:"network"` // Linkchain network ID (1=Frontier, 2=Morden, Ropsten=3, Rinkeby=4)
-	Genesis meta.BlockID `json:"genesis"` // hash of the host's genesis block
-	Head    meta.BlockID `json:"head"`    // hash of the host's best owned block
+	Network uint64       `json:"network"`          // Linkchain network ID (1=Frontier, 2=Morden, Ropsten=3, Rinkeby=4)
+	Genesis meta.BlockID `json:"genesis"`          // hash of the host's genesis block
+	Head    meta.BlockID `json:"head"`             // hash of the host's best owned block
+	Pruned  uint64       `json:"pruned,omitempty"` // lowest height of the blocks served by the host
+}
+
+// prunedChain is implemented by the chains deleting old block bodies.
+type prunedChain interface {
+	PrunedHeight() uint64
+}
+
+// prunedHeight returns the lowest height of the blocks served, 0 if every
+// block is.
+func (pm *ProtocolManager) prunedHeight() uint64 {
+	if chain, ok := pm.chain.(prunedChain); ok {
+		return chain.PrunedHeight()
+	}
+	return 0
 }
 
 // NodeInfo retrieves some protocol metadata about the running host node.
 func (pm *ProtocolManager) NodeInfo() *NodeInfo {
-	genesis, _ := pm.chain.GetBlockByHeight(0)
+	genesis := pm.chain.GetHeaderByHeight(0)
 	return &NodeInfo{
 		Network: pm.networkId,
 		Genesis: *genesis.GetBlockID(),
 		Head:    *pm.chain.GetBestBlock().GetBlockID(),
+		Pruned:  pm.prunedHeight(),
 	}
 }
 
@@ -451,12 +568,15 @@ func (pm *ProtocolManager) BroadcastBlock(block *meta.Block, propagate bool) {
 	hash := *block.GetBlockID()
 	peers := pm.peers.PeersWithoutBlock(hash)
 
+	// Keep the block around to serve the transactions missing from compact blocks
+	pm.recentBlocks.Add(hash, block)
+
 	// If propagation is requested, send to a subset of the peer
 	if propagate {
 		// Send the block to a subset of our peers
 		transfer := peers[:int(math.Sqrt(float64(len(peers))))]
 		for _, peer := range transfer {
-			peer.SendNewBlock(block)
+			peer.SendCompactBlock(block)
 		}
 		log.Trace("Propagated block", "hash", hash, "recipients", len(transfer))
 		return
@@ -464,7 +584,7 @@ func (pm *ProtocolManager) BroadcastBlock(block *meta.Block, propagate bool) {
 	// Otherwise if the block is indeed in out own chain, announce it
 	if pm.chain.HasBlock(hash) {
 		for _, peer := range peers {
-			peer.SendNewBlock(block)
+			peer.SendCompactBlock(block)
 			// peer.SendNewBlockHashes([]meta.DataID{hash}, []uint64{uint64(block.GetHeight())})
 		}
 		log.Trace("Announced block", "hash", hash, "recipients", len(peers))
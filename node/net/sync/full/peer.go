@@ -35,6 +35,7 @@ type PeerInfo struct {
 	Version int    `json:"version"` // Linkchain protocol version negotiated
 	Head    string `json:"head"`    // SHA3 hash of the peer's best owned block
 	Height  uint64 `json:"height"`
+	Pruned  uint64 `json:"pruned,omitempty"` // Lowest height of the blocks served by the peer
 }
 
 type peer struct {
@@ -48,6 +49,7 @@ type peer struct {
 
 	head   math.Hash
 	height uint64
+	pruned uint64 // Lowest height of the blocks the peer serves
 	lock   sync.RWMutex
 
 	knownTxs    set.Interface // Set of transaction hashes known to be known by this peer
@@ -75,6 +77,7 @@ func (p *peer) Info() *PeerInfo {
 		Version: p.version,
 		Head:    hash.GetString(),
 		Height:  height,
+		Pruned:  p.Pruned(),
 	}
 }
 
@@ -91,6 +94,24 @@ func (p *peer) Head() (hash meta.BlockID, height uint64) {
 	return hash, height
 }
 
+// Pruned returns the lowest height of the blocks the peer serves, 0 if it
+// serves every block.
+func (p *peer) Pruned() uint64 {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.pruned
+}
+
+// SetPruned updates the lowest height of the blocks the peer serves, which
+// moves up as the peer prunes its chain.
+func (p *peer) SetPruned(pruned uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.pruned = pruned
+}
+
 // SetHead updates the head hash and total difficulty of the peer.
 func (p *peer) SetHead(hash meta.BlockID, height uint64) {
 	p.lock.Lock()
@@ -139,7 +160,35 @@ func (p *peer) SendNewBlock(block *meta.Block) error {
 	return message.Send(p.rw, NewBlockMsg, block.Serialize())
 }
 
-func (p *peer) SendBlock(blocks []*meta.Block) error {
+// SendCompactBlock propagates a block as its header and short transaction ids,
+// which the peer rebuilds from its own pool. Peers predating full/02 are sent
+// the entire block instead.
+func (p *peer) SendCompactBlock(block *meta.Block) error {
+	if p.version < full02 {
+		return p.SendNewBlock(block)
+	}
+	p.knownBlocks.Add(block.GetBlockID())
+	log.Debug("Send CompactBlockMsg", "block is", block.GetBlockID())
+	return message.Send(p.rw, CompactBlockMsg, newCompactBlock(block).Serialize())
+}
+
+// RequestBlockTxn fetches the transactions of a compact block at the given
+// positions.
+func (p *peer) RequestBlockTxn(hash meta.BlockID, indexes []uint32) error {
+	p.Log().Trace("Fetching compact block transactions", "hash", hash, "count", len(indexes))
+	data := &getBlockTxnData{Hash: hash, Indexes: indexes}
+	return message.Send(p.rw, GetBlockTxnMsg, data.Serialize())
+}
+
+// SendBlockTxn sends the requested transactions of a compact block.
+func (p *peer) SendBlockTxn(hash meta.BlockID, txs []meta.Transaction) error {
+	data := &blockTxnData{Hash: hash, Txs: txs}
+	return message.Send(p.rw, BlockTxnMsg, data.Serialize())
+}
+
+// SendBlock sends a batch of blocks along with the lowest height of the blocks
+// served, keeping the peer informed of the pruning done since the handshake.
+func (p *peer) SendBlock(blocks []*meta.Block, pruned uint64) error {
 	var blockArray []*protobuf.Block
 	for _, block := range blocks {
 		outBlock := block.Serialize().(*protobuf.Block)
@@ -148,7 +197,7 @@ func (p *peer) SendBlock(blocks []*meta.Block) error {
 		blockArray = append(blockArray, outBlock)
 
 	}
-	outBlocks := &protobuf.Blocks{Block: blockArray}
+	outBlocks := &protobuf.Blocks{Block: blockArray, PrunedHeight: &pruned}
 	return message.Send(p.rw, BlockMsg, outBlocks)
 
 }
@@ -173,9 +222,25 @@ func (p *peer) RequestOneBlock(hash meta.BlockID) error {
 	return message.Send(p.rw, GetBlockMsg, data.Serialize().(*protobuf.GetBlockHeadersData))
 }
 
+// SendNodeData sends a batch of state trie nodes to the remote peer.
+func (p *peer) SendNodeData(data [][]byte) error {
+	return message.Send(p.rw, NodeDataMsg, &protobuf.NodeData{Data: data})
+}
+
+// RequestNodeData fetches a batch of state trie nodes corresponding to the
+// hashes specified.
+func (p *peer) RequestNodeData(hashes []math.Hash) error {
+	p.Log().Trace("Fetching batch of state data", "count", len(hashes))
+	data := &protobuf.GetNodeData{}
+	for _, hash := range hashes {
+		data.Hashes = append(data.Hashes, hash.Serialize().(*protobuf.Hash))
+	}
+	return message.Send(p.rw, GetNodeDataMsg, data)
+}
+
 // Handshake executes the linkchain protocol handshake, negotiating version number,
 // network IDs, difficulties, head and genesis blocks.
-func (p *peer) Handshake(network uint64, height uint64, head meta.BlockID, genesis meta.BlockID) error {
+func (p *peer) Handshake(network uint64, height uint64, head meta.BlockID, genesis meta.BlockID, pruned uint64) error {
 	// Send out own handshake in a new thread
 	errc := make(chan error, 2)
 	var status statusData // safe to read after two values have been received from errc
@@ -187,6 +252,7 @@ func (p *peer) Handshake(network uint64, height uint64, head meta.BlockID, genes
 			Height:          height,
 			CurrentBlock:    head,
 			GenesisBlock:    genesis,
+			PrunedHeight:    pruned,
 		}
 		log.Debug("Send StatusMsg", "data is", data)
 		errc <- message.Send(p.rw, StatusMsg, data.Serialize())
@@ -207,6 +273,9 @@ func (p *peer) Handshake(network uint64, height uint64, head meta.BlockID, genes
 		}
 	}
 	p.SetHead(status.CurrentBlock, status.Height)
+	p.lock.Lock()
+	p.pruned = status.PrunedHeight
+	p.lock.Unlock()
 	// copy(p.head[:], status.CurrentBlock.CloneBytes())
 	return nil
 }
@@ -371,8 +440,10 @@ func (ps *peerSet) PeersWithoutTx(hash meta.BlockID) []*peer {
 	return list
 }
 
-// BestPeer retrieves the known peer with the currently highest total difficulty.
-func (ps *peerSet) BestPeer() *peer {
+// BestPeer retrieves the known peer with the currently highest height among
+// those serving the blocks from the given height on, leaving out the peers
+// which pruned them.
+func (ps *peerSet) BestPeer(from uint64) *peer {
 	ps.lock.RLock()
 	defer ps.lock.RUnlock()
 
@@ -382,6 +453,9 @@ func (ps *peerSet) BestPeer() *peer {
 	)
 
 	for _, p := range ps.peers {
+		if p.Pruned() > from {
+			continue
+		}
 		if _, height := p.Head(); bestPeer == nil || height > bestHeight {
 			bestPeer, bestHeight = p, height
 		}
@@ -13,16 +13,18 @@ import (
 // Constants to match up protocol versions and messages
 const (
 	full01 = 1
+	full02 = 2
+	full03 = 3
 )
 
 // Official short name of the protocol used during capability negotiation.
 var ProtocolName = "full"
 
 // Supported versions of the linkchain protocol (first is primary).
-var ProtocolVersions = []uint64{full01}
+var ProtocolVersions = []uint64{full03, full02, full01}
 
 // Number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{8}
+var ProtocolLengths = []uint64{13, 11, 8}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -35,6 +37,15 @@ const (
 	GetBlockMsg       = 0x03
 	BlockMsg          = 0x04
 	NewBlockMsg       = 0x05
+
+	// Protocol messages belonging to full/02
+	CompactBlockMsg = 0x08
+	GetBlockTxnMsg  = 0x09
+	BlockTxnMsg     = 0x0a
+
+	// Protocol messages belonging to full/03
+	GetNodeDataMsg = 0x0b
+	NodeDataMsg    = 0x0c
 )
 
 type errCode int
@@ -74,6 +85,7 @@ type statusData struct {
 	Height          uint64
 	CurrentBlock    meta.BlockID
 	GenesisBlock    meta.BlockID
+	PrunedHeight    uint64 // Lowest height whose block the sender serves, 0 if it serves every block
 }
 
 func (s *statusData) Serialize() serialize.SerializeStream {
@@ -85,6 +97,7 @@ func (s *statusData) Serialize() serialize.SerializeStream {
 		Height:          &s.Height,
 		CurrentBlock:    currentBlock,
 		GenesisBlock:    genesisBlock,
+		PrunedHeight:    &s.PrunedHeight,
 	}
 
 	return status
@@ -101,6 +114,7 @@ func (s *statusData) Deserialize(data serialize.SerializeStream) {
 	current := meta.BlockID{}
 	current.Deserialize(d.CurrentBlock)
 	s.CurrentBlock = current
+	s.PrunedHeight = d.GetPrunedHeight()
 }
 
 // newBlockHashesData is the network packet for the block announcements.
@@ -158,3 +172,71 @@ func (n *getBlockHeadersData) Deserialize(data serialize.SerializeStream) {
 	n.Amount = *(d.Amount)
 	n.Skip = *(d.Skip)
 }
+
+// compactBlock is the network packet for compact block propagation, the header
+// of a block and the short ids of its transactions.
+type compactBlock struct {
+	Header   meta.BlockHeader
+	ShortIDs []uint64
+}
+
+func (c *compactBlock) Serialize() serialize.SerializeStream {
+	return &protobuf.CompactBlock{
+		Header:   c.Header.Serialize().(*protobuf.BlockHeader),
+		ShortIds: c.ShortIDs,
+	}
+}
+
+func (c *compactBlock) Deserialize(data serialize.SerializeStream) {
+	d := data.(*protobuf.CompactBlock)
+	c.Header = meta.BlockHeader{}
+	c.Header.Deserialize(d.Header)
+	c.ShortIDs = d.ShortIds
+}
+
+// getBlockTxnData is the network packet requesting the transactions of a
+// compact block missing from the pool.
+type getBlockTxnData struct {
+	Hash    meta.BlockID // Hash of the compact block
+	Indexes []uint32     // Positions of the requested transactions in the block
+}
+
+func (g *getBlockTxnData) Serialize() serialize.SerializeStream {
+	return &protobuf.GetBlockTxnData{
+		BlockHash: g.Hash.Serialize().(*protobuf.Hash),
+		Indexes:   g.Indexes,
+	}
+}
+
+func (g *getBlockTxnData) Deserialize(data serialize.SerializeStream) {
+	d := data.(*protobuf.GetBlockTxnData)
+	g.Hash = meta.BlockID{}
+	g.Hash.Deserialize(d.BlockHash)
+	g.Indexes = d.Indexes
+}
+
+// blockTxnData is the network packet answering a getBlockTxnData request.
+type blockTxnData struct {
+	Hash meta.BlockID
+	Txs  []meta.Transaction
+}
+
+func (b *blockTxnData) Serialize() serialize.SerializeStream {
+	data := &protobuf.BlockTxnData{
+		BlockHash: b.Hash.Serialize().(*protobuf.Hash),
+	}
+	for i := range b.Txs {
+		data.Txs = append(data.Txs, b.Txs[i].Serialize().(*protobuf.Transaction))
+	}
+	return data
+}
+
+func (b *blockTxnData) Deserialize(data serialize.SerializeStream) {
+	d := data.(*protobuf.BlockTxnData)
+	b.Hash = meta.BlockID{}
+	b.Hash.Deserialize(d.BlockHash)
+	b.Txs = make([]meta.Transaction, len(d.Txs))
+	for i, tx := range d.Txs {
+		b.Txs[i].Deserialize(tx)
+	}
+}
@@ -116,11 +116,11 @@ func (pm *ProtocolManager) syncer() {
 			if pm.peers.Len() < minDesiredPeerCount {
 				break
 			}
-			go pm.synchronise(pm.peers.BestPeer())
+			go pm.synchronise(pm.peers.BestPeer(pm.syncFrom()))
 
 		case <-forceSync.C:
 			// Force a sync even if not enough peers are present
-			go pm.synchronise(pm.peers.BestPeer())
+			go pm.synchronise(pm.peers.BestPeer(pm.syncFrom()))
 
 		case <-pm.noMorePeers:
 			return
@@ -128,6 +128,11 @@ func (pm *ProtocolManager) syncer() {
 	}
 }
 
+// syncFrom returns the height of the first block missing locally.
+func (pm *ProtocolManager) syncFrom() uint64 {
+	return uint64(pm.chain.GetBestBlock().GetHeight()) + 1
+}
+
 // synchronise tries to sync up our local block chain with a remote peer.
 func (pm *ProtocolManager) synchronise(peer *peer) {
 	// Short circuit if no peers are available
@@ -0,0 +1,65 @@
+package full
+
+import (
+	"testing"
+
+	"github.com/mihongtech/linkchain-core/common/util/event"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/chain"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/discover"
+	p2p_peer "github.com/mihongtech/linkchain-core/node/net/p2p/peer"
+	"github.com/mihongtech/linkchain-core/node/net/sync/full/downloader"
+)
+
+// headChain is a chain known only by its best block.
+type headChain struct {
+	chain.Chain
+	best *meta.Block
+}
+
+func (c *headChain) GetBestBlock() *meta.Block { return c.best }
+
+func newSyncTestPeer(id byte, height uint64, pruned uint64) *peer {
+	p := newPeer(full03, p2p_peer.NewTestPeer(discover.NodeID{id}, "test", nil), nil)
+	p.SetHead(meta.BlockID{id}, height)
+	p.SetPruned(pruned)
+	return p
+}
+
+// Tests that a peer which pruned the blocks to sync since its handshake is
+// passed over by the sync instead of dropped.
+func TestDropPrunedSyncPeer(t *testing.T) {
+	pm := &ProtocolManager{
+		chain:      &headChain{best: &meta.Block{Header: meta.BlockHeader{Height: 10}}},
+		peers:      newPeerSet(),
+		downloader: downloader.New(downloader.FullSync, new(event.TypeMux), nil, nil, nil),
+	}
+	defer pm.downloader.Terminate()
+
+	serving := newSyncTestPeer(1, 50, 5)
+	pruned := newSyncTestPeer(2, 100, 0)
+	for _, p := range []*peer{serving, pruned} {
+		if err := pm.peers.Register(p); err != nil {
+			t.Fatalf("failed to register peer: %v", err)
+		}
+	}
+	if best := pm.peers.BestPeer(pm.syncFrom()); best != pruned {
+		t.Fatalf("best peer before pruning: have %v, want %v", best, pruned)
+	}
+
+	// The pruned height of a block reply moves past the blocks to sync
+	pruned.SetPruned(20)
+	pm.dropSyncPeer(pruned.id)
+	if pm.peers.Peer(pruned.id) == nil {
+		t.Fatalf("pruned peer dropped")
+	}
+	if best := pm.peers.BestPeer(pm.syncFrom()); best != serving {
+		t.Fatalf("best peer after pruning: have %v, want %v", best, serving)
+	}
+
+	// A peer still serving the blocks is dropped for failing
+	pm.dropSyncPeer(serving.id)
+	if pm.peers.Peer(serving.id) != nil {
+		t.Fatalf("failing peer kept")
+	}
+}
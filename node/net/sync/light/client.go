@@ -0,0 +1,337 @@
+package light
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/util/event"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/chain"
+	p2p_node "github.com/mihongtech/linkchain-core/node/net/p2p/discover"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/message"
+	p2p_peer "github.com/mihongtech/linkchain-core/node/net/p2p/peer"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/peer_error"
+	"github.com/mihongtech/linkchain-core/node/net/sync/full/downloader"
+	"github.com/mihongtech/linkchain-core/protobuf"
+)
+
+var (
+	errNoServer      = errors.New("no light server available")
+	errTxNotFound    = errors.New("transaction not found")
+	errProofTimeout  = errors.New("transaction proof request timed out")
+	errUnknownHeader = errors.New("proof references an unknown header")
+)
+
+const (
+	forceSyncCycle  = 10 * time.Second // Time interval to force syncs, even if few peers are available
+	txProofTimeout  = 10 * time.Second // Time allowance for a server to answer a proof request
+	maxProofRetries = 3                // Number of servers asked for a proof before giving up
+)
+
+// Client runs the light protocol on a header-only node. It synchronises block
+// headers from light servers through the downloader, and retrieves
+// transactions with an inclusion proof which is verified against the local
+// headers.
+type Client struct {
+	networkId uint64
+	peers     *peerSet
+
+	downloader *downloader.Downloader
+
+	SubProtocols []p2p_peer.Protocol
+
+	chain *chain.LightChain
+
+	reqID       uint64 // Last used proof request id, accessed atomically
+	pending     map[uint64]chan *txProofData
+	pendingLock sync.Mutex
+
+	newPeerCh   chan *peer
+	quitSync    chan struct{}
+	noMorePeers chan struct{}
+
+	wg sync.WaitGroup
+}
+
+// NewClient returns a light protocol client syncing headers into chain.
+func NewClient(chain *chain.LightChain, networkId uint64, mux *event.TypeMux) (*Client, error) {
+	client := &Client{
+		networkId:   networkId,
+		peers:       newPeerSet(),
+		chain:       chain,
+		pending:     make(map[uint64]chan *txProofData),
+		newPeerCh:   make(chan *peer),
+		quitSync:    make(chan struct{}),
+		noMorePeers: make(chan struct{}),
+	}
+
+	client.SubProtocols = make([]p2p_peer.Protocol, 0, len(ProtocolVersions))
+	for i, version := range ProtocolVersions {
+		version := version // Closure for the run
+		client.SubProtocols = append(client.SubProtocols, p2p_peer.Protocol{
+			Name:    ProtocolName,
+			Version: version,
+			Length:  ProtocolLengths[i],
+			Run: func(p *p2p_peer.Peer, rw message.MsgReadWriter) error {
+				return client.handle(newPeer(int(version), p, rw))
+			},
+			NodeInfo: func() interface{} {
+				return nil
+			},
+			PeerInfo: func(id p2p_node.NodeID) interface{} {
+				if p := client.peers.Peer(fmt.Sprintf("%x", id[:8])); p != nil {
+					return p.Info()
+				}
+				return nil
+			},
+		})
+	}
+	if len(client.SubProtocols) == 0 {
+		return nil, errIncompatibleConfig
+	}
+
+	client.downloader = downloader.New(downloader.FullSync, mux, chain, nil, client.removePeer)
+	return client, nil
+}
+
+func (c *Client) Start() bool {
+	go c.syncer()
+	return true
+}
+
+func (c *Client) Stop() {
+	log.Info("Stopping light client")
+	c.noMorePeers <- struct{}{}
+	close(c.quitSync)
+	c.peers.Close()
+	c.wg.Wait()
+	log.Info("Light client stopped")
+}
+
+// handle is the callback invoked to manage the life cycle of a light server
+// peer. When this function terminates, the peer is disconnected.
+func (c *Client) handle(p *peer) error {
+	// Counted before registering, so Stop waits for the peer it closes
+	c.wg.Add(1)
+	defer c.wg.Done()
+
+	var (
+		genesis = c.chain.Genesis()
+		current = c.chain.CurrentHeader()
+	)
+	if err := p.Handshake(c.networkId, uint64(current.Height), *current.GetBlockID(), *genesis.GetBlockID(), false); err != nil {
+		p.Log().Debug("Light handshake failed", "err", err)
+		return err
+	}
+	if !p.server {
+		return errResp(ErrUselessPeer, "peer does not serve light clients")
+	}
+	if err := c.peers.Register(p); err != nil {
+		p.Log().Error("Light server registration failed", "err", err)
+		return err
+	}
+	defer c.removePeer(p.id)
+
+	if err := c.downloader.RegisterLightPeer(p.id, p.version, p); err != nil {
+		return err
+	}
+	select {
+	case c.newPeerCh <- p:
+	case <-c.quitSync:
+		return peer_error.DiscQuitting
+	}
+
+	for {
+		if err := c.handleMsg(p); err != nil {
+			p.Log().Debug("Light message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+// handleMsg is invoked whenever an inbound message is received from a light
+// server. The remote connection is torn down upon returning any error.
+func (c *Client) handleMsg(p *peer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	switch msg.Code {
+	case StatusMsg:
+		return errResp(ErrExtraStatusMsg, "uncontrolled status message")
+
+	case AnnounceMsg:
+		var h protobuf.BlockHeader
+		if err := msg.Decode(&h); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		header := &meta.BlockHeader{}
+		header.Deserialize(&h)
+		p.SetHead(*header.GetBlockID(), uint64(header.Height))
+
+		go c.synchronise(p)
+
+	case BlockHeadersMsg:
+		var h protobuf.BlockHeaders
+		if err := msg.Decode(&h); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		// The downloader works on blocks, hand the headers over without body
+		blocks := make([]*meta.Block, 0, len(h.Headers))
+		for _, ph := range h.Headers {
+			block := &meta.Block{}
+			block.Header.Deserialize(ph)
+			blocks = append(blocks, block)
+		}
+		if err := c.downloader.DeliverBlocks(p.id, blocks); err != nil {
+			log.Debug("Failed to deliver headers", "err", err)
+		}
+
+	case TxProofMsg:
+		var t protobuf.TxProofData
+		if err := msg.Decode(&t); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		data := &txProofData{}
+		data.Deserialize(&t)
+
+		c.pendingLock.Lock()
+		ch, ok := c.pending[data.ReqID]
+		delete(c.pending, data.ReqID)
+		c.pendingLock.Unlock()
+		if ok {
+			ch <- data
+		}
+
+	default:
+		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+	}
+	return nil
+}
+
+func (c *Client) removePeer(id string) {
+	peer := c.peers.Peer(id)
+	if peer == nil {
+		return
+	}
+	log.Debug("Removing light server", "peer", id)
+
+	c.downloader.UnregisterPeer(id)
+	if err := c.peers.Unregister(id); err != nil {
+		log.Error("Peer removal failed", "peer", id, "err", err)
+	}
+	peer.Peer.Disconnect(peer_error.DiscUselessPeer)
+}
+
+// syncer periodically synchronises the header chain with the best server.
+func (c *Client) syncer() {
+	defer c.downloader.Terminate()
+
+	forceSync := time.NewTicker(forceSyncCycle)
+	defer forceSync.Stop()
+
+	for {
+		select {
+		case p := <-c.newPeerCh:
+			go c.synchronise(p)
+
+		case <-forceSync.C:
+			go c.synchronise(c.peers.BestPeer())
+
+		case <-c.noMorePeers:
+			return
+		}
+	}
+}
+
+// synchronise tries to sync up the local header chain with a light server.
+func (c *Client) synchronise(p *peer) {
+	if p == nil {
+		return
+	}
+	head, height := p.Head()
+	if height <= uint64(c.chain.CurrentHeader().Height) {
+		return
+	}
+	c.downloader.Synchronise(p.id, head)
+}
+
+// GetTransaction retrieves a transaction together with the hash, number and
+// index of the block including it. The transaction is accepted only if its
+// inclusion proof verifies against the TxRoot of a canonical local header.
+func (c *Client) GetTransaction(txid meta.TxID) (*meta.Transaction, meta.BlockID, uint64, uint64, error) {
+	var err error = errNoServer
+	for i := 0; i < maxProofRetries; i++ {
+		p := c.peers.BestPeer()
+		if p == nil {
+			break
+		}
+		var data *txProofData
+		if data, err = c.requestTxProof(p, txid); err != nil {
+			continue
+		}
+		if data.Tx == nil {
+			return nil, meta.BlockID{}, 0, 0, errTxNotFound
+		}
+		if err = c.verifyTxProof(txid, data); err == errUnknownHeader {
+			// The including header has not been synchronised yet
+			break
+		} else if err != nil {
+			log.Warn("Invalid transaction proof, dropping peer", "peer", p.id, "err", err)
+			c.removePeer(p.id)
+			continue
+		}
+		return data.Tx, data.BlockHash, data.Number, data.Index, nil
+	}
+	return nil, meta.BlockID{}, 0, 0, err
+}
+
+// requestTxProof sends a proof request to a server and waits for the reply.
+func (c *Client) requestTxProof(p *peer, txid meta.TxID) (*txProofData, error) {
+	reqID := atomic.AddUint64(&c.reqID, 1)
+	ch := make(chan *txProofData, 1)
+
+	c.pendingLock.Lock()
+	c.pending[reqID] = ch
+	c.pendingLock.Unlock()
+	defer func() {
+		c.pendingLock.Lock()
+		delete(c.pending, reqID)
+		c.pendingLock.Unlock()
+	}()
+
+	if err := p.RequestTxProof(reqID, txid); err != nil {
+		return nil, err
+	}
+	timeout := time.NewTimer(txProofTimeout)
+	defer timeout.Stop()
+	select {
+	case data := <-ch:
+		return data, nil
+	case <-timeout.C:
+		return nil, errProofTimeout
+	case <-c.quitSync:
+		return nil, peer_error.DiscQuitting
+	}
+}
+
+// verifyTxProof checks a proof reply against the local canonical header.
+func (c *Client) verifyTxProof(txid meta.TxID, data *txProofData) error {
+	if !data.Tx.GetTxID().IsEqual(&txid) {
+		return errProofMismatch
+	}
+	header := c.chain.GetHeaderByHeight(data.Number)
+	if header == nil || !header.GetBlockID().IsEqual(&data.BlockHash) {
+		return errUnknownHeader
+	}
+	return verifyTxProof(header, data.Tx, data.Proof)
+}
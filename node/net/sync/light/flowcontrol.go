@@ -0,0 +1,57 @@
+package light
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	serveRate   = 100  // Request cost units refilled per second for every light client
+	serveBurst  = 1000 // Maximum request cost units a light client may accumulate
+	headerCost  = 1    // Cost of serving a single block header
+	txProofCost = 10   // Cost of serving a single transaction inclusion proof
+)
+
+// rateLimiter is a token bucket limiting the requests a light client may send
+// to a serving full node. Every request is charged its cost in tokens, and
+// tokens are refilled at a constant rate up to the bucket capacity.
+type rateLimiter struct {
+	rate     float64 // Tokens refilled per second
+	capacity float64 // Maximum number of tokens in the bucket
+
+	tokens float64
+	last   time.Time
+	lock   sync.Mutex
+
+	now func() time.Time // Clock, replaceable in tests
+}
+
+func newRateLimiter(rate, capacity float64) *rateLimiter {
+	return &rateLimiter{
+		rate:     rate,
+		capacity: capacity,
+		tokens:   capacity,
+		last:     time.Now(),
+		now:      time.Now,
+	}
+}
+
+// allow charges cost to the bucket, returning false if not enough tokens are
+// left to serve the request.
+func (r *rateLimiter) allow(cost float64) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.now()
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.capacity {
+		r.tokens = r.capacity
+	}
+	r.last = now
+
+	if r.tokens < cost {
+		return false
+	}
+	r.tokens -= cost
+	return true
+}
@@ -0,0 +1,35 @@
+package light
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := newRateLimiter(10, 100)
+	r.now = func() time.Time { return now }
+	r.last = now
+
+	if !r.allow(100) {
+		t.Fatalf("full bucket rejected request")
+	}
+	if r.allow(1) {
+		t.Fatalf("empty bucket accepted request")
+	}
+	now = now.Add(time.Second)
+	if !r.allow(10) {
+		t.Fatalf("refilled bucket rejected request")
+	}
+	if r.allow(1) {
+		t.Fatalf("bucket refilled above rate")
+	}
+	// Idle time never fills the bucket above its capacity
+	now = now.Add(time.Hour)
+	if r.allow(101) {
+		t.Fatalf("bucket exceeded capacity")
+	}
+	if !r.allow(100) {
+		t.Fatalf("full bucket rejected request")
+	}
+}
@@ -0,0 +1,311 @@
+package light
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/message"
+	p2p_peer "github.com/mihongtech/linkchain-core/node/net/p2p/peer"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/peer_error"
+	"github.com/mihongtech/linkchain-core/protobuf"
+)
+
+var (
+	errClosed            = errors.New("peer set is closed")
+	errAlreadyRegistered = errors.New("peer is already registered")
+	errNotRegistered     = errors.New("peer is not registered")
+)
+
+const (
+	handshakeTimeout = 5 * time.Second
+)
+
+// PeerInfo represents a short summary of the light sub-protocol metadata known
+// about a connected peer.
+type PeerInfo struct {
+	Version int    `json:"version"` // Light protocol version negotiated
+	Head    string `json:"head"`    // Hash of the peer's best owned block
+	Height  uint64 `json:"height"`
+	Server  bool   `json:"server"` // Whether the peer serves light client requests
+}
+
+type peer struct {
+	id string
+
+	*p2p_peer.Peer
+	rw message.MsgReadWriter
+
+	version int  // Protocol version negotiated
+	server  bool // Whether the remote side serves light client requests
+
+	head   meta.BlockID
+	height uint64
+	lock   sync.RWMutex
+
+	limiter *rateLimiter // Limits the requests served to this peer
+}
+
+func newPeer(version int, p *p2p_peer.Peer, rw message.MsgReadWriter) *peer {
+	id := p.ID()
+
+	return &peer{
+		Peer:    p,
+		rw:      rw,
+		version: version,
+		id:      fmt.Sprintf("%x", id[:8]),
+		limiter: newRateLimiter(serveRate, serveBurst),
+	}
+}
+
+// Info gathers and returns a collection of metadata known about a peer.
+func (p *peer) Info() *PeerInfo {
+	hash, height := p.Head()
+
+	return &PeerInfo{
+		Version: p.version,
+		Head:    hash.GetString(),
+		Height:  height,
+		Server:  p.server,
+	}
+}
+
+// Head retrieves a copy of the current head hash and height of the peer.
+func (p *peer) Head() (hash meta.BlockID, height uint64) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.head, p.height
+}
+
+// SetHead updates the head hash and height of the peer.
+func (p *peer) SetHead(hash meta.BlockID, height uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.head = hash
+	p.height = height
+}
+
+// RequestBlocksByHash fetches a batch of headers starting at the given hash.
+// The headers are delivered to the downloader as blocks without body.
+func (p *peer) RequestBlocksByHash(h meta.BlockID, amount int, skip int) error {
+	p.Log().Trace("Fetching headers by hash", "hash", h, "amount", amount)
+	data := &getBlockHeadersData{Hash: h, Amount: uint64(amount), Skip: uint64(skip)}
+	return message.Send(p.rw, GetBlockHeadersMsg, data.Serialize())
+}
+
+// RequestBlocksByNumber fetches a batch of canonical headers starting at the
+// given number.
+func (p *peer) RequestBlocksByNumber(i uint64, amount int, skip int) error {
+	p.Log().Trace("Fetching headers by number", "number", i, "amount", amount)
+	data := &getBlockHeadersData{Number: i, Amount: uint64(amount), Skip: uint64(skip)}
+	return message.Send(p.rw, GetBlockHeadersMsg, data.Serialize())
+}
+
+// RequestTxProof asks the peer for a transaction and its inclusion proof.
+func (p *peer) RequestTxProof(reqID uint64, txid meta.TxID) error {
+	p.Log().Trace("Fetching transaction proof", "txid", txid)
+	data := &getTxProofData{ReqID: reqID, TxID: txid}
+	return message.Send(p.rw, GetTxProofMsg, data.Serialize())
+}
+
+// SendBlockHeaders sends a batch of block headers to the remote peer.
+func (p *peer) SendBlockHeaders(headers []*meta.BlockHeader) error {
+	data := &protobuf.BlockHeaders{}
+	for _, header := range headers {
+		data.Headers = append(data.Headers, header.Serialize().(*protobuf.BlockHeader))
+	}
+	return message.Send(p.rw, BlockHeadersMsg, data)
+}
+
+// SendTxProof sends a transaction inclusion proof to the remote peer.
+func (p *peer) SendTxProof(proof *txProofData) error {
+	return message.Send(p.rw, TxProofMsg, proof.Serialize())
+}
+
+// SendAnnounce announces a new head header to the remote peer.
+func (p *peer) SendAnnounce(header *meta.BlockHeader) error {
+	return message.Send(p.rw, AnnounceMsg, header.Serialize())
+}
+
+// Handshake executes the light protocol handshake, negotiating version number,
+// network IDs, head and genesis blocks and whether each side serves requests.
+func (p *peer) Handshake(network uint64, height uint64, head meta.BlockID, genesis meta.BlockID, server bool) error {
+	// Send out own handshake in a new thread
+	errc := make(chan error, 2)
+	var status statusData // safe to read after two values have been received from errc
+
+	go func() {
+		data := &statusData{
+			ProtocolVersion: uint32(p.version),
+			NetworkId:       network,
+			Height:          height,
+			CurrentBlock:    head,
+			GenesisBlock:    genesis,
+			LightServer:     server,
+		}
+		errc <- message.Send(p.rw, StatusMsg, data.Serialize())
+	}()
+	go func() {
+		errc <- p.readStatus(network, &status, genesis)
+	}()
+	timeout := time.NewTimer(handshakeTimeout)
+	defer timeout.Stop()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errc:
+			if err != nil {
+				return err
+			}
+		case <-timeout.C:
+			return peer_error.DiscReadTimeout
+		}
+	}
+	p.server = status.LightServer
+	p.SetHead(status.CurrentBlock, status.Height)
+	return nil
+}
+
+func errResp(code errCode, format string, v ...interface{}) error {
+	return fmt.Errorf("%v - %v", code, fmt.Sprintf(format, v...))
+}
+
+func (p *peer) readStatus(network uint64, status *statusData, genesis meta.BlockID) (err error) {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Code != StatusMsg {
+		return errResp(ErrNoStatusMsg, "first msg has code %x (!= %x)", msg.Code, StatusMsg)
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	// Decode the handshake and make sure everything matches
+	data := protobuf.StatusData{}
+	if err := msg.Decode(&data); err != nil {
+		return errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	status.Deserialize(&data)
+	if !status.GenesisBlock.IsEqual(&genesis) {
+		return errResp(ErrGenesisBlockMismatch, "%x (!= %x)", status.GenesisBlock, genesis)
+	}
+	if status.NetworkId != network {
+		return errResp(ErrNetworkIdMismatch, "%d (!= %d)", status.NetworkId, network)
+	}
+	if int(status.ProtocolVersion) != p.version {
+		return errResp(ErrProtocolVersionMismatch, "%d (!= %d)", status.ProtocolVersion, p.version)
+	}
+	return nil
+}
+
+// String implements fmt.Stringer.
+func (p *peer) String() string {
+	return fmt.Sprintf("Peer %s [%s]", p.id,
+		fmt.Sprintf("light/%2d", p.version),
+	)
+}
+
+// peerSet represents the collection of active peers currently participating in
+// the light sub-protocol.
+type peerSet struct {
+	peers  map[string]*peer
+	lock   sync.RWMutex
+	closed bool
+}
+
+// newPeerSet creates a new peer set to track the active participants.
+func newPeerSet() *peerSet {
+	return &peerSet{
+		peers: make(map[string]*peer),
+	}
+}
+
+// Register injects a new peer into the working set, or returns an error if the
+// peer is already known.
+func (ps *peerSet) Register(p *peer) error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if ps.closed {
+		return errClosed
+	}
+	if _, ok := ps.peers[p.id]; ok {
+		return errAlreadyRegistered
+	}
+	ps.peers[p.id] = p
+	return nil
+}
+
+// Unregister removes a remote peer from the active set, disabling any further
+// actions to/from that particular entity.
+func (ps *peerSet) Unregister(id string) error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if _, ok := ps.peers[id]; !ok {
+		return errNotRegistered
+	}
+	delete(ps.peers, id)
+	return nil
+}
+
+// Peer retrieves the registered peer with the given id.
+func (ps *peerSet) Peer(id string) *peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	return ps.peers[id]
+}
+
+// Len returns if the current number of peers in the set.
+func (ps *peerSet) Len() int {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	return len(ps.peers)
+}
+
+// AllPeers retrieves a flat list of all the peers within the set.
+func (ps *peerSet) AllPeers() []*peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*peer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		list = append(list, p)
+	}
+	return list
+}
+
+// BestPeer retrieves the known peer with the currently highest head.
+func (ps *peerSet) BestPeer() *peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	var (
+		bestPeer   *peer
+		bestHeight uint64
+	)
+	for _, p := range ps.peers {
+		if _, height := p.Head(); bestPeer == nil || height > bestHeight {
+			bestPeer, bestHeight = p, height
+		}
+	}
+	return bestPeer
+}
+
+// Close disconnects all peers.
+// No new peers can be registered after Close has returned.
+func (ps *peerSet) Close() {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	for _, p := range ps.peers {
+		p.Disconnect(peer_error.DiscQuitting)
+	}
+	ps.closed = true
+}
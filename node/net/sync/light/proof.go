@@ -0,0 +1,55 @@
+package light
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/common/trie"
+	"github.com/mihongtech/linkchain-core/core/meta"
+)
+
+var (
+	errTxNotIncluded = errors.New("transaction is not included in the block")
+	errProofMismatch = errors.New("transaction does not match the proven value")
+)
+
+// buildTxProof collects the trie nodes proving that a transaction is part of
+// the tx trie of a block, whose root is stored in the block header's TxRoot.
+func buildTxProof(block *meta.Block, txid meta.TxID) ([][]byte, error) {
+	t := new(trie.Trie)
+	for _, tx := range block.TXs.Txs {
+		t.Update(tx.GetTxID().Bytes(), tx.Data)
+	}
+	proofDb, _ := lcdb.NewMemDatabase()
+	if err := t.Prove(txid.Bytes(), 0, proofDb); err != nil {
+		return nil, err
+	}
+	proof := make([][]byte, 0, proofDb.Len())
+	for _, key := range proofDb.Keys() {
+		node, _ := proofDb.Get(key)
+		proof = append(proof, node)
+	}
+	return proof, nil
+}
+
+// verifyTxProof checks that the proof nodes link the header's TxRoot to the
+// given transaction.
+func verifyTxProof(header *meta.BlockHeader, tx *meta.Transaction, proof [][]byte) error {
+	proofDb, _ := lcdb.NewMemDatabase()
+	for _, node := range proof {
+		proofDb.Put(math.HashB(node), node)
+	}
+	value, err, _ := trie.VerifyProof(header.TxRoot, tx.GetTxID().Bytes(), proofDb)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return errTxNotIncluded
+	}
+	if !bytes.Equal(value, tx.Data) {
+		return errProofMismatch
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package light
+
+import (
+	"testing"
+
+	"github.com/mihongtech/linkchain-core/core/meta"
+)
+
+func newProofBlock(n int) *meta.Block {
+	block := &meta.Block{}
+	for i := 0; i < n; i++ {
+		block.TXs.Txs = append(block.TXs.Txs, meta.Transaction{Data: []byte{byte(i), byte(i >> 8), 0x42}})
+	}
+	block.Header.TxRoot = block.CalculateTxTreeRoot()
+	return block
+}
+
+func TestTxProof(t *testing.T) {
+	block := newProofBlock(50)
+	for i := range block.TXs.Txs {
+		tx := &block.TXs.Txs[i]
+		proof, err := buildTxProof(block, *tx.GetTxID())
+		if err != nil {
+			t.Fatalf("tx %d: failed to build proof: %v", i, err)
+		}
+		if err := verifyTxProof(&block.Header, tx, proof); err != nil {
+			t.Fatalf("tx %d: failed to verify proof: %v", i, err)
+		}
+	}
+}
+
+func TestTxProofRejects(t *testing.T) {
+	block := newProofBlock(10)
+	tx := &block.TXs.Txs[3]
+	proof, _ := buildTxProof(block, *tx.GetTxID())
+
+	// A transaction of another block must not verify
+	other := &meta.Transaction{Data: []byte("not included")}
+	otherProof, _ := buildTxProof(block, *other.GetTxID())
+	if err := verifyTxProof(&block.Header, other, otherProof); err == nil {
+		t.Fatalf("proof of missing transaction verified")
+	}
+	// A proof against another root must not verify
+	header := block.Header
+	header.TxRoot = newProofBlock(11).Header.TxRoot
+	if err := verifyTxProof(&header, tx, proof); err == nil {
+		t.Fatalf("proof verified against foreign root")
+	}
+	// A truncated proof must not verify
+	if err := verifyTxProof(&block.Header, tx, proof[:len(proof)-1]); err == nil {
+		t.Fatalf("truncated proof verified")
+	}
+}
@@ -0,0 +1,184 @@
+package light
+
+import (
+	"github.com/mihongtech/linkchain-core/common/serialize"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/protobuf"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	light01 = 1
+)
+
+// Official short name of the protocol used during capability negotiation.
+var ProtocolName = "light"
+
+// Supported versions of the light protocol (first is primary).
+var ProtocolVersions = []uint64{light01}
+
+// Number of implemented message corresponding to different protocol versions.
+var ProtocolLengths = []uint64{6}
+
+const ProtocolMaxMsgSize = 2 * 1024 * 1024 // Maximum cap on the size of a protocol message
+
+// light protocol message codes
+const (
+	// Protocol messages belonging to light/01
+	StatusMsg          = 0x00
+	AnnounceMsg        = 0x01
+	GetBlockHeadersMsg = 0x02
+	BlockHeadersMsg    = 0x03
+	GetTxProofMsg      = 0x04
+	TxProofMsg         = 0x05
+)
+
+const (
+	MaxHeaderFetch = 192 // Amount of block headers to be fetched per retrieval request
+)
+
+type errCode int
+
+const (
+	ErrMsgTooLarge = iota
+	ErrDecode
+	ErrInvalidMsgCode
+	ErrProtocolVersionMismatch
+	ErrNetworkIdMismatch
+	ErrGenesisBlockMismatch
+	ErrNoStatusMsg
+	ErrExtraStatusMsg
+	ErrRequestRateExceeded
+	ErrUselessPeer
+)
+
+func (e errCode) String() string {
+	return errorToString[int(e)]
+}
+
+var errorToString = map[int]string{
+	ErrMsgTooLarge:             "Message too long",
+	ErrDecode:                  "Invalid message",
+	ErrInvalidMsgCode:          "Invalid message code",
+	ErrProtocolVersionMismatch: "Protocol version mismatch",
+	ErrNetworkIdMismatch:       "NetworkId mismatch",
+	ErrGenesisBlockMismatch:    "Genesis block mismatch",
+	ErrNoStatusMsg:             "No status message",
+	ErrExtraStatusMsg:          "Extra status message",
+	ErrRequestRateExceeded:     "Request rate exceeded",
+	ErrUselessPeer:             "Useless peer",
+}
+
+type statusData struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	Height          uint64
+	CurrentBlock    meta.BlockID
+	GenesisBlock    meta.BlockID
+	LightServer     bool // Whether the sender serves light client requests
+}
+
+func (s *statusData) Serialize() serialize.SerializeStream {
+	return &protobuf.StatusData{
+		ProtocolVersion: &s.ProtocolVersion,
+		NetworkId:       &s.NetworkId,
+		Height:          &s.Height,
+		CurrentBlock:    s.CurrentBlock.Serialize().(*protobuf.Hash),
+		GenesisBlock:    s.GenesisBlock.Serialize().(*protobuf.Hash),
+		LightServer:     &s.LightServer,
+	}
+}
+
+func (s *statusData) Deserialize(data serialize.SerializeStream) {
+	d := data.(*protobuf.StatusData)
+	s.ProtocolVersion = *d.ProtocolVersion
+	s.NetworkId = *d.NetworkId
+	s.Height = *d.Height
+	s.GenesisBlock = meta.BlockID{}
+	s.GenesisBlock.Deserialize(d.GenesisBlock)
+	s.CurrentBlock = meta.BlockID{}
+	s.CurrentBlock.Deserialize(d.CurrentBlock)
+	s.LightServer = d.GetLightServer()
+}
+
+type getBlockHeadersData struct {
+	Hash   meta.BlockID // Block hash from which to retrieve headers (excludes Number)
+	Number uint64       // Block number from which to retrieve headers (excludes Hash)
+	Amount uint64       // Maximum number of headers to retrieve
+	Skip   uint64       // Blocks to skip between consecutive headers
+}
+
+func (n *getBlockHeadersData) Serialize() serialize.SerializeStream {
+	return &protobuf.GetBlockHeadersData{
+		Hash:   n.Hash.Serialize().(*protobuf.Hash),
+		Number: &(n.Number),
+		Amount: &(n.Amount),
+		Skip:   &(n.Skip),
+	}
+}
+
+func (n *getBlockHeadersData) Deserialize(data serialize.SerializeStream) {
+	d := data.(*protobuf.GetBlockHeadersData)
+	n.Hash = meta.BlockID{}
+	n.Hash.Deserialize(d.Hash)
+	n.Number = *(d.Number)
+	n.Amount = *(d.Amount)
+	n.Skip = *(d.Skip)
+}
+
+type getTxProofData struct {
+	ReqID uint64    // Request identifier echoed in the reply
+	TxID  meta.TxID // Transaction to prove
+}
+
+func (g *getTxProofData) Serialize() serialize.SerializeStream {
+	return &protobuf.GetTxProofData{
+		ReqId: &g.ReqID,
+		TxId:  g.TxID.Serialize().(*protobuf.Hash),
+	}
+}
+
+func (g *getTxProofData) Deserialize(data serialize.SerializeStream) {
+	d := data.(*protobuf.GetTxProofData)
+	g.ReqID = *d.ReqId
+	g.TxID = meta.TxID{}
+	g.TxID.Deserialize(d.TxId)
+}
+
+// txProofData is the reply to a transaction proof request. An empty Tx means
+// the serving peer does not know the transaction.
+type txProofData struct {
+	ReqID     uint64
+	BlockHash meta.BlockID
+	Number    uint64
+	Index     uint64
+	Tx        *meta.Transaction
+	Proof     [][]byte // Trie nodes on the path from the block's TxRoot to the transaction
+}
+
+func (t *txProofData) Serialize() serialize.SerializeStream {
+	data := &protobuf.TxProofData{ReqId: &t.ReqID}
+	if t.Tx != nil {
+		data.BlockHash = t.BlockHash.Serialize().(*protobuf.Hash)
+		data.Number = &t.Number
+		data.Index = &t.Index
+		data.Tx = t.Tx.Serialize().(*protobuf.Transaction)
+		data.Proof = t.Proof
+	}
+	return data
+}
+
+func (t *txProofData) Deserialize(data serialize.SerializeStream) {
+	d := data.(*protobuf.TxProofData)
+	t.ReqID = *d.ReqId
+	if d.Tx == nil {
+		return
+	}
+	t.BlockHash = meta.BlockID{}
+	t.BlockHash.Deserialize(d.BlockHash)
+	t.Number = d.GetNumber()
+	t.Index = d.GetIndex()
+	t.Tx = &meta.Transaction{}
+	t.Tx.Deserialize(d.Tx)
+	t.Proof = d.Proof
+}
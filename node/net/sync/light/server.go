@@ -0,0 +1,264 @@
+package light
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mihongtech/linkchain-core/common/util/event"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/chain"
+	p2p_node "github.com/mihongtech/linkchain-core/node/net/p2p/discover"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/message"
+	p2p_peer "github.com/mihongtech/linkchain-core/node/net/p2p/peer"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/peer_error"
+	"github.com/mihongtech/linkchain-core/protobuf"
+)
+
+// errIncompatibleConfig is returned if the requested protocols and configs are
+// not compatible (low protocol version restrictions and high requirements).
+var errIncompatibleConfig = errors.New("incompatible configuration")
+
+const (
+	headChanSize = 10
+)
+
+// ServerChain is the chain a full node serves light clients from.
+type ServerChain interface {
+	chain.ChainReader
+	GetTxLookupEntry(txid meta.TxID) (meta.BlockID, uint64, uint64)
+	SubscribeChainHeadEvent(ch chan<- meta.ChainHeadEvent) event.Subscription
+}
+
+// Server answers header and transaction proof requests of light clients on
+// behalf of a full node, and announces new heads to them. Requests are rate
+// limited per client.
+type Server struct {
+	networkId uint64
+	maxPeers  int
+	peers     *peerSet
+
+	SubProtocols []p2p_peer.Protocol
+
+	chain   ServerChain
+	headCh  chan meta.ChainHeadEvent
+	headSub event.Subscription
+
+	wg sync.WaitGroup
+}
+
+// NewServer returns a light protocol server answering requests from chain.
+func NewServer(chain ServerChain, networkId uint64) (*Server, error) {
+	server := &Server{
+		networkId: networkId,
+		maxPeers:  32,
+		peers:     newPeerSet(),
+		chain:     chain,
+	}
+
+	server.SubProtocols = make([]p2p_peer.Protocol, 0, len(ProtocolVersions))
+	for i, version := range ProtocolVersions {
+		version := version // Closure for the run
+		server.SubProtocols = append(server.SubProtocols, p2p_peer.Protocol{
+			Name:    ProtocolName,
+			Version: version,
+			Length:  ProtocolLengths[i],
+			Run: func(p *p2p_peer.Peer, rw message.MsgReadWriter) error {
+				return server.handle(newPeer(int(version), p, rw))
+			},
+			NodeInfo: func() interface{} {
+				return nil
+			},
+			PeerInfo: func(id p2p_node.NodeID) interface{} {
+				if p := server.peers.Peer(fmt.Sprintf("%x", id[:8])); p != nil {
+					return p.Info()
+				}
+				return nil
+			},
+		})
+	}
+	if len(server.SubProtocols) == 0 {
+		return nil, errIncompatibleConfig
+	}
+	return server, nil
+}
+
+func (s *Server) Start() bool {
+	s.headCh = make(chan meta.ChainHeadEvent, headChanSize)
+	s.headSub = s.chain.SubscribeChainHeadEvent(s.headCh)
+	go s.announceLoop()
+	return true
+}
+
+func (s *Server) Stop() {
+	log.Info("Stopping light server")
+	s.headSub.Unsubscribe() // quits announceLoop
+	s.peers.Close()
+	s.wg.Wait()
+	log.Info("Light server stopped")
+}
+
+// handle is the callback invoked to manage the life cycle of a light client
+// peer. When this function terminates, the peer is disconnected.
+func (s *Server) handle(p *peer) error {
+	var (
+		genesis = s.chain.GetHeaderByHeight(0)
+		current = s.chain.GetBestBlock()
+	)
+	if err := p.Handshake(s.networkId, uint64(current.GetHeight()), *current.GetBlockID(), *genesis.GetBlockID(), true); err != nil {
+		p.Log().Debug("Light handshake failed", "err", err)
+		return err
+	}
+	if p.server {
+		// Another serving full node, nothing will be requested on this protocol
+		return s.idle(p)
+	}
+	// Counted before registering, so Stop waits for the peer it closes
+	s.wg.Add(1)
+	defer s.wg.Done()
+
+	if s.peers.Len() >= s.maxPeers && !p.Peer.Info().Network.Trusted {
+		return peer_error.DiscTooManyPeers
+	}
+	if err := s.peers.Register(p); err != nil {
+		p.Log().Error("Light client registration failed", "err", err)
+		return err
+	}
+	defer s.peers.Unregister(p.id)
+
+	for {
+		if err := s.handleMsg(p); err != nil {
+			p.Log().Debug("Light message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+// idle discards all messages of a peer that is not a light client.
+func (s *Server) idle(p *peer) error {
+	for {
+		msg, err := p.rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		msg.Discard()
+	}
+}
+
+// handleMsg is invoked whenever an inbound message is received from a light
+// client. The remote connection is torn down upon returning any error.
+func (s *Server) handleMsg(p *peer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	switch msg.Code {
+	case StatusMsg:
+		return errResp(ErrExtraStatusMsg, "uncontrolled status message")
+
+	case GetBlockHeadersMsg:
+		var query protobuf.GetBlockHeadersData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		data := &getBlockHeadersData{}
+		data.Deserialize(&query)
+
+		amount := data.Amount
+		if amount > MaxHeaderFetch {
+			amount = MaxHeaderFetch
+		}
+		if !p.limiter.allow(float64(amount * headerCost)) {
+			return errResp(ErrRequestRateExceeded, "header request of %d", amount)
+		}
+		return p.SendBlockHeaders(s.getHeaders(data, amount))
+
+	case GetTxProofMsg:
+		var query protobuf.GetTxProofData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		data := &getTxProofData{}
+		data.Deserialize(&query)
+
+		if !p.limiter.allow(txProofCost) {
+			return errResp(ErrRequestRateExceeded, "tx proof request")
+		}
+		return p.SendTxProof(s.getTxProof(data))
+
+	default:
+		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+	}
+}
+
+// getHeaders collects the headers matching a header query.
+func (s *Server) getHeaders(query *getBlockHeadersData, amount uint64) []*meta.BlockHeader {
+	headers := make([]*meta.BlockHeader, 0, amount)
+	number := query.Number
+	if !query.Hash.IsEmpty() {
+		block, err := s.chain.GetBlockByID(query.Hash)
+		if err != nil {
+			return headers
+		}
+		headers = append(headers, &block.Header)
+		number = uint64(block.GetHeight()) + query.Skip + 1
+	}
+	for uint64(len(headers)) < amount {
+		header := s.chain.GetHeaderByHeight(number)
+		if header == nil {
+			break
+		}
+		headers = append(headers, header)
+		number += query.Skip + 1
+	}
+	return headers
+}
+
+// getTxProof looks up a transaction in the canonical chain and proves its
+// inclusion against the including block's TxRoot.
+func (s *Server) getTxProof(query *getTxProofData) *txProofData {
+	reply := &txProofData{ReqID: query.ReqID}
+
+	hash, number, index := s.chain.GetTxLookupEntry(query.TxID)
+	if hash.IsEmpty() {
+		return reply
+	}
+	block, err := s.chain.GetBlockByID(hash)
+	if err != nil || int(index) >= len(block.TXs.Txs) {
+		return reply
+	}
+	proof, err := buildTxProof(block, query.TxID)
+	if err != nil {
+		log.Error("Failed to build tx proof", "txid", query.TxID, "err", err)
+		return reply
+	}
+	reply.BlockHash = hash
+	reply.Number = number
+	reply.Index = index
+	reply.Tx = &block.TXs.Txs[index]
+	reply.Proof = proof
+	return reply
+}
+
+// announceLoop announces every new canonical head to the light clients.
+func (s *Server) announceLoop() {
+	for {
+		select {
+		case ev := <-s.headCh:
+			for _, p := range s.peers.AllPeers() {
+				p.SendAnnounce(&ev.Block.Header)
+			}
+			log.Trace("Announced head to light clients", "hash", ev.Block.GetBlockID())
+
+			// Err() channel will be closed when unsubscribing.
+		case <-s.headSub.Err():
+			return
+		}
+	}
+}
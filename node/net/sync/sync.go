@@ -2,8 +2,12 @@ package sync
 
 import (
 	"github.com/mihongtech/linkchain-core/common/util/event"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/node/bcsi"
 	"github.com/mihongtech/linkchain-core/node/chain"
 	"github.com/mihongtech/linkchain-core/node/net/sync/full"
+	"github.com/mihongtech/linkchain-core/node/net/sync/full/downloader"
+	"github.com/mihongtech/linkchain-core/node/net/sync/light"
 	"github.com/mihongtech/linkchain-core/node/pool"
 
 	p2p_peer "github.com/mihongtech/linkchain-core/node/net/p2p/peer"
@@ -12,7 +16,9 @@ import (
 var ()
 
 type Service struct {
-	Engine *full.ProtocolManager
+	Engine      *full.ProtocolManager
+	LightServer *light.Server // Serves light clients, nil in light mode
+	LightClient *light.Client // Syncs headers in light mode, nil otherwise
 }
 
 type Config struct {
@@ -21,30 +27,76 @@ type Config struct {
 	EventMux  *event.TypeMux
 	EventTx   *event.Feed
 	NetworkId uint64
+	LightMode bool
+	FastSync  bool             // Import the app state at a pivot block instead of replaying the chain
+	State     bcsi.StateSyncer // App state access for fast sync, nil if the app does not support it
 }
 
 func (s *Service) Setup(i interface{}) bool {
 	//log.Info("sync service init...");
 	cfg := i.(*Config)
-	engine, err := full.NewProtocolManager(cfg.Chain, cfg.TxPool, cfg.NetworkId, cfg.EventMux, cfg.EventTx)
+	if cfg.LightMode {
+		lightChain, ok := cfg.Chain.(*chain.LightChain)
+		if !ok {
+			log.Error("light mode requires a header-only chain")
+			return false
+		}
+		client, err := light.NewClient(lightChain, cfg.NetworkId, cfg.EventMux)
+		if err != nil {
+			return false
+		}
+		s.LightClient = client
+		return true
+	}
+
+	mode := downloader.FullSync
+	if cfg.FastSync {
+		mode = downloader.FastSync
+	}
+	engine, err := full.NewProtocolManager(mode, cfg.Chain, cfg.TxPool, cfg.State, cfg.NetworkId, cfg.EventMux, cfg.EventTx)
 	if err != nil {
 		return false
 	}
 	s.Engine = engine
+	if serverChain, ok := cfg.Chain.(light.ServerChain); ok {
+		if s.LightServer, err = light.NewServer(serverChain, cfg.NetworkId); err != nil {
+			return false
+		}
+	}
 	return true
 }
 
 func (s *Service) Start() bool {
 	//log.Info("sync service start...");
+	if s.LightClient != nil {
+		return s.LightClient.Start()
+	}
 	s.Engine.Start()
+	if s.LightServer != nil {
+		s.LightServer.Start()
+	}
 	return true
 }
 
 func (s *Service) Stop() {
 	//log.Info("sync service stop...");
+	if s.LightClient != nil {
+		s.LightClient.Stop()
+		return
+	}
 	s.Engine.Stop()
+	if s.LightServer != nil {
+		s.LightServer.Stop()
+	}
 }
 
 func (s *Service) Protocols() []p2p_peer.Protocol {
-	return s.Engine.SubProtocols
+	if s.LightClient != nil {
+		return s.LightClient.SubProtocols
+	}
+	protocols := s.Engine.SubProtocols
+	if s.LightServer != nil {
+		protocols = append(protocols, s.LightServer.SubProtocols...)
+	}
+	return protocols
 }
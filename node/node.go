@@ -2,10 +2,12 @@ package node
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
 	"os"
 	"sync"
+	"time"
 
+	"github.com/mihongtech/linkchain-core/common/http/server"
 	"github.com/mihongtech/linkchain-core/common/lcdb"
 	"github.com/mihongtech/linkchain-core/common/math"
 	"github.com/mihongtech/linkchain-core/common/util/event"
@@ -19,7 +21,9 @@ import (
 	"github.com/mihongtech/linkchain-core/node/consensus/poa"
 	"github.com/mihongtech/linkchain-core/node/net"
 	"github.com/mihongtech/linkchain-core/node/net/p2p"
+	"github.com/mihongtech/linkchain-core/node/net/sync/light"
 	"github.com/mihongtech/linkchain-core/node/pool"
+	"github.com/mihongtech/linkchain-core/node/rpc"
 	"github.com/mihongtech/linkchain-core/storage"
 )
 
@@ -41,10 +45,15 @@ type Node struct {
 	//chain
 	chainMtx   sync.RWMutex
 	blockchain *chain.ChainImpl
+	lightchain *chain.LightChain //header-only chain in light mode
 	db         lcdb.Database
 
 	//net p2p
-	p2pSvc net.Net
+	p2pSvc      net.Net
+	lightClient *light.Client
+
+	//rpc
+	rpcSvc *rpc.CoreRPCServer
 
 	//event
 	newBlockEvent *event.TypeMux
@@ -76,7 +85,7 @@ func (n *Node) Setup(i interface{}) bool {
 	log.Info("Manage init...")
 
 	//DB
-	s := storage.NewStrorage(n.cfg.DataDir)
+	s := storage.NewStrorage(&n.cfg.BaseConfig)
 	if s == nil {
 		log.Error("init storage failed")
 		return false
@@ -86,14 +95,30 @@ func (n *Node) Setup(i interface{}) bool {
 	n.p2pSvc = p2p.NewP2P(n.cfg.BaseConfig)
 
 	chainCfg, genesisHash, err := n.initGenesis(n.db, n.cfg.GenesisPath)
+	if err != nil {
+		log.Error("init genesis failed", "err", err)
+		return false
+	}
 	//BCSI
 	n.bcsiAPI = n.cfg.BcsiAPI
 
 	//consensus
 	n.engine = poa.NewPoa(chainCfg, s.GetDB())
 
+	if !n.setupRPC() {
+		return false
+	}
+
+	if n.cfg.LightMode {
+		return n.setupLight(genesisHash, chainCfg)
+	}
+
 	//chain
-	n.blockchain, err = chain.NewBlockChain(s.GetDB(), genesisHash, nil, chainCfg, n.bcsiAPI, n.engine)
+	var cacheCfg *chain.CacheConfig
+	if n.cfg.PruneBlocks > 0 || n.cfg.BlockIndexes {
+		cacheCfg = &chain.CacheConfig{BlockRetention: n.cfg.PruneBlocks, BlockIndexes: n.cfg.BlockIndexes}
+	}
+	n.blockchain, err = chain.NewBlockChain(s.GetDB(), genesisHash, cacheCfg, chainCfg, n.bcsiAPI, n.engine)
 	if err != nil {
 		log.Error("init chain failed", "err", err)
 		return false
@@ -109,6 +134,10 @@ func (n *Node) Setup(i interface{}) bool {
 
 	//p2p init
 	p2pCfg := p2p.NewConfig(n.blockchain, n.txPool, 0, n.newBlockEvent, n.newTxEvent)
+	p2pCfg.FastSync = n.cfg.FastSync
+	if stateSyncer, ok := n.bcsiAPI.(bcsi.StateSyncer); ok {
+		p2pCfg.State = stateSyncer
+	}
 	if !n.p2pSvc.Setup(p2pCfg) {
 		return false
 	}
@@ -116,31 +145,62 @@ func (n *Node) Setup(i interface{}) bool {
 	return true
 }
 
-//initGeneisis() init gensisBlock config form gensis.json
-func (n *Node) initGenesis(db lcdb.Database, genesisPath string) (*config.ChainConfig, math.Hash, error) {
-	if len(genesisPath) == 0 {
-		return nil, math.Hash{}, errors.New("genesis file is nil")
+//setupRPC prepares the rpc server of the CoreAPI if an rpc address is configured.
+func (n *Node) setupRPC() bool {
+	if len(n.cfg.RpcAddr) == 0 {
+		return true
 	}
-	file, err := os.Open(genesisPath)
+	rpcCfg := server.NewConfig("node", time.Now().Unix(), n.cfg.RpcAddr, n.cfg.RpcUser, n.cfg.RpcPassword)
+	rpcCfg.Tokens = n.cfg.RpcTokens
+	rpcCfg.TLSCert = n.cfg.RpcTLSCert
+	rpcCfg.TLSKey = n.cfg.RpcTLSKey
+	rpcCfg.ClientCA = n.cfg.RpcClientCA
+	rpcSvc, err := rpc.NewCoreRPCServer(rpcCfg, NewPublicCoreAPI(n))
 	if err != nil {
-		log.Info("genesis file not found, use default Genesis")
+		log.Error("init rpc server failed", "err", err)
+		return false
 	}
-	defer file.Close()
+	n.rpcSvc = rpcSvc
+	return true
+}
 
-	genesisBlock := new(genesis.Genesis)
-	if err == nil {
+//setupLight prepares a header-only node, which runs neither tx pool nor miner.
+func (n *Node) setupLight(genesisHash math.Hash, chainCfg *config.ChainConfig) bool {
+	lightchain, err := chain.NewLightChain(n.db, genesisHash, chainCfg, n.engine)
+	if err != nil {
+		log.Error("init light chain failed", "err", err)
+		return false
+	}
+	n.lightchain = lightchain
+
+	p2pCfg := p2p.NewConfig(n.lightchain, nil, 0, n.newBlockEvent, n.newTxEvent)
+	p2pCfg.LightMode = true
+	if !n.p2pSvc.Setup(p2pCfg) {
+		return false
+	}
+	n.lightClient = n.p2pSvc.(*p2p.Service).LightClient()
+	return true
+}
+
+//initGeneisis() init gensisBlock config form gensis.json
+//Without genesis file, the stored genesis is used, or the default one in a new database.
+func (n *Node) initGenesis(db lcdb.Database, genesisPath string) (*config.ChainConfig, math.Hash, error) {
+	var genesisBlock *genesis.Genesis
+	if len(genesisPath) != 0 {
+		file, err := os.Open(genesisPath)
+		if err != nil {
+			return nil, math.Hash{}, err
+		}
+		defer file.Close()
+		genesisBlock = new(genesis.Genesis)
 		if err := json.NewDecoder(file).Decode(genesisBlock); err != nil {
-			log.Error("invalid genesis file")
-			return nil, math.Hash{}, errors.New("invalid genesis file")
+			return nil, math.Hash{}, fmt.Errorf("invalid genesis file: %v", err)
 		}
-	} else {
-		genesisBlock = nil
 	}
 
 	config, hash, err := genesis.SetupGenesisBlock(db, genesisBlock)
 	if err != nil {
-		log.Error("setup genesis failed", "err", err)
-		return nil, math.Hash{}, errors.New("setup genesis failed")
+		return nil, math.Hash{}, err
 	}
 
 	return config, hash, nil
@@ -148,6 +208,14 @@ func (n *Node) initGenesis(db lcdb.Database, genesisPath string) (*config.ChainC
 
 func (n *Node) Start() bool {
 	log.Info("Node is start...")
+	if n.cfg.LightMode {
+		if !n.p2pSvc.Start() {
+			return false
+		}
+		n.setCoreServices()
+		return n.startRPC()
+	}
+
 	//n.offchain.SetSubscription(n.chain.SubscribeChainEvent(n.offchain.MainChainCh), n.chain.SubscribeChainSideEvent(n.offchain.SideChainCh))
 	n.updateMainState = n.blockchain.SubscribeChainEvent(n.MainChainCh)
 	n.updateSideState = n.blockchain.SubscribeChainSideEvent(n.SideChainCh)
@@ -165,7 +233,22 @@ func (n *Node) Start() bool {
 	}
 
 	go n.updateState()
-	return true
+	n.setCoreServices()
+	return n.startRPC()
+}
+
+//setCoreServices hand the core services to an app which takes them
+func (n *Node) setCoreServices() {
+	if setter, ok := n.bcsiAPI.(bcsi.CoreServicesSetter); ok {
+		setter.SetCoreServices(NewPublicCoreAPI(n))
+	}
+}
+
+func (n *Node) startRPC() bool {
+	if n.rpcSvc == nil {
+		return true
+	}
+	return n.rpcSvc.Start()
 }
 
 func (n *Node) updateState() {
@@ -180,7 +263,17 @@ func (n *Node) updateState() {
 }
 func (n *Node) Stop() {
 	log.Info("Stop node...")
-	n.txPool.Stop()
-	n.engine.Stop()
-	n.blockchain.Stop()
+	if n.rpcSvc != nil {
+		n.rpcSvc.Stop()
+	}
+	//the peers stop feeding the chain before it stops, the chain before its db closes
+	n.p2pSvc.Stop()
+	if n.cfg.LightMode {
+		n.lightchain.Stop()
+	} else {
+		n.txPool.Stop()
+		n.engine.Stop()
+		n.blockchain.Stop()
+	}
+	n.db.Close()
 }
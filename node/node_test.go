@@ -1 +1,97 @@
 package node
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/chain/genesis"
+	"github.com/mihongtech/linkchain-core/node/config"
+	"github.com/mihongtech/linkchain-core/storage"
+)
+
+func writeGenesisFile(t *testing.T, dir string, name string, g *genesis.Genesis) string {
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(fn, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+func TestInitGenesis(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linkchain-genesis-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	chainCfg := &config.ChainConfig{ChainId: big.NewInt(7), Period: 5}
+	custom := writeGenesisFile(t, dir, "custom.json", &genesis.Genesis{Config: chainCfg, Time: 1500000000})
+	other := writeGenesisFile(t, dir, "other.json", &genesis.Genesis{Config: chainCfg, Time: 1600000000})
+
+	n := &Node{}
+	db, _ := lcdb.NewMemDatabase()
+	if _, _, err := n.initGenesis(db, filepath.Join(dir, "missing.json")); err == nil {
+		t.Fatalf("missing genesis file accepted")
+	}
+	_, hash, err := n.initGenesis(db, custom)
+	if err != nil {
+		t.Fatalf("failed to set up genesis: %v", err)
+	}
+	// Without file the stored genesis is used
+	if cfg, stored, err := n.initGenesis(db, ""); err != nil || stored != hash || cfg.ChainId.Int64() != 7 {
+		t.Fatalf("stored genesis: have %x %v (%v), want %x", stored[:4], cfg, err, hash[:4])
+	}
+	if _, _, err := n.initGenesis(db, other); err == nil {
+		t.Fatalf("mismatched genesis accepted")
+	}
+}
+
+// nopBCSI is an app accepting everything.
+type nopBCSI struct{}
+
+func (nopBCSI) GetBlockState(id meta.BlockID) (meta.TreeID, error) { return math.Hash{}, nil }
+func (nopBCSI) UpdateChain(head meta.Block) error                  { return nil }
+func (nopBCSI) ProcessBlock(block meta.Block) error                { return nil }
+func (nopBCSI) Commit(id meta.BlockID) error                       { return nil }
+func (nopBCSI) CheckBlock(block meta.Block) error                  { return nil }
+func (nopBCSI) CheckTx(transaction meta.Transaction) error         { return nil }
+func (nopBCSI) FilterTx(txs []meta.Transaction) []meta.Transaction { return txs }
+
+// Tests that a stopped node releases its database, in both modes.
+func TestNodeStop(t *testing.T) {
+	for _, light := range []bool{false, true} {
+		dir, err := ioutil.TempDir("", "linkchain-node-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		cfg := config.BaseConfig{DataDir: dir, ListenAddress: "127.0.0.1:0", NoDiscovery: true, LightMode: light}
+		n := NewNode(cfg)
+		if !n.Setup(&Config{BaseConfig: cfg, BcsiAPI: nopBCSI{}}) {
+			t.Fatalf("light %v: setup failed", light)
+		}
+		if !n.Start() {
+			t.Fatalf("light %v: start failed", light)
+		}
+		n.Stop()
+
+		// The database is locked while open
+		s := storage.NewStrorage(&cfg)
+		if s == nil {
+			t.Fatalf("light %v: database not released", light)
+		}
+		s.GetDB().Close()
+	}
+}
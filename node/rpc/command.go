@@ -0,0 +1,56 @@
+package rpc
+
+import (
+	"github.com/mihongtech/linkchain-core/core/meta"
+)
+
+type BlockIDCmd struct {
+	BlockId meta.BlockID `json:"blockId"`
+}
+
+type HeaderCmd struct {
+	BlockId meta.BlockID `json:"blockId"`
+	Height  uint64       `json:"height"`
+}
+
+type HeightCmd struct {
+	Height uint32 `json:"height"`
+}
+
+type TxIDCmd struct {
+	TxId meta.TxID `json:"txId"`
+}
+
+type TransactionCmd struct {
+	Transaction string `json:"transaction"`
+}
+
+type NodeCmd struct {
+	Node string `json:"node"`
+}
+
+type CommonRSP struct {
+	Data string `json:"data"`
+}
+
+type NodeRSP struct {
+	Node string `json:"node"`
+}
+
+type TransactionRSP struct {
+	Transaction string       `json:"transaction"`
+	BlockId     meta.BlockID `json:"blockId"`
+	Number      uint64       `json:"number"`
+	Index       uint64       `json:"index"`
+}
+
+type HeadRSP struct {
+	BlockId meta.BlockID `json:"blockId"`
+	Height  uint32       `json:"height"`
+	Header  string       `json:"header"`
+}
+
+type TxEventRSP struct {
+	TxId        meta.TxID `json:"txId"`
+	Transaction string    `json:"transaction"`
+}
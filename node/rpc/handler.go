@@ -0,0 +1,189 @@
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"reflect"
+
+	"github.com/mihongtech/linkchain-core/common/http/rpcjson"
+	"github.com/mihongtech/linkchain-core/common/http/server"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/discover"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/peer"
+)
+
+//encodeBlock hex encodes a block for a response, nil blocks become a null result.
+func encodeBlock(block *meta.Block) (interface{}, error) {
+	if block == nil {
+		return nil, nil
+	}
+	buff, err := block.EncodeToBytes()
+	if err != nil {
+		log.Error("CoreRPCServer", "block encode", err)
+		return nil, err
+	}
+	return &CommonRSP{Data: hex.EncodeToString(buff)}, nil
+}
+
+func onHasBlock(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
+	c, ok := cmd.(*BlockIDCmd)
+	if !ok {
+		log.Error("CoreRPCServer", "onHasBlock Type error:", reflect.TypeOf(cmd))
+		return nil, rpcjson.ErrRPCInvalidParams
+	}
+	return s.Context.(API).HasBlock(c.BlockId), nil
+}
+
+func onGetHeader(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
+	c, ok := cmd.(*HeaderCmd)
+	if !ok {
+		log.Error("CoreRPCServer", "onGetHeader Type error:", reflect.TypeOf(cmd))
+		return nil, rpcjson.ErrRPCInvalidParams
+	}
+	header := s.Context.(API).GetHeader(c.BlockId, c.Height)
+	if header == nil {
+		return nil, nil
+	}
+	buff, err := header.EncodeToBytes()
+	if err != nil {
+		log.Error("CoreRPCServer", "onGetHeader result encode header", err)
+		return nil, err
+	}
+	return &CommonRSP{Data: hex.EncodeToString(buff)}, nil
+}
+
+func onGetChainConfig(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
+	return s.Context.(API).GetChainConfig(), nil
+}
+
+func onGetBestBlock(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
+	return encodeBlock(s.Context.(API).GetBestBlock())
+}
+
+func onGetBlockNumber(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
+	c, ok := cmd.(*BlockIDCmd)
+	if !ok {
+		log.Error("CoreRPCServer", "onGetBlockNumber Type error:", reflect.TypeOf(cmd))
+		return nil, rpcjson.ErrRPCInvalidParams
+	}
+	return s.Context.(API).GetBlockNumber(c.BlockId), nil
+}
+
+func onGetBlockByID(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
+	c, ok := cmd.(*BlockIDCmd)
+	if !ok {
+		log.Error("CoreRPCServer", "onGetBlockByID Type error:", reflect.TypeOf(cmd))
+		return nil, rpcjson.ErrRPCInvalidParams
+	}
+	block, err := s.Context.(API).GetBlockByID(c.BlockId)
+	if err != nil {
+		return nil, err
+	}
+	return encodeBlock(block)
+}
+
+func onGetBlockByHeight(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
+	c, ok := cmd.(*HeightCmd)
+	if !ok {
+		log.Error("CoreRPCServer", "onGetBlockByHeight Type error:", reflect.TypeOf(cmd))
+		return nil, rpcjson.ErrRPCInvalidParams
+	}
+	block, err := s.Context.(API).GetBlockByHeight(c.Height)
+	if err != nil {
+		return nil, err
+	}
+	return encodeBlock(block)
+}
+
+func onGetChainID(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
+	id := s.Context.(API).GetChainID()
+	if id == nil {
+		return nil, nil
+	}
+	return id.String(), nil
+}
+
+func onSelf(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
+	return &NodeRSP{Node: s.Context.(API).Self().String()}, nil
+}
+
+func onAddPeer(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
+	c, ok := cmd.(*NodeCmd)
+	if !ok {
+		log.Error("CoreRPCServer", "onAddPeer Type error:", reflect.TypeOf(cmd))
+		return nil, rpcjson.ErrRPCInvalidParams
+	}
+	node, err := discover.ParseNode(c.Node)
+	if err != nil {
+		log.Error("CoreRPCServer", "onAddPeer cmd decode", err)
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCInvalidParams.Code, err.Error())
+	}
+	s.Context.(API).AddPeer(node)
+	return nil, nil
+}
+
+func onPeers(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
+	infos := make([]*peer.PeerInfo, 0)
+	for _, p := range s.Context.(API).Peers() {
+		infos = append(infos, p.Info())
+	}
+	return infos, nil
+}
+
+func onRemovePeer(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
+	c, ok := cmd.(*NodeCmd)
+	if !ok {
+		log.Error("CoreRPCServer", "onRemovePeer Type error:", reflect.TypeOf(cmd))
+		return nil, rpcjson.ErrRPCInvalidParams
+	}
+	node, err := discover.ParseNode(c.Node)
+	if err != nil {
+		log.Error("CoreRPCServer", "onRemovePeer cmd decode", err)
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCInvalidParams.Code, err.Error())
+	}
+	s.Context.(API).RemovePeer(node)
+	return nil, nil
+}
+
+func onProcessTx(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
+	c, ok := cmd.(*TransactionCmd)
+	if !ok {
+		log.Error("CoreRPCServer", "onProcessTx Type error:", reflect.TypeOf(cmd))
+		return nil, rpcjson.ErrRPCInvalidParams
+	}
+	buff, err := hex.DecodeString(c.Transaction)
+	if err != nil {
+		log.Error("CoreRPCServer", "onProcessTx hex cmd decode", err)
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCDecodeHexString, err.Error())
+	}
+	transaction := meta.Transaction{}
+	if err := transaction.DecodeFromBytes(buff); err != nil {
+		log.Error("CoreRPCServer", "onProcessTx cmd decode", err)
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCInvalidParams.Code, err.Error())
+	}
+	return nil, s.Context.(API).ProcessTx(&transaction)
+}
+
+func onGetTXByID(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
+	c, ok := cmd.(*TxIDCmd)
+	if !ok {
+		log.Error("CoreRPCServer", "onGetTXByID Type error:", reflect.TypeOf(cmd))
+		return nil, rpcjson.ErrRPCInvalidParams
+	}
+	tx, blockId, number, index := s.Context.(API).GetTXByID(c.TxId)
+	if tx == nil {
+		return nil, nil
+	}
+	buff, err := tx.EncodeToBytes()
+	if err != nil {
+		log.Error("CoreRPCServer", "onGetTXByID result encode transaction", err)
+		return nil, err
+	}
+	return &TransactionRSP{
+		Transaction: hex.EncodeToString(buff),
+		BlockId:     blockId,
+		Number:      number,
+		Index:       index,
+	}, nil
+}
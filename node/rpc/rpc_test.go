@@ -0,0 +1,223 @@
+package rpc
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/http/client"
+	"github.com/mihongtech/linkchain-core/common/http/rpcgen"
+	"github.com/mihongtech/linkchain-core/common/http/rpcjson"
+	"github.com/mihongtech/linkchain-core/common/http/server"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/config"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/discover"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/peer"
+)
+
+var errTestUnknown = errors.New("unknown block")
+
+// testAPI serves a single block holding a single transaction.
+type testAPI struct {
+	block   *meta.Block
+	self    *discover.Node
+	added   *discover.Node
+	pending *meta.Transaction
+}
+
+func newTestAPI() *testAPI {
+	block := &meta.Block{Header: meta.BlockHeader{Height: 7, Time: time.Unix(100, 0)}}
+	block.SetTx(meta.Transaction{Data: []byte("rpc")})
+	self := discover.NewNode(discover.NodeID{1}, net.IP{127, 0, 0, 1}, 30303, 30303)
+	return &testAPI{block: block, self: self}
+}
+
+func (a *testAPI) HasBlock(hash meta.BlockID) bool { return hash.IsEqual(a.block.GetBlockID()) }
+func (a *testAPI) GetHeader(hash math.Hash, height uint64) *meta.BlockHeader {
+	if !a.HasBlock(hash) {
+		return nil
+	}
+	return &a.block.Header
+}
+func (a *testAPI) GetChainConfig() *config.ChainConfig {
+	return &config.ChainConfig{ChainId: big.NewInt(1337), Period: 15}
+}
+func (a *testAPI) GetBestBlock() *meta.Block             { return a.block }
+func (a *testAPI) GetBlockNumber(id meta.BlockID) uint64 { return uint64(a.block.GetHeight()) }
+func (a *testAPI) GetBlockByID(hash meta.BlockID) (*meta.Block, error) {
+	if !a.HasBlock(hash) {
+		return nil, errTestUnknown
+	}
+	return a.block, nil
+}
+func (a *testAPI) GetBlockByHeight(height uint32) (*meta.Block, error) {
+	return a.GetBlockByID(*a.block.GetBlockID())
+}
+func (a *testAPI) GetChainID() *big.Int                 { return big.NewInt(1337) }
+func (a *testAPI) Self() *discover.Node                 { return a.self }
+func (a *testAPI) AddPeer(node *discover.Node)          { a.added = node }
+func (a *testAPI) Peers() []*peer.Peer                  { return nil }
+func (a *testAPI) RemovePeer(node *discover.Node)       { a.added = nil }
+func (a *testAPI) ProcessTx(tx *meta.Transaction) error { a.pending = tx; return nil }
+func (a *testAPI) GetTXByID(id meta.TxID) (*meta.Transaction, meta.BlockID, uint64, uint64) {
+	tx := a.block.TXs.Txs[0]
+	if !id.IsEqual(tx.GetTxID()) {
+		return nil, meta.BlockID{}, 0, 0
+	}
+	return &tx, *a.block.GetBlockID(), uint64(a.block.GetHeight()), 0
+}
+
+func newTestRPC(t *testing.T) (*testAPI, *CoreRPCServer, *CoreRPCClient) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	api := newTestAPI()
+	srv, err := NewCoreRPCServer(server.NewConfig("test", time.Now().Unix(), addr, "user", "pass"), api)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if !srv.Start() {
+		t.Fatalf("failed to start server")
+	}
+	return api, srv, NewCoreRPCClient(&client.Config{RPCUser: "user", RPCPassword: "pass", RPCServer: addr})
+}
+
+func TestCoreRPCChain(t *testing.T) {
+	api, srv, c := newTestRPC(t)
+	defer srv.Stop()
+	hash := *api.block.GetBlockID()
+
+	if has, err := c.HasBlock(hash); err != nil || !has {
+		t.Fatalf("HasBlock: have %v %v, want true", has, err)
+	}
+	if has, err := c.HasBlock(meta.BlockID{}); err != nil || has {
+		t.Fatalf("HasBlock unknown: have %v %v, want false", has, err)
+	}
+	if header, err := c.GetHeader(hash, 7); err != nil || !header.GetBlockID().IsEqual(&hash) {
+		t.Fatalf("GetHeader: have %v %v", header, err)
+	}
+	if header, err := c.GetHeader(meta.BlockID{}, 7); err != nil || header != nil {
+		t.Fatalf("GetHeader unknown: have %v %v, want nil", header, err)
+	}
+	if cfg, err := c.GetChainConfig(); err != nil || cfg.ChainId.Int64() != 1337 || cfg.Period != 15 {
+		t.Fatalf("GetChainConfig: have %v %v", cfg, err)
+	}
+	if block, err := c.GetBestBlock(); err != nil || !block.GetBlockID().IsEqual(&hash) || len(block.TXs.Txs) != 1 {
+		t.Fatalf("GetBestBlock: have %v %v", block, err)
+	}
+	if number, err := c.GetBlockNumber(hash); err != nil || number != 7 {
+		t.Fatalf("GetBlockNumber: have %d %v, want 7", number, err)
+	}
+	if block, err := c.GetBlockByHeight(7); err != nil || !block.GetBlockID().IsEqual(&hash) {
+		t.Fatalf("GetBlockByHeight: have %v %v", block, err)
+	}
+	if _, err := c.GetBlockByID(meta.BlockID{}); err == nil {
+		t.Fatalf("GetBlockByID of unknown block succeeded")
+	}
+	if id, err := c.GetChainID(); err != nil || id.Int64() != 1337 {
+		t.Fatalf("GetChainID: have %v %v, want 1337", id, err)
+	}
+}
+
+func TestCoreRPCTxAndPeers(t *testing.T) {
+	api, srv, c := newTestRPC(t)
+	defer srv.Stop()
+
+	tx := api.block.TXs.Txs[0]
+	have, blockId, number, index, err := c.GetTXByID(*tx.GetTxID())
+	if err != nil || !have.GetTxID().IsEqual(tx.GetTxID()) || !blockId.IsEqual(api.block.GetBlockID()) || number != 7 || index != 0 {
+		t.Fatalf("GetTXByID: have %v %v %d %d %v", have, blockId, number, index, err)
+	}
+	if have, _, _, _, err := c.GetTXByID(meta.TxID{}); err != nil || have != nil {
+		t.Fatalf("GetTXByID unknown: have %v %v, want nil", have, err)
+	}
+	pending := meta.Transaction{Data: []byte("pending")}
+	if err := c.ProcessTx(&pending); err != nil || !api.pending.GetTxID().IsEqual(pending.GetTxID()) {
+		t.Fatalf("ProcessTx: %v", err)
+	}
+
+	if self, err := c.Self(); err != nil || self.ID != api.self.ID || self.TCP != 30303 {
+		t.Fatalf("Self: have %v %v", self, err)
+	}
+	if err := c.AddPeer(api.self); err != nil || api.added == nil || api.added.ID != api.self.ID {
+		t.Fatalf("AddPeer: %v", err)
+	}
+	if err := c.RemovePeer(api.self); err != nil || api.added != nil {
+		t.Fatalf("RemovePeer: %v", err)
+	}
+	if peers, err := c.Peers(); err != nil || len(peers) != 0 {
+		t.Fatalf("Peers: have %v %v", peers, err)
+	}
+}
+
+// Tests that malformed parameters fail with the invalid params error codes.
+func TestCoreRPCInvalidParams(t *testing.T) {
+	_, srv, c := newTestRPC(t)
+	defer srv.Stop()
+
+	tests := []struct {
+		method string
+		cmd    interface{}
+		code   rpcjson.RPCErrorCode
+	}{
+		{"AddPeer", &NodeCmd{Node: "enode://bad"}, rpcjson.ErrRPCInvalidParams.Code},
+		{"RemovePeer", &NodeCmd{Node: "enode://bad"}, rpcjson.ErrRPCInvalidParams.Code},
+		{"ProcessTx", &TransactionCmd{Transaction: "zz"}, rpcjson.ErrRPCDecodeHexString},
+		{"ProcessTx", &TransactionCmd{Transaction: "ffff"}, rpcjson.ErrRPCInvalidParams.Code},
+	}
+	for _, tt := range tests {
+		_, err := client.RPC(tt.method, tt.cmd, c.cfg)
+		if rpcErr, ok := err.(*rpcjson.RPCError); !ok || rpcErr.Code != tt.code {
+			t.Errorf("%s %+v: have %v, want code %d", tt.method, tt.cmd, err, tt.code)
+		}
+	}
+}
+
+func TestCoreRPCAuth(t *testing.T) {
+	_, srv, c := newTestRPC(t)
+	defer srv.Stop()
+
+	c.cfg.RPCPassword = "wrong"
+	if _, err := c.GetBestBlock(); err == nil {
+		t.Fatalf("call with wrong password succeeded")
+	}
+}
+
+var update = flag.Bool("update", false, "regenerate the rpc stubs")
+
+// Tests that the generated stub matches the methods served, regenerating it
+// with -update.
+func TestCoreStub(t *testing.T) {
+	srv, err := NewCoreRPCServer(server.NewConfig("core", 0, "", "", ""), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := rpcgen.Generate(srv.rpcServer.Discover(), &rpcgen.Options{
+		Package:    "rpc",
+		ImportPath: "github.com/mihongtech/linkchain-core/node/rpc",
+		Client:     "coreStub",
+		Source:     "the rpc_discover document of CoreRPCServer",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err := ioutil.WriteFile("stub_gen.go", code, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	have, err := ioutil.ReadFile("stub_gen.go")
+	if err != nil || !bytes.Equal(have, code) {
+		t.Fatalf("stub_gen.go is out of date, run go generate: %v", err)
+	}
+}
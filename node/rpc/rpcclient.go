@@ -0,0 +1,163 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/mihongtech/linkchain-core/common/http/client"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/config"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/discover"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/peer"
+)
+
+var (
+	errInvalidChainID = errors.New("invalid chain id")
+	errNoNode         = errors.New("no node returned")
+)
+
+//CoreRPCClient calls the API of a node through its rpc server, decoding the results of the generated stub.
+type CoreRPCClient struct {
+	cfg  *client.Config
+	stub *coreStub
+}
+
+func NewCoreRPCClient(cfg *client.Config) *CoreRPCClient {
+	return &CoreRPCClient{cfg: cfg, stub: newCoreStub(cfg)}
+}
+
+//decodeBlock decodes the block of a rpc result, which is nil if the node has none.
+func decodeBlock(method string, rsp *CommonRSP, err error) (*meta.Block, error) {
+	if err != nil {
+		log.Error("CoreRPCClient", method+" rpc call", err)
+		return nil, err
+	}
+	if rsp == nil {
+		return nil, nil
+	}
+	buff, err := hex.DecodeString(rsp.Data)
+	if err != nil {
+		log.Error("CoreRPCClient", method+" response hex decode", err)
+		return nil, err
+	}
+	block := &meta.Block{}
+	if err := block.DecodeFromBytes(buff); err != nil {
+		log.Error("CoreRPCClient", method+" response decode", err)
+		return nil, err
+	}
+	return block, nil
+}
+
+func (c *CoreRPCClient) HasBlock(hash meta.BlockID) (bool, error) {
+	return c.stub.HasBlock(hash)
+}
+
+func (c *CoreRPCClient) GetHeader(hash math.Hash, height uint64) (*meta.BlockHeader, error) {
+	rsp, err := c.stub.GetHeader(hash, height)
+	if err != nil || rsp == nil {
+		return nil, err
+	}
+	buff, err := hex.DecodeString(rsp.Data)
+	if err != nil {
+		log.Error("CoreRPCClient", "GetHeader response hex decode", err)
+		return nil, err
+	}
+	header := &meta.BlockHeader{}
+	if err := header.DecodeFromBytes(buff); err != nil {
+		log.Error("CoreRPCClient", "GetHeader response decode", err)
+		return nil, err
+	}
+	return header, nil
+}
+
+func (c *CoreRPCClient) GetChainConfig() (*config.ChainConfig, error) {
+	return c.stub.GetChainConfig()
+}
+
+func (c *CoreRPCClient) GetBestBlock() (*meta.Block, error) {
+	rsp, err := c.stub.GetBestBlock()
+	return decodeBlock("GetBestBlock", rsp, err)
+}
+
+func (c *CoreRPCClient) GetBlockNumber(id meta.BlockID) (uint64, error) {
+	return c.stub.GetBlockNumber(id)
+}
+
+func (c *CoreRPCClient) GetBlockByID(hash meta.BlockID) (*meta.Block, error) {
+	rsp, err := c.stub.GetBlockByID(hash)
+	return decodeBlock("GetBlockByID", rsp, err)
+}
+
+func (c *CoreRPCClient) GetBlockByHeight(height uint32) (*meta.Block, error) {
+	rsp, err := c.stub.GetBlockByHeight(height)
+	return decodeBlock("GetBlockByHeight", rsp, err)
+}
+
+func (c *CoreRPCClient) GetChainID() (*big.Int, error) {
+	id, err := c.stub.GetChainID()
+	if err != nil || id == "" {
+		return nil, err
+	}
+	chainId, ok := new(big.Int).SetString(id, 10)
+	if !ok {
+		return nil, errInvalidChainID
+	}
+	return chainId, nil
+}
+
+func (c *CoreRPCClient) Self() (*discover.Node, error) {
+	rsp, err := c.stub.Self()
+	if err != nil {
+		return nil, err
+	}
+	if rsp == nil {
+		return nil, errNoNode
+	}
+	return discover.ParseNode(rsp.Node)
+}
+
+func (c *CoreRPCClient) AddPeer(node *discover.Node) error {
+	_, err := c.stub.AddPeer(node.String())
+	return err
+}
+
+func (c *CoreRPCClient) Peers() ([]*peer.PeerInfo, error) {
+	return c.stub.Peers()
+}
+
+func (c *CoreRPCClient) RemovePeer(node *discover.Node) error {
+	_, err := c.stub.RemovePeer(node.String())
+	return err
+}
+
+func (c *CoreRPCClient) ProcessTx(tx *meta.Transaction) error {
+	buff, err := tx.EncodeToBytes()
+	if err != nil {
+		log.Error("CoreRPCClient", "ProcessTx cmd encode", err)
+		return err
+	}
+	_, err = c.stub.ProcessTx(hex.EncodeToString(buff))
+	return err
+}
+
+//GetTXByID returns a nil transaction if the node doesn't know it.
+func (c *CoreRPCClient) GetTXByID(id meta.TxID) (*meta.Transaction, meta.BlockID, uint64, uint64, error) {
+	rsp, err := c.stub.GetTXByID(id)
+	if err != nil || rsp == nil {
+		return nil, meta.BlockID{}, 0, 0, err
+	}
+	buff, err := hex.DecodeString(rsp.Transaction)
+	if err != nil {
+		log.Error("CoreRPCClient", "GetTXByID response hex decode", err)
+		return nil, meta.BlockID{}, 0, 0, err
+	}
+	tx := &meta.Transaction{}
+	if err := tx.DecodeFromBytes(buff); err != nil {
+		log.Error("CoreRPCClient", "GetTXByID response decode", err)
+		return nil, meta.BlockID{}, 0, 0, err
+	}
+	return tx, rsp.BlockId, rsp.Number, rsp.Index, nil
+}
@@ -0,0 +1,104 @@
+package rpc
+
+import (
+	"math/big"
+	"reflect"
+
+	"github.com/mihongtech/linkchain-core/common/http/server"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/config"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/discover"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/peer"
+)
+
+//API is the node functionality served over rpc, implemented by node.CoreAPI.
+type API interface {
+	HasBlock(hash meta.BlockID) bool
+	GetHeader(hash math.Hash, height uint64) *meta.BlockHeader
+	GetChainConfig() *config.ChainConfig
+	GetBestBlock() *meta.Block
+	GetBlockNumber(id meta.BlockID) uint64
+	GetBlockByID(hash meta.BlockID) (*meta.Block, error)
+	GetBlockByHeight(height uint32) (*meta.Block, error)
+	GetChainID() *big.Int
+	Self() *discover.Node
+	AddPeer(node *discover.Node)
+	Peers() []*peer.Peer
+	RemovePeer(node *discover.Node)
+	ProcessTx(tx *meta.Transaction) error
+	GetTXByID(id meta.TxID) (*meta.Transaction, meta.BlockID, uint64, uint64)
+}
+
+//go:generate go test -run TestCoreStub -update
+
+type CoreRPCServer struct {
+	api       API
+	rpcServer *server.Server
+}
+
+func NewCoreRPCServer(cfg *server.Config, api API) (*CoreRPCServer, error) {
+	//create rpc server
+	rpcServer, err := server.NewRPCServer(cfg, api)
+	if err != nil {
+		log.Error("NewCoreRPCServer", "start rpc server failed", err)
+		return nil, err
+	}
+
+	//set handler
+	rpcServer.SetHandleFunc("HasBlock", onHasBlock)
+	rpcServer.SetHandleFunc("GetHeader", onGetHeader)
+	rpcServer.SetHandleFunc("GetChainConfig", onGetChainConfig)
+	rpcServer.SetHandleFunc("GetBestBlock", onGetBestBlock)
+	rpcServer.SetHandleFunc("GetBlockNumber", onGetBlockNumber)
+	rpcServer.SetHandleFunc("GetBlockByID", onGetBlockByID)
+	rpcServer.SetHandleFunc("GetBlockByHeight", onGetBlockByHeight)
+	rpcServer.SetHandleFunc("GetChainID", onGetChainID)
+	rpcServer.SetHandleFunc("Self", onSelf)
+	rpcServer.SetHandleFunc("AddPeer", onAddPeer)
+	rpcServer.SetHandleFunc("Peers", onPeers)
+	rpcServer.SetHandleFunc("RemovePeer", onRemovePeer)
+	rpcServer.SetHandleFunc("ProcessTx", onProcessTx)
+	rpcServer.SetHandleFunc("GetTXByID", onGetTXByID)
+	//set cmd
+	rpcServer.SetCmd("HasBlock", reflect.TypeOf((*BlockIDCmd)(nil)))
+	rpcServer.SetCmd("GetHeader", reflect.TypeOf((*HeaderCmd)(nil)))
+	rpcServer.SetCmd("GetBlockNumber", reflect.TypeOf((*BlockIDCmd)(nil)))
+	rpcServer.SetCmd("GetBlockByID", reflect.TypeOf((*BlockIDCmd)(nil)))
+	rpcServer.SetCmd("GetBlockByHeight", reflect.TypeOf((*HeightCmd)(nil)))
+	rpcServer.SetCmd("AddPeer", reflect.TypeOf((*NodeCmd)(nil)))
+	rpcServer.SetCmd("RemovePeer", reflect.TypeOf((*NodeCmd)(nil)))
+	rpcServer.SetCmd("ProcessTx", reflect.TypeOf((*TransactionCmd)(nil)))
+	rpcServer.SetCmd("GetTXByID", reflect.TypeOf((*TxIDCmd)(nil)))
+	//set result
+	rpcServer.SetResult("HasBlock", reflect.TypeOf(false))
+	rpcServer.SetResult("GetHeader", reflect.TypeOf((*CommonRSP)(nil)))
+	rpcServer.SetResult("GetChainConfig", reflect.TypeOf((*config.ChainConfig)(nil)))
+	rpcServer.SetResult("GetBestBlock", reflect.TypeOf((*CommonRSP)(nil)))
+	rpcServer.SetResult("GetBlockNumber", reflect.TypeOf(uint64(0)))
+	rpcServer.SetResult("GetBlockByID", reflect.TypeOf((*CommonRSP)(nil)))
+	rpcServer.SetResult("GetBlockByHeight", reflect.TypeOf((*CommonRSP)(nil)))
+	rpcServer.SetResult("GetChainID", reflect.TypeOf(""))
+	rpcServer.SetResult("Self", reflect.TypeOf((*NodeRSP)(nil)))
+	rpcServer.SetResult("Peers", reflect.TypeOf([]*peer.PeerInfo(nil)))
+	rpcServer.SetResult("GetTXByID", reflect.TypeOf((*TransactionRSP)(nil)))
+	//set websocket topics
+	if events, ok := api.(EventAPI); ok {
+		setSubscriptions(rpcServer, events)
+	}
+	return &CoreRPCServer{api: api, rpcServer: rpcServer}, nil
+}
+
+func (s *CoreRPCServer) SetUp(i interface{}) bool {
+	return true
+}
+
+func (s *CoreRPCServer) Start() bool {
+	return s.rpcServer.Start() == nil
+}
+
+func (s *CoreRPCServer) Stop() bool {
+	s.rpcServer.Stop()
+	return true
+}
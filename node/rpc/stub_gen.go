@@ -0,0 +1,148 @@
+// Code generated by rpcgen from the rpc_discover document of CoreRPCServer. DO NOT EDIT.
+
+package rpc
+
+import (
+	"encoding/json"
+
+	"github.com/mihongtech/linkchain-core/common/http/client"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/node/config"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/peer"
+)
+
+// coreStub calls the methods of core over JSON-RPC.
+type coreStub struct {
+	cfg *client.Config
+}
+
+// newCoreStub returns a client of the server described by cfg.
+func newCoreStub(cfg *client.Config) *coreStub {
+	return &coreStub{cfg: cfg}
+}
+
+// AddPeer calls the AddPeer method.
+func (c *coreStub) AddPeer(node string) (json.RawMessage, error) {
+	params := struct {
+		Node string `json:"node"`
+	}{node}
+	var result json.RawMessage
+	err := client.Call("AddPeer", &params, &result, c.cfg)
+	return result, err
+}
+
+// GetBestBlock calls the GetBestBlock method.
+func (c *coreStub) GetBestBlock() (*CommonRSP, error) {
+	var result *CommonRSP
+	err := client.Call("GetBestBlock", nil, &result, c.cfg)
+	return result, err
+}
+
+// GetBlockByHeight calls the GetBlockByHeight method.
+func (c *coreStub) GetBlockByHeight(height uint32) (*CommonRSP, error) {
+	params := struct {
+		Height uint32 `json:"height"`
+	}{height}
+	var result *CommonRSP
+	err := client.Call("GetBlockByHeight", &params, &result, c.cfg)
+	return result, err
+}
+
+// GetBlockByID calls the GetBlockByID method.
+func (c *coreStub) GetBlockByID(blockId math.Hash) (*CommonRSP, error) {
+	params := struct {
+		BlockId math.Hash `json:"blockId"`
+	}{blockId}
+	var result *CommonRSP
+	err := client.Call("GetBlockByID", &params, &result, c.cfg)
+	return result, err
+}
+
+// GetBlockNumber calls the GetBlockNumber method.
+func (c *coreStub) GetBlockNumber(blockId math.Hash) (uint64, error) {
+	params := struct {
+		BlockId math.Hash `json:"blockId"`
+	}{blockId}
+	var result uint64
+	err := client.Call("GetBlockNumber", &params, &result, c.cfg)
+	return result, err
+}
+
+// GetChainConfig calls the GetChainConfig method.
+func (c *coreStub) GetChainConfig() (*config.ChainConfig, error) {
+	var result *config.ChainConfig
+	err := client.Call("GetChainConfig", nil, &result, c.cfg)
+	return result, err
+}
+
+// GetChainID calls the GetChainID method.
+func (c *coreStub) GetChainID() (string, error) {
+	var result string
+	err := client.Call("GetChainID", nil, &result, c.cfg)
+	return result, err
+}
+
+// GetHeader calls the GetHeader method.
+func (c *coreStub) GetHeader(blockId math.Hash, height uint64) (*CommonRSP, error) {
+	params := struct {
+		BlockId math.Hash `json:"blockId"`
+		Height  uint64    `json:"height"`
+	}{blockId, height}
+	var result *CommonRSP
+	err := client.Call("GetHeader", &params, &result, c.cfg)
+	return result, err
+}
+
+// GetTXByID calls the GetTXByID method.
+func (c *coreStub) GetTXByID(txId math.Hash) (*TransactionRSP, error) {
+	params := struct {
+		TxId math.Hash `json:"txId"`
+	}{txId}
+	var result *TransactionRSP
+	err := client.Call("GetTXByID", &params, &result, c.cfg)
+	return result, err
+}
+
+// HasBlock calls the HasBlock method.
+func (c *coreStub) HasBlock(blockId math.Hash) (bool, error) {
+	params := struct {
+		BlockId math.Hash `json:"blockId"`
+	}{blockId}
+	var result bool
+	err := client.Call("HasBlock", &params, &result, c.cfg)
+	return result, err
+}
+
+// Peers calls the Peers method.
+func (c *coreStub) Peers() ([]*peer.PeerInfo, error) {
+	var result []*peer.PeerInfo
+	err := client.Call("Peers", nil, &result, c.cfg)
+	return result, err
+}
+
+// ProcessTx calls the ProcessTx method.
+func (c *coreStub) ProcessTx(transaction string) (json.RawMessage, error) {
+	params := struct {
+		Transaction string `json:"transaction"`
+	}{transaction}
+	var result json.RawMessage
+	err := client.Call("ProcessTx", &params, &result, c.cfg)
+	return result, err
+}
+
+// RemovePeer calls the RemovePeer method.
+func (c *coreStub) RemovePeer(node string) (json.RawMessage, error) {
+	params := struct {
+		Node string `json:"node"`
+	}{node}
+	var result json.RawMessage
+	err := client.Call("RemovePeer", &params, &result, c.cfg)
+	return result, err
+}
+
+// Self calls the Self method.
+func (c *coreStub) Self() (*NodeRSP, error) {
+	var result *NodeRSP
+	err := client.Call("Self", nil, &result, c.cfg)
+	return result, err
+}
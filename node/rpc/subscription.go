@@ -0,0 +1,144 @@
+package rpc
+
+import (
+	"encoding/hex"
+
+	"github.com/mihongtech/linkchain-core/common/http/server"
+	"github.com/mihongtech/linkchain-core/common/util/event"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	node_event "github.com/mihongtech/linkchain-core/node/event"
+	"github.com/mihongtech/linkchain-core/node/net/p2p/peer_error"
+)
+
+//eventQueue is the number of events buffered from a source for each subscriber.
+const eventQueue = 16
+
+//EventAPI is implemented by APIs whose events can be subscribed to over websocket.
+type EventAPI interface {
+	SubscribeChainHeadEvent(ch chan<- meta.ChainHeadEvent) event.Subscription
+	SubscribeChainSideEvent(ch chan<- meta.ChainSideEvent) event.Subscription
+	SubscribeTxEvent(ch chan<- node_event.TxEvent) event.Subscription
+	SubscribePeerEvent(ch chan *peer_error.PeerEvent) event.Subscription
+}
+
+//setSubscriptions registers the websocket topics of the events.
+func setSubscriptions(rpcServer *server.Server, api EventAPI) {
+	rpcServer.SetSubscription("newHeads", subscribeHeads(api))
+	rpcServer.SetSubscription("sideBlocks", subscribeSideBlocks(api))
+	rpcServer.SetSubscription("pendingTxs", subscribeTxs(api))
+	rpcServer.SetSubscription("peers", subscribePeers(api))
+}
+
+//notify hands a notification to the websocket client unless unsubscribed.
+func notify(ch chan<- interface{}, data interface{}, quit <-chan struct{}) bool {
+	select {
+	case ch <- data:
+		return true
+	case <-quit:
+		return false
+	}
+}
+
+func newHeadRSP(block *meta.Block) interface{} {
+	buff, err := block.Header.EncodeToBytes()
+	if err != nil {
+		log.Error("CoreRPCServer", "header encode", err)
+		return nil
+	}
+	return &HeadRSP{BlockId: *block.GetBlockID(), Height: block.GetHeight(), Header: hex.EncodeToString(buff)}
+}
+
+func subscribeHeads(api EventAPI) server.SubscribeFunc {
+	return func(ch chan<- interface{}) event.Subscription {
+		return event.NewSubscription(func(quit <-chan struct{}) error {
+			events := make(chan meta.ChainHeadEvent, eventQueue)
+			sub := api.SubscribeChainHeadEvent(events)
+			defer sub.Unsubscribe()
+			for {
+				select {
+				case ev := <-events:
+					if !notify(ch, newHeadRSP(ev.Block), quit) {
+						return nil
+					}
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			}
+		})
+	}
+}
+
+func subscribeSideBlocks(api EventAPI) server.SubscribeFunc {
+	return func(ch chan<- interface{}) event.Subscription {
+		return event.NewSubscription(func(quit <-chan struct{}) error {
+			events := make(chan meta.ChainSideEvent, eventQueue)
+			sub := api.SubscribeChainSideEvent(events)
+			defer sub.Unsubscribe()
+			for {
+				select {
+				case ev := <-events:
+					if !notify(ch, newHeadRSP(ev.Block), quit) {
+						return nil
+					}
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			}
+		})
+	}
+}
+
+func subscribeTxs(api EventAPI) server.SubscribeFunc {
+	return func(ch chan<- interface{}) event.Subscription {
+		return event.NewSubscription(func(quit <-chan struct{}) error {
+			events := make(chan node_event.TxEvent, eventQueue)
+			sub := api.SubscribeTxEvent(events)
+			defer sub.Unsubscribe()
+			for {
+				select {
+				case ev := <-events:
+					buff, err := ev.Tx.EncodeToBytes()
+					if err != nil {
+						log.Error("CoreRPCServer", "transaction encode", err)
+						continue
+					}
+					rsp := &TxEventRSP{TxId: *ev.Tx.GetTxID(), Transaction: hex.EncodeToString(buff)}
+					if !notify(ch, rsp, quit) {
+						return nil
+					}
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			}
+		})
+	}
+}
+
+func subscribePeers(api EventAPI) server.SubscribeFunc {
+	return func(ch chan<- interface{}) event.Subscription {
+		return event.NewSubscription(func(quit <-chan struct{}) error {
+			events := make(chan *peer_error.PeerEvent, eventQueue)
+			sub := api.SubscribePeerEvent(events)
+			defer sub.Unsubscribe()
+			for {
+				select {
+				case ev := <-events:
+					if !notify(ch, ev, quit) {
+						return nil
+					}
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			}
+		})
+	}
+}
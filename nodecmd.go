@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+
+	"github.com/mihongtech/linkchain-core/common/http/client"
+	"github.com/mihongtech/linkchain-core/common/lcdb"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/node"
+	"github.com/mihongtech/linkchain-core/node/chain/genesis"
+	chainstorage "github.com/mihongtech/linkchain-core/node/chain/storage"
+	"github.com/mihongtech/linkchain-core/node/config"
+	"github.com/mihongtech/linkchain-core/node/net/sync/full"
+	noderpc "github.com/mihongtech/linkchain-core/node/rpc"
+	"github.com/mihongtech/linkchain-core/proxy/rpc"
+	"github.com/mihongtech/linkchain-core/storage"
+)
+
+// clientVersion is the version of the linkchain executable.
+const clientVersion = "0.1.0"
+
+// gitCommit is the commit the executable was built from, set by the build:
+//
+//	go build -ldflags "-X main.gitCommit=$(git rev-parse HEAD)"
+var gitCommit = ""
+
+// databaseFlags binds the flags locating the chain database to cfg.
+func databaseFlags(flags *flag.FlagSet, cfg *config.BaseConfig) {
+	flags.StringVar(&cfg.DataDir, "datadir", config.DefaultDataDir(), "data directory of the chain")
+	flags.StringVar(&cfg.DatabaseBackend, "db", lcdb.DefaultBackend, "database backend of the chain")
+}
+
+// appFlags binds the flags reaching the app over BCSI RPC to the returned config.
+func appFlags(flags *flag.FlagSet) *client.Config {
+	app := &client.Config{}
+	flags.StringVar(&app.RPCServer, "app", "", "BCSI RPC address of the app checking the blocks")
+	flags.StringVar(&app.RPCUser, "appuser", "", "BCSI RPC username")
+	flags.StringVar(&app.RPCPassword, "apppass", "", "BCSI RPC password")
+	flags.StringVar(&app.RPCToken, "apptoken", "", "BCSI RPC bearer token")
+	return app
+}
+
+// runNode runs a node, with the app reached over BCSI RPC, until it receives
+// SIGINT or SIGTERM:
+//
+//	linkchain run -app host:port [-genesis file] [-datadir dir] [-listen addr] [-bootnodes enodes] [-rpcaddr addr] ...
+func runNode(args []string) error {
+	cfg := config.BaseConfig{}
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	databaseFlags(flags, &cfg)
+	flags.StringVar(&cfg.GenesisPath, "genesis", "", "genesis file of the chain, the stored or default genesis if empty")
+	flags.StringVar(&cfg.ListenAddress, "listen", ":30303", "p2p listening address")
+	flags.StringVar(&cfg.BootstrapNodes, "bootnodes", "", "comma separated enode URLs of the p2p bootstrap nodes")
+	flags.BoolVar(&cfg.NoDiscovery, "nodiscover", false, "disable the p2p peer discovery")
+	flags.StringVar(&cfg.DiscoveryMode, "discovery", "", "p2p peer discovery: udp, static, exchange or none")
+	flags.StringVar(&cfg.StaticNodesFile, "staticnodes", "", "file listing the nodes of the static discovery")
+	flags.BoolVar(&cfg.LightMode, "light", false, "run a header-only node")
+	flags.BoolVar(&cfg.FastSync, "fastsync", false, "download the app state of a recent block instead of replaying the chain")
+	flags.Uint64Var(&cfg.PruneBlocks, "prune", 0, "keep the bodies of only the most recent blocks, 0 keeps every block")
+	flags.BoolVar(&cfg.BlockIndexes, "indexes", false, "index the blocks by signer and time")
+	flags.StringVar(&cfg.RpcAddr, "rpcaddr", "", "listening address of the RPC server, none if empty")
+	flags.StringVar(&cfg.RpcUser, "rpcuser", "", "RPC username")
+	flags.StringVar(&cfg.RpcPassword, "rpcpass", "", "RPC password")
+	app := appFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || app.RPCServer == "" {
+		return errors.New("usage: run -app host:port [flags], see run -h")
+	}
+	bcsiAPI := rpc.NewBCSIRPCClient(app)
+	defer bcsiAPI.Close()
+
+	n := node.NewNode(cfg)
+	if !n.Setup(&node.Config{BaseConfig: cfg, BcsiAPI: bcsiAPI}) {
+		return errors.New("failed to set up the node")
+	}
+	if !n.Start() {
+		n.Stop()
+		return errors.New("failed to start the node")
+	}
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+	sig := <-sigc
+	log.Info("Got signal, shutting down...", "signal", sig)
+
+	// A second signal gives up on the clean shutdown
+	done := make(chan struct{})
+	go func() {
+		n.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-sigc:
+		return errors.New("interrupted again, exiting before the node stopped")
+	}
+}
+
+// initChain writes the genesis block of a genesis file into a new chain
+// database, or checks that an existing one has the same:
+//
+//	linkchain init -genesis file [-datadir dir] [-db backend]
+func initChain(args []string) error {
+	cfg := config.BaseConfig{}
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	databaseFlags(flags, &cfg)
+	flags.StringVar(&cfg.GenesisPath, "genesis", "", "genesis file of the chain")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || cfg.GenesisPath == "" {
+		return errors.New("usage: init -genesis file [-datadir dir] [-db backend]")
+	}
+
+	file, err := os.Open(cfg.GenesisPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	g := new(genesis.Genesis)
+	if err := json.NewDecoder(file).Decode(g); err != nil {
+		return fmt.Errorf("invalid genesis file: %v", err)
+	}
+
+	s := storage.NewStrorage(&cfg)
+	if s == nil {
+		return errors.New("failed to open the chain database")
+	}
+	defer s.GetDB().Close()
+
+	if err := chainstorage.Migrate(s.GetDB()); err != nil {
+		return err
+	}
+	_, hash, err := genesis.SetupGenesisBlock(s.GetDB(), g)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Initialized the chain in %s, genesis %s\n", cfg.DataDir, hash)
+	return nil
+}
+
+// listPeers prints the peers of a running node, asked over its RPC server:
+//
+//	linkchain peers -rpcaddr host:port [-rpcuser user] [-rpcpass password] [-rpctoken token] [-tls]
+func listPeers(args []string) error {
+	rpcCfg := &client.Config{}
+	flags := flag.NewFlagSet("peers", flag.ContinueOnError)
+	flags.StringVar(&rpcCfg.RPCServer, "rpcaddr", "", "RPC address of the node")
+	flags.StringVar(&rpcCfg.RPCUser, "rpcuser", "", "RPC username")
+	flags.StringVar(&rpcCfg.RPCPassword, "rpcpass", "", "RPC password")
+	flags.StringVar(&rpcCfg.RPCToken, "rpctoken", "", "RPC bearer token")
+	flags.BoolVar(&rpcCfg.TLS, "tls", false, "connect to the RPC server over TLS")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || rpcCfg.RPCServer == "" {
+		return errors.New("usage: peers -rpcaddr host:port [-rpcuser user] [-rpcpass password] [-rpctoken token] [-tls]")
+	}
+
+	peers, err := noderpc.NewCoreRPCClient(rpcCfg).Peers()
+	if err != nil {
+		return err
+	}
+	for _, p := range peers {
+		direction := "outbound"
+		if p.Network.Inbound {
+			direction = "inbound"
+		}
+		fmt.Printf("%s %s %s %s\n", p.ID, p.Network.RemoteAddress, direction, p.Name)
+	}
+	fmt.Printf("%d peers\n", len(peers))
+	return nil
+}
+
+// printVersion prints the version of the executable and of the formats it uses:
+//
+//	linkchain version
+func printVersion(args []string) error {
+	flags := flag.NewFlagSet("version", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	version := clientVersion
+	if gitCommit != "" {
+		version += "-" + gitCommit
+	}
+	fmt.Println("linkchain", version)
+	fmt.Println("Database version:", chainstorage.DatabaseVersion)
+	fmt.Println("Protocol versions:", full.ProtocolVersions)
+	fmt.Println("Database backends:", lcdb.Backends())
+	fmt.Printf("Go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return nil
+}
@@ -0,0 +1,222 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: protobuf/bcsi.proto
+
+package protobuf
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type BCSIRequest struct {
+	Id                   *uint64  `protobuf:"varint,1,req,name=id" json:"id,omitempty"`
+	Method               *string  `protobuf:"bytes,2,req,name=method" json:"method,omitempty"`
+	Data                 []byte   `protobuf:"bytes,3,opt,name=data" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BCSIRequest) Reset()         { *m = BCSIRequest{} }
+func (m *BCSIRequest) String() string { return proto.CompactTextString(m) }
+func (*BCSIRequest) ProtoMessage()    {}
+func (*BCSIRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_603f96baee472185, []int{0}
+}
+
+func (m *BCSIRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BCSIRequest.Unmarshal(m, b)
+}
+func (m *BCSIRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BCSIRequest.Marshal(b, m, deterministic)
+}
+func (m *BCSIRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BCSIRequest.Merge(m, src)
+}
+func (m *BCSIRequest) XXX_Size() int {
+	return xxx_messageInfo_BCSIRequest.Size(m)
+}
+func (m *BCSIRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BCSIRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BCSIRequest proto.InternalMessageInfo
+
+func (m *BCSIRequest) GetId() uint64 {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return 0
+}
+
+func (m *BCSIRequest) GetMethod() string {
+	if m != nil && m.Method != nil {
+		return *m.Method
+	}
+	return ""
+}
+
+func (m *BCSIRequest) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type BCSIResponse struct {
+	Id                   *uint64  `protobuf:"varint,1,req,name=id" json:"id,omitempty"`
+	Data                 []byte   `protobuf:"bytes,2,opt,name=data" json:"data,omitempty"`
+	Error                *string  `protobuf:"bytes,3,opt,name=error" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BCSIResponse) Reset()         { *m = BCSIResponse{} }
+func (m *BCSIResponse) String() string { return proto.CompactTextString(m) }
+func (*BCSIResponse) ProtoMessage()    {}
+func (*BCSIResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_603f96baee472185, []int{1}
+}
+
+func (m *BCSIResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BCSIResponse.Unmarshal(m, b)
+}
+func (m *BCSIResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BCSIResponse.Marshal(b, m, deterministic)
+}
+func (m *BCSIResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BCSIResponse.Merge(m, src)
+}
+func (m *BCSIResponse) XXX_Size() int {
+	return xxx_messageInfo_BCSIResponse.Size(m)
+}
+func (m *BCSIResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BCSIResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BCSIResponse proto.InternalMessageInfo
+
+func (m *BCSIResponse) GetId() uint64 {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return 0
+}
+
+func (m *BCSIResponse) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *BCSIResponse) GetError() string {
+	if m != nil && m.Error != nil {
+		return *m.Error
+	}
+	return ""
+}
+
+type TxLookup struct {
+	Tx                   *Transaction `protobuf:"bytes,1,req,name=tx" json:"tx,omitempty"`
+	BlockId              *Hash        `protobuf:"bytes,2,req,name=blockId" json:"blockId,omitempty"`
+	Height               *uint64      `protobuf:"varint,3,req,name=height" json:"height,omitempty"`
+	Index                *uint64      `protobuf:"varint,4,req,name=index" json:"index,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *TxLookup) Reset()         { *m = TxLookup{} }
+func (m *TxLookup) String() string { return proto.CompactTextString(m) }
+func (*TxLookup) ProtoMessage()    {}
+func (*TxLookup) Descriptor() ([]byte, []int) {
+	return fileDescriptor_603f96baee472185, []int{2}
+}
+
+func (m *TxLookup) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TxLookup.Unmarshal(m, b)
+}
+func (m *TxLookup) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TxLookup.Marshal(b, m, deterministic)
+}
+func (m *TxLookup) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TxLookup.Merge(m, src)
+}
+func (m *TxLookup) XXX_Size() int {
+	return xxx_messageInfo_TxLookup.Size(m)
+}
+func (m *TxLookup) XXX_DiscardUnknown() {
+	xxx_messageInfo_TxLookup.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TxLookup proto.InternalMessageInfo
+
+func (m *TxLookup) GetTx() *Transaction {
+	if m != nil {
+		return m.Tx
+	}
+	return nil
+}
+
+func (m *TxLookup) GetBlockId() *Hash {
+	if m != nil {
+		return m.BlockId
+	}
+	return nil
+}
+
+func (m *TxLookup) GetHeight() uint64 {
+	if m != nil && m.Height != nil {
+		return *m.Height
+	}
+	return 0
+}
+
+func (m *TxLookup) GetIndex() uint64 {
+	if m != nil && m.Index != nil {
+		return *m.Index
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*BCSIRequest)(nil), "protobuf.BCSIRequest")
+	proto.RegisterType((*BCSIResponse)(nil), "protobuf.BCSIResponse")
+	proto.RegisterType((*TxLookup)(nil), "protobuf.TxLookup")
+}
+
+func init() { proto.RegisterFile("protobuf/bcsi.proto", fileDescriptor_603f96baee472185) }
+
+var fileDescriptor_603f96baee472185 = []byte{
+	// 249 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x8f, 0x41, 0x6e, 0xb3, 0x30,
+	0x14, 0x84, 0x85, 0xc3, 0xff, 0x37, 0x79, 0x44, 0x59, 0xb8, 0x4d, 0x85, 0x58, 0x21, 0xa4, 0x4a,
+	0xac, 0xa8, 0x94, 0x23, 0xb4, 0x9b, 0x20, 0x75, 0xe5, 0xe6, 0x02, 0x06, 0xbb, 0xc5, 0x4a, 0xe1,
+	0x51, 0xdb, 0x48, 0x5c, 0xa1, 0xb7, 0xae, 0x62, 0xc7, 0x61, 0xd1, 0x9d, 0xbf, 0x19, 0xbf, 0xd1,
+	0x0c, 0xdc, 0x8f, 0x1a, 0x2d, 0x36, 0xd3, 0xc7, 0x73, 0xd3, 0x1a, 0x55, 0x39, 0xa2, 0xeb, 0x20,
+	0x66, 0xfb, 0x9b, 0xdd, 0x62, 0xdf, 0xe3, 0xe0, 0x3f, 0x64, 0xd9, 0x4d, 0xb6, 0x9a, 0x0f, 0x86,
+	0xb7, 0x56, 0x05, 0xaf, 0xa8, 0x21, 0x79, 0x79, 0x7d, 0xaf, 0x99, 0xfc, 0x9e, 0xa4, 0xb1, 0x74,
+	0x07, 0x44, 0x89, 0x34, 0xca, 0x49, 0x19, 0x33, 0xa2, 0x04, 0x7d, 0x84, 0xff, 0xbd, 0xb4, 0x1d,
+	0x8a, 0x94, 0xe4, 0xa4, 0xdc, 0xb0, 0x2b, 0x51, 0x0a, 0xb1, 0xe0, 0x96, 0xa7, 0xab, 0x3c, 0x2a,
+	0xb7, 0xcc, 0xbd, 0x8b, 0x23, 0x6c, 0x7d, 0x94, 0x19, 0x71, 0x30, 0xf2, 0x4f, 0x56, 0xb8, 0x21,
+	0xcb, 0x0d, 0x7d, 0x80, 0x7f, 0x52, 0x6b, 0xd4, 0x2e, 0x68, 0xc3, 0x3c, 0x14, 0x3f, 0x11, 0xac,
+	0x4f, 0xf3, 0x1b, 0xe2, 0x79, 0x1a, 0xe9, 0x13, 0x10, 0x3b, 0xbb, 0x98, 0xe4, 0xb0, 0xaf, 0xc2,
+	0x94, 0xea, 0xb4, 0x4c, 0x61, 0xc4, 0xce, 0xb4, 0x84, 0xbb, 0xe6, 0x0b, 0xdb, 0x73, 0xed, 0xab,
+	0x26, 0x87, 0xdd, 0xf2, 0xf7, 0xc8, 0x4d, 0xc7, 0x82, 0x7d, 0xd9, 0xd4, 0x49, 0xf5, 0xd9, 0xd9,
+	0x74, 0xe5, 0xba, 0x5d, 0xe9, 0xd2, 0x45, 0x0d, 0x42, 0xce, 0x69, 0xec, 0x64, 0x0f, 0xbf, 0x03,
+	0x00, 0x30, 0xff, 0xce, 0xee, 0x73, 0x01, 0x00, 0x00,
+}
@@ -180,6 +180,7 @@ func (m *Block) GetTxList() *Transactions {
 
 type Blocks struct {
 	Block                []*Block `protobuf:"bytes,1,rep,name=block" json:"block,omitempty"`
+	PrunedHeight         *uint64  `protobuf:"varint,2,opt,name=prunedHeight" json:"prunedHeight,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -217,35 +218,84 @@ func (m *Blocks) GetBlock() []*Block {
 	return nil
 }
 
+func (m *Blocks) GetPrunedHeight() uint64 {
+	if m != nil && m.PrunedHeight != nil {
+		return *m.PrunedHeight
+	}
+	return 0
+}
+
+type BlockHeaders struct {
+	Headers              []*BlockHeader `protobuf:"bytes,1,rep,name=headers" json:"headers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *BlockHeaders) Reset()         { *m = BlockHeaders{} }
+func (m *BlockHeaders) String() string { return proto.CompactTextString(m) }
+func (*BlockHeaders) ProtoMessage()    {}
+func (*BlockHeaders) Descriptor() ([]byte, []int) {
+	return fileDescriptor_65a48bcf14e684fd, []int{3}
+}
+
+func (m *BlockHeaders) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BlockHeaders.Unmarshal(m, b)
+}
+func (m *BlockHeaders) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BlockHeaders.Marshal(b, m, deterministic)
+}
+func (m *BlockHeaders) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BlockHeaders.Merge(m, src)
+}
+func (m *BlockHeaders) XXX_Size() int {
+	return xxx_messageInfo_BlockHeaders.Size(m)
+}
+func (m *BlockHeaders) XXX_DiscardUnknown() {
+	xxx_messageInfo_BlockHeaders.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BlockHeaders proto.InternalMessageInfo
+
+func (m *BlockHeaders) GetHeaders() []*BlockHeader {
+	if m != nil {
+		return m.Headers
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*BlockHeader)(nil), "protobuf.BlockHeader")
 	proto.RegisterType((*Block)(nil), "protobuf.Block")
 	proto.RegisterType((*Blocks)(nil), "protobuf.Blocks")
+	proto.RegisterType((*BlockHeaders)(nil), "protobuf.BlockHeaders")
 }
 
 func init() { proto.RegisterFile("protobuf/block.proto", fileDescriptor_65a48bcf14e684fd) }
 
 var fileDescriptor_65a48bcf14e684fd = []byte{
-	// 323 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x91, 0xdf, 0x4e, 0x83, 0x30,
-	0x14, 0xc6, 0x03, 0x63, 0x6c, 0x1e, 0xfc, 0x93, 0x54, 0xb7, 0x34, 0xbb, 0x30, 0x84, 0x44, 0xe5,
-	0x46, 0x96, 0xf0, 0x08, 0x5e, 0xed, 0xc2, 0xab, 0xea, 0x0b, 0x74, 0xac, 0x6c, 0x8d, 0xa3, 0x5d,
-	0xe8, 0x61, 0xd1, 0x77, 0xf3, 0xe1, 0x4c, 0x0b, 0x8c, 0xc5, 0xec, 0xae, 0xdf, 0xf9, 0xfd, 0x72,
-	0xa0, 0x5f, 0xe1, 0xe1, 0x50, 0x6b, 0xd4, 0xeb, 0xa6, 0x5c, 0xae, 0xf7, 0xba, 0xf8, 0xca, 0x5c,
-	0x24, 0xd3, 0x7e, 0xba, 0x98, 0x9d, 0x78, 0xa1, 0xab, 0x4a, 0xab, 0x56, 0x58, 0x2c, 0x4e, 0x63,
-	0xac, 0xb9, 0x32, 0xbc, 0x40, 0xd9, 0xb3, 0xe4, 0xd7, 0x87, 0xe8, 0xcd, 0x2e, 0x5b, 0x09, 0xbe,
-	0x11, 0x35, 0xa1, 0x30, 0x39, 0x8a, 0xda, 0x48, 0xad, 0xa8, 0x17, 0xfb, 0xe9, 0x0d, 0xeb, 0x23,
-	0x99, 0x43, 0xb8, 0x13, 0x72, 0xbb, 0x43, 0xea, 0x3b, 0xd0, 0x25, 0x42, 0x20, 0x40, 0x59, 0x09,
-	0x3a, 0x8a, 0xfd, 0x74, 0xc4, 0xdc, 0xd9, 0xba, 0x4a, 0x37, 0xaa, 0x10, 0x34, 0x68, 0xdd, 0x36,
-	0x91, 0x47, 0x80, 0x8d, 0x2c, 0x4b, 0x59, 0x34, 0x7b, 0xfc, 0xa1, 0x63, 0xc7, 0xce, 0x26, 0x24,
-	0x81, 0xe0, 0x50, 0x8b, 0x23, 0x0d, 0x63, 0x3f, 0x8d, 0xf2, 0xdb, 0xac, 0xff, 0xf1, 0x6c, 0xc5,
-	0xcd, 0x8e, 0x39, 0x46, 0x9e, 0x21, 0xc4, 0x6f, 0xa6, 0x35, 0xd2, 0xc9, 0x45, 0xab, 0xa3, 0xd6,
-	0x33, 0xc8, 0xb1, 0x31, 0x74, 0x7a, 0xd9, 0x6b, 0x29, 0x79, 0x81, 0xc0, 0xc8, 0xad, 0xa2, 0x57,
-	0xb1, 0x97, 0x46, 0xf9, 0xfd, 0x60, 0x7d, 0xc8, 0xad, 0xe2, 0xd8, 0xd4, 0x82, 0x39, 0xc1, 0x5e,
-	0x74, 0xc3, 0x91, 0x53, 0x88, 0xbd, 0xf4, 0x9a, 0xb9, 0x73, 0x52, 0xc2, 0xd8, 0xb5, 0x47, 0x5e,
-	0x6d, 0x3b, 0xb6, 0x41, 0x57, 0x5b, 0x94, 0xcf, 0x86, 0x3d, 0x67, 0xf5, 0xb2, 0x4e, 0x22, 0x99,
-	0xbd, 0xc4, 0xbb, 0x34, 0x6d, 0x99, 0x51, 0x3e, 0x1f, 0xf4, 0xcf, 0xe1, 0x8d, 0x0c, 0xeb, 0xac,
-	0x64, 0x09, 0xa1, 0x5b, 0x63, 0xc8, 0x13, 0x8c, 0xdd, 0xe3, 0x53, 0x2f, 0x1e, 0xa5, 0x51, 0x7e,
-	0xf7, 0xef, 0x3b, 0xac, 0xa5, 0x7f, 0x01, 0x00, 0x00, 0xff, 0xff, 0x73, 0x1c, 0x98, 0x42, 0x2b,
+	// 355 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x91, 0x41, 0x4e, 0xf3, 0x30,
+	0x10, 0x85, 0x95, 0x34, 0x4d, 0xfb, 0x4f, 0xfa, 0x83, 0x34, 0xd0, 0xca, 0xea, 0x02, 0x45, 0x91,
+	0x80, 0x6c, 0x48, 0xa5, 0x5c, 0x00, 0x89, 0x55, 0x17, 0xac, 0x5c, 0x2e, 0xe0, 0x26, 0x4e, 0x6b,
+	0xd1, 0xda, 0x55, 0xec, 0x54, 0x70, 0x37, 0x0e, 0x87, 0xe2, 0x24, 0x4d, 0x41, 0x65, 0xe7, 0x99,
+	0xf7, 0xe9, 0x79, 0xe6, 0x0d, 0xdc, 0x1e, 0x4a, 0x65, 0xd4, 0xba, 0x2a, 0x16, 0xeb, 0x9d, 0xca,
+	0xde, 0x13, 0x5b, 0xe2, 0xb8, 0xeb, 0xce, 0xa7, 0x27, 0x3d, 0x53, 0xfb, 0xbd, 0x92, 0x0d, 0x30,
+	0x9f, 0x9f, 0xda, 0xa6, 0x64, 0x52, 0xb3, 0xcc, 0x88, 0x4e, 0x8b, 0xbe, 0x5c, 0x08, 0x5e, 0x6a,
+	0xb3, 0x25, 0x67, 0x39, 0x2f, 0x91, 0xc0, 0xe8, 0xc8, 0x4b, 0x2d, 0x94, 0x24, 0x4e, 0xe8, 0xc6,
+	0xff, 0x69, 0x57, 0xe2, 0x0c, 0xfc, 0x2d, 0x17, 0x9b, 0xad, 0x21, 0xae, 0x15, 0xda, 0x0a, 0x11,
+	0x3c, 0x23, 0xf6, 0x9c, 0x0c, 0x42, 0x37, 0x1e, 0x50, 0xfb, 0xae, 0x59, 0xa9, 0x2a, 0x99, 0x71,
+	0xe2, 0x35, 0x6c, 0x53, 0xe1, 0x1d, 0x40, 0x2e, 0x8a, 0x42, 0x64, 0xd5, 0xce, 0x7c, 0x92, 0xa1,
+	0xd5, 0xce, 0x3a, 0x18, 0x81, 0x77, 0x28, 0xf9, 0x91, 0xf8, 0xa1, 0x1b, 0x07, 0xe9, 0x55, 0xd2,
+	0x0d, 0x9e, 0x2c, 0x99, 0xde, 0x52, 0xab, 0xe1, 0x03, 0xf8, 0xe6, 0x83, 0x2a, 0x65, 0xc8, 0xe8,
+	0x22, 0xd5, 0xaa, 0x35, 0xa7, 0x0d, 0x33, 0x95, 0x26, 0xe3, 0xcb, 0x5c, 0xa3, 0xe2, 0x23, 0x78,
+	0x5a, 0x6c, 0x24, 0xf9, 0x17, 0x3a, 0x71, 0x90, 0xde, 0xf4, 0xd4, 0x4a, 0x6c, 0x24, 0x33, 0x55,
+	0xc9, 0xa9, 0x05, 0xea, 0x45, 0x73, 0x66, 0x18, 0x81, 0xd0, 0x89, 0x27, 0xd4, 0xbe, 0xa3, 0x02,
+	0x86, 0x36, 0x3d, 0x7c, 0xaa, 0xd3, 0xa9, 0x13, 0xb4, 0xb1, 0x05, 0xe9, 0xb4, 0xf7, 0x39, 0x8b,
+	0x97, 0xb6, 0x10, 0x26, 0xf5, 0x12, 0xaf, 0x42, 0x37, 0x61, 0x06, 0xe9, 0xac, 0xc7, 0xdf, 0xfa,
+	0x1b, 0x69, 0xda, 0x52, 0xd1, 0x0a, 0x7c, 0x6b, 0xa3, 0xf1, 0x1e, 0x86, 0xf6, 0xf8, 0xc4, 0x09,
+	0x07, 0x71, 0x90, 0x5e, 0xff, 0xfa, 0x87, 0x36, 0x2a, 0x46, 0x30, 0x39, 0x94, 0x95, 0xe4, 0xf9,
+	0xb2, 0xbb, 0x99, 0x13, 0x7b, 0xf4, 0x47, 0x2f, 0x7a, 0x86, 0xc9, 0xd9, 0x6c, 0x1a, 0x17, 0x30,
+	0x6a, 0xc6, 0xd3, 0xad, 0xf9, 0x1f, 0x4b, 0x74, 0xd4, 0xf7, 0x00, 0xa6, 0x1c, 0x82, 0x68, 0x90,
 	0x02, 0x00, 0x00,
 }
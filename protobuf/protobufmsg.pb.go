@@ -26,6 +26,8 @@ type StatusData struct {
 	Height               *uint64  `protobuf:"varint,3,req,name=height" json:"height,omitempty"`
 	CurrentBlock         *Hash    `protobuf:"bytes,4,req,name=currentBlock" json:"currentBlock,omitempty"`
 	GenesisBlock         *Hash    `protobuf:"bytes,5,req,name=genesisBlock" json:"genesisBlock,omitempty"`
+	LightServer          *bool    `protobuf:"varint,6,opt,name=lightServer" json:"lightServer,omitempty"`
+	PrunedHeight         *uint64  `protobuf:"varint,7,opt,name=prunedHeight" json:"prunedHeight,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -91,6 +93,20 @@ func (m *StatusData) GetGenesisBlock() *Hash {
 	return nil
 }
 
+func (m *StatusData) GetLightServer() bool {
+	if m != nil && m.LightServer != nil {
+		return *m.LightServer
+	}
+	return false
+}
+
+func (m *StatusData) GetPrunedHeight() uint64 {
+	if m != nil && m.PrunedHeight != nil {
+		return *m.PrunedHeight
+	}
+	return 0
+}
+
 type NewBlockHashData struct {
 	Hash                 *Hash    `protobuf:"bytes,1,req,name=hash" json:"hash,omitempty"`
 	Number               *uint64  `protobuf:"varint,2,req,name=number" json:"number,omitempty"`
@@ -240,6 +256,351 @@ func (m *GetBlockHeadersData) GetSkip() uint64 {
 	return 0
 }
 
+type GetTxProofData struct {
+	ReqId                *uint64  `protobuf:"varint,1,req,name=reqId" json:"reqId,omitempty"`
+	TxId                 *Hash    `protobuf:"bytes,2,req,name=txId" json:"txId,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTxProofData) Reset()         { *m = GetTxProofData{} }
+func (m *GetTxProofData) String() string { return proto.CompactTextString(m) }
+func (*GetTxProofData) ProtoMessage()    {}
+func (*GetTxProofData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_47f67d614acbc48c, []int{4}
+}
+
+func (m *GetTxProofData) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTxProofData.Unmarshal(m, b)
+}
+func (m *GetTxProofData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTxProofData.Marshal(b, m, deterministic)
+}
+func (m *GetTxProofData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTxProofData.Merge(m, src)
+}
+func (m *GetTxProofData) XXX_Size() int {
+	return xxx_messageInfo_GetTxProofData.Size(m)
+}
+func (m *GetTxProofData) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTxProofData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTxProofData proto.InternalMessageInfo
+
+func (m *GetTxProofData) GetReqId() uint64 {
+	if m != nil && m.ReqId != nil {
+		return *m.ReqId
+	}
+	return 0
+}
+
+func (m *GetTxProofData) GetTxId() *Hash {
+	if m != nil {
+		return m.TxId
+	}
+	return nil
+}
+
+type TxProofData struct {
+	ReqId                *uint64      `protobuf:"varint,1,req,name=reqId" json:"reqId,omitempty"`
+	BlockHash            *Hash        `protobuf:"bytes,2,opt,name=blockHash" json:"blockHash,omitempty"`
+	Number               *uint64      `protobuf:"varint,3,opt,name=number" json:"number,omitempty"`
+	Index                *uint64      `protobuf:"varint,4,opt,name=index" json:"index,omitempty"`
+	Tx                   *Transaction `protobuf:"bytes,5,opt,name=tx" json:"tx,omitempty"`
+	Proof                [][]byte     `protobuf:"bytes,6,rep,name=proof" json:"proof,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *TxProofData) Reset()         { *m = TxProofData{} }
+func (m *TxProofData) String() string { return proto.CompactTextString(m) }
+func (*TxProofData) ProtoMessage()    {}
+func (*TxProofData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_47f67d614acbc48c, []int{5}
+}
+
+func (m *TxProofData) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TxProofData.Unmarshal(m, b)
+}
+func (m *TxProofData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TxProofData.Marshal(b, m, deterministic)
+}
+func (m *TxProofData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TxProofData.Merge(m, src)
+}
+func (m *TxProofData) XXX_Size() int {
+	return xxx_messageInfo_TxProofData.Size(m)
+}
+func (m *TxProofData) XXX_DiscardUnknown() {
+	xxx_messageInfo_TxProofData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TxProofData proto.InternalMessageInfo
+
+func (m *TxProofData) GetReqId() uint64 {
+	if m != nil && m.ReqId != nil {
+		return *m.ReqId
+	}
+	return 0
+}
+
+func (m *TxProofData) GetBlockHash() *Hash {
+	if m != nil {
+		return m.BlockHash
+	}
+	return nil
+}
+
+func (m *TxProofData) GetNumber() uint64 {
+	if m != nil && m.Number != nil {
+		return *m.Number
+	}
+	return 0
+}
+
+func (m *TxProofData) GetIndex() uint64 {
+	if m != nil && m.Index != nil {
+		return *m.Index
+	}
+	return 0
+}
+
+func (m *TxProofData) GetTx() *Transaction {
+	if m != nil {
+		return m.Tx
+	}
+	return nil
+}
+
+func (m *TxProofData) GetProof() [][]byte {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+type GetNodeData struct {
+	Hashes               []*Hash  `protobuf:"bytes,1,rep,name=hashes" json:"hashes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetNodeData) Reset()         { *m = GetNodeData{} }
+func (m *GetNodeData) String() string { return proto.CompactTextString(m) }
+func (*GetNodeData) ProtoMessage()    {}
+func (*GetNodeData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_47f67d614acbc48c, []int{6}
+}
+
+func (m *GetNodeData) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetNodeData.Unmarshal(m, b)
+}
+func (m *GetNodeData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetNodeData.Marshal(b, m, deterministic)
+}
+func (m *GetNodeData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetNodeData.Merge(m, src)
+}
+func (m *GetNodeData) XXX_Size() int {
+	return xxx_messageInfo_GetNodeData.Size(m)
+}
+func (m *GetNodeData) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetNodeData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetNodeData proto.InternalMessageInfo
+
+func (m *GetNodeData) GetHashes() []*Hash {
+	if m != nil {
+		return m.Hashes
+	}
+	return nil
+}
+
+type NodeData struct {
+	Data                 [][]byte `protobuf:"bytes,1,rep,name=data" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeData) Reset()         { *m = NodeData{} }
+func (m *NodeData) String() string { return proto.CompactTextString(m) }
+func (*NodeData) ProtoMessage()    {}
+func (*NodeData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_47f67d614acbc48c, []int{7}
+}
+
+func (m *NodeData) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeData.Unmarshal(m, b)
+}
+func (m *NodeData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeData.Marshal(b, m, deterministic)
+}
+func (m *NodeData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeData.Merge(m, src)
+}
+func (m *NodeData) XXX_Size() int {
+	return xxx_messageInfo_NodeData.Size(m)
+}
+func (m *NodeData) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeData proto.InternalMessageInfo
+
+func (m *NodeData) GetData() [][]byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type CompactBlock struct {
+	Header               *BlockHeader `protobuf:"bytes,1,req,name=header" json:"header,omitempty"`
+	ShortIds             []uint64     `protobuf:"varint,2,rep,name=shortIds" json:"shortIds,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *CompactBlock) Reset()         { *m = CompactBlock{} }
+func (m *CompactBlock) String() string { return proto.CompactTextString(m) }
+func (*CompactBlock) ProtoMessage()    {}
+func (*CompactBlock) Descriptor() ([]byte, []int) {
+	return fileDescriptor_47f67d614acbc48c, []int{8}
+}
+
+func (m *CompactBlock) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CompactBlock.Unmarshal(m, b)
+}
+func (m *CompactBlock) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CompactBlock.Marshal(b, m, deterministic)
+}
+func (m *CompactBlock) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CompactBlock.Merge(m, src)
+}
+func (m *CompactBlock) XXX_Size() int {
+	return xxx_messageInfo_CompactBlock.Size(m)
+}
+func (m *CompactBlock) XXX_DiscardUnknown() {
+	xxx_messageInfo_CompactBlock.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CompactBlock proto.InternalMessageInfo
+
+func (m *CompactBlock) GetHeader() *BlockHeader {
+	if m != nil {
+		return m.Header
+	}
+	return nil
+}
+
+func (m *CompactBlock) GetShortIds() []uint64 {
+	if m != nil {
+		return m.ShortIds
+	}
+	return nil
+}
+
+type GetBlockTxnData struct {
+	BlockHash            *Hash    `protobuf:"bytes,1,req,name=blockHash" json:"blockHash,omitempty"`
+	Indexes              []uint32 `protobuf:"varint,2,rep,name=indexes" json:"indexes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetBlockTxnData) Reset()         { *m = GetBlockTxnData{} }
+func (m *GetBlockTxnData) String() string { return proto.CompactTextString(m) }
+func (*GetBlockTxnData) ProtoMessage()    {}
+func (*GetBlockTxnData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_47f67d614acbc48c, []int{9}
+}
+
+func (m *GetBlockTxnData) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBlockTxnData.Unmarshal(m, b)
+}
+func (m *GetBlockTxnData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBlockTxnData.Marshal(b, m, deterministic)
+}
+func (m *GetBlockTxnData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBlockTxnData.Merge(m, src)
+}
+func (m *GetBlockTxnData) XXX_Size() int {
+	return xxx_messageInfo_GetBlockTxnData.Size(m)
+}
+func (m *GetBlockTxnData) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBlockTxnData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBlockTxnData proto.InternalMessageInfo
+
+func (m *GetBlockTxnData) GetBlockHash() *Hash {
+	if m != nil {
+		return m.BlockHash
+	}
+	return nil
+}
+
+func (m *GetBlockTxnData) GetIndexes() []uint32 {
+	if m != nil {
+		return m.Indexes
+	}
+	return nil
+}
+
+type BlockTxnData struct {
+	BlockHash            *Hash          `protobuf:"bytes,1,req,name=blockHash" json:"blockHash,omitempty"`
+	Txs                  []*Transaction `protobuf:"bytes,2,rep,name=txs" json:"txs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *BlockTxnData) Reset()         { *m = BlockTxnData{} }
+func (m *BlockTxnData) String() string { return proto.CompactTextString(m) }
+func (*BlockTxnData) ProtoMessage()    {}
+func (*BlockTxnData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_47f67d614acbc48c, []int{10}
+}
+
+func (m *BlockTxnData) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BlockTxnData.Unmarshal(m, b)
+}
+func (m *BlockTxnData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BlockTxnData.Marshal(b, m, deterministic)
+}
+func (m *BlockTxnData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BlockTxnData.Merge(m, src)
+}
+func (m *BlockTxnData) XXX_Size() int {
+	return xxx_messageInfo_BlockTxnData.Size(m)
+}
+func (m *BlockTxnData) XXX_DiscardUnknown() {
+	xxx_messageInfo_BlockTxnData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BlockTxnData proto.InternalMessageInfo
+
+func (m *BlockTxnData) GetBlockHash() *Hash {
+	if m != nil {
+		return m.BlockHash
+	}
+	return nil
+}
+
+func (m *BlockTxnData) GetTxs() []*Transaction {
+	if m != nil {
+		return m.Txs
+	}
+	return nil
+}
+
 type Msg struct {
 	Code                 *uint64  `protobuf:"varint,1,req,name=code" json:"code,omitempty"`
 	Payload              []byte   `protobuf:"bytes,2,opt,name=payload" json:"payload,omitempty"`
@@ -252,7 +613,7 @@ func (m *Msg) Reset()         { *m = Msg{} }
 func (m *Msg) String() string { return proto.CompactTextString(m) }
 func (*Msg) ProtoMessage()    {}
 func (*Msg) Descriptor() ([]byte, []int) {
-	return fileDescriptor_47f67d614acbc48c, []int{4}
+	return fileDescriptor_47f67d614acbc48c, []int{11}
 }
 
 func (m *Msg) XXX_Unmarshal(b []byte) error {
@@ -299,7 +660,7 @@ func (m *Cap) Reset()         { *m = Cap{} }
 func (m *Cap) String() string { return proto.CompactTextString(m) }
 func (*Cap) ProtoMessage()    {}
 func (*Cap) Descriptor() ([]byte, []int) {
-	return fileDescriptor_47f67d614acbc48c, []int{5}
+	return fileDescriptor_47f67d614acbc48c, []int{12}
 }
 
 func (m *Cap) XXX_Unmarshal(b []byte) error {
@@ -350,7 +711,7 @@ func (m *ProtoHandshake) Reset()         { *m = ProtoHandshake{} }
 func (m *ProtoHandshake) String() string { return proto.CompactTextString(m) }
 func (*ProtoHandshake) ProtoMessage()    {}
 func (*ProtoHandshake) Descriptor() ([]byte, []int) {
-	return fileDescriptor_47f67d614acbc48c, []int{6}
+	return fileDescriptor_47f67d614acbc48c, []int{13}
 }
 
 func (m *ProtoHandshake) XXX_Unmarshal(b []byte) error {
@@ -427,7 +788,7 @@ func (m *Node) Reset()         { *m = Node{} }
 func (m *Node) String() string { return proto.CompactTextString(m) }
 func (*Node) ProtoMessage()    {}
 func (*Node) Descriptor() ([]byte, []int) {
-	return fileDescriptor_47f67d614acbc48c, []int{7}
+	return fileDescriptor_47f67d614acbc48c, []int{14}
 }
 
 func (m *Node) XXX_Unmarshal(b []byte) error {
@@ -476,6 +837,45 @@ func (m *Node) GetId() []byte {
 	return nil
 }
 
+type Peers struct {
+	Nodes                []*Node  `protobuf:"bytes,1,rep,name=nodes" json:"nodes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Peers) Reset()         { *m = Peers{} }
+func (m *Peers) String() string { return proto.CompactTextString(m) }
+func (*Peers) ProtoMessage()    {}
+func (*Peers) Descriptor() ([]byte, []int) {
+	return fileDescriptor_47f67d614acbc48c, []int{15}
+}
+
+func (m *Peers) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Peers.Unmarshal(m, b)
+}
+func (m *Peers) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Peers.Marshal(b, m, deterministic)
+}
+func (m *Peers) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Peers.Merge(m, src)
+}
+func (m *Peers) XXX_Size() int {
+	return xxx_messageInfo_Peers.Size(m)
+}
+func (m *Peers) XXX_DiscardUnknown() {
+	xxx_messageInfo_Peers.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Peers proto.InternalMessageInfo
+
+func (m *Peers) GetNodes() []*Node {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
 type Endpoint struct {
 	Ip                   []byte   `protobuf:"bytes,1,req,name=ip" json:"ip,omitempty"`
 	Udp                  *uint32  `protobuf:"varint,2,req,name=udp" json:"udp,omitempty"`
@@ -489,7 +889,7 @@ func (m *Endpoint) Reset()         { *m = Endpoint{} }
 func (m *Endpoint) String() string { return proto.CompactTextString(m) }
 func (*Endpoint) ProtoMessage()    {}
 func (*Endpoint) Descriptor() ([]byte, []int) {
-	return fileDescriptor_47f67d614acbc48c, []int{8}
+	return fileDescriptor_47f67d614acbc48c, []int{16}
 }
 
 func (m *Endpoint) XXX_Unmarshal(b []byte) error {
@@ -546,7 +946,7 @@ func (m *Ping) Reset()         { *m = Ping{} }
 func (m *Ping) String() string { return proto.CompactTextString(m) }
 func (*Ping) ProtoMessage()    {}
 func (*Ping) Descriptor() ([]byte, []int) {
-	return fileDescriptor_47f67d614acbc48c, []int{9}
+	return fileDescriptor_47f67d614acbc48c, []int{17}
 }
 
 func (m *Ping) XXX_Unmarshal(b []byte) error {
@@ -616,7 +1016,7 @@ func (m *Pong) Reset()         { *m = Pong{} }
 func (m *Pong) String() string { return proto.CompactTextString(m) }
 func (*Pong) ProtoMessage()    {}
 func (*Pong) Descriptor() ([]byte, []int) {
-	return fileDescriptor_47f67d614acbc48c, []int{10}
+	return fileDescriptor_47f67d614acbc48c, []int{18}
 }
 
 func (m *Pong) XXX_Unmarshal(b []byte) error {
@@ -678,7 +1078,7 @@ func (m *Findnode) Reset()         { *m = Findnode{} }
 func (m *Findnode) String() string { return proto.CompactTextString(m) }
 func (*Findnode) ProtoMessage()    {}
 func (*Findnode) Descriptor() ([]byte, []int) {
-	return fileDescriptor_47f67d614acbc48c, []int{11}
+	return fileDescriptor_47f67d614acbc48c, []int{19}
 }
 
 func (m *Findnode) XXX_Unmarshal(b []byte) error {
@@ -733,7 +1133,7 @@ func (m *Neighbors) Reset()         { *m = Neighbors{} }
 func (m *Neighbors) String() string { return proto.CompactTextString(m) }
 func (*Neighbors) ProtoMessage()    {}
 func (*Neighbors) Descriptor() ([]byte, []int) {
-	return fileDescriptor_47f67d614acbc48c, []int{12}
+	return fileDescriptor_47f67d614acbc48c, []int{20}
 }
 
 func (m *Neighbors) XXX_Unmarshal(b []byte) error {
@@ -780,10 +1180,18 @@ func init() {
 	proto.RegisterType((*NewBlockHashData)(nil), "protobuf.NewBlockHashData")
 	proto.RegisterType((*NewBlockHashesDatas)(nil), "protobuf.NewBlockHashesDatas")
 	proto.RegisterType((*GetBlockHeadersData)(nil), "protobuf.GetBlockHeadersData")
+	proto.RegisterType((*GetTxProofData)(nil), "protobuf.GetTxProofData")
+	proto.RegisterType((*TxProofData)(nil), "protobuf.TxProofData")
+	proto.RegisterType((*GetNodeData)(nil), "protobuf.GetNodeData")
+	proto.RegisterType((*NodeData)(nil), "protobuf.NodeData")
+	proto.RegisterType((*CompactBlock)(nil), "protobuf.CompactBlock")
+	proto.RegisterType((*GetBlockTxnData)(nil), "protobuf.GetBlockTxnData")
+	proto.RegisterType((*BlockTxnData)(nil), "protobuf.BlockTxnData")
 	proto.RegisterType((*Msg)(nil), "protobuf.Msg")
 	proto.RegisterType((*Cap)(nil), "protobuf.Cap")
 	proto.RegisterType((*ProtoHandshake)(nil), "protobuf.ProtoHandshake")
 	proto.RegisterType((*Node)(nil), "protobuf.Node")
+	proto.RegisterType((*Peers)(nil), "protobuf.Peers")
 	proto.RegisterType((*Endpoint)(nil), "protobuf.Endpoint")
 	proto.RegisterType((*Ping)(nil), "protobuf.Ping")
 	proto.RegisterType((*Pong)(nil), "protobuf.Pong")
@@ -794,44 +1202,61 @@ func init() {
 func init() { proto.RegisterFile("protobuf/protobufmsg.proto", fileDescriptor_47f67d614acbc48c) }
 
 var fileDescriptor_47f67d614acbc48c = []byte{
-	// 610 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0x5d, 0x6f, 0xd3, 0x30,
-	0x14, 0x55, 0x12, 0x77, 0x74, 0x77, 0xeb, 0x98, 0x3c, 0x31, 0x45, 0x15, 0x42, 0x25, 0x42, 0x28,
-	0x4f, 0x45, 0x2a, 0xef, 0x3c, 0x30, 0x06, 0x03, 0x89, 0xaa, 0x32, 0x68, 0xef, 0x5e, 0xe2, 0xa5,
-	0x51, 0x1b, 0xdb, 0xb2, 0x5d, 0xc6, 0x90, 0xf8, 0x2f, 0xbc, 0xf0, 0x8f, 0xf8, 0x41, 0xc8, 0x37,
-	0x49, 0x9b, 0x95, 0x6d, 0x0f, 0xe3, 0xed, 0x7e, 0x9c, 0x7b, 0xce, 0xbd, 0xa7, 0x4e, 0x61, 0xa8,
-	0x8d, 0x72, 0xea, 0x62, 0x75, 0xf9, 0xaa, 0x0d, 0x2a, 0x5b, 0x8c, 0x31, 0xa6, 0xfd, 0xb6, 0x34,
-	0x7c, 0xb2, 0x46, 0x65, 0xaa, 0xaa, 0x94, 0xac, 0x01, 0xc9, 0x9f, 0x00, 0xe0, 0x8b, 0xe3, 0x6e,
-	0x65, 0xdf, 0x71, 0xc7, 0x69, 0x0a, 0x8f, 0xb1, 0x9e, 0xa9, 0xe5, 0xb9, 0x30, 0xb6, 0x54, 0x32,
-	0x0e, 0x46, 0x61, 0x3a, 0x60, 0xdb, 0x65, 0xfa, 0x14, 0x76, 0xa5, 0x70, 0x57, 0xca, 0x2c, 0x3e,
-	0xe6, 0x71, 0x38, 0x0a, 0x53, 0xc2, 0x36, 0x05, 0x7a, 0x0c, 0x3b, 0x73, 0x51, 0x16, 0x73, 0x17,
-	0x47, 0xd8, 0x6a, 0x32, 0x3a, 0x81, 0xfd, 0x6c, 0x65, 0x8c, 0x90, 0xee, 0xed, 0x52, 0x65, 0x8b,
-	0x98, 0x8c, 0xc2, 0x74, 0x6f, 0x72, 0x30, 0x6e, 0x97, 0x1b, 0x9f, 0x71, 0x3b, 0x67, 0x37, 0x30,
-	0x7e, 0xa6, 0x10, 0x52, 0xd8, 0xd2, 0xd6, 0x33, 0xbd, 0xdb, 0x67, 0xba, 0x98, 0x64, 0x0a, 0x87,
-	0x53, 0x71, 0x85, 0xb1, 0xef, 0xe2, 0x6d, 0x09, 0x90, 0x39, 0xb7, 0x73, 0x3c, 0xe8, 0xdf, 0x79,
-	0xec, 0xf9, 0xbd, 0xe5, 0xaa, 0xba, 0x10, 0xa6, 0x39, 0xa9, 0xc9, 0x92, 0x53, 0x38, 0xea, 0xf2,
-	0x09, 0x74, 0xcb, 0xd2, 0x31, 0x90, 0x9c, 0x3b, 0x1e, 0x07, 0xa3, 0x28, 0xdd, 0x9b, 0x0c, 0x37,
-	0x94, 0xdb, 0xe2, 0x0c, 0x71, 0xc9, 0x4f, 0x38, 0xfa, 0x20, 0xea, 0xb3, 0xce, 0x04, 0xcf, 0x85,
-	0xb1, 0xff, 0xbb, 0x99, 0xaf, 0xf3, 0x4a, 0xad, 0xe4, 0xda, 0xe9, 0x3a, 0xa3, 0x14, 0x88, 0x5d,
-	0x94, 0x1a, 0x1d, 0x26, 0x0c, 0xe3, 0xe4, 0x35, 0x44, 0x9f, 0x6d, 0xe1, 0x5b, 0x99, 0xca, 0x05,
-	0xca, 0x11, 0x86, 0x31, 0x8d, 0xe1, 0x91, 0xe6, 0xd7, 0x4b, 0xc5, 0xfd, 0x8f, 0x19, 0xa4, 0xfb,
-	0xac, 0x4d, 0xfd, 0xd0, 0x09, 0xd7, 0x7e, 0x48, 0xf2, 0xaa, 0x1e, 0xda, 0x65, 0x18, 0xfb, 0xa1,
-	0x6f, 0xcd, 0x2b, 0xa9, 0x97, 0x6a, 0xd3, 0xe4, 0x77, 0x00, 0x07, 0x33, 0x7f, 0xc5, 0x19, 0x97,
-	0xb9, 0x9d, 0xf3, 0xc5, 0x0d, 0x70, 0x70, 0x03, 0xbc, 0xa6, 0x0e, 0x3b, 0xd4, 0xcf, 0x81, 0x64,
-	0x5c, 0xdb, 0x38, 0x42, 0x67, 0x07, 0x1b, 0x4b, 0x4e, 0xb8, 0x66, 0xd8, 0xa2, 0xcf, 0x00, 0x96,
-	0xa5, 0x75, 0x42, 0xce, 0x94, 0x71, 0x31, 0x19, 0x05, 0x29, 0x61, 0x9d, 0x0a, 0x3d, 0x80, 0xb0,
-	0xcc, 0xe3, 0x1e, 0x5e, 0x13, 0x96, 0xb9, 0x97, 0x31, 0xc2, 0xba, 0x78, 0x07, 0x2b, 0x18, 0x27,
-	0x9f, 0x80, 0x4c, 0xfd, 0xf9, 0x1e, 0xab, 0x71, 0x2f, 0x8f, 0xd5, 0xf4, 0x10, 0xa2, 0x55, 0xae,
-	0x71, 0xa3, 0x01, 0xf3, 0xa1, 0xaf, 0xb8, 0x4c, 0xa3, 0xc9, 0x03, 0xe6, 0xc3, 0x86, 0x9f, 0x34,
-	0x33, 0x79, 0xf2, 0x06, 0xfa, 0xa7, 0x32, 0xd7, 0xaa, 0x94, 0xee, 0x21, 0x7c, 0xc9, 0xaf, 0x00,
-	0xc8, 0xac, 0x94, 0xc5, 0x3d, 0x4e, 0xbd, 0x04, 0x72, 0x69, 0x54, 0x85, 0x3c, 0x7b, 0x13, 0xba,
-	0x71, 0xa5, 0x15, 0x66, 0xd8, 0xa7, 0x09, 0x84, 0x4e, 0x21, 0xf7, 0xed, 0xa8, 0xd0, 0x29, 0x6f,
-	0x9f, 0xf8, 0xae, 0x4b, 0xc3, 0x9d, 0x17, 0xaa, 0x9f, 0x49, 0xa7, 0xb2, 0xb6, 0xab, 0xd7, 0xb1,
-	0xeb, 0x07, 0x90, 0x99, 0x92, 0x45, 0xc3, 0x1f, 0xdc, 0xcb, 0x3f, 0x84, 0xbe, 0x11, 0x7a, 0x79,
-	0xfd, 0x55, 0x2d, 0x70, 0xdf, 0x7d, 0xb6, 0xce, 0xb7, 0xb4, 0xa3, 0x3b, 0xb5, 0x49, 0x47, 0xfb,
-	0x1c, 0xfa, 0xef, 0x4b, 0x99, 0x4b, 0xff, 0x73, 0x1d, 0xc3, 0x8e, 0xe3, 0xa6, 0x10, 0xae, 0xb1,
-	0xb8, 0xc9, 0xb6, 0x78, 0xc3, 0x3b, 0x79, 0xa3, 0x0e, 0xaf, 0x80, 0xdd, 0xa9, 0xff, 0x73, 0xba,
-	0x50, 0xc6, 0xd2, 0x17, 0xd0, 0xf3, 0x02, 0xb6, 0xf9, 0xa2, 0x3b, 0x9f, 0xa2, 0x7f, 0x26, 0xac,
-	0x6e, 0x3e, 0x44, 0xe6, 0x6f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x9e, 0x5d, 0xfa, 0x89, 0xa6, 0x05,
-	0x00, 0x00,
+	// 881 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0x51, 0x6f, 0xdb, 0x36,
+	0x10, 0x86, 0x24, 0x3a, 0x75, 0xce, 0x72, 0x5a, 0xb0, 0xe9, 0x20, 0x18, 0x43, 0xa1, 0x11, 0x5b,
+	0xa7, 0x87, 0x35, 0x03, 0x32, 0xec, 0x75, 0x0f, 0xcb, 0xba, 0x24, 0x05, 0x16, 0x18, 0x6c, 0x50,
+	0xa0, 0x8f, 0xb4, 0xc8, 0xd8, 0x82, 0x6d, 0x52, 0x23, 0xe9, 0xd6, 0x1d, 0xb0, 0x3f, 0xb2, 0xa7,
+	0xbd, 0xec, 0x5f, 0xec, 0xc7, 0x0d, 0xa4, 0x28, 0x5b, 0x36, 0x9c, 0xae, 0x68, 0xdf, 0xee, 0x8e,
+	0x77, 0xdf, 0x7d, 0xfa, 0xee, 0x48, 0xc1, 0xa8, 0xd6, 0xca, 0xaa, 0xc9, 0xea, 0xee, 0xfb, 0xd6,
+	0x58, 0x9a, 0xe9, 0x99, 0xb7, 0x71, 0xbf, 0x0d, 0x8d, 0x9e, 0x6c, 0xb2, 0x4a, 0xb5, 0x5c, 0x2a,
+	0xd9, 0x24, 0x8c, 0x4e, 0x37, 0xe1, 0xc9, 0x42, 0x95, 0xf3, 0x10, 0xdd, 0x42, 0x5a, 0xcd, 0xa4,
+	0x61, 0xa5, 0xad, 0xda, 0x0a, 0xf2, 0x57, 0x0c, 0xf0, 0xca, 0x32, 0xbb, 0x32, 0xbf, 0x30, 0xcb,
+	0x70, 0x01, 0x0f, 0x7d, 0xbc, 0x54, 0x8b, 0xd7, 0x42, 0x9b, 0x4a, 0xc9, 0x2c, 0xca, 0xe3, 0x62,
+	0x48, 0xf7, 0xc3, 0xf8, 0x4b, 0x38, 0x96, 0xc2, 0xbe, 0x53, 0x7a, 0x7e, 0xcd, 0xb3, 0x38, 0x8f,
+	0x0b, 0x44, 0xb7, 0x01, 0xfc, 0x05, 0x1c, 0xcd, 0x44, 0x35, 0x9d, 0xd9, 0x2c, 0xf1, 0x47, 0xc1,
+	0xc3, 0xe7, 0x90, 0x96, 0x2b, 0xad, 0x85, 0xb4, 0x3f, 0x3b, 0x82, 0x19, 0xca, 0xe3, 0x62, 0x70,
+	0x7e, 0x72, 0xd6, 0x32, 0x3c, 0xbb, 0x62, 0x66, 0x46, 0x77, 0x72, 0x5c, 0xcd, 0x54, 0x48, 0x61,
+	0x2a, 0xd3, 0xd4, 0xf4, 0x0e, 0xd7, 0x74, 0x73, 0x70, 0x0e, 0x83, 0x85, 0x6b, 0xf8, 0x4a, 0xe8,
+	0xb7, 0x42, 0x67, 0x47, 0x79, 0x54, 0xf4, 0x69, 0x37, 0x84, 0x09, 0xa4, 0xb5, 0x5e, 0x49, 0xc1,
+	0xaf, 0x1a, 0x9e, 0x0f, 0xf2, 0xa8, 0x40, 0x74, 0x27, 0x46, 0x6e, 0xe0, 0xd1, 0x8d, 0x78, 0xe7,
+	0x11, 0x5d, 0x0f, 0xaf, 0x10, 0x01, 0x34, 0x63, 0x66, 0x96, 0x45, 0x07, 0x59, 0xf8, 0x33, 0xf7,
+	0xf5, 0x72, 0xb5, 0x9c, 0x08, 0x1d, 0x84, 0x09, 0x1e, 0x79, 0x01, 0x8f, 0xbb, 0x78, 0xc2, 0x6b,
+	0x6e, 0xf0, 0x19, 0x20, 0xce, 0x2c, 0xcb, 0xa2, 0x3c, 0x29, 0x06, 0xe7, 0xa3, 0x2d, 0xe4, 0x7e,
+	0x73, 0xea, 0xf3, 0xc8, 0x9f, 0xf0, 0xf8, 0x52, 0x34, 0xe2, 0x5c, 0x09, 0xc6, 0x85, 0x36, 0x9f,
+	0xcb, 0xcc, 0xc5, 0xd9, 0x52, 0xad, 0xe4, 0x66, 0x5e, 0x8d, 0x87, 0x31, 0x20, 0x33, 0xaf, 0x6a,
+	0x3f, 0x27, 0x44, 0xbd, 0x4d, 0x5e, 0xc2, 0xc9, 0xa5, 0xb0, 0xb7, 0xeb, 0xb1, 0x56, 0xea, 0xce,
+	0x77, 0x3e, 0x85, 0x9e, 0x16, 0xbf, 0x5f, 0x73, 0xdf, 0x1a, 0xd1, 0xc6, 0x71, 0x7c, 0xec, 0x3a,
+	0x2c, 0xc7, 0x01, 0x3e, 0xee, 0x8c, 0xfc, 0x1b, 0xc1, 0xe0, 0xff, 0x91, 0xbe, 0x83, 0xe3, 0x49,
+	0xab, 0x43, 0x16, 0xe7, 0xd1, 0x01, 0xb8, 0x6d, 0x42, 0xe7, 0x1b, 0x13, 0x3f, 0xd3, 0xf6, 0x1b,
+	0x4f, 0xa1, 0x57, 0x49, 0x2e, 0xd6, 0x19, 0xf2, 0xe1, 0xc6, 0xc1, 0xdf, 0x40, 0x6c, 0xd7, 0x59,
+	0xcf, 0x83, 0x3e, 0xd9, 0x82, 0xde, 0x6e, 0x6f, 0x0a, 0x8d, 0xed, 0xda, 0x15, 0xd7, 0x8e, 0x65,
+	0x76, 0x94, 0x27, 0x45, 0x4a, 0x1b, 0x87, 0xfc, 0x08, 0x83, 0x4b, 0x61, 0x6f, 0x14, 0x17, 0x9e,
+	0xfd, 0x33, 0x38, 0x9a, 0xf9, 0xb9, 0x86, 0x51, 0xee, 0x93, 0x0c, 0xa7, 0xe4, 0x29, 0xf4, 0x37,
+	0x35, 0xb8, 0x33, 0xfc, 0x34, 0x0c, 0xf8, 0x0d, 0xa4, 0x17, 0x6a, 0x59, 0xb3, 0x32, 0xdc, 0x80,
+	0xe7, 0xee, 0x36, 0xb9, 0x41, 0x87, 0xd9, 0x76, 0x78, 0x76, 0xb6, 0x80, 0x86, 0x24, 0x3c, 0x82,
+	0xbe, 0x99, 0x29, 0x6d, 0xaf, 0xb9, 0xc9, 0xe2, 0x3c, 0x29, 0x10, 0xdd, 0xf8, 0xe4, 0x0d, 0x3c,
+	0x6c, 0x77, 0xe7, 0x76, 0x2d, 0x3d, 0x83, 0x1d, 0x75, 0x0f, 0x2f, 0x4f, 0x47, 0xdd, 0x0c, 0x1e,
+	0x78, 0xe1, 0x44, 0x83, 0x3d, 0xa4, 0xad, 0x4b, 0x04, 0xa4, 0x9f, 0x81, 0xfb, 0x2d, 0x24, 0x76,
+	0xdd, 0x60, 0xde, 0x3b, 0x08, 0x97, 0x41, 0x7e, 0x80, 0xe4, 0x37, 0x33, 0x75, 0xba, 0x95, 0x8a,
+	0x8b, 0xb0, 0x28, 0xde, 0x76, 0xdc, 0x6a, 0xf6, 0x7e, 0xa1, 0x18, 0xf7, 0x5b, 0x92, 0xd2, 0xd6,
+	0x75, 0x45, 0x17, 0xac, 0x76, 0x45, 0x92, 0x2d, 0x9b, 0xa2, 0x63, 0xea, 0x6d, 0x57, 0xf4, 0x36,
+	0x3c, 0x75, 0xcd, 0x9d, 0x68, 0x5d, 0xf2, 0x4f, 0x04, 0x27, 0x63, 0xc7, 0xe3, 0x8a, 0x49, 0x6e,
+	0x66, 0x6c, 0xbe, 0x93, 0x1c, 0xed, 0x24, 0x6f, 0xa0, 0xe3, 0x0e, 0xf4, 0x57, 0x80, 0x4a, 0x56,
+	0x9b, 0x2c, 0xf1, 0x1f, 0x35, 0xdc, 0x7e, 0xd4, 0x05, 0xab, 0xa9, 0x3f, 0xc2, 0x4f, 0x01, 0x16,
+	0x95, 0xb1, 0x42, 0x8e, 0x95, 0xb6, 0x61, 0x33, 0x3b, 0x11, 0x7c, 0x02, 0x71, 0xc5, 0xfd, 0x7a,
+	0xa6, 0x34, 0xae, 0xb8, 0x6b, 0xa3, 0x85, 0xb1, 0xfe, 0x45, 0x4b, 0xa9, 0xb7, 0xc9, 0x4b, 0x40,
+	0x6e, 0x9d, 0x7c, 0x6e, 0xed, 0x79, 0xb9, 0xdc, 0x1a, 0x3f, 0x82, 0x64, 0xc5, 0x6b, 0xcf, 0x68,
+	0x48, 0x9d, 0xe9, 0x22, 0xb6, 0xac, 0xfd, 0x1d, 0x1f, 0x52, 0x67, 0x06, 0x7c, 0x14, 0x6a, 0x38,
+	0x79, 0x0e, 0xbd, 0xb1, 0x10, 0xda, 0xe0, 0xaf, 0xa1, 0x27, 0x15, 0x3f, 0xb4, 0xca, 0xae, 0x17,
+	0x6d, 0x0e, 0xc9, 0x4f, 0xd0, 0x7f, 0x21, 0x79, 0xad, 0x2a, 0x69, 0x3f, 0xa5, 0x3d, 0xf9, 0x3b,
+	0x02, 0x34, 0xae, 0xe4, 0xf4, 0x03, 0xc2, 0x3e, 0x03, 0x74, 0xa7, 0xd5, 0x32, 0x3c, 0x23, 0x78,
+	0xcb, 0xa3, 0x6d, 0x4c, 0xfd, 0x39, 0x26, 0x10, 0x5b, 0x95, 0x25, 0xf7, 0x66, 0xc5, 0x56, 0x39,
+	0xb5, 0xc5, 0xba, 0xae, 0x34, 0x73, 0xeb, 0x14, 0x1e, 0xb5, 0x4e, 0x64, 0xa3, 0x6e, 0xaf, 0xa3,
+	0xee, 0x1f, 0x80, 0xc6, 0x4a, 0x4e, 0x03, 0x7e, 0xf4, 0x41, 0xfc, 0x11, 0xf4, 0xb5, 0xa8, 0x17,
+	0xef, 0x6f, 0xd5, 0xdc, 0xf3, 0x4d, 0xe9, 0xc6, 0xdf, 0xeb, 0x9d, 0xdc, 0xdb, 0x1b, 0x75, 0x7a,
+	0xbf, 0x86, 0xfe, 0xaf, 0x95, 0xe4, 0x4e, 0x6b, 0xf7, 0xac, 0x59, 0xa6, 0xa7, 0xc2, 0x06, 0x89,
+	0x83, 0xb7, 0x87, 0x1b, 0xdf, 0x8b, 0x9b, 0x74, 0x70, 0x05, 0x1c, 0xdf, 0xb8, 0x5f, 0xdc, 0x44,
+	0x7d, 0xec, 0xa4, 0x3f, 0xa5, 0xcd, 0x7f, 0x03, 0x00, 0x76, 0x2f, 0x40, 0x7d, 0xcc, 0x08, 0x00,
+	0x00,
 }
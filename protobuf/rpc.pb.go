@@ -0,0 +1,202 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: protobuf/rpc.proto
+
+package protobuf
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type RPCRequest struct {
+	Id                   *uint64  `protobuf:"varint,1,req,name=id" json:"id,omitempty"`
+	Method               *string  `protobuf:"bytes,2,req,name=method" json:"method,omitempty"`
+	Params               []byte   `protobuf:"bytes,3,opt,name=params" json:"params,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RPCRequest) Reset()         { *m = RPCRequest{} }
+func (m *RPCRequest) String() string { return proto.CompactTextString(m) }
+func (*RPCRequest) ProtoMessage()    {}
+func (*RPCRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3060b84577900fd3, []int{0}
+}
+
+func (m *RPCRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RPCRequest.Unmarshal(m, b)
+}
+func (m *RPCRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RPCRequest.Marshal(b, m, deterministic)
+}
+func (m *RPCRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RPCRequest.Merge(m, src)
+}
+func (m *RPCRequest) XXX_Size() int {
+	return xxx_messageInfo_RPCRequest.Size(m)
+}
+func (m *RPCRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RPCRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RPCRequest proto.InternalMessageInfo
+
+func (m *RPCRequest) GetId() uint64 {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return 0
+}
+
+func (m *RPCRequest) GetMethod() string {
+	if m != nil && m.Method != nil {
+		return *m.Method
+	}
+	return ""
+}
+
+func (m *RPCRequest) GetParams() []byte {
+	if m != nil {
+		return m.Params
+	}
+	return nil
+}
+
+type RPCError struct {
+	Code                 *int32   `protobuf:"varint,1,req,name=code" json:"code,omitempty"`
+	Message              *string  `protobuf:"bytes,2,req,name=message" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RPCError) Reset()         { *m = RPCError{} }
+func (m *RPCError) String() string { return proto.CompactTextString(m) }
+func (*RPCError) ProtoMessage()    {}
+func (*RPCError) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3060b84577900fd3, []int{1}
+}
+
+func (m *RPCError) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RPCError.Unmarshal(m, b)
+}
+func (m *RPCError) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RPCError.Marshal(b, m, deterministic)
+}
+func (m *RPCError) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RPCError.Merge(m, src)
+}
+func (m *RPCError) XXX_Size() int {
+	return xxx_messageInfo_RPCError.Size(m)
+}
+func (m *RPCError) XXX_DiscardUnknown() {
+	xxx_messageInfo_RPCError.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RPCError proto.InternalMessageInfo
+
+func (m *RPCError) GetCode() int32 {
+	if m != nil && m.Code != nil {
+		return *m.Code
+	}
+	return 0
+}
+
+func (m *RPCError) GetMessage() string {
+	if m != nil && m.Message != nil {
+		return *m.Message
+	}
+	return ""
+}
+
+type RPCResponse struct {
+	Id                   *uint64   `protobuf:"varint,1,req,name=id" json:"id,omitempty"`
+	Result               []byte    `protobuf:"bytes,2,opt,name=result" json:"result,omitempty"`
+	Error                *RPCError `protobuf:"bytes,3,opt,name=error" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *RPCResponse) Reset()         { *m = RPCResponse{} }
+func (m *RPCResponse) String() string { return proto.CompactTextString(m) }
+func (*RPCResponse) ProtoMessage()    {}
+func (*RPCResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3060b84577900fd3, []int{2}
+}
+
+func (m *RPCResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RPCResponse.Unmarshal(m, b)
+}
+func (m *RPCResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RPCResponse.Marshal(b, m, deterministic)
+}
+func (m *RPCResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RPCResponse.Merge(m, src)
+}
+func (m *RPCResponse) XXX_Size() int {
+	return xxx_messageInfo_RPCResponse.Size(m)
+}
+func (m *RPCResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RPCResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RPCResponse proto.InternalMessageInfo
+
+func (m *RPCResponse) GetId() uint64 {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return 0
+}
+
+func (m *RPCResponse) GetResult() []byte {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (m *RPCResponse) GetError() *RPCError {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*RPCRequest)(nil), "protobuf.RPCRequest")
+	proto.RegisterType((*RPCError)(nil), "protobuf.RPCError")
+	proto.RegisterType((*RPCResponse)(nil), "protobuf.RPCResponse")
+}
+
+func init() { proto.RegisterFile("protobuf/rpc.proto", fileDescriptor_3060b84577900fd3) }
+
+var fileDescriptor_3060b84577900fd3 = []byte{
+	// 190 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x8f, 0x31, 0x6f, 0x83, 0x30,
+	0x10, 0x85, 0x85, 0x0b, 0x2d, 0x3d, 0xaa, 0x0e, 0x37, 0x54, 0x1e, 0x11, 0x93, 0x27, 0x2a, 0x75,
+	0xea, 0x8e, 0xba, 0x75, 0x40, 0xf7, 0x07, 0x22, 0x82, 0x2f, 0x09, 0x52, 0x88, 0x1d, 0xdb, 0xfc,
+	0xff, 0x08, 0x83, 0xb7, 0x6c, 0xfe, 0x9e, 0xac, 0xf7, 0xbe, 0x03, 0xb4, 0xce, 0x04, 0x73, 0x5c,
+	0x4e, 0xdf, 0xce, 0x8e, 0x6d, 0x04, 0x2c, 0x53, 0xd6, 0xfc, 0x03, 0x50, 0xdf, 0x11, 0xdf, 0x17,
+	0xf6, 0x01, 0x3f, 0x41, 0x4c, 0x5a, 0x66, 0xb5, 0x50, 0x39, 0x89, 0x49, 0xe3, 0x17, 0xbc, 0xce,
+	0x1c, 0x2e, 0x46, 0x4b, 0x51, 0x0b, 0xf5, 0x4e, 0x3b, 0xad, 0xb9, 0x1d, 0xdc, 0x30, 0x7b, 0xf9,
+	0x52, 0x67, 0xea, 0x83, 0x76, 0x6a, 0x7e, 0xa1, 0xa4, 0xbe, 0xfb, 0x73, 0xce, 0x38, 0x44, 0xc8,
+	0x47, 0xa3, 0x39, 0xb6, 0x15, 0x14, 0xdf, 0x28, 0xe1, 0x6d, 0x66, 0xef, 0x87, 0x33, 0xef, 0x85,
+	0x09, 0x9b, 0x03, 0x54, 0xd1, 0xc3, 0x5b, 0x73, 0xf3, 0xfc, 0x4c, 0xc4, 0xb1, 0x5f, 0xae, 0x41,
+	0x8a, 0x6d, 0x70, 0x23, 0x54, 0x50, 0xf0, 0xba, 0x16, 0x3d, 0xaa, 0x1f, 0x6c, 0xd3, 0x61, 0x6d,
+	0xf2, 0xa0, 0xed, 0xc3, 0x63, 0x00, 0xc3, 0xad, 0x07, 0xf1, 0x07, 0x01, 0x00, 0x00,
+}
@@ -2,6 +2,7 @@ package local
 
 import (
 	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/bcsi"
 )
 
 type LocalClient struct {
@@ -39,3 +40,8 @@ func (s *LocalClient) CheckTx(transaction meta.Transaction) error {
 func (s *LocalClient) FilterTx(txs []meta.Transaction) []meta.Transaction {
 	return s.server.FilterTx(txs)
 }
+
+//SetCoreServices carry the core services over to the app side
+func (s *LocalClient) SetCoreServices(core bcsi.CoreServices) {
+	s.server.SetCoreServices(core)
+}
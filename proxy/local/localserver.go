@@ -6,7 +6,8 @@ import (
 )
 
 type LocalServer struct {
-	api bcsi.BCSI
+	api  bcsi.BCSI
+	core bcsi.CoreServices
 }
 
 func NewLocalServer(api bcsi.BCSI) *LocalServer {
@@ -40,3 +41,16 @@ func (s *LocalServer) CheckTx(transaction meta.Transaction) error {
 func (s *LocalServer) FilterTx(txs []meta.Transaction) []meta.Transaction {
 	return s.api.FilterTx(txs)
 }
+
+//SetCoreServices receive the core services, handing them to the app if it takes them
+func (s *LocalServer) SetCoreServices(core bcsi.CoreServices) {
+	s.core = core
+	if setter, ok := s.api.(bcsi.CoreServicesSetter); ok {
+		setter.SetCoreServices(core)
+	}
+}
+
+//Core return the core services for the app, nil until the core started
+func (s *LocalServer) Core() bcsi.CoreServices {
+	return s.core
+}
@@ -19,3 +19,7 @@ type TransactionsCmd struct {
 type CommonRSP struct {
 	Data string `json:"data"`
 }
+
+type CoreCallCmd struct {
+	Call string `json:"call"`
+}
@@ -0,0 +1,321 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/mihongtech/linkchain-core/common/http/rpcjson"
+	"github.com/mihongtech/linkchain-core/common/http/server"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/bcsi"
+	"github.com/mihongtech/linkchain-core/protobuf"
+)
+
+//The core services are served over the BCSI channel in reverse: the core keeps polling the app
+//for calls into the core and posts back their results, so no connection from the app to the core is needed.
+const (
+	corePollWait    = 5 * time.Second  //time a poll waits for a call before returning empty
+	coreCallTimeout = 30 * time.Second //time allowance for the core to answer a call
+	coreRetryDelay  = 3 * time.Second  //delay before polling again after a failed poll
+	coreCallQueue   = 64               //calls waiting to be polled
+)
+
+var (
+	ErrCoreNotConnected = errors.New("core is not polling for calls")
+	ErrCoreCallTimeout  = errors.New("core call timeout")
+	ErrNoBestBlock      = errors.New("core has no best block")
+)
+
+//coreCaller is the app side client of the core services, queueing the calls for the core to poll
+type coreCaller struct {
+	calls    chan *protobuf.RPCRequest
+	polling  int32 //polls waiting for a call
+	lastPoll int64 //unix nano time the last poll ended
+
+	lock    sync.Mutex
+	pending map[uint64]chan *protobuf.RPCResponse
+	nextID  uint64
+}
+
+func newCoreCaller() *coreCaller {
+	return &coreCaller{
+		calls:   make(chan *protobuf.RPCRequest, coreCallQueue),
+		pending: make(map[uint64]chan *protobuf.RPCResponse),
+	}
+}
+
+//call queue a call for the core and wait for its result
+func (c *coreCaller) call(method string, params []byte) ([]byte, error) {
+	if !c.connected() {
+		return nil, ErrCoreNotConnected
+	}
+	c.lock.Lock()
+	c.nextID++
+	id := c.nextID
+	ch := make(chan *protobuf.RPCResponse, 1)
+	c.pending[id] = ch
+	c.lock.Unlock()
+
+	defer func() {
+		c.lock.Lock()
+		delete(c.pending, id)
+		c.lock.Unlock()
+	}()
+
+	timeout := time.NewTimer(coreCallTimeout)
+	defer timeout.Stop()
+
+	select {
+	case c.calls <- &protobuf.RPCRequest{Id: proto.Uint64(id), Method: proto.String(method), Params: params}:
+	default:
+		return nil, ErrCoreNotConnected
+	}
+	select {
+	case rsp := <-ch:
+		if rsp.Error != nil {
+			return nil, errors.New(rsp.Error.GetMessage())
+		}
+		return rsp.Result, nil
+	case <-timeout.C:
+		return nil, ErrCoreCallTimeout
+	}
+}
+
+//connected report whether the core is polling, or polled recently enough to be back shortly
+func (c *coreCaller) connected() bool {
+	if atomic.LoadInt32(&c.polling) > 0 {
+		return true
+	}
+	return time.Since(time.Unix(0, atomic.LoadInt64(&c.lastPoll))) < 2*coreRetryDelay
+}
+
+//onPollCoreCall hand the next queued call to the core, or nothing if none came up in time
+func (c *coreCaller) onPollCoreCall(ctx context.Context, s *server.Server, params []byte) ([]byte, error) {
+	atomic.AddInt32(&c.polling, 1)
+	defer func() {
+		atomic.StoreInt64(&c.lastPoll, time.Now().UnixNano())
+		atomic.AddInt32(&c.polling, -1)
+	}()
+
+	wait := time.NewTimer(corePollWait)
+	defer wait.Stop()
+	for {
+		select {
+		case req := <-c.calls:
+			//a call given up by its caller is dropped, the core would run it for nothing
+			if !c.isPending(req.GetId()) {
+				continue
+			}
+			return proto.Marshal(req)
+		case <-wait.C:
+		case <-ctx.Done():
+		case <-s.Closing():
+		}
+		return nil, nil
+	}
+}
+
+//isPending report whether the caller of a call still waits for its result
+func (c *coreCaller) isPending(id uint64) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, ok := c.pending[id]
+	return ok
+}
+
+//onCoreCallResult deliver the result of a call posted back by the core
+func (c *coreCaller) onCoreCallResult(ctx context.Context, s *server.Server, params []byte) ([]byte, error) {
+	rsp := &protobuf.RPCResponse{}
+	if err := proto.Unmarshal(params, rsp); err != nil {
+		log.Error("BCSIRPCServer", "onCoreCallResult cmd decode", err)
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCInvalidParams.Code, err.Error())
+	}
+	//removed with the lock held, so a result posted twice finds nothing to deliver to
+	c.lock.Lock()
+	ch, ok := c.pending[rsp.GetId()]
+	delete(c.pending, rsp.GetId())
+	c.lock.Unlock()
+	if ok {
+		select {
+		case ch <- rsp:
+		default:
+		}
+	}
+	return nil, nil
+}
+
+func (c *coreCaller) ProcessTx(tx *meta.Transaction) error {
+	buff, err := tx.EncodeToBytes()
+	if err != nil {
+		return err
+	}
+	_, err = c.call("ProcessTx", buff)
+	return err
+}
+
+func (c *coreCaller) GetBlockByID(hash meta.BlockID) (*meta.Block, error) {
+	buff, err := hash.EncodeToBytes()
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.call("GetBlockByID", buff)
+	if err != nil {
+		return nil, err
+	}
+	block := &meta.Block{}
+	if err := block.DecodeFromBytes(result); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func (c *coreCaller) GetBestBlock() *meta.Block {
+	result, err := c.call("GetBestBlock", nil)
+	if err != nil {
+		log.Error("BCSIRPCServer", "GetBestBlock core call", err)
+		return nil
+	}
+	block := &meta.Block{}
+	if err := block.DecodeFromBytes(result); err != nil {
+		log.Error("BCSIRPCServer", "GetBestBlock result decode", err)
+		return nil
+	}
+	return block
+}
+
+func (c *coreCaller) GetTXByID(id meta.TxID) (*meta.Transaction, meta.BlockID, uint64, uint64) {
+	buff, err := id.EncodeToBytes()
+	if err != nil {
+		return nil, meta.BlockID{}, 0, 0
+	}
+	result, err := c.call("GetTXByID", buff)
+	if err != nil {
+		log.Error("BCSIRPCServer", "GetTXByID core call", err)
+		return nil, meta.BlockID{}, 0, 0
+	}
+	if len(result) == 0 {
+		return nil, meta.BlockID{}, 0, 0
+	}
+	lookup := &protobuf.TxLookup{}
+	if err := proto.Unmarshal(result, lookup); err != nil {
+		log.Error("BCSIRPCServer", "GetTXByID result decode", err)
+		return nil, meta.BlockID{}, 0, 0
+	}
+	tx := &meta.Transaction{}
+	blockId := meta.BlockID{}
+	if err := tx.Deserialize(lookup.Tx); err != nil {
+		return nil, meta.BlockID{}, 0, 0
+	}
+	if err := blockId.Deserialize(lookup.BlockId); err != nil {
+		return nil, meta.BlockID{}, 0, 0
+	}
+	return tx, blockId, lookup.GetHeight(), lookup.GetIndex()
+}
+
+//callCore serve a call polled from the app with the core services
+func callCore(core bcsi.CoreServices, method string, params []byte) ([]byte, error) {
+	switch method {
+	case "ProcessTx":
+		tx := &meta.Transaction{}
+		if err := tx.DecodeFromBytes(params); err != nil {
+			return nil, err
+		}
+		return nil, core.ProcessTx(tx)
+
+	case "GetBlockByID":
+		hash := meta.BlockID{}
+		if err := hash.DecodeFromBytes(params); err != nil {
+			return nil, err
+		}
+		block, err := core.GetBlockByID(hash)
+		if err != nil {
+			return nil, err
+		}
+		return block.EncodeToBytes()
+
+	case "GetBestBlock":
+		block := core.GetBestBlock()
+		if block == nil {
+			return nil, ErrNoBestBlock
+		}
+		return block.EncodeToBytes()
+
+	case "GetTXByID":
+		id := meta.TxID{}
+		if err := id.DecodeFromBytes(params); err != nil {
+			return nil, err
+		}
+		tx, blockId, height, index := core.GetTXByID(id)
+		if tx == nil {
+			return nil, nil
+		}
+		return proto.Marshal(&protobuf.TxLookup{
+			Tx:      tx.Serialize().(*protobuf.Transaction),
+			BlockId: blockId.Serialize().(*protobuf.Hash),
+			Height:  proto.Uint64(height),
+			Index:   proto.Uint64(index),
+		})
+	}
+	return nil, fmt.Errorf("unknown core method %s", method)
+}
+
+//SetCoreServices start serving the calls of the app into core, polled over the BCSI channel
+func (c *BCSIRPCClient) SetCoreServices(core bcsi.CoreServices) {
+	go c.serveCore(core)
+}
+
+//Close stop serving the core services and probing the app
+func (c *BCSIRPCClient) Close() {
+	c.closeOnce.Do(func() { close(c.quit) })
+}
+
+func (c *BCSIRPCClient) serveCore(core bcsi.CoreServices) {
+	for {
+		select {
+		case <-c.quit:
+			return
+		default:
+		}
+		data, err := c.call("PollCoreCall", nil, (*bcsiStub).PollCoreCall)
+		if err != nil {
+			select {
+			case <-time.After(coreRetryDelay):
+			case <-c.quit:
+				return
+			}
+			continue
+		}
+		if len(data) == 0 {
+			continue
+		}
+		req := &protobuf.RPCRequest{}
+		if err := proto.Unmarshal(data, req); err != nil {
+			log.Error("BCSIRPCClient", "PollCoreCall response decode", err)
+			continue
+		}
+		go c.answerCore(core, req)
+	}
+}
+
+//answerCore serve a polled call and post back its result
+func (c *BCSIRPCClient) answerCore(core bcsi.CoreServices, req *protobuf.RPCRequest) {
+	rsp := &protobuf.RPCResponse{Id: req.Id}
+	result, err := callCore(core, req.GetMethod(), req.Params)
+	if err != nil {
+		rsp.Error = &protobuf.RPCError{Code: proto.Int32(0), Message: proto.String(err.Error())}
+	} else {
+		rsp.Result = result
+	}
+	buff, err := proto.Marshal(rsp)
+	if err != nil {
+		log.Error("BCSIRPCClient", "CoreCallResult cmd encode", err)
+		return
+	}
+	c.call("CoreCallResult", buff, (*bcsiStub).CoreCallResult)
+}
@@ -1,31 +1,60 @@
 package rpc
 
 import (
+	"context"
 	"encoding/hex"
 	"reflect"
 
+	"github.com/mihongtech/linkchain-core/common/http/rpcjson"
 	"github.com/mihongtech/linkchain-core/common/http/server"
 	"github.com/mihongtech/linkchain-core/common/util/log"
 	"github.com/mihongtech/linkchain-core/core/meta"
 	"github.com/mihongtech/linkchain-core/node/bcsi"
 )
 
-func onGetBlockState(s *server.Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	c, ok := cmd.(*BlockIDCmd)
-	if !ok {
-		log.Error("BCSIRPCServer", "onGetBlockState Type error:", reflect.TypeOf(cmd))
-		return nil, nil
-	}
-	buff, err := hex.DecodeString(c.BlockId)
-	if err != nil {
-		log.Error("BCSIRPCServer", "onGetBlockState hex cmd decode", err)
-		return nil, err
+//binary handlers take and return the protobuf encoded payloads, serving protobuf requests directly
+//and json requests through jsonHandler
+type binaryHandler = func(ctx context.Context, s *server.Server, params []byte) ([]byte, error)
+
+//jsonHandler serve a json request by hex decoding its cmd for handler and hex encoding the result
+func jsonHandler(method string, handler binaryHandler) func(context.Context, *server.Server, interface{}) (interface{}, error) {
+	return func(ctx context.Context, s *server.Server, cmd interface{}) (interface{}, error) {
+		var params string
+		switch c := cmd.(type) {
+		case *BlockIDCmd:
+			params = c.BlockId
+		case *BlockCmd:
+			params = c.Block
+		case *TransactionCmd:
+			params = c.Transaction
+		case *TransactionsCmd:
+			params = c.Transactions
+		case *CoreCallCmd:
+			params = c.Call
+		default:
+			log.Error("BCSIRPCServer", method+" Type error:", reflect.TypeOf(cmd))
+			return nil, rpcjson.ErrRPCInvalidParams
+		}
+		buff, err := hex.DecodeString(params)
+		if err != nil {
+			log.Error("BCSIRPCServer", method+" hex cmd decode", err)
+			return nil, rpcjson.NewRPCError(rpcjson.ErrRPCDecodeHexString, err.Error())
+		}
+		result, err := handler(ctx, s, buff)
+		if err != nil || result == nil {
+			return nil, err
+		}
+		return &CommonRSP{
+			Data: hex.EncodeToString(result),
+		}, nil
 	}
+}
 
+func onGetBlockState(ctx context.Context, s *server.Server, params []byte) ([]byte, error) {
 	blockId := meta.BlockID{}
-	if err := blockId.DecodeFromBytes(buff); err != nil {
+	if err := blockId.DecodeFromBytes(params); err != nil {
 		log.Error("BCSIRPCServer", "onGetBlockState cmd decode", err)
-		return nil, err
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCInvalidParams.Code, err.Error())
 	}
 
 	treeId, err := s.Context.(bcsi.BCSI).GetBlockState(blockId)
@@ -39,122 +68,59 @@ func onGetBlockState(s *server.Server, cmd interface{}, closeChan <-chan struct{
 		log.Error("BCSIRPCServer", "onGetBlockState result encode treeID", err)
 		return nil, err
 	}
-	return &CommonRSP{
-		Data: hex.EncodeToString(treeBuff),
-	}, nil
+	return treeBuff, nil
 }
 
-func onUpdateChain(s *server.Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	c, ok := cmd.(*BlockCmd)
-	if !ok {
-		log.Error("BCSIRPCServer", "onUpdateChain Type error:", reflect.TypeOf(cmd))
-		return nil, nil
-	}
-
-	buff, err := hex.DecodeString(c.Block)
-	if err != nil {
-		log.Error("BCSIRPCServer", "onUpdateChain hex cmd decode", err)
-		return nil, err
-	}
+func onUpdateChain(ctx context.Context, s *server.Server, params []byte) ([]byte, error) {
 	block := meta.Block{}
-	if err := block.DecodeFromBytes(buff); err != nil {
+	if err := block.DecodeFromBytes(params); err != nil {
 		log.Error("BCSIRPCServer", "onUpdateChain cmd decode", err)
-		return nil, err
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCInvalidParams.Code, err.Error())
 	}
 	return nil, s.Context.(bcsi.BCSI).UpdateChain(block)
 }
 
-func onProcessBlock(s *server.Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	c, ok := cmd.(*BlockCmd)
-	if !ok {
-		log.Error("BCSIRPCServer", "onProcessBlock Type error:", reflect.TypeOf(cmd))
-		return nil, nil
-	}
-	buff, err := hex.DecodeString(c.Block)
-	if err != nil {
-		log.Error("BCSIRPCServer", "onProcessBlock hex cmd decode", err)
-		return nil, err
-	}
+func onProcessBlock(ctx context.Context, s *server.Server, params []byte) ([]byte, error) {
 	block := meta.Block{}
-	if err := block.DecodeFromBytes(buff); err != nil {
+	if err := block.DecodeFromBytes(params); err != nil {
 		log.Error("BCSIRPCServer", "onProcessBlock cmd decode", err)
-		return nil, err
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCInvalidParams.Code, err.Error())
 	}
 	return nil, s.Context.(bcsi.BCSI).ProcessBlock(block)
 }
 
-func onCommit(s *server.Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	c, ok := cmd.(*BlockIDCmd)
-	if !ok {
-		log.Error("BCSIRPCServer", "onCommit Type error:", reflect.TypeOf(cmd))
-		return nil, nil
-	}
-	buff, err := hex.DecodeString(c.BlockId)
-	if err != nil {
-		log.Error("BCSIRPCServer", "onCommit hex cmd decode", err)
-		return nil, err
-	}
+func onCommit(ctx context.Context, s *server.Server, params []byte) ([]byte, error) {
 	blockId := meta.BlockID{}
-	if err := blockId.DecodeFromBytes(buff); err != nil {
+	if err := blockId.DecodeFromBytes(params); err != nil {
 		log.Error("BCSIRPCServer", "onCommit cmd decode", err)
-		return nil, err
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCInvalidParams.Code, err.Error())
 	}
 	return nil, s.Context.(bcsi.BCSI).Commit(blockId)
 }
 
-func onCheckBlock(s *server.Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	c, ok := cmd.(*BlockCmd)
-	if !ok {
-		log.Error("BCSIRPCServer", "onCheckBlock Type error:", reflect.TypeOf(cmd))
-		return nil, nil
-	}
-	buff, err := hex.DecodeString(c.Block)
-	if err != nil {
-		log.Error("BCSIRPCServer", "onCheckBlock hex cmd decode", err)
-		return nil, err
-	}
+func onCheckBlock(ctx context.Context, s *server.Server, params []byte) ([]byte, error) {
 	block := meta.Block{}
-	if err := block.DecodeFromBytes(buff); err != nil {
+	if err := block.DecodeFromBytes(params); err != nil {
 		log.Error("BCSIRPCServer", "onCheckBlock cmd decode", err)
-		return nil, err
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCInvalidParams.Code, err.Error())
 	}
 	return nil, s.Context.(bcsi.BCSI).CheckBlock(block)
 }
 
-func onCheckTx(s *server.Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	c, ok := cmd.(*TransactionCmd)
-	if !ok {
-		log.Error("BCSIRPCServer", "onCheckTx Type error:", reflect.TypeOf(cmd))
-		return nil, nil
-	}
-	buff, err := hex.DecodeString(c.Transaction)
-	if err != nil {
-		log.Error("BCSIRPCServer", "onCheckTx hex cmd decode", err)
-		return nil, err
-	}
+func onCheckTx(ctx context.Context, s *server.Server, params []byte) ([]byte, error) {
 	transaction := meta.Transaction{}
-	if err := transaction.DecodeFromBytes(buff); err != nil {
+	if err := transaction.DecodeFromBytes(params); err != nil {
 		log.Error("BCSIRPCServer", "onCheckTx cmd decode", err)
-		return nil, err
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCInvalidParams.Code, err.Error())
 	}
 	return nil, s.Context.(bcsi.BCSI).CheckTx(transaction)
 }
 
-func onFilterTx(s *server.Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	c, ok := cmd.(*TransactionsCmd)
-	if !ok {
-		log.Error("BCSIRPCServer", "onFilterTx Type error:", reflect.TypeOf(cmd))
-		return nil, nil
-	}
-	buff, err := hex.DecodeString(c.Transactions)
-	if err != nil {
-		log.Error("BCSIRPCServer", "onFilterTx hex cmd decode", err)
-		return nil, err
-	}
+func onFilterTx(ctx context.Context, s *server.Server, params []byte) ([]byte, error) {
 	transactions := meta.Transactions{}
-	if err := transactions.DecodeFromBytes(buff); err != nil {
+	if err := transactions.DecodeFromBytes(params); err != nil {
 		log.Error("BCSIRPCServer", "onFilterTx cmd decode", err)
-		return nil, err
+		return nil, rpcjson.NewRPCError(rpcjson.ErrRPCInvalidParams.Code, err.Error())
 	}
 
 	result := s.Context.(bcsi.BCSI).FilterTx(transactions.Txs)
@@ -164,7 +130,5 @@ func onFilterTx(s *server.Server, cmd interface{}, closeChan <-chan struct{}) (i
 		log.Error("BCSIRPCServer", "onFilterTx result encode transactions", err)
 		return nil, err
 	}
-	return &CommonRSP{
-		Data: hex.EncodeToString(resultBuff),
-	}, nil
+	return resultBuff, nil
 }
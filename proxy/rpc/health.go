@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/util/log"
+)
+
+//The BCSIRPCClient guards the core against an app which is down or hung: every call has a time limit,
+//the calls which can safely be repeated are retried with a growing delay, and after repeated failures
+//the app is held unavailable, failing the calls at once, until a probe reaches it again.
+const (
+	DefaultCallTimeout = 10 * time.Second //time limit of the calls without one of their own
+	DefaultCallRetries = 3                //retries of a failed idempotent call
+
+	retryBaseDelay   = 200 * time.Millisecond //delay before the first retry, doubling after each
+	retryMaxDelay    = 3 * time.Second        //longest delay between two retries
+	failureThreshold = 3                      //consecutive failed calls making the app unavailable
+	probeInterval    = 2 * time.Second        //delay between the probes of an unavailable app
+)
+
+//idempotentMethods are the calls which don't change the app state and can be repeated
+var idempotentMethods = map[string]bool{
+	"GetBlockState": true,
+	"CheckBlock":    true,
+	"CheckTx":       true,
+	"FilterTx":      true,
+}
+
+//isTransportError tell whether err is a failure to reach the app: a dial, timeout or I/O error.
+//Errors answered by the app or its server, such as an http status of a wrong password, are not.
+func isTransportError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+//appHealth track whether the app is reachable from the outcome of the calls
+type appHealth struct {
+	lock      sync.Mutex
+	available bool
+	failures  int //consecutive calls which didn't reach the app
+}
+
+func newAppHealth() *appHealth {
+	return &appHealth{available: true}
+}
+
+func (h *appHealth) healthy() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.available
+}
+
+//succeeded record a call which reached the app
+func (h *appHealth) succeeded() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if !h.available {
+		log.Info("BCSIRPCClient", "app", "reachable again")
+	}
+	h.available = true
+	h.failures = 0
+}
+
+//failed record a call which didn't reach the app, return true if the app just became unavailable
+func (h *appHealth) failed() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.failures++
+	if h.available && h.failures >= failureThreshold {
+		log.Warn("BCSIRPCClient", "app", "unreachable", "failures", h.failures)
+		h.available = false
+		return true
+	}
+	return false
+}
@@ -0,0 +1,408 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/mihongtech/linkchain-core/common/http/client"
+	"github.com/mihongtech/linkchain-core/common/http/rpcgen"
+	"github.com/mihongtech/linkchain-core/common/http/rpcjson"
+	"github.com/mihongtech/linkchain-core/common/http/server"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/bcsi"
+	"github.com/mihongtech/linkchain-core/protobuf"
+)
+
+var errTestBadTx = errors.New("bad transaction")
+
+// testBCSI is an app rejecting transactions marked bad.
+type testBCSI struct {
+	processed *meta.Block
+	committed meta.BlockID
+	core      bcsi.CoreServices
+}
+
+func (a *testBCSI) SetCoreServices(core bcsi.CoreServices) { a.core = core }
+
+func (a *testBCSI) GetBlockState(id meta.BlockID) (meta.TreeID, error) {
+	return math.HashH(id.CloneBytes()), nil
+}
+func (a *testBCSI) UpdateChain(head meta.Block) error   { return nil }
+func (a *testBCSI) ProcessBlock(block meta.Block) error { a.processed = &block; return nil }
+func (a *testBCSI) Commit(id meta.BlockID) error        { a.committed = id; return nil }
+func (a *testBCSI) CheckBlock(block meta.Block) error   { return nil }
+func (a *testBCSI) CheckTx(tx meta.Transaction) error {
+	if string(tx.Data) == "bad" {
+		return errTestBadTx
+	}
+	return nil
+}
+func (a *testBCSI) FilterTx(txs []meta.Transaction) []meta.Transaction {
+	var kept []meta.Transaction
+	for _, tx := range txs {
+		if a.CheckTx(tx) == nil {
+			kept = append(kept, tx)
+		}
+	}
+	return kept
+}
+
+func newTestBCSIRPC(t *testing.T) (*testBCSI, *BCSIRPCServer, *client.Config) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	api := &testBCSI{}
+	return api, startTestBCSIRPC(t, addr, api), &client.Config{RPCUser: "user", RPCPassword: "pass", RPCServer: addr}
+}
+
+func startTestBCSIRPC(t *testing.T, addr string, api bcsi.BCSI) *BCSIRPCServer {
+	srv, err := NewBCSIRPCServer(server.NewConfig("test", time.Now().Unix(), addr, "user", "pass"), api)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if !srv.Start() {
+		t.Fatalf("failed to start server")
+	}
+	return srv
+}
+
+// Tests that the json and protobuf encodings are served side by side.
+func TestBCSIRPCEncodings(t *testing.T) {
+	api, srv, cfg := newTestBCSIRPC(t)
+	defer srv.Stop()
+
+	block := &meta.Block{Header: meta.BlockHeader{Height: 9, Time: time.Unix(9, 0)}}
+	block.SetTx(meta.Transaction{Data: []byte("good")})
+	hash := *block.GetBlockID()
+
+	for _, protobuf := range []bool{false, true} {
+		cfg := *cfg
+		cfg.Protobuf = protobuf
+		c := NewBCSIRPCClient(&cfg)
+
+		if state, err := c.GetBlockState(hash); err != nil || state != math.HashH(hash.CloneBytes()) {
+			t.Fatalf("protobuf %v: GetBlockState: have %v %v", protobuf, state, err)
+		}
+		api.processed = nil
+		if err := c.ProcessBlock(*block); err != nil || api.processed == nil || !api.processed.GetBlockID().IsEqual(&hash) {
+			t.Fatalf("protobuf %v: ProcessBlock: have %v %v", protobuf, api.processed, err)
+		}
+		if err := c.Commit(hash); err != nil || api.committed != hash {
+			t.Fatalf("protobuf %v: Commit: have %v %v", protobuf, api.committed, err)
+		}
+		if err := c.CheckTx(meta.Transaction{Data: []byte("bad")}); err == nil || !strings.Contains(err.Error(), errTestBadTx.Error()) {
+			t.Fatalf("protobuf %v: CheckTx: have %v, want %v", protobuf, err, errTestBadTx)
+		}
+		txs := []meta.Transaction{{Data: []byte("good")}, {Data: []byte("bad")}, {Data: []byte("fine")}}
+		if kept := c.FilterTx(txs); len(kept) != 2 || string(kept[1].Data) != "fine" {
+			t.Fatalf("protobuf %v: FilterTx: have %v", protobuf, kept)
+		}
+	}
+}
+
+func TestBCSIRPCProtobufErrors(t *testing.T) {
+	_, srv, cfg := newTestBCSIRPC(t)
+	defer srv.Stop()
+
+	_, err := client.RPCProtobuf("Unknown", nil, cfg)
+	if rpcErr, ok := err.(*rpcjson.RPCError); !ok || rpcErr.Code != rpcjson.ErrRPCMethodNotFound.Code {
+		t.Fatalf("unknown method: have %v, want %v", err, rpcjson.ErrRPCMethodNotFound)
+	}
+	wrong := *cfg
+	wrong.RPCPassword = "wrong"
+	_, err = client.RPCProtobuf("Commit", nil, &wrong)
+	if rpcErr, ok := err.(*rpcjson.RPCError); !ok || rpcErr.Code != rpcjson.ErrRPCVerify {
+		t.Fatalf("wrong password: have %v, want code %d", err, rpcjson.ErrRPCVerify)
+	}
+	_, err = client.RPCProtobuf("ProcessBlock", []byte{0xff, 0xff}, cfg)
+	if rpcErr, ok := err.(*rpcjson.RPCError); !ok || rpcErr.Code != rpcjson.ErrRPCInvalidParams.Code {
+		t.Fatalf("malformed block: have %v, want %v", err, rpcjson.ErrRPCInvalidParams)
+	}
+	_, err = client.RPC("ProcessBlock", &BlockCmd{Block: "zz"}, cfg)
+	if rpcErr, ok := err.(*rpcjson.RPCError); !ok || rpcErr.Code != rpcjson.ErrRPCDecodeHexString {
+		t.Fatalf("malformed hex block: have %v, want code %d", err, rpcjson.ErrRPCDecodeHexString)
+	}
+}
+
+// testCore serves a single block holding a single transaction.
+type testCore struct {
+	block   *meta.Block
+	pending *meta.Transaction
+}
+
+func (c *testCore) ProcessTx(tx *meta.Transaction) error {
+	if string(tx.Data) == "bad" {
+		return errTestBadTx
+	}
+	c.pending = tx
+	return nil
+}
+func (c *testCore) GetBlockByID(hash meta.BlockID) (*meta.Block, error) {
+	if !hash.IsEqual(c.block.GetBlockID()) {
+		return nil, errors.New("unknown block")
+	}
+	return c.block, nil
+}
+func (c *testCore) GetBestBlock() *meta.Block { return c.block }
+func (c *testCore) GetTXByID(id meta.TxID) (*meta.Transaction, meta.BlockID, uint64, uint64) {
+	tx := c.block.TXs.Txs[0]
+	if !id.IsEqual(tx.GetTxID()) {
+		return nil, meta.BlockID{}, 0, 0
+	}
+	return &tx, *c.block.GetBlockID(), uint64(c.block.GetHeight()), 0
+}
+
+// Tests that the app reaches the core services through the core's BCSI client.
+func TestBCSIRPCCoreServices(t *testing.T) {
+	api, srv, cfg := newTestBCSIRPC(t)
+	defer srv.Stop()
+	if api.core != srv.Core() {
+		t.Fatalf("core services not handed to the app")
+	}
+	if _, err := api.core.GetBlockByID(meta.BlockID{}); err != ErrCoreNotConnected {
+		t.Fatalf("call without a polling core: have %v, want %v", err, ErrCoreNotConnected)
+	}
+
+	block := &meta.Block{Header: meta.BlockHeader{Height: 4, Time: time.Unix(4, 0)}}
+	block.SetTx(meta.Transaction{Data: []byte("included")})
+	hash := *block.GetBlockID()
+	core := &testCore{block: block}
+
+	for _, protobuf := range []bool{false, true} {
+		cfg := *cfg
+		cfg.Protobuf = protobuf
+		c := NewBCSIRPCClient(&cfg)
+		c.SetCoreServices(core)
+		for !srv.core.connected() {
+			time.Sleep(time.Millisecond)
+		}
+
+		if best := api.core.GetBestBlock(); best == nil || !best.GetBlockID().IsEqual(&hash) {
+			t.Fatalf("protobuf %v: GetBestBlock: have %v", protobuf, best)
+		}
+		if got, err := api.core.GetBlockByID(hash); err != nil || !got.GetBlockID().IsEqual(&hash) {
+			t.Fatalf("protobuf %v: GetBlockByID: have %v %v", protobuf, got, err)
+		}
+		if _, err := api.core.GetBlockByID(meta.BlockID{}); err == nil {
+			t.Fatalf("protobuf %v: GetBlockByID of unknown block succeeded", protobuf)
+		}
+		txid := *block.TXs.Txs[0].GetTxID()
+		tx, blockId, height, _ := api.core.GetTXByID(txid)
+		if tx == nil || !tx.GetTxID().IsEqual(&txid) || blockId != hash || height != 4 {
+			t.Fatalf("protobuf %v: GetTXByID: have %v %v %d", protobuf, tx, blockId, height)
+		}
+		if tx, _, _, _ := api.core.GetTXByID(meta.TxID{}); tx != nil {
+			t.Fatalf("protobuf %v: GetTXByID of unknown tx: have %v", protobuf, tx)
+		}
+		if err := api.core.ProcessTx(&meta.Transaction{Data: []byte("new")}); err != nil || string(core.pending.Data) != "new" {
+			t.Fatalf("protobuf %v: ProcessTx: have %v %v", protobuf, core.pending, err)
+		}
+		if err := api.core.ProcessTx(&meta.Transaction{Data: []byte("bad")}); err == nil || err.Error() != errTestBadTx.Error() {
+			t.Fatalf("protobuf %v: ProcessTx: have %v, want %v", protobuf, err, errTestBadTx)
+		}
+		c.Close()
+	}
+}
+
+// Tests that a result posted back twice by the core doesn't block the second post.
+func TestCoreCallResultTwice(t *testing.T) {
+	c := newCoreCaller()
+	ch := make(chan *protobuf.RPCResponse, 1)
+	c.pending[1] = ch
+
+	params, _ := proto.Marshal(&protobuf.RPCResponse{Id: proto.Uint64(1), Result: []byte("result")})
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 2; i++ {
+			c.onCoreCallResult(context.Background(), nil, params)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("second result blocked")
+	}
+	if rsp := <-ch; string(rsp.Result) != "result" {
+		t.Fatalf("delivered result: have %q", rsp.Result)
+	}
+	if len(c.pending) != 0 {
+		t.Fatalf("call still pending after its result")
+	}
+}
+
+// Tests that a call given up by its caller isn't handed to the core.
+func TestCorePollSkipsAbandonedCall(t *testing.T) {
+	c := newCoreCaller()
+	c.pending[2] = make(chan *protobuf.RPCResponse, 1)
+	c.calls <- &protobuf.RPCRequest{Id: proto.Uint64(1), Method: proto.String("ProcessTx")}
+	c.calls <- &protobuf.RPCRequest{Id: proto.Uint64(2), Method: proto.String("GetBestBlock")}
+
+	data, err := c.onPollCoreCall(context.Background(), &server.Server{}, nil)
+	if err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	req := &protobuf.RPCRequest{}
+	if err := proto.Unmarshal(data, req); err != nil || req.GetId() != 2 {
+		t.Fatalf("polled call: have %v %v, want id 2", req, err)
+	}
+}
+
+// hangingBCSI is an app whose block processing hangs until released.
+type hangingBCSI struct {
+	testBCSI
+	release chan struct{}
+}
+
+func (a *hangingBCSI) ProcessBlock(block meta.Block) error {
+	<-a.release
+	return nil
+}
+
+// Tests that calls to a hung app give up after their time limit.
+func TestBCSIRPCClientTimeout(t *testing.T) {
+	_, srv, cfg := newTestBCSIRPC(t)
+	srv.Stop()
+
+	api := &hangingBCSI{release: make(chan struct{})}
+	srv = startTestBCSIRPC(t, cfg.RPCServer, api)
+	defer srv.Stop()
+	defer close(api.release)
+
+	c := NewBCSIRPCClient(cfg)
+	defer c.Close()
+	c.SetCallTimeout("ProcessBlock", 200*time.Millisecond)
+
+	start := time.Now()
+	if err := c.ProcessBlock(meta.Block{}); err != bcsi.ErrAppUnavailable {
+		t.Fatalf("hung ProcessBlock: have %v, want %v", err, bcsi.ErrAppUnavailable)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("hung ProcessBlock took %v", elapsed)
+	}
+	if _, err := c.GetBlockState(meta.BlockID{}); err != nil {
+		t.Fatalf("GetBlockState: %v", err)
+	}
+}
+
+// Tests that idempotent calls are retried until the app comes up.
+func TestBCSIRPCClientRetry(t *testing.T) {
+	_, srv, cfg := newTestBCSIRPC(t)
+	srv.Stop()
+
+	c := NewBCSIRPCClient(cfg)
+	defer c.Close()
+
+	started := make(chan *BCSIRPCServer)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		started <- startTestBCSIRPC(t, cfg.RPCServer, &testBCSI{})
+	}()
+	defer func() { (<-started).Stop() }()
+
+	var id meta.BlockID
+	if state, err := c.GetBlockState(id); err != nil || state != math.HashH(id.CloneBytes()) {
+		t.Fatalf("GetBlockState: have %v %v", state, err)
+	}
+}
+
+// Tests that the client turns unhealthy while the app is down, without
+// counting the errors returned by the app, and recovers once it is back.
+func TestBCSIRPCClientHealth(t *testing.T) {
+	_, srv, cfg := newTestBCSIRPC(t)
+	c := NewBCSIRPCClient(cfg)
+	defer c.Close()
+	c.SetCallRetries(0)
+
+	for i := 0; i < failureThreshold; i++ {
+		if err := c.CheckTx(meta.Transaction{Data: []byte("bad")}); err == nil || err == bcsi.ErrAppUnavailable {
+			t.Fatalf("CheckTx: have %v, want the app error", err)
+		}
+	}
+	if !c.Healthy() {
+		t.Fatal("app errors made the client unhealthy")
+	}
+	// The http errors of a reached server aren't transport errors either
+	wrongCfg := *cfg
+	wrongCfg.RPCPassword = "wrong"
+	wrong := NewBCSIRPCClient(&wrongCfg)
+	defer wrong.Close()
+	wrong.SetCallRetries(0)
+	for i := 0; i < failureThreshold; i++ {
+		if err := wrong.Commit(meta.BlockID{}); err == nil || err == bcsi.ErrAppUnavailable {
+			t.Fatalf("Commit with a wrong password: have %v, want the http error", err)
+		}
+	}
+	if !wrong.Healthy() {
+		t.Fatal("http errors made the client unhealthy")
+	}
+
+	srv.Stop()
+	for i := 0; i < failureThreshold; i++ {
+		if err := c.Commit(meta.BlockID{}); err != bcsi.ErrAppUnavailable {
+			t.Fatalf("Commit to a stopped app: have %v, want %v", err, bcsi.ErrAppUnavailable)
+		}
+	}
+	if c.Healthy() {
+		t.Fatal("client healthy while the app is down")
+	}
+	srv = startTestBCSIRPC(t, cfg.RPCServer, &testBCSI{})
+	defer srv.Stop()
+
+	// Calls fail at once until a probe finds the app back
+	if err := c.Commit(meta.BlockID{}); err != bcsi.ErrAppUnavailable {
+		t.Fatalf("Commit while unhealthy: have %v, want %v", err, bcsi.ErrAppUnavailable)
+	}
+	deadline := time.Now().Add(3 * probeInterval)
+	for !c.Healthy() {
+		if time.Now().After(deadline) {
+			t.Fatal("client didn't recover")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err := c.Commit(meta.BlockID{}); err != nil {
+		t.Fatalf("Commit after recovery: %v", err)
+	}
+}
+
+var update = flag.Bool("update", false, "regenerate the rpc stubs")
+
+// Tests that the generated stub matches the methods served, regenerating it
+// with -update.
+func TestBCSIStub(t *testing.T) {
+	srv, err := NewBCSIRPCServer(server.NewConfig("bcsi", 0, "", "", ""), &testBCSI{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := rpcgen.Generate(srv.rpcServer.Discover(), &rpcgen.Options{
+		Package:    "rpc",
+		ImportPath: "github.com/mihongtech/linkchain-core/proxy/rpc",
+		Client:     "bcsiStub",
+		Source:     "the rpc_discover document of BCSIRPCServer",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err := ioutil.WriteFile("stub_gen.go", code, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	have, err := ioutil.ReadFile("stub_gen.go")
+	if err != nil || !bytes.Equal(have, code) {
+		t.Fatalf("stub_gen.go is out of date, run go generate: %v", err)
+	}
+}
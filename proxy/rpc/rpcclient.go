@@ -2,44 +2,171 @@ package rpc
 
 import (
 	"encoding/hex"
-	"encoding/json"
+	"sync"
+	"time"
+
 	"github.com/mihongtech/linkchain-core/common/http/client"
 	"github.com/mihongtech/linkchain-core/common/math"
 	"github.com/mihongtech/linkchain-core/common/util/log"
 	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/bcsi"
 )
 
+//BCSIRPCClient call a BCSIRPCServer, sending protobuf payloads directly if cfg.Protobuf is set
+//and hex inside json otherwise.
+//Calls which don't reach the app fail with bcsi.ErrAppUnavailable, and the client reports itself unhealthy
+//while the app can't be reached.
 type BCSIRPCClient struct {
 	cfg *client.Config
+
+	timeouts map[string]time.Duration //time limits of the methods, set before use
+	retries  int
+	health   *appHealth
+
+	quit      chan struct{}
+	closeOnce sync.Once
 }
 
 func NewBCSIRPCClient(cfg *client.Config) *BCSIRPCClient {
-	return &BCSIRPCClient{cfg: cfg}
+	return &BCSIRPCClient{
+		cfg:      cfg,
+		timeouts: map[string]time.Duration{"PollCoreCall": corePollWait + DefaultCallTimeout},
+		retries:  DefaultCallRetries,
+		health:   newAppHealth(),
+		quit:     make(chan struct{}),
+	}
 }
 
-func (c *BCSIRPCClient) GetBlockState(id meta.BlockID) (meta.TreeID, error) {
-	buff, err := id.EncodeToBytes()
-	if err != nil {
-		log.Error("BCSIRPCClient", "GetBlockState cmd encode", err)
-		return math.Hash{}, err
+//SetCallTimeout set the time limit of the calls of method, 0 for the default one.
+//The default is cfg.Timeout if set and DefaultCallTimeout otherwise.
+func (c *BCSIRPCClient) SetCallTimeout(method string, timeout time.Duration) {
+	if timeout == 0 {
+		delete(c.timeouts, method)
+		return
 	}
-	cmd := BlockIDCmd{BlockId: hex.EncodeToString(buff)}
+	c.timeouts[method] = timeout
+}
+
+//SetCallRetries set how many times an idempotent call failing to reach the app is retried
+func (c *BCSIRPCClient) SetCallRetries(retries int) {
+	c.retries = retries
+}
 
-	response, err := client.RPC("GetBlockState", cmd, c.cfg)
+//Healthy report whether the app is reachable, implementing bcsi.HealthChecker
+func (c *BCSIRPCClient) Healthy() bool {
+	return c.health.healthy()
+}
+
+func (c *BCSIRPCClient) callTimeout(method string) time.Duration {
+	if timeout, ok := c.timeouts[method]; ok {
+		return timeout
+	}
+	if c.cfg.Timeout != 0 {
+		return c.cfg.Timeout
+	}
+	return DefaultCallTimeout
+}
+
+//jsonCall is the method of the generated stub sending the hex params of a call as json
+type jsonCall func(stub *bcsiStub, params string) (*CommonRSP, error)
+
+//post send a request for method within its time limit and return the raw result, hex for json
+func (c *BCSIRPCClient) post(method string, params []byte, call jsonCall) ([]byte, error) {
+	cfg := *c.cfg
+	cfg.Timeout = c.callTimeout(method)
+	if cfg.Protobuf {
+		return client.RPCProtobuf(method, params, &cfg)
+	}
+	rsp, err := call(newBcsiStub(&cfg), hex.EncodeToString(params))
+	if err != nil || rsp == nil {
+		return nil, err
+	}
+	return []byte(rsp.Data), nil
+}
+
+//call send the encoded params of method through call for json and return the encoded result.
+//Idempotent calls are retried with backoff while they don't reach the app.
+func (c *BCSIRPCClient) call(method string, params []byte, call jsonCall) ([]byte, error) {
+	if !c.health.healthy() {
+		return nil, bcsi.ErrAppUnavailable
+	}
+	attempts := 1
+	if idempotentMethods[method] {
+		attempts += c.retries
+	}
+	delay := retryBaseDelay
+	for i := 0; ; i++ {
+		response, err := c.post(method, params, call)
+		if !isTransportError(err) {
+			c.health.succeeded()
+			if err != nil {
+				log.Error("BCSIRPCClient", method+" rpc call", err)
+				return nil, err
+			}
+			return c.decode(method, response)
+		}
+		log.Warn("BCSIRPCClient", method+" rpc connect", err, "attempt", i+1)
+		if i+1 >= attempts {
+			break
+		}
+		select {
+		case <-time.After(delay):
+		case <-c.quit:
+			return nil, bcsi.ErrAppUnavailable
+		}
+		if delay *= 2; delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+	}
+	if c.health.failed() {
+		go c.probe()
+	}
+	return nil, bcsi.ErrAppUnavailable
+}
+
+//decode return the encoded result from the raw one
+func (c *BCSIRPCClient) decode(method string, response []byte) ([]byte, error) {
+	if c.cfg.Protobuf {
+		return response, nil
+	}
+
+	responseBuff, err := hex.DecodeString(string(response))
 	if err != nil {
-		log.Error("BCSIRPCClient", "GetBlockState rpc connect", err)
-		return math.Hash{}, err
+		log.Error("BCSIRPCClient", method+" response hex decode", err)
+		return nil, err
+	}
+	return responseBuff, nil
+}
+
+//probe query the unavailable app until it answers, any answer proving it is reachable again
+func (c *BCSIRPCClient) probe() {
+	var id meta.BlockID
+	params, _ := id.EncodeToBytes()
+	ticker := time.NewTicker(probeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.quit:
+			return
+		}
+		if _, err := c.post("GetBlockState", params, (*bcsiStub).GetBlockState); !isTransportError(err) {
+			c.health.succeeded()
+			return
+		}
 	}
+}
 
-	rsp := CommonRSP{}
-	if err = json.Unmarshal([]byte(response), &rsp); err != nil {
-		log.Error("BCSIRPCClient", "GetBlockState response json Unmarshal", err)
+func (c *BCSIRPCClient) GetBlockState(id meta.BlockID) (meta.TreeID, error) {
+	buff, err := id.EncodeToBytes()
+	if err != nil {
+		log.Error("BCSIRPCClient", "GetBlockState cmd encode", err)
 		return math.Hash{}, err
 	}
 
-	responseBuff, err := hex.DecodeString(rsp.Data)
+	responseBuff, err := c.call("GetBlockState", buff, (*bcsiStub).GetBlockState)
 	if err != nil {
-		log.Error("BCSIRPCClient", "GetBlockState response hex decode", err)
 		return math.Hash{}, err
 	}
 
@@ -54,13 +181,8 @@ func (c *BCSIRPCClient) UpdateChain(head meta.Block) error {
 		log.Error("BCSIRPCClient", "UpdateChain cmd encode", err)
 		return err
 	}
-	cmd := BlockCmd{Block: hex.EncodeToString(buff)}
-	_, err = client.RPC("UpdateChain", cmd, c.cfg)
-	if err != nil {
-		log.Error("BCSIRPCClient", "UpdateChain rpc connect", err)
-		return err
-	}
-	return nil
+	_, err = c.call("UpdateChain", buff, (*bcsiStub).UpdateChain)
+	return err
 }
 
 func (c *BCSIRPCClient) ProcessBlock(block meta.Block) error {
@@ -69,13 +191,8 @@ func (c *BCSIRPCClient) ProcessBlock(block meta.Block) error {
 		log.Error("BCSIRPCClient", "ProcessBlock cmd encode", err)
 		return err
 	}
-	cmd := BlockCmd{Block: hex.EncodeToString(buff)}
-	_, err = client.RPC("ProcessBlock", cmd, c.cfg)
-	if err != nil {
-		log.Error("BCSIRPCClient", "ProcessBlock rpc connect", err)
-		return err
-	}
-	return nil
+	_, err = c.call("ProcessBlock", buff, (*bcsiStub).ProcessBlock)
+	return err
 }
 
 func (c *BCSIRPCClient) Commit(id meta.BlockID) error {
@@ -84,13 +201,8 @@ func (c *BCSIRPCClient) Commit(id meta.BlockID) error {
 		log.Error("BCSIRPCClient", "Commit cmd encode", err)
 		return err
 	}
-	cmd := BlockIDCmd{BlockId: hex.EncodeToString(buff)}
-	_, err = client.RPC("Commit", cmd, c.cfg)
-	if err != nil {
-		log.Error("BCSIRPCClient", "Commit rpc connect", err)
-		return err
-	}
-	return nil
+	_, err = c.call("Commit", buff, (*bcsiStub).Commit)
+	return err
 }
 
 func (c *BCSIRPCClient) CheckBlock(block meta.Block) error {
@@ -99,13 +211,8 @@ func (c *BCSIRPCClient) CheckBlock(block meta.Block) error {
 		log.Error("BCSIRPCClient", "CheckBlock cmd encode", err)
 		return err
 	}
-	cmd := BlockCmd{Block: hex.EncodeToString(buff)}
-	_, err = client.RPC("CheckBlock", cmd, c.cfg)
-	if err != nil {
-		log.Error("BCSIRPCClient", "CheckBlock rpc connect", err)
-		return err
-	}
-	return nil
+	_, err = c.call("CheckBlock", buff, (*bcsiStub).CheckBlock)
+	return err
 }
 
 func (c *BCSIRPCClient) CheckTx(transaction meta.Transaction) error {
@@ -114,13 +221,8 @@ func (c *BCSIRPCClient) CheckTx(transaction meta.Transaction) error {
 		log.Error("BCSIRPCClient", "CheckTx cmd encode", err)
 		return err
 	}
-	cmd := TransactionCmd{Transaction: hex.EncodeToString(buff)}
-	_, err = client.RPC("CheckTx", cmd, c.cfg)
-	if err != nil {
-		log.Error("BCSIRPCClient", "CheckTx rpc connect", err)
-		return err
-	}
-	return nil
+	_, err = c.call("CheckTx", buff, (*bcsiStub).CheckTx)
+	return err
 }
 
 func (c *BCSIRPCClient) FilterTx(txs []meta.Transaction) []meta.Transaction {
@@ -131,23 +233,9 @@ func (c *BCSIRPCClient) FilterTx(txs []meta.Transaction) []meta.Transaction {
 		log.Error("BCSIRPCClient", "FilterTx cmd encode", err)
 		return filterTxs
 	}
-	cmd := TransactionsCmd{Transactions: hex.EncodeToString(buff)}
-
-	response, err := client.RPC("FilterTx", cmd, c.cfg)
-	if err != nil {
-		log.Error("BCSIRPCClient", "FilterTx rpc connect", err)
-		return filterTxs
-	}
-
-	rsp := CommonRSP{}
-	if err = json.Unmarshal([]byte(response), &rsp); err != nil {
-		log.Error("BCSIRPCClient", "FilterTx response json Unmarshal", err)
-		return filterTxs
-	}
 
-	responseBuff, err := hex.DecodeString(rsp.Data)
+	responseBuff, err := c.call("FilterTx", buff, (*bcsiStub).FilterTx)
 	if err != nil {
-		log.Error("BCSIRPCClient", "FilterTx response hex decode", err)
 		return filterTxs
 	}
 
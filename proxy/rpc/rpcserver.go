@@ -8,8 +8,11 @@ import (
 	"github.com/mihongtech/linkchain-core/node/bcsi"
 )
 
+//go:generate go test -run TestBCSIStub -update
+
 type BCSIRPCServer struct {
 	api       bcsi.BCSI
+	core      *coreCaller
 	rpcServer *server.Server
 }
 
@@ -21,14 +24,28 @@ func NewBCSIRPCServer(cfg *server.Config, api bcsi.BCSI) (*BCSIRPCServer, error)
 		return nil, err
 	}
 
+	core := newCoreCaller()
+	if setter, ok := api.(bcsi.CoreServicesSetter); ok {
+		setter.SetCoreServices(core)
+	}
+
 	//set handler
-	rpcServer.SetHandleFunc("GetBlockState", onGetBlockState)
-	rpcServer.SetHandleFunc("UpdateChain", onUpdateChain)
-	rpcServer.SetHandleFunc("ProcessBlock", onProcessBlock)
-	rpcServer.SetHandleFunc("Commit", onCommit)
-	rpcServer.SetHandleFunc("CheckBlock", onCheckBlock)
-	rpcServer.SetHandleFunc("CheckTx", onCheckTx)
-	rpcServer.SetHandleFunc("FilterTx", onFilterTx)
+	handlers := map[string]binaryHandler{
+		"GetBlockState":  onGetBlockState,
+		"UpdateChain":    onUpdateChain,
+		"ProcessBlock":   onProcessBlock,
+		"Commit":         onCommit,
+		"CheckBlock":     onCheckBlock,
+		"CheckTx":        onCheckTx,
+		"FilterTx":       onFilterTx,
+		"PollCoreCall":   core.onPollCoreCall,
+		"CoreCallResult": core.onCoreCallResult,
+	}
+	for method, handler := range handlers {
+		rpcServer.SetHandleFunc(method, jsonHandler(method, handler))
+		rpcServer.SetBinaryHandleFunc(method, handler)
+		rpcServer.SetResult(method, reflect.TypeOf((*CommonRSP)(nil)))
+	}
 	//set cmd
 	rpcServer.SetCmd("GetBlockState", reflect.TypeOf((*BlockIDCmd)(nil)))
 	rpcServer.SetCmd("UpdateChain", reflect.TypeOf((*BlockCmd)(nil)))
@@ -37,7 +54,9 @@ func NewBCSIRPCServer(cfg *server.Config, api bcsi.BCSI) (*BCSIRPCServer, error)
 	rpcServer.SetCmd("CheckBlock", reflect.TypeOf((*BlockCmd)(nil)))
 	rpcServer.SetCmd("CheckTx", reflect.TypeOf((*TransactionCmd)(nil)))
 	rpcServer.SetCmd("FilterTx", reflect.TypeOf((*TransactionsCmd)(nil)))
-	return &BCSIRPCServer{api: api, rpcServer: rpcServer}, nil
+	rpcServer.SetCmd("PollCoreCall", reflect.TypeOf((*CoreCallCmd)(nil)))
+	rpcServer.SetCmd("CoreCallResult", reflect.TypeOf((*CoreCallCmd)(nil)))
+	return &BCSIRPCServer{api: api, core: core, rpcServer: rpcServer}, nil
 }
 
 func (s *BCSIRPCServer) SetUp(i interface{}) bool {
@@ -45,11 +64,15 @@ func (s *BCSIRPCServer) SetUp(i interface{}) bool {
 }
 
 func (s *BCSIRPCServer) Start() bool {
-	s.rpcServer.Start()
-	return true
+	return s.rpcServer.Start() == nil
 }
 
 func (s *BCSIRPCServer) Stop() bool {
 	s.rpcServer.Stop()
 	return true
 }
+
+//Core return the client of the core services for the app, served once the core polls for calls
+func (s *BCSIRPCServer) Core() bcsi.CoreServices {
+	return s.core
+}
@@ -0,0 +1,107 @@
+// Code generated by rpcgen from the rpc_discover document of BCSIRPCServer. DO NOT EDIT.
+
+package rpc
+
+import (
+	"github.com/mihongtech/linkchain-core/common/http/client"
+)
+
+// bcsiStub calls the methods of bcsi over JSON-RPC.
+type bcsiStub struct {
+	cfg *client.Config
+}
+
+// newBcsiStub returns a client of the server described by cfg.
+func newBcsiStub(cfg *client.Config) *bcsiStub {
+	return &bcsiStub{cfg: cfg}
+}
+
+// CheckBlock calls the CheckBlock method.
+func (c *bcsiStub) CheckBlock(block string) (*CommonRSP, error) {
+	params := struct {
+		Block string `json:"block"`
+	}{block}
+	var result *CommonRSP
+	err := client.Call("CheckBlock", &params, &result, c.cfg)
+	return result, err
+}
+
+// CheckTx calls the CheckTx method.
+func (c *bcsiStub) CheckTx(transaction string) (*CommonRSP, error) {
+	params := struct {
+		Transaction string `json:"transaction"`
+	}{transaction}
+	var result *CommonRSP
+	err := client.Call("CheckTx", &params, &result, c.cfg)
+	return result, err
+}
+
+// Commit calls the Commit method.
+func (c *bcsiStub) Commit(blockId string) (*CommonRSP, error) {
+	params := struct {
+		BlockId string `json:"blockId"`
+	}{blockId}
+	var result *CommonRSP
+	err := client.Call("Commit", &params, &result, c.cfg)
+	return result, err
+}
+
+// CoreCallResult calls the CoreCallResult method.
+func (c *bcsiStub) CoreCallResult(call string) (*CommonRSP, error) {
+	params := struct {
+		Call string `json:"call"`
+	}{call}
+	var result *CommonRSP
+	err := client.Call("CoreCallResult", &params, &result, c.cfg)
+	return result, err
+}
+
+// FilterTx calls the FilterTx method.
+func (c *bcsiStub) FilterTx(transactions string) (*CommonRSP, error) {
+	params := struct {
+		Transactions string `json:"transactions"`
+	}{transactions}
+	var result *CommonRSP
+	err := client.Call("FilterTx", &params, &result, c.cfg)
+	return result, err
+}
+
+// GetBlockState calls the GetBlockState method.
+func (c *bcsiStub) GetBlockState(blockId string) (*CommonRSP, error) {
+	params := struct {
+		BlockId string `json:"blockId"`
+	}{blockId}
+	var result *CommonRSP
+	err := client.Call("GetBlockState", &params, &result, c.cfg)
+	return result, err
+}
+
+// PollCoreCall calls the PollCoreCall method.
+func (c *bcsiStub) PollCoreCall(call string) (*CommonRSP, error) {
+	params := struct {
+		Call string `json:"call"`
+	}{call}
+	var result *CommonRSP
+	err := client.Call("PollCoreCall", &params, &result, c.cfg)
+	return result, err
+}
+
+// ProcessBlock calls the ProcessBlock method.
+func (c *bcsiStub) ProcessBlock(block string) (*CommonRSP, error) {
+	params := struct {
+		Block string `json:"block"`
+	}{block}
+	var result *CommonRSP
+	err := client.Call("ProcessBlock", &params, &result, c.cfg)
+	return result, err
+}
+
+// UpdateChain calls the UpdateChain method.
+func (c *bcsiStub) UpdateChain(block string) (*CommonRSP, error) {
+	params := struct {
+		Block string `json:"block"`
+	}{block}
+	var result *CommonRSP
+	err := client.Call("UpdateChain", &params, &result, c.cfg)
+	return result, err
+}
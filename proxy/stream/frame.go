@@ -0,0 +1,64 @@
+package stream
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+)
+
+//BCSI methods carried by the frames
+const (
+	methodGetBlockState = "GetBlockState"
+	methodUpdateChain   = "UpdateChain"
+	methodProcessBlock  = "ProcessBlock"
+	methodCommit        = "Commit"
+	methodCheckBlock    = "CheckBlock"
+	methodCheckTx       = "CheckTx"
+	methodFilterTx      = "FilterTx"
+)
+
+//maxFrameSize bound the size of a single frame, large enough for any block
+const maxFrameSize = 32 * 1024 * 1024
+
+var (
+	ErrFrameTooLarge  = errors.New("stream frame too large")
+	ErrConnectionLost = errors.New("stream connection lost")
+	ErrClientClosed   = errors.New("stream client closed")
+	ErrCallTimeout    = errors.New("stream call timeout")
+)
+
+//writeFrame write msg as a frame: its big endian uint32 length followed by the protobuf encoding
+func writeFrame(w io.Writer, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if len(data) > maxFrameSize {
+		return ErrFrameTooLarge
+	}
+	frame := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	copy(frame[4:], data)
+	_, err = w.Write(frame)
+	return err
+}
+
+//readFrame read the next frame into msg
+func readFrame(r io.Reader, msg proto.Message) error {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return err
+	}
+	length := binary.BigEndian.Uint32(size[:])
+	if length > maxFrameSize {
+		return fmt.Errorf("%v: %d bytes", ErrFrameTooLarge, length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, msg)
+}
@@ -0,0 +1,285 @@
+package stream
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mihongtech/linkchain-core/common/http/client"
+	"github.com/mihongtech/linkchain-core/common/http/server"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/proxy/rpc"
+)
+
+var errTestBadTx = errors.New("bad transaction")
+
+// testBCSI is an app holding back transactions marked slow until released and
+// rejecting transactions marked bad.
+type testBCSI struct {
+	lock      sync.Mutex
+	processed []uint32
+	committed meta.BlockID
+	release   chan struct{}
+}
+
+func newTestBCSI() *testBCSI {
+	return &testBCSI{release: make(chan struct{})}
+}
+
+func (a *testBCSI) GetBlockState(id meta.BlockID) (meta.TreeID, error) {
+	return math.HashH(id.CloneBytes()), nil
+}
+func (a *testBCSI) UpdateChain(head meta.Block) error { return nil }
+func (a *testBCSI) ProcessBlock(block meta.Block) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.processed = append(a.processed, block.GetHeight())
+	return nil
+}
+func (a *testBCSI) Commit(id meta.BlockID) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.committed = id
+	return nil
+}
+func (a *testBCSI) CheckBlock(block meta.Block) error { return nil }
+func (a *testBCSI) CheckTx(transaction meta.Transaction) error {
+	switch string(transaction.Data) {
+	case "bad":
+		return errTestBadTx
+	case "slow":
+		<-a.release
+	}
+	return nil
+}
+func (a *testBCSI) FilterTx(txs []meta.Transaction) []meta.Transaction {
+	var kept []meta.Transaction
+	for _, tx := range txs {
+		if a.CheckTx(tx) == nil {
+			kept = append(kept, tx)
+		}
+	}
+	return kept
+}
+
+func makeTestBlock(height uint32, txs int) *meta.Block {
+	block := &meta.Block{Header: meta.BlockHeader{Height: height, Time: time.Unix(int64(height), 0)}}
+	for i := 0; i < txs; i++ {
+		block.SetTx(meta.Transaction{Data: []byte{byte(i), byte(i >> 8), 0x5e}})
+	}
+	return block
+}
+
+func newTestStream(t testing.TB, network string) (*testBCSI, *StreamServer, *StreamClient) {
+	addr := "127.0.0.1:0"
+	if network == "unix" {
+		addr = filepath.Join(t.TempDir(), "bcsi.sock")
+	}
+	api := newTestBCSI()
+	srv := NewStreamServer(network, addr, api)
+	if !srv.Start() {
+		t.Fatalf("failed to start server")
+	}
+	return api, srv, NewStreamClient(network, srv.Addr().String())
+}
+
+func TestStreamBCSI(t *testing.T) {
+	for _, network := range []string{"tcp", "unix"} {
+		api, srv, c := newTestStream(t, network)
+
+		block := makeTestBlock(3, 4)
+		hash := *block.GetBlockID()
+		if state, err := c.GetBlockState(hash); err != nil || state != math.HashH(hash.CloneBytes()) {
+			t.Fatalf("%s: GetBlockState: have %v %v", network, state, err)
+		}
+		if err := c.ProcessBlock(*block); err != nil {
+			t.Fatalf("%s: ProcessBlock: %v", network, err)
+		}
+		if err := c.Commit(hash); err != nil || api.committed != hash {
+			t.Fatalf("%s: Commit: have %v %v, want %v", network, api.committed, err, hash)
+		}
+		if err := c.CheckTx(meta.Transaction{Data: []byte("bad")}); err == nil || err.Error() != errTestBadTx.Error() {
+			t.Fatalf("%s: CheckTx: have %v, want %v", network, err, errTestBadTx)
+		}
+		txs := []meta.Transaction{{Data: []byte("good")}, {Data: []byte("bad")}, {Data: []byte("fine")}}
+		if kept := c.FilterTx(txs); len(kept) != 2 || string(kept[1].Data) != "fine" {
+			t.Fatalf("%s: FilterTx: have %v", network, kept)
+		}
+		c.Close()
+		srv.Stop()
+
+		if len(api.processed) != 1 || api.processed[0] != 3 {
+			t.Fatalf("%s: processed blocks: have %v, want [3]", network, api.processed)
+		}
+	}
+}
+
+// Tests that a slow call doesn't hold up the calls behind it on the connection.
+func TestStreamMultiplex(t *testing.T) {
+	api, srv, c := newTestStream(t, "tcp")
+	defer srv.Stop()
+	defer c.Close()
+
+	slow := make(chan error)
+	go func() { slow <- c.CheckTx(meta.Transaction{Data: []byte("slow")}) }()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := c.ProcessBlock(*makeTestBlock(uint32(i), 2)); err != nil {
+				t.Errorf("ProcessBlock %d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-slow:
+		t.Fatalf("slow call returned early: %v", err)
+	default:
+	}
+	close(api.release)
+	if err := <-slow; err != nil {
+		t.Fatalf("slow call: %v", err)
+	}
+}
+
+// Tests that a restarted server is dialed again and calls pending on the lost
+// connection fail.
+func TestStreamReconnect(t *testing.T) {
+	api, srv, c := newTestStream(t, "tcp")
+	defer c.Close()
+	addr := srv.Addr().String()
+
+	if err := c.Commit(meta.BlockID{1}); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	slow := make(chan error)
+	go func() { slow <- c.CheckTx(meta.Transaction{Data: []byte("slow")}) }()
+	time.Sleep(50 * time.Millisecond)
+
+	go srv.Stop()
+	select {
+	case err := <-slow:
+		if err != ErrConnectionLost {
+			t.Fatalf("pending call: have %v, want %v", err, ErrConnectionLost)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("pending call not failed on connection loss")
+	}
+	close(api.release)
+
+	srv = NewStreamServer("tcp", addr, api)
+	if !srv.Start() {
+		t.Fatalf("failed to restart server")
+	}
+	defer srv.Stop()
+	if err := c.Commit(meta.BlockID{2}); err != nil || api.committed != (meta.BlockID{2}) {
+		t.Fatalf("Commit after restart: have %v %v", api.committed, err)
+	}
+}
+
+// Tests that a call the app doesn't answer in time fails and drops the
+// connection, which the next call dials again.
+func TestStreamCallTimeout(t *testing.T) {
+	api, srv, c := newTestStream(t, "tcp")
+	defer srv.Stop()
+	defer c.Close()
+	defer close(api.release)
+
+	c.SetCallTimeout(methodCheckTx, 100*time.Millisecond)
+	start := time.Now()
+	if err := c.CheckTx(meta.Transaction{Data: []byte("slow")}); err != ErrCallTimeout {
+		t.Fatalf("slow call: have %v, want %v", err, ErrCallTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("slow call failed after %v", elapsed)
+	}
+	c.lock.Lock()
+	conn, pending := c.conn, len(c.pending)
+	c.lock.Unlock()
+	if conn != nil || pending != 0 {
+		t.Fatalf("timed out connection kept: conn %v, %d pending calls", conn, pending)
+	}
+	if err := c.Commit(meta.BlockID{1}); err != nil || api.committed != (meta.BlockID{1}) {
+		t.Fatalf("Commit after timeout: have %v %v", api.committed, err)
+	}
+}
+
+func TestStreamWriteTimeout(t *testing.T) {
+	//an app which accepts the connection but never reads it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	c := NewStreamClient("tcp", listener.Addr().String())
+	defer c.Close()
+	c.SetTimeout(200 * time.Millisecond)
+
+	start := time.Now()
+	//far more than the socket buffers take
+	if err := c.CheckTx(meta.Transaction{Data: make([]byte, 16*1024*1024)}); err != ErrCallTimeout {
+		t.Fatalf("blocked write: have %v, want %v", err, ErrCallTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("blocked write failed after %v", elapsed)
+	}
+}
+
+func benchmarkProcessBlock(b *testing.B, api interface{ ProcessBlock(meta.Block) error }, txs int) {
+	block := makeTestBlock(1, txs)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := api.ProcessBlock(*block); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkStream(b *testing.B, network string, txs int) {
+	_, srv, c := newTestStream(b, network)
+	defer srv.Stop()
+	defer c.Close()
+	benchmarkProcessBlock(b, c, txs)
+}
+
+func benchmarkHTTP(b *testing.B, protobuf bool, txs int) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	srv, err := rpc.NewBCSIRPCServer(server.NewConfig("bench", time.Now().Unix(), addr, "user", "pass"), newTestBCSI())
+	if err != nil {
+		b.Fatal(err)
+	}
+	srv.Start()
+	defer srv.Stop()
+	benchmarkProcessBlock(b, rpc.NewBCSIRPCClient(&client.Config{RPCUser: "user", RPCPassword: "pass", RPCServer: addr, Protobuf: protobuf}), txs)
+}
+
+func BenchmarkStreamTCPProcessBlock10(b *testing.B)      { benchmarkStream(b, "tcp", 10) }
+func BenchmarkStreamTCPProcessBlock1000(b *testing.B)    { benchmarkStream(b, "tcp", 1000) }
+func BenchmarkStreamUnixProcessBlock10(b *testing.B)     { benchmarkStream(b, "unix", 10) }
+func BenchmarkStreamUnixProcessBlock1000(b *testing.B)   { benchmarkStream(b, "unix", 1000) }
+func BenchmarkHTTPProcessBlock10(b *testing.B)           { benchmarkHTTP(b, false, 10) }
+func BenchmarkHTTPProcessBlock1000(b *testing.B)         { benchmarkHTTP(b, false, 1000) }
+func BenchmarkHTTPProtobufProcessBlock10(b *testing.B)   { benchmarkHTTP(b, true, 10) }
+func BenchmarkHTTPProtobufProcessBlock1000(b *testing.B) { benchmarkHTTP(b, true, 1000) }
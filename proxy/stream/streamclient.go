@@ -0,0 +1,271 @@
+package stream
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/mihongtech/linkchain-core/common/math"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/protobuf"
+	"github.com/mihongtech/linkchain-core/proxy/rpc"
+)
+
+const dialTimeout = 5 * time.Second
+
+//StreamClient implement bcsi.BCSI over a single long-lived connection to a StreamServer.
+//Calls are multiplexed on the connection by request id, so concurrent callers don't wait for each other.
+//A lost connection fails the pending calls and is dialed again by the next call.
+//Calls have the time limits of the BCSIRPCClient, a call timing out drops the connection.
+type StreamClient struct {
+	network string
+	addr    string
+
+	timeout  time.Duration            //time limit of the methods without one of their own
+	timeouts map[string]time.Duration //time limits of the methods, set before use
+
+	writeLock sync.Mutex //serialise the frames written to conn
+
+	lock    sync.Mutex
+	conn    net.Conn
+	pending map[uint64]chan *protobuf.BCSIResponse
+	nextID  uint64
+	closed  bool
+}
+
+//NewStreamClient create a client of the StreamServer at addr, network is "tcp" or "unix"
+func NewStreamClient(network string, addr string) *StreamClient {
+	return &StreamClient{
+		network:  network,
+		addr:     addr,
+		timeout:  rpc.DefaultCallTimeout,
+		timeouts: make(map[string]time.Duration),
+		pending:  make(map[uint64]chan *protobuf.BCSIResponse),
+	}
+}
+
+//SetTimeout set the time limit of the calls, 0 for rpc.DefaultCallTimeout.
+//It is the Timeout of the config of a BCSIRPCClient.
+func (c *StreamClient) SetTimeout(timeout time.Duration) {
+	if timeout == 0 {
+		timeout = rpc.DefaultCallTimeout
+	}
+	c.timeout = timeout
+}
+
+//SetCallTimeout set the time limit of the calls of method, 0 for the one set by SetTimeout
+func (c *StreamClient) SetCallTimeout(method string, timeout time.Duration) {
+	if timeout == 0 {
+		delete(c.timeouts, method)
+		return
+	}
+	c.timeouts[method] = timeout
+}
+
+func (c *StreamClient) callTimeout(method string) time.Duration {
+	if timeout, ok := c.timeouts[method]; ok {
+		return timeout
+	}
+	return c.timeout
+}
+
+//Close close the connection, failing the pending calls
+func (c *StreamClient) Close() {
+	c.lock.Lock()
+	c.closed = true
+	conn := c.conn
+	c.lock.Unlock()
+
+	if conn != nil {
+		c.drop(conn)
+	}
+}
+
+//register allocate a request id on the current connection, dialing it if needed
+func (c *StreamClient) register() (net.Conn, uint64, chan *protobuf.BCSIResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return nil, 0, nil, ErrClientClosed
+	}
+	if c.conn == nil {
+		conn, err := net.DialTimeout(c.network, c.addr, dialTimeout)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		c.conn = conn
+		go c.readLoop(conn)
+	}
+	c.nextID++
+	ch := make(chan *protobuf.BCSIResponse, 1)
+	c.pending[c.nextID] = ch
+	return c.conn, c.nextID, ch, nil
+}
+
+//drop close conn and fail its pending calls
+func (c *StreamClient) drop(conn net.Conn) {
+	c.lock.Lock()
+	if c.conn == conn {
+		c.conn = nil
+		for id, ch := range c.pending {
+			close(ch)
+			delete(c.pending, id)
+		}
+	}
+	c.lock.Unlock()
+	conn.Close()
+}
+
+//readLoop deliver the responses read from conn to their callers
+func (c *StreamClient) readLoop(conn net.Conn) {
+	defer c.drop(conn)
+
+	reader := bufio.NewReader(conn)
+	for {
+		rsp := &protobuf.BCSIResponse{}
+		if err := readFrame(reader, rsp); err != nil {
+			log.Debug("StreamClient", "read response", err)
+			return
+		}
+		c.lock.Lock()
+		ch, ok := c.pending[rsp.GetId()]
+		delete(c.pending, rsp.GetId())
+		c.lock.Unlock()
+
+		if ok {
+			ch <- rsp
+		}
+	}
+}
+
+//call send a request and wait for its response data
+func (c *StreamClient) call(method string, data []byte) ([]byte, error) {
+	conn, id, ch, err := c.register()
+	if err != nil {
+		return nil, err
+	}
+	req := &protobuf.BCSIRequest{Id: proto.Uint64(id), Method: proto.String(method), Data: data}
+
+	//the time limit covers the write too, an app which stops reading can't hold up the writers
+	deadline := time.Now().Add(c.callTimeout(method))
+	c.writeLock.Lock()
+	conn.SetWriteDeadline(deadline)
+	err = writeFrame(conn, req)
+	c.writeLock.Unlock()
+	if err != nil {
+		c.drop(conn)
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			log.Debug("StreamClient", "call write timeout", method)
+			return nil, ErrCallTimeout
+		}
+		return nil, err
+	}
+
+	timeout := time.NewTimer(time.Until(deadline))
+	defer timeout.Stop()
+
+	var rsp *protobuf.BCSIResponse
+	select {
+	case r, ok := <-ch:
+		if !ok {
+			return nil, ErrConnectionLost
+		}
+		rsp = r
+	case <-timeout.C:
+		//the app may be hung, the connection is given up with the calls pending on it
+		c.lock.Lock()
+		delete(c.pending, id)
+		c.lock.Unlock()
+		c.drop(conn)
+		log.Debug("StreamClient", "call timeout", method)
+		return nil, ErrCallTimeout
+	}
+	if rsp.Error != nil {
+		return nil, errors.New(rsp.GetError())
+	}
+	return rsp.Data, nil
+}
+
+func (c *StreamClient) GetBlockState(id meta.BlockID) (meta.TreeID, error) {
+	buff, err := id.EncodeToBytes()
+	if err != nil {
+		log.Error("StreamClient", "GetBlockState cmd encode", err)
+		return math.Hash{}, err
+	}
+	data, err := c.call(methodGetBlockState, buff)
+	if err != nil {
+		log.Error("StreamClient", "GetBlockState call", err)
+		return math.Hash{}, err
+	}
+	treeId := meta.TreeID{}
+	err = treeId.DecodeFromBytes(data)
+	return treeId, err
+}
+
+func (c *StreamClient) UpdateChain(head meta.Block) error {
+	return c.callBlock(methodUpdateChain, &head)
+}
+
+func (c *StreamClient) ProcessBlock(block meta.Block) error {
+	return c.callBlock(methodProcessBlock, &block)
+}
+
+func (c *StreamClient) Commit(id meta.BlockID) error {
+	buff, err := id.EncodeToBytes()
+	if err != nil {
+		log.Error("StreamClient", "Commit cmd encode", err)
+		return err
+	}
+	_, err = c.call(methodCommit, buff)
+	return err
+}
+
+func (c *StreamClient) CheckBlock(block meta.Block) error {
+	return c.callBlock(methodCheckBlock, &block)
+}
+
+func (c *StreamClient) CheckTx(transaction meta.Transaction) error {
+	buff, err := transaction.EncodeToBytes()
+	if err != nil {
+		log.Error("StreamClient", "CheckTx cmd encode", err)
+		return err
+	}
+	_, err = c.call(methodCheckTx, buff)
+	return err
+}
+
+func (c *StreamClient) FilterTx(txs []meta.Transaction) []meta.Transaction {
+	filterTxs := make([]meta.Transaction, 0)
+	buff, err := meta.NewTransactions(txs...).EncodeToBytes()
+	if err != nil {
+		log.Error("StreamClient", "FilterTx cmd encode", err)
+		return filterTxs
+	}
+	data, err := c.call(methodFilterTx, buff)
+	if err != nil {
+		log.Error("StreamClient", "FilterTx call", err)
+		return filterTxs
+	}
+	resultTxs := meta.Transactions{}
+	if err = resultTxs.DecodeFromBytes(data); err != nil {
+		log.Error("StreamClient", "FilterTx response decode", err)
+		return filterTxs
+	}
+	return resultTxs.Txs
+}
+
+//callBlock call a method taking a single block
+func (c *StreamClient) callBlock(method string, block *meta.Block) error {
+	buff, err := block.EncodeToBytes()
+	if err != nil {
+		log.Error("StreamClient", method+" cmd encode", err)
+		return err
+	}
+	_, err = c.call(method, buff)
+	return err
+}
@@ -0,0 +1,213 @@
+package stream
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/mihongtech/linkchain-core/common/util/log"
+	"github.com/mihongtech/linkchain-core/core/meta"
+	"github.com/mihongtech/linkchain-core/node/bcsi"
+	"github.com/mihongtech/linkchain-core/protobuf"
+)
+
+//maxInflight bound the requests of a connection served at the same time.
+//Reading further requests waits for one of them to finish.
+const maxInflight = 64
+
+//StreamServer serve a bcsi.BCSI to StreamClients over long-lived connections
+type StreamServer struct {
+	api     bcsi.BCSI
+	network string
+	addr    string
+
+	lock     sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+//NewStreamServer create a server of api listening on addr, network is "tcp" or "unix"
+func NewStreamServer(network string, addr string, api bcsi.BCSI) *StreamServer {
+	return &StreamServer{
+		api:     api,
+		network: network,
+		addr:    addr,
+		conns:   make(map[net.Conn]struct{}),
+	}
+}
+
+func (s *StreamServer) SetUp(i interface{}) bool {
+	return true
+}
+
+func (s *StreamServer) Start() bool {
+	if s.network == "unix" {
+		//remove the socket left by a previous run
+		os.Remove(s.addr)
+	}
+	listener, err := net.Listen(s.network, s.addr)
+	if err != nil {
+		log.Error("StreamServer", "listen failed", err)
+		return false
+	}
+	s.lock.Lock()
+	s.listener = listener
+	s.lock.Unlock()
+
+	s.wg.Add(1)
+	go s.acceptLoop(listener)
+	log.Info("BCSI stream server started", "network", s.network, "addr", listener.Addr())
+	return true
+}
+
+func (s *StreamServer) Stop() bool {
+	s.lock.Lock()
+	if s.listener != nil {
+		s.listener.Close()
+		s.listener = nil
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.lock.Unlock()
+
+	s.wg.Wait()
+	return true
+}
+
+//Addr return the listening address, nil if the server is not started
+func (s *StreamServer) Addr() net.Addr {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+func (s *StreamServer) acceptLoop(listener net.Listener) {
+	defer s.wg.Done()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Debug("StreamServer", "accept", err)
+			return
+		}
+		s.lock.Lock()
+		if s.listener == nil {
+			//stopped meanwhile
+			s.lock.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.lock.Unlock()
+
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+//serveConn read the requests of conn and answer each of them as soon as it is done
+func (s *StreamServer) serveConn(conn net.Conn) {
+	var (
+		writeLock sync.Mutex
+		inflight  = make(chan struct{}, maxInflight)
+		handlers  sync.WaitGroup
+	)
+	defer func() {
+		handlers.Wait()
+		s.lock.Lock()
+		delete(s.conns, conn)
+		s.lock.Unlock()
+		conn.Close()
+		s.wg.Done()
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		req := &protobuf.BCSIRequest{}
+		if err := readFrame(reader, req); err != nil {
+			log.Debug("StreamServer", "read request", err)
+			return
+		}
+		inflight <- struct{}{}
+		handlers.Add(1)
+		go func() {
+			defer func() {
+				<-inflight
+				handlers.Done()
+			}()
+			rsp := &protobuf.BCSIResponse{Id: req.Id}
+			data, err := s.dispatch(req.GetMethod(), req.Data)
+			if err != nil {
+				rsp.Error = proto.String(err.Error())
+			} else {
+				rsp.Data = data
+			}
+			writeLock.Lock()
+			defer writeLock.Unlock()
+			if err := writeFrame(conn, rsp); err != nil {
+				log.Debug("StreamServer", "write response", err)
+				conn.Close()
+			}
+		}()
+	}
+}
+
+//dispatch decode a request, call the api and encode its result
+func (s *StreamServer) dispatch(method string, data []byte) ([]byte, error) {
+	switch method {
+	case methodGetBlockState:
+		blockId := meta.BlockID{}
+		if err := blockId.DecodeFromBytes(data); err != nil {
+			return nil, err
+		}
+		treeId, err := s.api.GetBlockState(blockId)
+		if err != nil {
+			return nil, err
+		}
+		return treeId.EncodeToBytes()
+
+	case methodCommit:
+		blockId := meta.BlockID{}
+		if err := blockId.DecodeFromBytes(data); err != nil {
+			return nil, err
+		}
+		return nil, s.api.Commit(blockId)
+
+	case methodUpdateChain, methodProcessBlock, methodCheckBlock:
+		block := meta.Block{}
+		if err := block.DecodeFromBytes(data); err != nil {
+			return nil, err
+		}
+		switch method {
+		case methodUpdateChain:
+			return nil, s.api.UpdateChain(block)
+		case methodProcessBlock:
+			return nil, s.api.ProcessBlock(block)
+		default:
+			return nil, s.api.CheckBlock(block)
+		}
+
+	case methodCheckTx:
+		transaction := meta.Transaction{}
+		if err := transaction.DecodeFromBytes(data); err != nil {
+			return nil, err
+		}
+		return nil, s.api.CheckTx(transaction)
+
+	case methodFilterTx:
+		transactions := meta.Transactions{}
+		if err := transactions.DecodeFromBytes(data); err != nil {
+			return nil, err
+		}
+		return meta.NewTransactions(s.api.FilterTx(transactions.Txs)...).EncodeToBytes()
+	}
+	return nil, fmt.Errorf("unknown method %s", method)
+}
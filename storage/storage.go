@@ -7,15 +7,24 @@ import (
 
 	"github.com/mihongtech/linkchain-core/common/lcdb"
 	"github.com/mihongtech/linkchain-core/common/util/log"
+	chainstorage "github.com/mihongtech/linkchain-core/node/chain/storage"
+	"github.com/mihongtech/linkchain-core/node/config"
+)
+
+//default database cache (MB) and open files, for the backends using them
+const (
+	defaultDatabaseCache   = 1024
+	defaultDatabaseHandles = 256
 )
 
 type Storage struct {
 	Name    string
 	db      lcdb.Database
 	dataDir string
+	backend string
 }
 
-func NewStrorage(dataDir string) *Storage {
+func NewStrorage(cfg *config.BaseConfig) *Storage {
 	log.Info("Stroage init...")
 
 	s := &Storage{}
@@ -23,13 +32,28 @@ func NewStrorage(dataDir string) *Storage {
 	//load genesis from storage
 	var err error
 	s.Name = "chaindata"
-	s.dataDir = dataDir
-	s.db, err = s.OpenDatabase("fullchain", 1024, 256)
+	s.dataDir = cfg.DataDir
+	s.backend = cfg.DatabaseBackend
+	cache, handles := cfg.DatabaseCache, cfg.DatabaseHandles
+	if cache == 0 {
+		cache = defaultDatabaseCache
+	}
+	if handles == 0 {
+		handles = defaultDatabaseHandles
+	}
+	s.db, err = s.OpenDatabase("fullchain", cache, handles)
 	if err != nil {
 		log.Error("init storage failed", "err", err)
 		return nil
 	}
 
+	//bring the key layout up to date, refusing databases of newer nodes
+	if err := chainstorage.Migrate(s.db); err != nil {
+		log.Error("init storage failed", "err", err)
+		s.db.Close()
+		return nil
+	}
+
 	return s
 }
 
@@ -38,7 +62,7 @@ func (s *Storage) OpenDatabase(name string, cache, handles int) (lcdb.Database,
 		return lcdb.NewMemDatabase()
 	}
 
-	return lcdb.NewLDBDatabase(s.resolvePath(name), cache, handles)
+	return lcdb.Open(s.backend, s.resolvePath(name), cache, handles)
 }
 
 func (s *Storage) resolvePath(path string) string {